@@ -0,0 +1,119 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxAdminURLLength is the maximum length of HydraAdmin.URL.
+const maxAdminURLLength = 256
+
+// maxDNSServers is the maximum number of HydraAdmin.DNSServers, like the
+// nameservers of resolv.conf.
+const maxDNSServers = 3
+
+// AdminURL returns the URL of the clients endpoint of the hydra instance h
+// points to. The deprecated Port is added to URL unless it carries a port,
+// and the deprecated Endpoint replaces its path if set, so h may hold the
+// full URL, e.g. http://[fd00::1]:4445/admin/clients, or its parts.
+func (h HydraAdmin) AdminURL() (*url.URL, error) {
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("hydra URL %q has no host", h.URL)
+	}
+	if strings.Contains(u.Hostname(), ":") && !strings.HasPrefix(u.Host, "[") {
+		return nil, fmt.Errorf("hydra URL %q must enclose the IPv6 address in brackets", h.URL)
+	}
+	if u.Port() == "" && h.Port != 0 {
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(h.Port))
+	}
+	if h.Endpoint != "" {
+		u = u.ResolveReference(&url.URL{Path: h.Endpoint})
+	}
+	return u, nil
+}
+
+// Address returns the scheme, host and port of the hydra instance h points
+// to, e.g. to report it in errors, or URL if it is invalid.
+func (h HydraAdmin) Address() string {
+	u, err := h.AdminURL()
+	if err != nil {
+		return h.URL
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+}
+
+// FoldAddress folds the deprecated Port and Endpoint into URL and clears
+// them, so URL holds the full address of the clients endpoint. URLs which
+// are invalid, have another scheme than http and https or would become too
+// long are left as they are.
+func (h *HydraAdmin) FoldAddress() {
+	if h.Port == 0 && h.Endpoint == "" {
+		return
+	}
+	if scheme, _, _ := strings.Cut(h.URL, "://"); scheme != "http" && scheme != "https" {
+		return
+	}
+	u, err := h.AdminURL()
+	if err != nil || len(u.String()) > maxAdminURLLength {
+		return
+	}
+	h.URL, h.Port, h.Endpoint = u.String(), 0, ""
+}
+
+// DNSServerAddresses returns the DNSServers of h as <ip>:<port>, with the
+// port 53 if they have none.
+func (h HydraAdmin) DNSServerAddresses() ([]string, error) {
+	addrs := make([]string, 0, len(h.DNSServers))
+	for _, server := range h.DNSServers {
+		addr, err := dnsServerAddress(server)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// Timeout returns the RequestTimeout of h, or zero if it has none.
+func (h HydraAdmin) Timeout() (time.Duration, error) {
+	if h.RequestTimeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(h.RequestTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("request timeout %q must be positive", h.RequestTimeout)
+	}
+	return d, nil
+}
+
+func dnsServerAddress(server string) (string, error) {
+	if ip := net.ParseIP(server); ip != nil {
+		return net.JoinHostPort(ip.String(), "53"), nil
+	}
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return "", fmt.Errorf("DNS server %q must be an IP address with an optional port", server)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", fmt.Errorf("DNS server %q must be an IP address, not a host name", server)
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return "", fmt.Errorf("DNS server %q has an invalid port", server)
+	}
+	return net.JoinHostPort(ip.String(), port), nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHydraAdmin(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		admin   HydraAdmin
+		url     string
+		address string
+		folded  HydraAdmin
+	}{
+		{
+			name:    "case=separate fields",
+			admin:   HydraAdmin{URL: "http://hydra-admin", Port: 4445, Endpoint: "/clients"},
+			url:     "http://hydra-admin:4445/clients",
+			address: "http://hydra-admin:4445",
+			folded:  HydraAdmin{URL: "http://hydra-admin:4445/clients"},
+		},
+		{
+			name:    "case=single URL",
+			admin:   HydraAdmin{URL: "http://hydra-admin:4445/admin/clients"},
+			url:     "http://hydra-admin:4445/admin/clients",
+			address: "http://hydra-admin:4445",
+			folded:  HydraAdmin{URL: "http://hydra-admin:4445/admin/clients"},
+		},
+		{
+			name:    "case=IPv6 literal with a port",
+			admin:   HydraAdmin{URL: "https://[fd00::1]:4445/admin/clients", Port: 4445},
+			url:     "https://[fd00::1]:4445/admin/clients",
+			address: "https://[fd00::1]:4445",
+			folded:  HydraAdmin{URL: "https://[fd00::1]:4445/admin/clients"},
+		},
+		{
+			name:    "case=IPv6 literal with the port field",
+			admin:   HydraAdmin{URL: "http://[fd00::1]", Port: 4445},
+			url:     "http://[fd00::1]:4445",
+			address: "http://[fd00::1]:4445",
+			folded:  HydraAdmin{URL: "http://[fd00::1]:4445"},
+		},
+		{
+			name:    "case=endpoint replaces the path",
+			admin:   HydraAdmin{URL: "http://gateway/hydra", Endpoint: "/clients", ForwardedProto: "https"},
+			url:     "http://gateway/clients",
+			address: "http://gateway",
+			folded:  HydraAdmin{URL: "http://gateway/clients", ForwardedProto: "https"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := tc.admin.AdminURL()
+			require.NoError(t, err)
+			assert.Equal(t, tc.url, u.String())
+			assert.Equal(t, tc.address, tc.admin.Address())
+
+			folded := tc.admin
+			folded.FoldAddress()
+			assert.Equal(t, tc.folded, folded)
+		})
+	}
+
+	t.Run("case=leaves other schemes", func(t *testing.T) {
+		admin := HydraAdmin{URL: "unix:///var/run/hydra.sock", Endpoint: "/clients"}
+		folded := admin
+		folded.FoldAddress()
+		assert.Equal(t, admin, folded)
+	})
+
+	t.Run("case=rejects IPv6 literals without brackets", func(t *testing.T) {
+		_, err := HydraAdmin{URL: "http://fd00::1:4445"}.AdminURL()
+		assert.Error(t, err)
+	})
+
+	t.Run("case=DNS server addresses", func(t *testing.T) {
+		addrs, err := HydraAdmin{DNSServers: []string{"10.0.0.53", "10.0.0.54:5353", "fd00::53", "[fd00::54]:5353"}}.DNSServerAddresses()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.53:53", "10.0.0.54:5353", "[fd00::53]:53", "[fd00::54]:5353"}, addrs)
+
+		for _, server := range []string{"dns.internal", "dns.internal:53", "10.0.0.53:0", "10.0.0.53:dns"} {
+			_, err := HydraAdmin{DNSServers: []string{server}}.DNSServerAddresses()
+			assert.Error(t, err, server)
+		}
+	})
+
+	t.Run("case=request timeout", func(t *testing.T) {
+		d, err := HydraAdmin{}.Timeout()
+		require.NoError(t, err)
+		assert.Zero(t, d)
+
+		d, err = HydraAdmin{RequestTimeout: "1m30s"}.Timeout()
+		require.NoError(t, err)
+		assert.Equal(t, 90*time.Second, d)
+
+		for _, timeout := range []string{"30", "0s", "-1s", "1d"} {
+			_, err := HydraAdmin{RequestTimeout: timeout}.Timeout()
+			assert.Error(t, err, timeout)
+		}
+	})
+
+	t.Run("case=defaulting webhook", func(t *testing.T) {
+		c := &OAuth2Client{Spec: OAuth2ClientSpec{HydraAdmin: HydraAdmin{URL: "http://hydra-admin", Port: 4445, Endpoint: "/clients"}}}
+		require.NoError(t, Defaulter{}.Default(context.Background(), c))
+		assert.Equal(t, HydraAdmin{URL: "http://hydra-admin:4445/clients"}, c.Spec.HydraAdmin)
+	})
+}
@@ -0,0 +1,71 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+const (
+	dns1123SubdomainPattern = `^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	configMapKeyPattern     = `^[-._a-zA-Z0-9]+$`
+)
+
+// SchemaRule is a rule of Validate expressed as JSON Schema keywords which
+// apply to the field at Path, e.g. spec.redirectUris[] for the items of
+// spec.redirectUris.
+// +kubebuilder:object:generate=false
+type SchemaRule struct {
+	Path   string
+	Schema map[string]interface{}
+}
+
+// SchemaRules returns the rules of Validate which the CRD schema doesn't
+// enforce, so JSON Schema validators can apply the same rules as the
+// admission webhook. The patterns are those of Validate, so the rules can't
+// drift apart.
+func SchemaRules() []SchemaRule {
+	httpURL := map[string]interface{}{"pattern": httpURLPattern.String()}
+	redirectURI := map[string]interface{}{
+		"pattern": redirectURIPattern.String(),
+		"not":     map[string]interface{}{"pattern": "#"},
+	}
+
+	return []SchemaRule{
+		{Path: "spec.secretName", Schema: map[string]interface{}{"pattern": dns1123SubdomainPattern}},
+		{Path: "spec.redirectUris[]", Schema: redirectURI},
+		{Path: "spec.postLogoutRedirectUris[]", Schema: redirectURI},
+		{Path: "spec.allowedCorsOrigins[]", Schema: map[string]interface{}{"pattern": corsOriginPattern.String()}},
+		{Path: "spec.scope", Schema: map[string]interface{}{"pattern": scopePattern.String()}},
+		{Path: "spec.scopeArray[]", Schema: map[string]interface{}{"pattern": scopeTokenPattern.String()}},
+		{Path: "spec.jwksUri", Schema: httpURL},
+		{Path: "spec.frontChannelLogoutURI", Schema: httpURL},
+		{Path: "spec.backChannelLogoutURI", Schema: httpURL},
+		{Path: "spec.hydraAdmin.url", Schema: map[string]interface{}{"pattern": adminURLPattern.String()}},
+		{Path: "spec.hydraAdmin.fallbackUrl", Schema: map[string]interface{}{"pattern": adminURLPattern.String()}},
+		{Path: "spec.hydraAdmin.endpoint", Schema: map[string]interface{}{"pattern": endpointPattern.String()}},
+		{Path: "spec.hydraAdmin.forwardedProto", Schema: map[string]interface{}{"pattern": forwardedPattern.String()}},
+		{Path: "spec.hydraAdmin.publicUrl", Schema: httpURL},
+		{Path: "spec.metadataFrom", Schema: map[string]interface{}{"required": []string{"configMapRef"}}},
+		{Path: "spec.metadataFrom.configMapRef.name", Schema: map[string]interface{}{"pattern": dns1123SubdomainPattern}},
+		{Path: "spec.metadataFrom.configMapRef.key", Schema: map[string]interface{}{"pattern": configMapKeyPattern, "maxLength": 253}},
+		{Path: "spec.discoveryConfigMapName", Schema: map[string]interface{}{"pattern": `(^$|` + dns1123SubdomainPattern + `)`}},
+		{Path: "spec", Schema: map[string]interface{}{
+			// scope is deprecated and must not be set together with scopeArray
+			"not": map[string]interface{}{
+				"required": []string{"scope", "scopeArray"},
+				"properties": map[string]interface{}{
+					"scope":      map[string]interface{}{"minLength": 1},
+					"scopeArray": map[string]interface{}{"minItems": 1},
+				},
+			},
+		}},
+		{Path: "spec", Schema: map[string]interface{}{
+			"if": map[string]interface{}{
+				"required":   []string{"tokenEndpointAuthMethod"},
+				"properties": map[string]interface{}{"tokenEndpointAuthMethod": map[string]interface{}{"const": "private_key_jwt"}},
+			},
+			"then": map[string]interface{}{
+				"required":   []string{"jwksUri"},
+				"properties": map[string]interface{}{"jwksUri": map[string]interface{}{"minLength": 1}},
+			},
+		}},
+	}
+}
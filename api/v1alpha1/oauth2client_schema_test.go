@@ -0,0 +1,45 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSchemaRules(t *testing.T) {
+	raw, err := os.ReadFile("../../config/crd/bases/hydra.ory.sh_oauth2clients.yaml")
+	require.NoError(t, err)
+	var crd apiextensionsv1.CustomResourceDefinition
+	require.NoError(t, yaml.Unmarshal(raw, &crd))
+	schema := crd.Spec.Versions[0].Schema.OpenAPIV3Schema
+
+	for _, rule := range SchemaRules() {
+		t.Run("path="+rule.Path, func(t *testing.T) {
+			node := schema
+			for _, name := range strings.Split(rule.Path, ".") {
+				name, items := strings.CutSuffix(name, "[]")
+				next, ok := node.Properties[name]
+				require.True(t, ok, "the CRD schema has no field %s", name)
+				node = &next
+				if items {
+					require.NotNil(t, node.Items, "%s is not an array", name)
+					node = node.Items.Schema
+				}
+			}
+
+			if pattern, ok := rule.Schema["pattern"].(string); ok {
+				_, err := regexp.Compile(pattern)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
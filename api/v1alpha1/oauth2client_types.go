@@ -6,41 +6,91 @@ package v1alpha1
 import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type StatusCode string
 
 const (
-	StatusRegistrationFailed  StatusCode = "CLIENT_REGISTRATION_FAILED"
-	StatusCreateSecretFailed  StatusCode = "SECRET_CREATION_FAILED"
-	StatusUpdateFailed        StatusCode = "CLIENT_UPDATE_FAILED"
-	StatusInvalidSecret       StatusCode = "INVALID_SECRET"
-	StatusInvalidHydraAddress StatusCode = "INVALID_HYDRA_ADDRESS"
+	StatusRegistrationFailed    StatusCode = "CLIENT_REGISTRATION_FAILED"
+	StatusCreateSecretFailed    StatusCode = "SECRET_CREATION_FAILED"
+	StatusUpdateFailed          StatusCode = "CLIENT_UPDATE_FAILED"
+	StatusInvalidSecret         StatusCode = "INVALID_SECRET"
+	StatusInvalidHydraAddress   StatusCode = "INVALID_HYDRA_ADDRESS"
+	StatusCreateConfigMapFailed StatusCode = "CONFIGMAP_CREATION_FAILED"
+	StatusUnsupportedHydra      StatusCode = "UNSUPPORTED_HYDRA_VERSION"
+	StatusHydraUnreachable      StatusCode = "HYDRA_UNREACHABLE"
+	StatusConflict              StatusCode = "CONFLICT"
+	StatusUnauthorized          StatusCode = "UNAUTHORIZED"
+	StatusInvalidSpec           StatusCode = "INVALID_SPEC"
+	StatusUpdateSecretFailed    StatusCode = "SECRET_UPDATE_FAILED"
+	StatusDeletionProtected     StatusCode = "DELETION_PROTECTED"
+	StatusHydraAddressForbidden StatusCode = "HYDRA_ADDRESS_NOT_ALLOWED"
+	StatusScopeNotAllowed       StatusCode = "SCOPE_NOT_ALLOWED"
+	StatusRedirectURINotAllowed StatusCode = "REDIRECT_URI_NOT_ALLOWED"
+	StatusRelationTuplesFailed  StatusCode = "RELATION_TUPLES_FAILED"
+	StatusAccessRuleFailed      StatusCode = "ACCESS_RULE_FAILED"
 )
 
+// Reason returns the reason of the Ready condition for an error with code c.
+func (c StatusCode) Reason() ConditionReason {
+	switch c {
+	case StatusHydraUnreachable, StatusUnsupportedHydra:
+		return ReasonHydraUnavailable
+	case StatusUnauthorized:
+		return ReasonAccessDenied
+	case StatusInvalidSpec, StatusInvalidSecret, StatusInvalidHydraAddress, StatusHydraAddressForbidden, StatusScopeNotAllowed, StatusRedirectURINotAllowed, StatusConflict:
+		return ReasonInvalidConfiguration
+	case StatusCreateSecretFailed, StatusUpdateSecretFailed, StatusCreateConfigMapFailed, StatusAccessRuleFailed:
+		return ReasonKubernetesError
+	case StatusDeletionProtected:
+		return ReasonDeletionProtected
+	case StatusRelationTuplesFailed:
+		return ReasonKetoError
+	default:
+		return ReasonHydraError
+	}
+}
+
 // HydraAdmin defines the desired hydra admin instance to use for OAuth2Client
 type HydraAdmin struct {
-	// +kubebuilder:validation:MaxLength=64
-	// +kubebuilder:validation:Pattern=`(^$|^https?://.*)`
+	// +kubebuilder:validation:MaxLength=256
+	// +kubebuilder:validation:Pattern=`(^$|^[a-z][a-z0-9+.-]*://.*)`
 	//
-	// URL is the URL for the hydra instance on
-	// which to set up the client. This value will override the value
-	// provided to `--hydra-url`
+	// URL is the URL of the clients endpoint of the hydra instance on
+	// which to set up the client, with the port and path, e.g.
+	// `http://[fd00::1]:4445/admin/clients`. This value will override
+	// the value provided to `--hydra-url`. Schemes other than http and
+	// https require a client factory registered for the scheme in the
+	// controller.
 	URL string `json:"url,omitempty"`
 
+	// ServiceRef references the Service of the hydra instance on
+	// which to set up the client, instead of URL. The controller
+	// resolves it to the cluster-internal URL of the Service on every
+	// reconciliation, so renames of its ports and changes of their
+	// numbers are picked up.
+	ServiceRef *ServiceReference `json:"serviceRef,omitempty"`
+
 	// +kubebuilder:validation:Maximum=65535
 	//
 	// Port is the port for the hydra instance on
-	// which to set up the client. This value will override the value
-	// provided to `--hydra-port`
+	// which to set up the client, if URL has none. This value will
+	// override the value provided to `--hydra-port`.
+	//
+	// Deprecated: set the port in URL. The admission webhook moves it
+	// there.
 	Port int `json:"port,omitempty"`
 
 	// +kubebuilder:validation:Pattern=(^$|^/.*)
 	//
 	// Endpoint is the endpoint for the hydra instance on which
-	// to set up the client. This value will override the value
-	// provided to `--endpoint` (defaults to `"/clients"` in the
-	// application)
+	// to set up the client, replacing the path of URL. This value will
+	// override the value provided to `--endpoint` (defaults to
+	// `"/clients"` in the application)
+	//
+	// Deprecated: set the path in URL. The admission webhook moves it
+	// there.
 	Endpoint string `json:"endpoint,omitempty"`
 
 	// +kubebuilder:validation:Pattern=(^$|https?|off)
@@ -49,6 +99,70 @@ type HydraAdmin struct {
 	// value "off" will force this to be off even if
 	// `--forwarded-proto` is specified
 	ForwardedProto string `json:"forwardedProto,omitempty"`
+
+	// +kubebuilder:validation:MaxLength=64
+	// +kubebuilder:validation:Pattern=`(^$|^https?://.*)`
+	//
+	// PublicURL is the public URL of the hydra instance, used to
+	// derive the issuer and the OAuth2 endpoints published to the
+	// discovery ConfigMap. This value will override the value
+	// provided to `--hydra-public-url`
+	PublicURL string `json:"publicUrl,omitempty"`
+
+	// +kubebuilder:validation:MaxLength=64
+	// +kubebuilder:validation:Pattern=`(^$|^[a-z][a-z0-9+.-]*://.*)`
+	//
+	// FallbackURL is the URL of a second hydra instance sharing the
+	// database of the first, to which requests are sent while the
+	// first is not ready. This value will override the value provided
+	// to `--hydra-fallback-url`
+	FallbackURL string `json:"fallbackUrl,omitempty"`
+
+	// +kubebuilder:validation:Maximum=65535
+	//
+	// FallbackPort is the port of the fallback hydra instance. Port is
+	// used if it is not set
+	FallbackPort int `json:"fallbackPort,omitempty"`
+
+	// +kubebuilder:validation:MaxItems=3
+	//
+	// DNSServers are the DNS servers resolving the hosts of URL and
+	// FallbackURL, as IP addresses with an optional port, e.g.
+	// `10.0.0.53` or `[fd00::53]:5353`, for hydra instances whose
+	// hostname only resolves on a dedicated resolver. This value will
+	// override the value provided to `--dns-servers`
+	DNSServers []string `json:"dnsServers,omitempty"`
+
+	// +kubebuilder:validation:Pattern=`(^$|^([0-9]+(\.[0-9]+)?(ns|us|ms|s|m|h))+$)`
+	//
+	// RequestTimeout is the timeout of each request to the hydra
+	// instance, as a duration such as `30s`, for instances behind slow
+	// links. This value will override the value provided to
+	// `--hydra-request-timeout`
+	RequestTimeout string `json:"requestTimeout,omitempty"`
+}
+
+// ServiceReference references the Service of a hydra instance, see
+// HydraAdmin.ServiceRef.
+type ServiceReference struct {
+	// +kubebuilder:validation:MinLength=1
+	//
+	// Name is the name of the Service.
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Service, that of the
+	// OAuth2Client if empty.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Port is the name or number of the admin port of the Service. It
+	// may be empty if the Service has a single port. The URL uses https
+	// if the appProtocol of the port is https.
+	Port intstr.IntOrString `json:"port,omitempty"`
+
+	// +kubebuilder:validation:Pattern=(^$|^/.*)
+	//
+	// Path is the path of the clients endpoint, `/clients` if empty.
+	Path string `json:"path,omitempty"`
 }
 
 // TokenLifespans defines the desired token durations by grant type for OAuth2Client
@@ -121,10 +235,11 @@ type OAuth2ClientSpec struct {
 	ClientName string `json:"clientName,omitempty"`
 
 	// +kubebuilder:validation:MaxItems=4
-	// +kubebuilder:validation:MinItems=1
 	//
 	// GrantTypes is an array of grant types the client is allowed to use.
-	GrantTypes []GrantType `json:"grantTypes"`
+	// If empty, the grant types of the hydra-maester-defaults ConfigMap of the
+	// namespace are used, which must set them then.
+	GrantTypes []GrantType `json:"grantTypes,omitempty"`
 
 	// +kubebuilder:validation:MaxItems=3
 	// +kubebuilder:validation:MinItems=1
@@ -140,12 +255,12 @@ type OAuth2ClientSpec struct {
 	PostLogoutRedirectURIs []RedirectURI `json:"postLogoutRedirectUris,omitempty"`
 
 	// AllowedCorsOrigins is an array of allowed CORS origins
-	AllowedCorsOrigins []RedirectURI `json:"allowedCorsOrigins,omitempty"`
+	AllowedCorsOrigins []CorsOrigin `json:"allowedCorsOrigins,omitempty"`
 
 	// Audience is a whitelist defining the audiences this client is allowed to request tokens for
 	Audience []string `json:"audience,omitempty"`
 
-	// +kubebuilder:validation:Pattern=([a-zA-Z0-9\.\*]+\s?)*
+	// +kubebuilder:validation:Pattern=`([!#-\[\]-~]+\s?)*`
 	// +kubebuilder:deprecatedversion:warning="Property scope is deprecated. Use scopeArray instead."
 	//
 	// Scope is a string containing a space-separated list of scope values (as
@@ -156,6 +271,9 @@ type OAuth2ClientSpec struct {
 
 	// Scope is an array of scope values (as described in Section 3.3 of OAuth 2.0 [RFC6749])
 	// that the client can use when requesting access tokens.
+	// Unlike scope, it can be patched element-wise, e.g. with kustomize. It must
+	// not be set together with scope; the scopes are registered in ORY Hydra
+	// space-separated.
 	ScopeArray []string `json:"scopeArray,omitempty"`
 
 	// +kubebuilder:validation:MinLength=1
@@ -183,12 +301,21 @@ type OAuth2ClientSpec struct {
 	// depending on the used grant type.
 	TokenLifespans TokenLifespans `json:"tokenLifespans,omitempty"`
 
-	// +kubebuilder:validation:Type=object
 	// +nullable
 	// +optional
 	//
-	// Metadata is arbitrary data
-	Metadata apiextensionsv1.JSON `json:"metadata,omitempty"`
+	// Metadata is arbitrary data, an object whose values are any JSON. On
+	// updates, it is deep merged into the metadata of the client in ORY
+	// Hydra like a JSON merge patch, so keys written by other systems are
+	// kept: objects are merged key by key, keys set to null are removed and
+	// other values replace those in ORY Hydra.
+	Metadata map[string]apiextensionsv1.JSON `json:"metadata,omitempty"`
+
+	// MetadataFrom references metadata merged into the metadata of the
+	// client, e.g. standardized keys like the cost center maintained by a
+	// platform team. Objects are merged key by key, other values of
+	// spec.metadata win.
+	MetadataFrom *MetadataSource `json:"metadataFrom,omitempty"`
 
 	// +kubebuilder:validation:type=string
 	// +kubebuilder:validation:Pattern=`(^$|^https?://.*)`
@@ -220,25 +347,77 @@ type OAuth2ClientSpec struct {
 	// BackChannelLogoutURI RP URL that will cause the RP to log itself out when sent a Logout Token by the OP
 	BackChannelLogoutURI string `json:"backChannelLogoutURI,omitempty"`
 
-	// +kubebuilder:validation:Enum=1;2
+	// +kubebuilder:validation:Enum=1;2;3
 	//
 	// Indicates if a deleted OAuth2Client custom resource should delete the database row or not.
 	// Value 1 means deletion of the OAuth2 client, value 2 means keep an orphan oauth2 client.
+	// Value 3 keeps an orphan oauth2 client as well, but adds no finalizer, so the deletion of
+	// the OAuth2Client does not wait for the controller.
 	DeletionPolicy OAuth2ClientDeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// +kubebuilder:validation:Enum=fail;adopt;regenerate
+	//
+	// ConflictPolicy determines what happens when the client ID of the Secret is already
+	// taken in ORY Hydra by a client of another owner. Value fail records the conflict in
+	// the status, adopt takes over the existing client, and regenerate registers the client
+	// with a new ID and writes it to the Secret. Defaults to the --conflict-policy flag.
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`(^$|^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$)`
+	//
+	// DiscoveryConfigMapName is the optional name of a ConfigMap in which the
+	// controller publishes the non-secret OAuth2 configuration of this client:
+	// the issuer URL, the authorization and token endpoints, the client ID
+	// and the scopes. An existing ConfigMap not owned by this client is not
+	// overwritten, and the ConfigMap is deleted once the name is changed or
+	// cleared.
+	DiscoveryConfigMapName string `json:"discoveryConfigMapName,omitempty"`
+}
+
+// MetadataSource references client metadata kept outside of the OAuth2Client
+type MetadataSource struct {
+	// ConfigMapRef references a key of a ConfigMap in the namespace of the
+	// OAuth2Client holding a JSON or YAML object
+	ConfigMapRef *ConfigMapKeyRef `json:"configMapRef,omitempty"`
+}
+
+// ConfigMapKeyRef references a key of a ConfigMap in the namespace of the
+// OAuth2Client
+type ConfigMapKeyRef struct {
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	//
+	// Name is the name of the ConfigMap
+	Name string `json:"name"`
+
+	// +kubebuilder:default=metadata
+	//
+	// Key is the key of the ConfigMap, metadata by default
+	Key string `json:"key,omitempty"`
 }
 
 // GrantType represents an OAuth 2.0 grant type
 // +kubebuilder:validation:Enum=client_credentials;authorization_code;implicit;refresh_token
 type GrantType string
 
-// ResponseType represents an OAuth 2.0 response type strings
-// +kubebuilder:validation:Enum=id_token;code;token;code token;code id_token;id_token token;code id_token token
+// ResponseType represents an OAuth 2.0 response type strings, a
+// space-separated list of response names such as code or id_token. Other
+// names than code, id_token and token are allowed for the response types of
+// newer specifications, which the admission webhook warns about.
+// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_]+( [A-Za-z0-9_]+)*$`
 type ResponseType string
 
 // RedirectURI represents a redirect URI for the client
 // +kubebuilder:validation:Pattern=`\w+:/?/?[^\s]+`
 type RedirectURI string
 
+// CorsOrigin represents an allowed CORS origin of the client, a scheme and a
+// host without a path, e.g. https://example.com. A leading *. in the host
+// matches all subdomains, e.g. https://*.example.com
+// +kubebuilder:validation:Pattern=`\w+:/?/?[^\s]+`
+type CorsOrigin string
+
 // TokenEndpointAuthMethod represents an authentication method for token endpoint
 // +kubebuilder:validation:Enum=client_secret_basic;client_secret_post;private_key_jwt;none
 type TokenEndpointAuthMethod string
@@ -249,6 +428,28 @@ type OAuth2ClientStatus struct {
 	ObservedGeneration  int64                   `json:"observedGeneration,omitempty"`
 	ReconciliationError ReconciliationError     `json:"reconciliationError,omitempty"`
 	Conditions          []OAuth2ClientCondition `json:"conditions,omitempty"`
+	// ConsecutiveFailures is the number of reconciliations which failed in a
+	// row since the last successful one or the last change of the spec or the
+	// hydra.ory.sh/max-retries annotation. Once it reaches the degraded
+	// threshold of the controller, failures with the error recorded already
+	// are not counted
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+	// MaxRetries is the hydra.ory.sh/max-retries annotation the consecutive
+	// failures were counted against
+	MaxRetries int32 `json:"maxRetries,omitempty"`
+	// AppliedDefaults are the values the hydra-maester-defaults ConfigMap of
+	// the namespace set for fields the spec leaves empty, keyed like the
+	// ConfigMap
+	AppliedDefaults map[string]string `json:"appliedDefaults,omitempty"`
+	// MetadataFromVersion is the resource version of the ConfigMap referenced
+	// by spec.metadataFrom which the client in ORY Hydra was last written with
+	MetadataFromVersion string `json:"metadataFromVersion,omitempty"`
+	// HydraEndpoint is the address of the hydra instance the client was
+	// last reconciled against, if a fallback instance is configured
+	HydraEndpoint string `json:"hydraEndpoint,omitempty"`
+	// RelationTuples are the relation tuples written to ORY Keto for the
+	// client, in the syntax of ORY Keto
+	RelationTuples []string `json:"relationTuples,omitempty"`
 }
 
 // ReconciliationError represents an error that occurred during the reconciliation process
@@ -257,18 +458,41 @@ type ReconciliationError struct {
 	Code StatusCode `json:"statusCode,omitempty"`
 	// Description is the description of the reconciliation error
 	Description string `json:"description,omitempty"`
+	// HTTPStatusCode is the HTTP status code of the failed ORY Hydra request, if any
+	HTTPStatusCode int `json:"httpStatusCode,omitempty"`
+	// HydraResponse is the beginning of the response body of the failed ORY Hydra request, with secrets redacted
+	HydraResponse string `json:"hydraResponse,omitempty"`
 }
 
 // OAuth2ClientCondition contains condition information for an OAuth2Client
 type OAuth2ClientCondition struct {
 	Type   OAuth2ClientConditionType `json:"type"`
 	Status ConditionStatus           `json:"status"`
+	// Reason is a machine-readable explanation of the status, meant to
+	// distinguish failures of ORY Hydra from misconfigured resources.
+	Reason ConditionReason `json:"reason,omitempty"`
+	// Message is a human-readable explanation of the status, e.g. the
+	// fields ORY Hydra ignores.
+	Message string `json:"message,omitempty"`
 }
 
 type OAuth2ClientConditionType string
 
 const (
 	OAuth2ClientConditionReady = "Ready"
+	// OAuth2ClientConditionDegraded is true once the reconciliation failed
+	// a configurable number of times in a row, telling persistent failures
+	// apart from transient ones.
+	OAuth2ClientConditionDegraded = "Degraded"
+	// OAuth2ClientConditionRetriesExhausted is true once the reconciliation
+	// failed as many times in a row as the hydra.ory.sh/max-retries
+	// annotation allows. The OAuth2Client is not retried until its spec or
+	// the annotation changes.
+	OAuth2ClientConditionRetriesExhausted = "RetriesExhausted"
+	// OAuth2ClientConditionIncompatible is true if the spec sets fields the
+	// detected version of ORY Hydra ignores or deprecates, which the message
+	// lists. It is updated whenever the client is written to ORY Hydra.
+	OAuth2ClientConditionIncompatible = "Incompatible"
 )
 
 // OAuth2ClientDeletionPolicy represents if a deleted oauth2 client object should delete the database row or not.
@@ -277,6 +501,42 @@ type OAuth2ClientDeletionPolicy int
 const (
 	OAuth2ClientDeletionPolicyDelete = iota + 1
 	OAuth2ClientDeletionPolicyOrphan
+	// OAuth2ClientDeletionPolicyDetach orphans the client without adding a
+	// finalizer to the OAuth2Client.
+	OAuth2ClientDeletionPolicyDetach
+)
+
+// ConflictPolicy represents how a client ID which is already taken in ORY Hydra is handled.
+type ConflictPolicy string
+
+const (
+	ConflictPolicyFail       ConflictPolicy = "fail"
+	ConflictPolicyAdopt      ConflictPolicy = "adopt"
+	ConflictPolicyRegenerate ConflictPolicy = "regenerate"
+)
+
+// ConditionReason represents the reason of a condition.
+type ConditionReason string
+
+const (
+	// ReasonReconciled means the client is registered in ORY Hydra as specified.
+	ReasonReconciled ConditionReason = "Reconciled"
+	// ReasonHydraUnavailable means ORY Hydra is down, unreachable or has an unsupported version.
+	ReasonHydraUnavailable ConditionReason = "HydraUnavailable"
+	// ReasonAccessDenied means ORY Hydra rejected the credentials of the controller.
+	ReasonAccessDenied ConditionReason = "AccessDenied"
+	// ReasonInvalidConfiguration means the OAuth2Client or its Secret needs to be fixed.
+	ReasonInvalidConfiguration ConditionReason = "InvalidConfiguration"
+	// ReasonKubernetesError means the controller failed to write Kubernetes resources.
+	ReasonKubernetesError ConditionReason = "KubernetesError"
+	// ReasonHydraError means ORY Hydra failed the request for another reason.
+	ReasonHydraError ConditionReason = "HydraError"
+	// ReasonDeletionProtected means the deletion waits for the protection to be lifted.
+	ReasonDeletionProtected ConditionReason = "DeletionProtected"
+	// ReasonKetoError means ORY Keto failed to write the relation tuples of the client.
+	ReasonKetoError ConditionReason = "KetoError"
+	// ReasonVersionSkew means the version of ORY Hydra ignores or deprecates fields of the spec.
+	ReasonVersionSkew ConditionReason = "VersionSkew"
 )
 
 // +kubebuilder:validation:Enum=True;False;Unknown
@@ -288,6 +548,7 @@ const (
 	ConditionUnknown ConditionStatus = "Unknown"
 )
 
+// +genclient
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 
@@ -90,8 +90,8 @@ func TestCreateAPI(t *testing.T) {
 
 			for desc, modifyClient := range map[string]func(){
 				"invalid grant type":                                func() { created.Spec.GrantTypes = []GrantType{"invalid"} },
-				"invalid response type":                             func() { created.Spec.ResponseTypes = []ResponseType{"invalid", "code"} },
-				"invalid composite response type":                   func() { created.Spec.ResponseTypes = []ResponseType{"invalid code", "code id_token"} },
+				"invalid response type":                             func() { created.Spec.ResponseTypes = []ResponseType{"id-token", "code"} },
+				"invalid composite response type":                   func() { created.Spec.ResponseTypes = []ResponseType{"code  token", "code id_token"} },
 				"missing secret name":                               func() { created.Spec.SecretName = "" },
 				"invalid redirect URI":                              func() { created.Spec.RedirectURIs = []RedirectURI{"invalid"} },
 				"invalid logout redirect URI":                       func() { created.Spec.PostLogoutRedirectURIs = []RedirectURI{"invalid"} },
@@ -110,6 +110,7 @@ func TestCreateAPI(t *testing.T) {
 				"invalid lifespan refresh token id token":           func() { created.Spec.TokenLifespans.RefreshTokenGrantIdTokenLifespan = "invalid" },
 				"invalid lifespan refresh token refresh token":      func() { created.Spec.TokenLifespans.RefreshTokenGrantRefreshTokenLifespan = "invalid" },
 				"invalid deletion policy":                           func() { created.Spec.DeletionPolicy = -1 },
+				"invalid conflict policy":                           func() { created.Spec.ConflictPolicy = "ignore" },
 			} {
 				t.Run(fmt.Sprintf("case=%s", desc), func(t *testing.T) {
 					resetTestClient()
@@ -125,6 +126,7 @@ func TestCreateAPI(t *testing.T) {
 				"single response type": func() { created.Spec.ResponseTypes = []ResponseType{"token", "id_token", "code"} },
 				"double response type": func() { created.Spec.ResponseTypes = []ResponseType{"id_token token", "code id_token", "code token"} },
 				"triple response type": func() { created.Spec.ResponseTypes = []ResponseType{"code id_token token"} },
+				"newer response type":  func() { created.Spec.ResponseTypes = []ResponseType{"vp_token", "vp_token id_token"} },
 			} {
 				t.Run(fmt.Sprintf("case=%s", desc), func(t *testing.T) {
 					resetTestClient()
@@ -176,3 +178,23 @@ func resetTestClient() {
 		},
 	}
 }
+
+func TestStatusCodeReason(t *testing.T) {
+	for code, reason := range map[StatusCode]ConditionReason{
+		StatusHydraUnreachable:      ReasonHydraUnavailable,
+		StatusUnsupportedHydra:      ReasonHydraUnavailable,
+		StatusUnauthorized:          ReasonAccessDenied,
+		StatusInvalidSpec:           ReasonInvalidConfiguration,
+		StatusConflict:              ReasonInvalidConfiguration,
+		StatusInvalidSecret:         ReasonInvalidConfiguration,
+		StatusUpdateSecretFailed:    ReasonKubernetesError,
+		StatusRegistrationFailed:    ReasonHydraError,
+		StatusDeletionProtected:     ReasonDeletionProtected,
+		StatusHydraAddressForbidden: ReasonInvalidConfiguration,
+		StatusScopeNotAllowed:       ReasonInvalidConfiguration,
+		StatusRedirectURINotAllowed: ReasonInvalidConfiguration,
+		StatusRelationTuplesFailed:  ReasonKetoError,
+	} {
+		assert.Equal(t, reason, code.Reason(), "code %s", code)
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var (
+	grantTypes = []GrantType{
+		"client_credentials", "authorization_code", "implicit", "refresh_token",
+	}
+	// responseTypeNames are the response types ORY Hydra supports without
+	// extensions, response types with other names only cause a warning
+	responseTypeNames        = []string{"code", "id_token", "token"}
+	tokenEndpointAuthMethods = []TokenEndpointAuthMethod{
+		"client_secret_basic", "client_secret_post", "private_key_jwt", "none",
+	}
+
+	httpURLPattern   = regexp.MustCompile(`(^$|^https?://.*)`)
+	adminURLPattern  = regexp.MustCompile(`(^$|^[a-z][a-z0-9+.-]*://.*)`)
+	endpointPattern  = regexp.MustCompile(`(^$|^/.*)`)
+	forwardedPattern = regexp.MustCompile(`^(|https?|off)$`)
+	// scopes are made of the characters of scope tokens in RFC 6749 3.3, so
+	// URLs like https://api.example.com/read and read:users are allowed
+	scopePattern      = regexp.MustCompile(`^([!#-\[\]-~]+\s?)*$`)
+	scopeTokenPattern = regexp.MustCompile(`^[!#-\[\]-~]+$`)
+	// redirect URIs start with a scheme as in RFC 3986 3.1, so private-use
+	// schemes of native apps like com.example.app:/callback are allowed
+	redirectURIPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:[^\s]+$`)
+	// CORS origins are bare http(s) origins as sent in the Origin header,
+	// with an optional wildcard matching all subdomains
+	corsOriginPattern = regexp.MustCompile(`^https?://(\*\.)?[^\s/?#@*]+$`)
+	// response types are space-separated response names as in RFC 6749
+	// 3.1.1, so newer ones like vp_token are allowed
+	responseTypePattern = regexp.MustCompile(`^[A-Za-z0-9_]+( [A-Za-z0-9_]+)*$`)
+)
+
+// Validate runs the semantic checks of an OAuth2Client, covering the
+// validation rules of the CRD schema and the constraints between fields which
+// the schema can't express. It returns nil if the resource is valid.
+func (c *OAuth2Client) Validate() field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+	s := c.Spec
+
+	if s.SecretName == "" {
+		errs = append(errs, field.Required(spec.Child("secretName"), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(s.SecretName) {
+			errs = append(errs, field.Invalid(spec.Child("secretName"), s.SecretName, msg))
+		}
+	}
+
+	// empty grant types are set by the namespace defaults
+	if len(s.GrantTypes) > 4 {
+		errs = append(errs, field.TooMany(spec.Child("grantTypes"), len(s.GrantTypes), 4))
+	}
+	for i, gt := range s.GrantTypes {
+		if !contains(grantTypes, gt) {
+			errs = append(errs, field.NotSupported(spec.Child("grantTypes").Index(i), gt, grantTypes))
+		}
+	}
+
+	if len(s.ResponseTypes) > 3 {
+		errs = append(errs, field.TooMany(spec.Child("responseTypes"), len(s.ResponseTypes), 3))
+	}
+	errs = append(errs, validateResponseTypes(spec.Child("responseTypes"), s.ResponseTypes)...)
+
+	errs = append(errs, validateRedirectURIs(spec.Child("redirectUris"), s.RedirectURIs)...)
+	errs = append(errs, validateRedirectURIs(spec.Child("postLogoutRedirectUris"), s.PostLogoutRedirectURIs)...)
+	errs = append(errs, validateCorsOrigins(spec.Child("allowedCorsOrigins"), s.AllowedCorsOrigins)...)
+
+	if !scopePattern.MatchString(s.Scope) {
+		errs = append(errs, field.Invalid(spec.Child("scope"), s.Scope, "must be a space-separated list of scopes"))
+	}
+	if s.Scope != "" && len(s.ScopeArray) > 0 {
+		errs = append(errs, field.Forbidden(spec.Child("scopeArray"), "must not be set together with scope, which is deprecated"))
+	}
+	for i, scope := range s.ScopeArray {
+		if !scopeTokenPattern.MatchString(scope) {
+			errs = append(errs, field.Invalid(spec.Child("scopeArray").Index(i), scope, "must be a scope without spaces, quotes or backslashes"))
+		}
+	}
+
+	if s.TokenEndpointAuthMethod != "" && !contains(tokenEndpointAuthMethods, s.TokenEndpointAuthMethod) {
+		errs = append(errs, field.NotSupported(spec.Child("tokenEndpointAuthMethod"), s.TokenEndpointAuthMethod, tokenEndpointAuthMethods))
+	}
+	if s.TokenEndpointAuthMethod == "private_key_jwt" && s.JwksUri == "" {
+		errs = append(errs, field.Required(spec.Child("jwksUri"), "required when tokenEndpointAuthMethod is private_key_jwt"))
+	}
+
+	for _, f := range []struct{ name, value string }{
+		{"jwksUri", s.JwksUri},
+		{"frontChannelLogoutURI", s.FrontChannelLogoutURI},
+		{"backChannelLogoutURI", s.BackChannelLogoutURI},
+	} {
+		if !httpURLPattern.MatchString(f.value) {
+			errs = append(errs, field.Invalid(spec.Child(f.name), f.value, "must be an http(s) URL"))
+		}
+	}
+
+	errs = append(errs, validateHydraAdmin(spec.Child("hydraAdmin"), s.HydraAdmin)...)
+	errs = append(errs, validateTokenLifespans(spec.Child("tokenLifespans"), s.TokenLifespans)...)
+
+	if s.MetadataFrom != nil {
+		ref := spec.Child("metadataFrom", "configMapRef")
+		if s.MetadataFrom.ConfigMapRef == nil {
+			errs = append(errs, field.Required(ref, ""))
+		} else {
+			for _, msg := range validation.IsDNS1123Subdomain(s.MetadataFrom.ConfigMapRef.Name) {
+				errs = append(errs, field.Invalid(ref.Child("name"), s.MetadataFrom.ConfigMapRef.Name, msg))
+			}
+			if key := s.MetadataFrom.ConfigMapRef.Key; key != "" {
+				for _, msg := range validation.IsConfigMapKey(key) {
+					errs = append(errs, field.Invalid(ref.Child("key"), key, msg))
+				}
+			}
+		}
+	}
+
+	switch s.DeletionPolicy {
+	case 0, OAuth2ClientDeletionPolicyDelete, OAuth2ClientDeletionPolicyOrphan, OAuth2ClientDeletionPolicyDetach:
+	default:
+		errs = append(errs, field.NotSupported(spec.Child("deletionPolicy"), s.DeletionPolicy, []string{"1", "2", "3"}))
+	}
+
+	switch s.ConflictPolicy {
+	case "", ConflictPolicyFail, ConflictPolicyAdopt, ConflictPolicyRegenerate:
+	default:
+		errs = append(errs, field.NotSupported(spec.Child("conflictPolicy"), s.ConflictPolicy, []string{"fail", "adopt", "regenerate"}))
+	}
+
+	if s.DiscoveryConfigMapName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(s.DiscoveryConfigMapName) {
+			errs = append(errs, field.Invalid(spec.Child("discoveryConfigMapName"), s.DiscoveryConfigMapName, msg))
+		}
+	}
+
+	return errs
+}
+
+func validateRedirectURIs(path *field.Path, uris []RedirectURI) field.ErrorList {
+	var errs field.ErrorList
+	for i, u := range uris {
+		if !redirectURIPattern.MatchString(string(u)) {
+			errs = append(errs, field.Invalid(path.Index(i), u, "must be a URI"))
+		} else if strings.Contains(string(u), "#") {
+			errs = append(errs, field.Invalid(path.Index(i), u, "must not contain a fragment"))
+		}
+	}
+	return errs
+}
+
+func validateCorsOrigins(path *field.Path, origins []CorsOrigin) field.ErrorList {
+	var errs field.ErrorList
+	for i, o := range origins {
+		if !corsOriginPattern.MatchString(string(o)) {
+			errs = append(errs, field.Invalid(path.Index(i), o, "must be an http(s) origin without a path, e.g. https://example.com or https://*.example.com"))
+		}
+	}
+	return errs
+}
+
+func validateHydraAdmin(path *field.Path, h HydraAdmin) field.ErrorList {
+	var errs field.ErrorList
+	if len(h.URL) > maxAdminURLLength {
+		errs = append(errs, field.TooLong(path.Child("url"), h.URL, maxAdminURLLength))
+	}
+	if !adminURLPattern.MatchString(h.URL) {
+		errs = append(errs, field.Invalid(path.Child("url"), h.URL, "must be a URL"))
+	} else if httpURLPattern.MatchString(h.URL) && h.URL != "" {
+		if u, err := (HydraAdmin{URL: h.URL}).AdminURL(); err != nil {
+			errs = append(errs, field.Invalid(path.Child("url"), h.URL, "must be a URL with a host, IPv6 addresses in brackets"))
+		} else if p := u.Port(); p != "" && h.Port != 0 && p != strconv.Itoa(h.Port) {
+			errs = append(errs, field.Invalid(path.Child("port"), h.Port, "conflicts with the port of url"))
+		}
+	}
+	if h.Port < 0 || h.Port > 65535 {
+		errs = append(errs, field.Invalid(path.Child("port"), h.Port, "must be a valid port number"))
+	}
+	if ref := h.ServiceRef; ref != nil {
+		refPath := path.Child("serviceRef")
+		if h.URL != "" || h.Port != 0 || h.Endpoint != "" {
+			errs = append(errs, field.Forbidden(refPath, "must not be set together with url, port and endpoint"))
+		}
+		for _, msg := range validation.IsDNS1035Label(ref.Name) {
+			errs = append(errs, field.Invalid(refPath.Child("name"), ref.Name, msg))
+		}
+		if ref.Namespace != "" {
+			for _, msg := range validation.IsDNS1123Label(ref.Namespace) {
+				errs = append(errs, field.Invalid(refPath.Child("namespace"), ref.Namespace, msg))
+			}
+		}
+		if !endpointPattern.MatchString(ref.Path) {
+			errs = append(errs, field.Invalid(refPath.Child("path"), ref.Path, "must be an absolute path"))
+		}
+	}
+	if !endpointPattern.MatchString(h.Endpoint) {
+		errs = append(errs, field.Invalid(path.Child("endpoint"), h.Endpoint, "must be an absolute path"))
+	}
+	if !forwardedPattern.MatchString(h.ForwardedProto) {
+		errs = append(errs, field.Invalid(path.Child("forwardedProto"), h.ForwardedProto, "must be http, https or off"))
+	}
+	if !httpURLPattern.MatchString(h.PublicURL) {
+		errs = append(errs, field.Invalid(path.Child("publicUrl"), h.PublicURL, "must be an http(s) URL"))
+	}
+	if len(h.FallbackURL) > 64 {
+		errs = append(errs, field.TooLong(path.Child("fallbackUrl"), h.FallbackURL, 64))
+	}
+	if !adminURLPattern.MatchString(h.FallbackURL) {
+		errs = append(errs, field.Invalid(path.Child("fallbackUrl"), h.FallbackURL, "must be a URL"))
+	}
+	if h.FallbackPort < 0 || h.FallbackPort > 65535 {
+		errs = append(errs, field.Invalid(path.Child("fallbackPort"), h.FallbackPort, "must be a valid port number"))
+	}
+	if len(h.DNSServers) > maxDNSServers {
+		errs = append(errs, field.TooMany(path.Child("dnsServers"), len(h.DNSServers), maxDNSServers))
+	}
+	for i, server := range h.DNSServers {
+		if _, err := dnsServerAddress(server); err != nil {
+			errs = append(errs, field.Invalid(path.Child("dnsServers").Index(i), server, "must be an IP address with an optional port"))
+		}
+	}
+	if _, err := h.Timeout(); err != nil {
+		errs = append(errs, field.Invalid(path.Child("requestTimeout"), h.RequestTimeout, "must be a positive duration such as 30s"))
+	}
+	return errs
+}
+
+func validateTokenLifespans(path *field.Path, l TokenLifespans) field.ErrorList {
+	var errs field.ErrorList
+	for _, f := range []struct{ name, value string }{
+		{"authorization_code_grant_access_token_lifespan", l.AuthorizationCodeGrantAccessTokenLifespan},
+		{"authorization_code_grant_id_token_lifespan", l.AuthorizationCodeGrantIdTokenLifespan},
+		{"authorization_code_grant_refresh_token_lifespan", l.AuthorizationCodeGrantRefreshTokenLifespan},
+		{"client_credentials_grant_access_token_lifespan", l.ClientCredentialsGrantAccessTokenLifespan},
+		{"implicit_grant_access_token_lifespan", l.ImplicitGrantAccessTokenLifespan},
+		{"implicit_grant_id_token_lifespan", l.ImplicitGrantIdTokenLifespan},
+		{"jwt_bearer_grant_access_token_lifespan", l.JwtBearerGrantAccessTokenLifespan},
+		{"refresh_token_grant_access_token_lifespan", l.RefreshTokenGrantAccessTokenLifespan},
+		{"refresh_token_grant_id_token_lifespan", l.RefreshTokenGrantIdTokenLifespan},
+		{"refresh_token_grant_refresh_token_lifespan", l.RefreshTokenGrantRefreshTokenLifespan},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(f.value); err != nil {
+			errs = append(errs, field.Invalid(path.Child(f.name), f.value, "must be a duration such as 1h or 30m"))
+		}
+	}
+	return errs
+}
+
+func contains[T comparable](values []T, v T) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// validateResponseTypes checks that each response type is a space-separated
+// list of distinct response names, and that no response type is listed
+// twice, in whatever order of its names.
+func validateResponseTypes(path *field.Path, responseTypes []ResponseType) field.ErrorList {
+	var errs field.ErrorList
+	seen := make(map[string]bool, len(responseTypes))
+	for i, rt := range responseTypes {
+		if !responseTypePattern.MatchString(string(rt)) {
+			errs = append(errs, field.Invalid(path.Index(i), rt, "must be a space-separated list of response names made of letters, digits and underscores"))
+			continue
+		}
+		names := strings.Fields(string(rt))
+		sort.Strings(names)
+		if dup := repeated(names); dup != "" {
+			errs = append(errs, field.Invalid(path.Index(i), rt, fmt.Sprintf("must not repeat %s", dup)))
+			continue
+		}
+		key := strings.Join(names, " ")
+		if seen[key] {
+			errs = append(errs, field.Duplicate(path.Index(i), rt))
+		}
+		seen[key] = true
+	}
+	return errs
+}
+
+// repeated returns the first name of the sorted names which is repeated, or
+// "" if they are distinct.
+func repeated(names []string) string {
+	for i := 1; i < len(names); i++ {
+		if names[i] == names[i-1] {
+			return names[i]
+		}
+	}
+	return ""
+}
+
+// Warnings returns the warnings about the spec of c which don't make it
+// invalid, e.g. response types ORY Hydra doesn't support without extensions.
+func (c *OAuth2Client) Warnings() []string {
+	var warnings []string
+	for i, rt := range c.Spec.ResponseTypes {
+		for _, name := range strings.Fields(string(rt)) {
+			if !contains(responseTypeNames, name) {
+				warnings = append(warnings, fmt.Sprintf("spec.responseTypes[%d]: %s is not one of the response types ORY Hydra supports without extensions: %s", i, name, strings.Join(responseTypeNames, ", ")))
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,208 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() *OAuth2Client {
+		return &OAuth2Client{
+			Spec: OAuth2ClientSpec{
+				GrantTypes:    []GrantType{"client_credentials"},
+				ResponseTypes: []ResponseType{"token"},
+				RedirectURIs:  []RedirectURI{"https://client/callback"},
+				Scope:         "read write",
+				SecretName:    "my-secret",
+				HydraAdmin: HydraAdmin{
+					URL:      "http://hydra-admin",
+					Port:     4445,
+					Endpoint: "/clients",
+				},
+				TokenLifespans: TokenLifespans{
+					ClientCredentialsGrantAccessTokenLifespan: "1h",
+				},
+				Metadata: map[string]apiextensionsv1.JSON{"tier": {Raw: []byte(`"gold"`)}},
+			},
+		}
+	}
+
+	assert.Empty(t, valid().Validate())
+
+	custom := valid()
+	custom.Spec.HydraAdmin.URL = "unix:///var/run/hydra.sock"
+	assert.Empty(t, custom.Validate())
+
+	fullURL := valid()
+	fullURL.Spec.HydraAdmin = HydraAdmin{URL: "http://[fd00::1]:4445/admin/clients"}
+	assert.Empty(t, fullURL.Validate())
+
+	serviceRef := valid()
+	serviceRef.Spec.HydraAdmin = HydraAdmin{ServiceRef: &ServiceReference{Name: "hydra-admin", Namespace: "ory", Port: intstr.FromString("http-admin")}}
+	assert.Empty(t, serviceRef.Validate())
+
+	nativeApp := valid()
+	nativeApp.Spec.RedirectURIs = []RedirectURI{
+		"http://127.0.0.1:51004/callback",
+		"http://[::1]/callback",
+		"com.example.app:/oauth2redirect",
+		"https://client/callback?tenant=a&flow=b",
+	}
+	assert.Empty(t, nativeApp.Validate())
+
+	corsOrigins := valid()
+	corsOrigins.Spec.AllowedCorsOrigins = []CorsOrigin{
+		"https://example.com",
+		"https://*.example.com",
+		"http://localhost:3000",
+	}
+	assert.Empty(t, corsOrigins.Validate())
+
+	scopes := valid()
+	scopes.Spec.Scope = "openid read:users https://api.example.com/read api-gateway.write"
+	assert.Empty(t, scopes.Validate())
+
+	responseTypes := valid()
+	responseTypes.Spec.ResponseTypes = []ResponseType{"code id_token", "vp_token", "id_token vp_token"}
+	assert.Empty(t, responseTypes.Validate())
+
+	scopeArray := valid()
+	scopeArray.Spec.Scope = ""
+	scopeArray.Spec.ScopeArray = []string{"read:users", "https://api.example.com/read"}
+	assert.Empty(t, scopeArray.Validate())
+
+	for desc, tc := range map[string]struct {
+		modify func(c *OAuth2Client)
+		field  string
+	}{
+		"missing secret name": {
+			func(c *OAuth2Client) { c.Spec.SecretName = "" },
+			"spec.secretName",
+		},
+		"unknown grant type": {
+			func(c *OAuth2Client) { c.Spec.GrantTypes = []GrantType{"password"} },
+			"spec.grantTypes[0]",
+		},
+		"malformed response type": {
+			func(c *OAuth2Client) { c.Spec.ResponseTypes = []ResponseType{"code", "code,id_token"} },
+			"spec.responseTypes[1]",
+		},
+		"response type repeating a name": {
+			func(c *OAuth2Client) { c.Spec.ResponseTypes = []ResponseType{"code token code"} },
+			"spec.responseTypes[0]",
+		},
+		"response type listed twice": {
+			func(c *OAuth2Client) {
+				c.Spec.ResponseTypes = []ResponseType{"code id_token", "token", "id_token code"}
+			},
+			"spec.responseTypes[2]",
+		},
+		"private_key_jwt without jwksUri": {
+			func(c *OAuth2Client) { c.Spec.TokenEndpointAuthMethod = "private_key_jwt" },
+			"spec.jwksUri",
+		},
+		"invalid hydra admin port": {
+			func(c *OAuth2Client) { c.Spec.HydraAdmin.Port = 70000 },
+			"spec.hydraAdmin.port",
+		},
+		"hydra admin URL with an IPv6 address without brackets": {
+			func(c *OAuth2Client) { c.Spec.HydraAdmin = HydraAdmin{URL: "http://fd00::1:4445"} },
+			"spec.hydraAdmin.url",
+		},
+		"hydra admin port conflicting with the URL": {
+			func(c *OAuth2Client) { c.Spec.HydraAdmin.URL = "http://hydra-admin:4446" },
+			"spec.hydraAdmin.port",
+		},
+		"hydra admin service reference with a URL": {
+			func(c *OAuth2Client) { c.Spec.HydraAdmin.ServiceRef = &ServiceReference{Name: "hydra-admin"} },
+			"spec.hydraAdmin.serviceRef",
+		},
+		"hydra admin service reference with an invalid name": {
+			func(c *OAuth2Client) {
+				c.Spec.HydraAdmin = HydraAdmin{ServiceRef: &ServiceReference{Name: "hydra.admin"}}
+			},
+			"spec.hydraAdmin.serviceRef.name",
+		},
+		"hydra admin DNS server with a host name": {
+			func(c *OAuth2Client) { c.Spec.HydraAdmin.DNSServers = []string{"10.0.0.53", "dns.internal:53"} },
+			"spec.hydraAdmin.dnsServers[1]",
+		},
+		"zero hydra admin request timeout": {
+			func(c *OAuth2Client) { c.Spec.HydraAdmin.RequestTimeout = "0s" },
+			"spec.hydraAdmin.requestTimeout",
+		},
+		"invalid hydra admin fallback URL": {
+			func(c *OAuth2Client) { c.Spec.HydraAdmin.FallbackURL = "hydra-admin:4445" },
+			"spec.hydraAdmin.fallbackUrl",
+		},
+		"invalid token lifespan": {
+			func(c *OAuth2Client) { c.Spec.TokenLifespans.ImplicitGrantAccessTokenLifespan = "1d" },
+			"spec.tokenLifespans.implicit_grant_access_token_lifespan",
+		},
+		"redirect URI without scheme": {
+			func(c *OAuth2Client) { c.Spec.RedirectURIs = []RedirectURI{"127.0.0.1:8080/callback"} },
+			"spec.redirectUris[0]",
+		},
+		"redirect URI with fragment": {
+			func(c *OAuth2Client) { c.Spec.RedirectURIs = []RedirectURI{"https://client/callback#token"} },
+			"spec.redirectUris[0]",
+		},
+		"CORS origin with path": {
+			func(c *OAuth2Client) { c.Spec.AllowedCorsOrigins = []CorsOrigin{"https://example.com/"} },
+			"spec.allowedCorsOrigins[0]",
+		},
+		"CORS origin with wildcard in the middle": {
+			func(c *OAuth2Client) { c.Spec.AllowedCorsOrigins = []CorsOrigin{"https://app.*.example.com"} },
+			"spec.allowedCorsOrigins[0]",
+		},
+		"CORS origin without http(s) scheme": {
+			func(c *OAuth2Client) { c.Spec.AllowedCorsOrigins = []CorsOrigin{"com.example.app://"} },
+			"spec.allowedCorsOrigins[0]",
+		},
+		"scope with quotes": {
+			func(c *OAuth2Client) { c.Spec.Scope = `read "write"` },
+			"spec.scope",
+		},
+		"scope array item with spaces": {
+			func(c *OAuth2Client) {
+				c.Spec.Scope = ""
+				c.Spec.ScopeArray = []string{"read", "read write"}
+			},
+			"spec.scopeArray[1]",
+		},
+		"scope and scope array": {
+			func(c *OAuth2Client) { c.Spec.ScopeArray = []string{"read"} },
+			"spec.scopeArray",
+		},
+		"metadataFrom without a reference": {
+			func(c *OAuth2Client) { c.Spec.MetadataFrom = &MetadataSource{} },
+			"spec.metadataFrom.configMapRef",
+		},
+		"metadataFrom with an invalid ConfigMap key": {
+			func(c *OAuth2Client) {
+				c.Spec.MetadataFrom = &MetadataSource{ConfigMapRef: &ConfigMapKeyRef{Name: "metadata", Key: "cost center"}}
+			},
+			"spec.metadataFrom.configMapRef.key",
+		},
+		"unknown conflict policy": {
+			func(c *OAuth2Client) { c.Spec.ConflictPolicy = "ignore" },
+			"spec.conflictPolicy",
+		},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			c := valid()
+			tc.modify(c)
+
+			errs := c.Validate()
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, tc.field, errs[0].Field)
+			}
+		})
+	}
+}
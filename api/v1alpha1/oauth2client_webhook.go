@@ -0,0 +1,108 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/mutate-hydra-ory-sh-v1alpha1-oauth2client,mutating=true,failurePolicy=fail,sideEffects=None,groups=hydra.ory.sh,resources=oauth2clients,verbs=create;update,versions=v1alpha1,name=moauth2client.hydra.ory.sh,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-hydra-ory-sh-v1alpha1-oauth2client,mutating=false,failurePolicy=fail,sideEffects=None,groups=hydra.ory.sh,resources=oauth2clients,verbs=create;update;delete,versions=v1alpha1,name=voauth2client.hydra.ory.sh,admissionReviewVersions=v1
+
+// ProtectAnnotation protects an OAuth2Client and its client in ORY Hydra from
+// being deleted, set to "true". The Validator rejects the deletion until the
+// annotation is removed.
+const ProtectAnnotation = "hydra.ory.sh/protect"
+
+// Validator is the validating admission webhook of OAuth2Clients. It rejects
+// OAuth2Clients failing Validate, covering the constraints the CRD schema
+// checks only loosely, e.g. the format of scopes, and returns their Warnings.
+// Deletions of protected OAuth2Clients are rejected too.
+type Validator struct{}
+
+var _ admission.CustomValidator = Validator{}
+
+// Defaulter is the mutating admission webhook of OAuth2Clients. It moves
+// the deprecated spec.hydraAdmin.port and endpoint into spec.hydraAdmin.url,
+// see HydraAdmin.FoldAddress.
+type Defaulter struct{}
+
+var _ admission.CustomDefaulter = Defaulter{}
+
+// SetupWebhookWithManager registers the mutating and validating webhooks of
+// OAuth2Clients with the webhook server of mgr.
+func SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&OAuth2Client{}).
+		WithDefaulter(Defaulter{}).
+		WithValidator(Validator{}).
+		Complete()
+}
+
+// Default implements admission.CustomDefaulter.
+func (Defaulter) Default(_ context.Context, obj runtime.Object) error {
+	c, ok := obj.(*OAuth2Client)
+	if !ok {
+		return fmt.Errorf("expected an OAuth2Client, got %T", obj)
+	}
+	c.Spec.HydraAdmin.FoldAddress()
+	return nil
+}
+
+// ValidateCreate implements admission.CustomValidator.
+func (Validator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return validate(obj)
+}
+
+// ValidateUpdate implements admission.CustomValidator. Updates which leave
+// the spec unchanged, e.g. of finalizers or annotations, are always allowed,
+// so OAuth2Clients created before a validation was added can still be
+// deleted.
+func (Validator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	old, ok := oldObj.(*OAuth2Client)
+	if !ok {
+		return nil, fmt.Errorf("expected an OAuth2Client, got %T", oldObj)
+	}
+	c, ok := newObj.(*OAuth2Client)
+	if !ok {
+		return nil, fmt.Errorf("expected an OAuth2Client, got %T", newObj)
+	}
+	if equality.Semantic.DeepEqual(old.Spec, c.Spec) {
+		return nil, nil
+	}
+	return validate(c)
+}
+
+// ValidateDelete implements admission.CustomValidator. Deletions of
+// OAuth2Clients with the ProtectAnnotation are rejected.
+func (Validator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	c, ok := obj.(*OAuth2Client)
+	if !ok {
+		return nil, fmt.Errorf("expected an OAuth2Client, got %T", obj)
+	}
+	if c.Annotations[ProtectAnnotation] == "true" {
+		return nil, apierrors.NewForbidden(GroupVersion.WithResource("oauth2clients").GroupResource(), c.Name,
+			fmt.Errorf("deletion is blocked by the %s annotation, remove it to delete the OAuth2Client", ProtectAnnotation))
+	}
+	return nil, nil
+}
+
+// validate returns the Warnings of obj, or an error if it fails Validate.
+func validate(obj runtime.Object) (admission.Warnings, error) {
+	c, ok := obj.(*OAuth2Client)
+	if !ok {
+		return nil, fmt.Errorf("expected an OAuth2Client, got %T", obj)
+	}
+	if errs := c.Validate(); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(GroupVersion.WithKind("OAuth2Client").GroupKind(), c.Name, errs)
+	}
+	return c.Warnings(), nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidator(t *testing.T) {
+	valid := &OAuth2Client{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-client", Namespace: "default"},
+		Spec: OAuth2ClientSpec{
+			GrantTypes: []GrantType{"client_credentials"},
+			Scope:      "read:users https://api.example.com/read",
+			SecretName: "my-secret",
+		},
+	}
+	invalid := valid.DeepCopy()
+	invalid.Spec.Scope = `read "write"`
+
+	t.Run("case=create", func(t *testing.T) {
+		_, err := Validator{}.ValidateCreate(context.Background(), valid)
+		assert.NoError(t, err)
+
+		_, err = Validator{}.ValidateCreate(context.Background(), invalid)
+		assert.True(t, apierrors.IsInvalid(err), "%v", err)
+		assert.ErrorContains(t, err, "spec.scope")
+	})
+
+	t.Run("case=warns about response types ORY Hydra does not support natively", func(t *testing.T) {
+		c := valid.DeepCopy()
+		c.Spec.ResponseTypes = []ResponseType{"code", "vp_token id_token"}
+		warnings, err := Validator{}.ValidateCreate(context.Background(), c)
+		assert.NoError(t, err)
+		assert.Equal(t, admission.Warnings{"spec.responseTypes[1]: vp_token is not one of the response types ORY Hydra supports without extensions: code, id_token, token"}, warnings)
+
+		warnings, err = Validator{}.ValidateUpdate(context.Background(), valid, c)
+		assert.NoError(t, err)
+		assert.Len(t, warnings, 1)
+
+		warnings, err = Validator{}.ValidateCreate(context.Background(), valid)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("case=update", func(t *testing.T) {
+		_, err := Validator{}.ValidateUpdate(context.Background(), valid, invalid)
+		assert.True(t, apierrors.IsInvalid(err), "%v", err)
+
+		// invalid OAuth2Clients whose spec does not change can still be
+		// updated, e.g. to remove their finalizer
+		finalized := invalid.DeepCopy()
+		finalized.Finalizers = nil
+		_, err = Validator{}.ValidateUpdate(context.Background(), invalid, finalized)
+		assert.NoError(t, err)
+	})
+
+	t.Run("case=delete", func(t *testing.T) {
+		_, err := Validator{}.ValidateDelete(context.Background(), invalid)
+		assert.NoError(t, err)
+	})
+
+	t.Run("case=rejects deleting protected OAuth2Clients", func(t *testing.T) {
+		protected := valid.DeepCopy()
+		protected.Annotations = map[string]string{ProtectAnnotation: "true"}
+		_, err := Validator{}.ValidateDelete(context.Background(), protected)
+		assert.True(t, apierrors.IsForbidden(err), "%v", err)
+		assert.ErrorContains(t, err, "deletion is blocked by the hydra.ory.sh/protect annotation")
+
+		protected.Annotations[ProtectAnnotation] = "false"
+		_, err = Validator{}.ValidateDelete(context.Background(), protected)
+		assert.NoError(t, err)
+	})
+}
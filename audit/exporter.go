@@ -0,0 +1,143 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+	defaultMaxWait       = 5 * time.Second
+	defaultBackoff       = time.Second
+	maxBackoff           = time.Minute
+)
+
+// Exporter queues audit records and sends them to a sink in batches. Failed
+// batches are retried with exponential backoff until they are sent. While the
+// sink is unavailable the queue fills up, and Audit blocks for up to MaxWait
+// before the record is dropped, slowing down the reconciliations instead of
+// buffering without bound.
+type Exporter struct {
+	Sink Sink
+	Log  logr.Logger
+	// BatchSize is the maximum number of records sent at once.
+	BatchSize int
+	// FlushInterval is how long records are collected before an incomplete
+	// batch is sent.
+	FlushInterval time.Duration
+	// MaxWait is how long Audit waits for room in a full queue.
+	MaxWait time.Duration
+	// Backoff is the delay before the first retry of a failed batch. It
+	// doubles with every retry, up to a minute.
+	Backoff time.Duration
+
+	queue chan Record
+}
+
+// NewExporter returns an exporter to sink queueing up to queueSize records.
+// It must be started, e.g. by adding it to the manager.
+func NewExporter(sink Sink, queueSize int, log logr.Logger) *Exporter {
+	return &Exporter{
+		Sink:          sink,
+		Log:           log,
+		BatchSize:     defaultBatchSize,
+		FlushInterval: defaultFlushInterval,
+		MaxWait:       defaultMaxWait,
+		Backoff:       defaultBackoff,
+		queue:         make(chan Record, queueSize),
+	}
+}
+
+// Audit queues r for export. If the queue stays full for MaxWait or ctx is
+// done, r is dropped and logged.
+func (e *Exporter) Audit(ctx context.Context, r Record) {
+	select {
+	case e.queue <- r:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(e.MaxWait)
+	defer timer.Stop()
+	select {
+	case e.queue <- r:
+	case <-timer.C:
+		e.Log.Error(nil, "audit queue is full, dropping record", "record", r)
+	case <-ctx.Done():
+		e.Log.Error(ctx.Err(), "dropping audit record", "record", r)
+	}
+}
+
+// Start sends the queued records until ctx is done. The records which are
+// queued then are flushed with a last attempt.
+func (e *Exporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(e.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []Record
+	for {
+		select {
+		case <-ctx.Done():
+			e.flush(batch)
+			return nil
+		case r := <-e.queue:
+			batch = append(batch, r)
+			if len(batch) < e.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if err := e.send(ctx, batch); err != nil {
+			// ctx is done, the next iteration flushes the batch
+			continue
+		}
+		batch = nil
+	}
+}
+
+// send sends batch, retrying until it succeeds or ctx is done.
+func (e *Exporter) send(ctx context.Context, batch []Record) error {
+	backoff := e.Backoff
+	for {
+		err := e.Sink.Send(ctx, batch)
+		if err == nil {
+			return nil
+		}
+		e.Log.Error(err, "exporting audit records failed, retrying", "records", len(batch), "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// flush makes a last attempt to send batch and the queued records.
+func (e *Exporter) flush(batch []Record) {
+	for len(e.queue) > 0 {
+		batch = append(batch, <-e.queue)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.FlushInterval)
+	defer cancel()
+	if err := e.Sink.Send(ctx, batch); err != nil {
+		e.Log.Error(err, "dropping audit records on shutdown", "records", len(batch))
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/hydra-maester/audit"
+)
+
+// sinkFunc is a Sink collecting the records it is sent, failing while fail
+// returns true.
+type sinkFunc struct {
+	mu      sync.Mutex
+	fail    func() bool
+	batches [][]audit.Record
+}
+
+func (s *sinkFunc) Send(_ context.Context, records []audit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail != nil && s.fail() {
+		return errors.New("unavailable")
+	}
+	s.batches = append(s.batches, records)
+	return nil
+}
+
+func (s *sinkFunc) received() []audit.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []audit.Record
+	for _, b := range s.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+func TestExporter(t *testing.T) {
+	t.Run("case=sends batches", func(t *testing.T) {
+		sink := &sinkFunc{}
+		e := audit.NewExporter(sink, 10, logr.Discard())
+		e.BatchSize = 2
+		e.FlushInterval = time.Hour
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = e.Start(ctx)
+		}()
+
+		for _, id := range []string{"a", "b", "c"} {
+			e.Audit(ctx, audit.Record{ClientID: id})
+		}
+		assert.Eventually(t, func() bool { return len(sink.received()) == 2 }, time.Second, 5*time.Millisecond)
+		cancel()
+		<-done
+
+		// the incomplete batch is flushed on shutdown
+		assert.Equal(t, [][]audit.Record{{{ClientID: "a"}, {ClientID: "b"}}, {{ClientID: "c"}}}, sink.batches)
+	})
+
+	t.Run("case=retries failed batches", func(t *testing.T) {
+		attempts := 0
+		sink := &sinkFunc{fail: func() bool {
+			attempts++
+			return attempts < 3
+		}}
+		e := audit.NewExporter(sink, 10, logr.Discard())
+		e.FlushInterval = 10 * time.Millisecond
+		e.Backoff = time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = e.Start(ctx) }()
+
+		e.Audit(ctx, audit.Record{ClientID: "a"})
+		assert.Eventually(t, func() bool { return len(sink.received()) == 1 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("case=drops records when the queue stays full", func(t *testing.T) {
+		e := audit.NewExporter(&sinkFunc{}, 1, logr.Discard())
+		e.MaxWait = 10 * time.Millisecond
+
+		start := time.Now()
+		e.Audit(context.Background(), audit.Record{ClientID: "a"})
+		e.Audit(context.Background(), audit.Record{ClientID: "b"})
+		assert.GreaterOrEqual(t, time.Since(start), e.MaxWait)
+	})
+
+	t.Run("case=flushes queued records on shutdown", func(t *testing.T) {
+		sink := &sinkFunc{}
+		e := audit.NewExporter(sink, 10, logr.Discard())
+		e.FlushInterval = time.Hour
+
+		ctx, cancel := context.WithCancel(context.Background())
+		e.Audit(ctx, audit.Record{ClientID: "a"})
+		cancel()
+		assert.NoError(t, e.Start(ctx))
+		assert.Equal(t, []audit.Record{{ClientID: "a"}}, sink.received())
+	})
+}
@@ -0,0 +1,39 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package audit records the changes the controller makes to clients in ORY
+// Hydra and exports them to an external sink, e.g. a SIEM, so the lifecycle
+// of client credentials can be traced outside of the cluster.
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+// Action is the kind of change made to a client in ORY Hydra.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Record describes a change made to a client in ORY Hydra. Failed changes are
+// recorded too, with Error set.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Action    Action    `json:"action"`
+	ClientID  string    `json:"clientId,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Auditor receives the audit records of the controller.
+type Auditor interface {
+	Audit(ctx context.Context, r Record)
+}
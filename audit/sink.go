@@ -0,0 +1,180 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sink sends batches of audit records to an external system.
+type Sink interface {
+	Send(ctx context.Context, records []Record) error
+}
+
+// ParseSink returns the sink of rawURL:
+//
+//   - http:// and https:// URLs receive the records as a JSON array in a POST
+//     request, see WebhookSink.
+//   - syslog+tcp://host:port and syslog+udp://host:port receive one RFC 5424
+//     message per record, see SyslogSink.
+//   - kafka+http:// and kafka+https:// URLs of a topic of a Kafka REST Proxy,
+//     e.g. kafka+https://kafka-rest:8082/topics/hydra-audit, produce one
+//     message per record, see KafkaRESTSink.
+//
+// header is added to the requests of the HTTP based sinks.
+func ParseSink(rawURL string, header http.Header) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit sink: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid audit sink %q: the host is missing", rawURL)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return &WebhookSink{URL: u.String(), Header: header}, nil
+	case "syslog+tcp", "syslog+udp":
+		return &SyslogSink{Network: strings.TrimPrefix(u.Scheme, "syslog+"), Address: u.Host}, nil
+	case "kafka+http", "kafka+https":
+		if !strings.Contains(u.Path, "/topics/") {
+			return nil, fmt.Errorf("invalid audit sink %q: the path must name a topic, e.g. /topics/hydra-audit", rawURL)
+		}
+		u.Scheme = strings.TrimPrefix(u.Scheme, "kafka+")
+		return &KafkaRESTSink{URL: u.String(), Header: header}, nil
+	default:
+		return nil, fmt.Errorf("invalid audit sink %q: unsupported scheme %q", rawURL, u.Scheme)
+	}
+}
+
+// WebhookSink posts the records as a JSON array to URL.
+type WebhookSink struct {
+	URL    string
+	Header http.Header
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Send implements Sink.
+func (s *WebhookSink) Send(ctx context.Context, records []Record) error {
+	return post(ctx, s.HTTPClient, s.URL, "application/json", s.Header, records)
+}
+
+// KafkaRESTSink produces one message per record to the topic of URL with the
+// v2 API of the Kafka REST Proxy. The messages are keyed by the client ID, so
+// the records of a client keep their order.
+type KafkaRESTSink struct {
+	// URL is the address of the topic, e.g. https://kafka-rest:8082/topics/hydra-audit.
+	URL    string
+	Header http.Header
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Send implements Sink.
+func (s *KafkaRESTSink) Send(ctx context.Context, records []Record) error {
+	type message struct {
+		Key   string `json:"key,omitempty"`
+		Value Record `json:"value"`
+	}
+	body := struct {
+		Records []message `json:"records"`
+	}{}
+	for _, r := range records {
+		body.Records = append(body.Records, message{Key: r.ClientID, Value: r})
+	}
+	return post(ctx, s.HTTPClient, s.URL, "application/vnd.kafka.json.v2+json", s.Header, body)
+}
+
+func post(ctx context.Context, c *http.Client, url, contentType string, header http.Header, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded with %s", url, resp.Status)
+	}
+	return nil
+}
+
+// syslogPriority is the priority of the messages of SyslogSink, the
+// authpriv facility and the notice severity.
+const syslogPriority = 10*8 + 5
+
+// SyslogSink sends one RFC 5424 message per record to a syslog server. The
+// message is the record in JSON. Over TCP, the messages are framed by octet
+// counting (RFC 6587).
+type SyslogSink struct {
+	// Network is tcp or udp.
+	Network string
+	Address string
+	// AppName is the APP-NAME of the messages, hydra-maester if empty.
+	AppName string
+}
+
+// Send implements Sink. Every batch is sent over a new connection.
+func (s *SyslogSink) Send(ctx context.Context, records []Record) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, s.Network, s.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	appName := s.AppName
+	if appName == "" {
+		appName = "hydra-maester"
+	}
+
+	for _, r := range records {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("<%d>1 %s %s %s - %s - %s", syslogPriority, r.Time.UTC().Format(time.RFC3339Nano), hostname, appName, r.Action, b)
+		if s.Network == "tcp" {
+			msg = fmt.Sprintf("%d %s", len(msg), msg)
+		}
+		if _, err := io.WriteString(conn, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
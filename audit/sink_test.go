@@ -0,0 +1,135 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/audit"
+)
+
+var record = audit.Record{
+	Time:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	Action:    audit.ActionCreate,
+	ClientID:  "client-id",
+	Owner:     "name/namespace",
+	Namespace: "namespace",
+	Name:      "name",
+}
+
+func TestParseSink(t *testing.T) {
+	for _, tc := range []struct {
+		url      string
+		expected audit.Sink
+	}{
+		{url: "https://siem.example.com/hooks/hydra", expected: &audit.WebhookSink{URL: "https://siem.example.com/hooks/hydra"}},
+		{url: "syslog+tcp://syslog:514", expected: &audit.SyslogSink{Network: "tcp", Address: "syslog:514"}},
+		{url: "syslog+udp://syslog:514", expected: &audit.SyslogSink{Network: "udp", Address: "syslog:514"}},
+		{url: "kafka+https://kafka-rest:8082/topics/hydra-audit", expected: &audit.KafkaRESTSink{URL: "https://kafka-rest:8082/topics/hydra-audit"}},
+	} {
+		t.Run("url="+tc.url, func(t *testing.T) {
+			sink, err := audit.ParseSink(tc.url, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, sink)
+		})
+	}
+
+	for _, url := range []string{"ftp://siem", "https://", "kafka+https://kafka-rest:8082/hydra-audit"} {
+		t.Run("invalid="+url, func(t *testing.T) {
+			_, err := audit.ParseSink(url, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []audit.Record
+	var auth string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer s.Close()
+
+	sink, err := audit.ParseSink(s.URL, http.Header{"Authorization": []string{"Bearer token"}})
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(context.Background(), []audit.Record{record}))
+	assert.Equal(t, []audit.Record{record}, received)
+	assert.Equal(t, "Bearer token", auth)
+
+	t.Run("case=fails on error responses", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer s.Close()
+
+		err := (&audit.WebhookSink{URL: s.URL}).Send(context.Background(), []audit.Record{record})
+		assert.ErrorContains(t, err, "503")
+	})
+}
+
+func TestKafkaRESTSink(t *testing.T) {
+	var body struct {
+		Records []struct {
+			Key   string       `json:"key"`
+			Value audit.Record `json:"value"`
+		} `json:"records"`
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/hydra-audit", r.URL.Path)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer s.Close()
+
+	sink, err := audit.ParseSink("kafka+"+s.URL+"/topics/hydra-audit", nil)
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(context.Background(), []audit.Record{record}))
+	require.Len(t, body.Records, 1)
+	assert.Equal(t, "client-id", body.Records[0].Key)
+	assert.Equal(t, record, body.Records[0].Value)
+}
+
+func TestSyslogSink(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	sink := &audit.SyslogSink{Network: "tcp", Address: l.Addr().String()}
+	require.NoError(t, sink.Send(context.Background(), []audit.Record{record}))
+
+	msg := <-received
+	length, msg, ok := strings.Cut(msg, " ")
+	require.True(t, ok)
+	n, err := strconv.Atoi(length)
+	require.NoError(t, err)
+	assert.Len(t, msg, n)
+	assert.True(t, strings.HasPrefix(msg, "<85>1 2024-05-01T12:00:00Z "), msg)
+	assert.Contains(t, msg, " hydra-maester - create - {")
+	assert.Contains(t, msg, `"clientId":"client-id"`)
+}
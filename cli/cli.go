@@ -0,0 +1,245 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cli implements the hydra-maester subcommands and the kubectl-hydra
+// plugin. Both talk to the cluster with the current kubeconfig and to ORY
+// Hydra with the same flags as the controller.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+const usage = `Usage: hydra-maester [flags]
+       hydra-maester <command> [flags]
+
+Without a command, hydra-maester runs the controller.
+
+Commands:
+  import    Print OAuth2Client manifests for the clients of an ORY Hydra instance
+  export    Print the controller-owned clients of an ORY Hydra instance for backup
+  restore   Replay backed up clients into an ORY Hydra instance
+  validate  Check OAuth2Client manifests without a cluster
+  migrate   Rewrite OAuth2Client manifests to the current form of the API
+  doctor    Check the cluster and ORY Hydra for configuration problems
+  decrypt   Decrypt the encrypted client secrets of a mounted Secret
+  schema    Print the JSON Schema of OAuth2Client manifests`
+
+// Run runs the hydra-maester subcommand name with the given arguments.
+func Run(name string, args []string, out io.Writer) error {
+	switch name {
+	case "import":
+		return runImport(args, out)
+	case "export":
+		return runExport(args, out)
+	case "restore":
+		return runRestore(args, out)
+	case "validate":
+		return runValidate(args, out)
+	case "migrate":
+		return runMigrate(args, out)
+	case "doctor":
+		return runDoctor(args, out)
+	case "decrypt":
+		return runDecrypt(args, out)
+	case "schema":
+		return runSchema(args, out)
+	case "help":
+		fmt.Fprintln(out, usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", name, usage)
+	}
+}
+
+// hydraOptions holds the flags used to reach the default ORY Hydra instance.
+type hydraOptions struct {
+	url, endpoint, forwardedProto, tlsTrustStore, dnsServers, requestTimeout string
+	port                                                                     int
+	insecureSkipVerify                                                       bool
+}
+
+func (o *hydraOptions) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.url, "hydra-url", "", "The address of ORY Hydra, optionally with the port and path of the clients endpoint, which take precedence over hydra-port and endpoint")
+	fs.IntVar(&o.port, "hydra-port", 4445, "Port ORY Hydra is listening on")
+	fs.StringVar(&o.endpoint, "endpoint", "/clients", "ORY Hydra's client endpoint")
+	fs.StringVar(&o.forwardedProto, "forwarded-proto", "", "If set, this adds the value as the X-Forwarded-Proto header in requests to the ORY Hydra admin server")
+	fs.StringVar(&o.tlsTrustStore, "tls-trust-store", "", "trust store certificate path. If set ca will be set in http client to connect with hydra admin")
+	fs.BoolVar(&o.insecureSkipVerify, "insecure-skip-verify", false, "If set, http client will be configured to skip insecure verification to connect with hydra admin")
+	fs.StringVar(&o.dnsServers, "dns-servers", "", "The --dns-servers of the controller, comma-separated")
+	fs.StringVar(&o.requestTimeout, "hydra-request-timeout", "", "The --hydra-request-timeout of the controller, e.g. 10s")
+}
+
+func (o *hydraOptions) spec() hydrav1alpha1.OAuth2ClientSpec {
+	// the port and path of the URL take precedence over the flags
+	endpoint := o.endpoint
+	if u, err := (hydrav1alpha1.HydraAdmin{URL: o.url}).AdminURL(); err == nil && strings.Trim(u.Path, "/") != "" {
+		endpoint = ""
+	}
+	return hydrav1alpha1.OAuth2ClientSpec{
+		HydraAdmin: hydrav1alpha1.HydraAdmin{
+			URL:            o.url,
+			Port:           o.port,
+			Endpoint:       endpoint,
+			ForwardedProto: o.forwardedProto,
+			DNSServers:     o.dnsServerList(),
+			RequestTimeout: o.requestTimeoutOrEmpty(),
+		},
+	}
+}
+
+// dnsServerList returns the DNS servers of the dns-servers flag.
+func (o *hydraOptions) dnsServerList() []string {
+	var servers []string
+	for _, server := range strings.Split(o.dnsServers, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// requestTimeoutOrEmpty returns the hydra-request-timeout flag, or "" if it
+// is zero, like the controller treats it.
+func (o *hydraOptions) requestTimeoutOrEmpty() string {
+	if d, err := time.ParseDuration(o.requestTimeout); err == nil && d == 0 {
+		return ""
+	}
+	return o.requestTimeout
+}
+
+// defaultClient returns a client for the instance configured by the flags.
+func (o *hydraOptions) defaultClient() (hydra.Client, error) {
+	if o.url == "" {
+		return nil, fmt.Errorf("--hydra-url can't be empty")
+	}
+	return hydra.New(o.spec(), o.tlsTrustStore, o.insecureSkipVerify)
+}
+
+// clientFor returns the client managing c, honoring its hydraAdmin override
+// the same way the controller does.
+func (o *hydraOptions) clientFor(c *hydrav1alpha1.OAuth2Client) (hydra.Client, error) {
+	if ref := c.Spec.HydraAdmin.ServiceRef; ref != nil {
+		return nil, fmt.Errorf("OAuth2Client %s/%s references the hydra admin service %s, which is only reachable in the cluster", c.Namespace, c.Name, ref.Name)
+	}
+	if c.Spec.HydraAdmin.URL != "" {
+		spec := c.Spec
+		if len(spec.HydraAdmin.DNSServers) == 0 {
+			spec.HydraAdmin.DNSServers = o.dnsServerList()
+		}
+		if spec.HydraAdmin.RequestTimeout == "" {
+			spec.HydraAdmin.RequestTimeout = o.requestTimeoutOrEmpty()
+		}
+		return hydra.New(spec, "", false)
+	}
+	return o.defaultClient()
+}
+
+// ownerOptions holds the flags identifying the controller, which are part of
+// the owner of its clients in ORY Hydra.
+type ownerOptions struct {
+	clusterName, controllerID string
+}
+
+func (o *ownerOptions) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.clusterName, "cluster-name", "", "The --cluster-name of the controller.")
+	fs.StringVar(&o.controllerID, "controller-id", "", "The --controller-id of the controller.")
+}
+
+// owner returns the owner the controller writes for the OAuth2Client
+// name/namespace.
+func (o *ownerOptions) owner(name, namespace string) string {
+	return hydra.Owner(name, namespace, o.clusterName, o.controllerID)
+}
+
+// owns reports whether owner names an OAuth2Client of the controller, i.e.
+// carries its cluster and controller identity.
+func (o *ownerOptions) owns(owner string) bool {
+	_, _, cluster, ok := hydra.ParseOwner(owner)
+	_, controller := hydra.SplitOwnerController(owner)
+	return ok && cluster == o.clusterName && controller == o.controllerID
+}
+
+// kubeOptions holds the flags used to reach the Kubernetes API server.
+type kubeOptions struct {
+	kubeconfig, namespace string
+}
+
+func (o *kubeOptions) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to the KUBECONFIG environment variable or ~/.kube/config.")
+	fs.StringVar(&o.namespace, "namespace", "", "Namespace of the OAuth2Clients. Defaults to the namespace of the current context.")
+	fs.StringVar(&o.namespace, "n", "", "Shorthand for --namespace.")
+}
+
+// client returns a Kubernetes client and the namespace to operate in.
+func (o *kubeOptions) client() (client.Client, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.kubeconfig
+	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{})
+
+	cfg, err := cc.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	ns := o.namespace
+	if ns == "" {
+		if ns, _, err = cc.Namespace(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	k, err := newClient(cfg, client.Options{Scheme: newScheme()})
+	if err != nil {
+		return nil, "", err
+	}
+	return k, ns, nil
+}
+
+// newClient creates the Kubernetes client of the commands. Tests replace it
+// with a fake client.
+var newClient = client.New
+
+func newScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	_ = apiv1.AddToScheme(s)
+	_ = apiextensionsv1.AddToScheme(s)
+	_ = authorizationv1.AddToScheme(s)
+	_ = hydrav1alpha1.AddToScheme(s)
+	return s
+}
+
+// credentialsFor reads the client ID and secret of c from its Secret.
+func credentialsFor(ctx context.Context, k client.Client, c *hydrav1alpha1.OAuth2Client) (*hydra.Oauth2ClientCredentials, *apiv1.Secret, error) {
+	var secret apiv1.Secret
+	if err := k.Get(ctx, types.NamespacedName{Name: c.Spec.SecretName, Namespace: c.Namespace}, &secret); err != nil {
+		return nil, nil, err
+	}
+
+	id, found := secret.Data[controllers.ClientIDKey]
+	if !found {
+		return nil, nil, fmt.Errorf("secret %s/%s: %s property missing", secret.Namespace, secret.Name, controllers.ClientIDKey)
+	}
+
+	return &hydra.Oauth2ClientCredentials{
+		ID:       id,
+		Password: secret.Data[controllers.ClientSecretKey],
+	}, &secret, nil
+}
@@ -0,0 +1,205 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/ory/hydra-maester/cli"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+const (
+	// clientsEndpoint is the path under which hydraServer serves OAuth2
+	// clients.
+	clientsEndpoint = "/admin/clients"
+	// hydraVersion is the version reported by hydraServer.
+	hydraVersion = "v2.2.0"
+)
+
+// hydraServer is an in-memory fake of the client, version and readiness
+// endpoints of the ORY Hydra admin API.
+type hydraServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	clients  map[string]*hydra.OAuth2ClientJSON
+	lastID   int
+	notReady bool
+}
+
+// newHydraServer starts a hydraServer, which is closed at the end of the test.
+func newHydraServer(t *testing.T) *hydraServer {
+	s := &hydraServer{clients: map[string]*hydra.OAuth2ClientJSON{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// AddClient stores c as if it had been registered through the API.
+func (s *hydraServer) AddClient(c *hydra.OAuth2ClientJSON) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *c
+	s.clients[*c.ClientID] = &stored
+}
+
+// GetClient returns the stored client with the given ID.
+func (s *hydraServer) GetClient(id string) (*hydra.OAuth2ClientJSON, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.clients[id]
+	if !ok {
+		return nil, false
+	}
+	stored := *c
+	return &stored, true
+}
+
+// SetReady changes whether the server reports to be ready.
+func (s *hydraServer) SetReady(ready bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notReady = !ready
+}
+
+func (s *hydraServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, clientsEndpoint), "/")
+	stored, found := s.clients[id]
+	switch {
+	case r.URL.Path == "/version":
+		writeJSON(w, http.StatusOK, map[string]string{"version": hydraVersion})
+	case r.URL.Path == "/health/ready" && s.notReady:
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+	case r.URL.Path == "/health/ready":
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	case !strings.HasPrefix(r.URL.Path, clientsEndpoint):
+		w.WriteHeader(http.StatusNotFound)
+	case id == "" && r.Method == http.MethodGet:
+		ids := make([]string, 0, len(s.clients))
+		for id := range s.clients {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		list := make([]*hydra.OAuth2ClientJSON, 0, len(ids))
+		for _, id := range ids {
+			c := *s.clients[id]
+			c.Secret = nil
+			list = append(list, &c)
+		}
+		writeJSON(w, http.StatusOK, list)
+	case id == "" && r.Method == http.MethodPost:
+		var c hydra.OAuth2ClientJSON
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if c.ClientID == nil {
+			s.lastID++
+			c.ClientID = ptr.To(fmt.Sprintf("client-%d", s.lastID))
+		}
+		if c.Secret == nil && c.TokenEndpointAuthMethod != "none" {
+			c.Secret = ptr.To("generated-secret")
+		}
+		s.clients[*c.ClientID] = &c
+		writeJSON(w, http.StatusCreated, &c)
+	case !found:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodGet:
+		c := *stored
+		c.Secret = nil
+		writeJSON(w, http.StatusOK, &c)
+	case r.Method == http.MethodPut:
+		var c hydra.OAuth2ClientJSON
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.ClientID = ptr.To(id)
+		if c.Secret == nil || *c.Secret == "" {
+			c.Secret = stored.Secret
+		}
+		s.clients[id] = &c
+		writeJSON(w, http.StatusOK, &c)
+	case r.Method == http.MethodDelete:
+		delete(s.clients, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// run runs the command name and returns what it printed.
+func run(t *testing.T, name string, args ...string) (string, error) {
+	var out bytes.Buffer
+	err := cli.Run(name, args, &out)
+	return out.String(), err
+}
+
+// hydraFlags returns the flags reaching s as the default ORY Hydra instance.
+func hydraFlags(s *hydraServer) []string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	return []string{"--hydra-url", u.Scheme + "://" + u.Hostname(), "--hydra-port", u.Port(), "--endpoint", clientsEndpoint}
+}
+
+// kubeFlags makes the commands use a fake cluster holding objs and returns
+// the flags of a kubeconfig whose current context is in namespace.
+func kubeFlags(t *testing.T, namespace string, objs ...client.Object) ([]string, client.Client) {
+	k := fake.NewClientBuilder().WithScheme(cli.NewScheme()).WithObjects(objs...).Build()
+	cli.SetKubeClient(t, k)
+	return kubeconfigFlags(t, namespace), k
+}
+
+// kubeconfigFlags returns the flags of a kubeconfig whose current context is
+// in namespace.
+func kubeconfigFlags(t *testing.T, namespace string) []string {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+users:
+- name: test
+  user:
+    token: test
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: %s
+current-context: test
+`, namespace)), 0o600))
+	return []string{"--kubeconfig", kubeconfig}
+}
@@ -0,0 +1,62 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ory/hydra-maester/envelope"
+)
+
+// runDecrypt copies the files of a mounted Secret to another directory,
+// decrypting the values encrypted with --secret-encryption-plugin. It is meant
+// to run in an initContainer, so applications read the plaintext client
+// secret from a shared emptyDir volume.
+func runDecrypt(args []string, out io.Writer) error {
+	var plugin, in, outDir string
+
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	fs.StringVar(&plugin, "secret-encryption-plugin", "", "Path of the executable unwrapping the data keys, the same plugin the controller encrypts with.")
+	fs.StringVar(&in, "in", "", "Directory of the mounted Secret.")
+	fs.StringVar(&outDir, "out", "", "Directory the decrypted files are written to, e.g. an emptyDir volume.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if plugin == "" || in == "" || outDir == "" {
+		return fmt.Errorf("--secret-encryption-plugin, --in and --out are required")
+	}
+
+	entries, err := os.ReadDir(in)
+	if err != nil {
+		return err
+	}
+	kw := envelope.Plugin{Path: plugin}
+	for _, e := range entries {
+		// skip the ..data link and the timestamped directory of the Secret volume
+		if strings.HasPrefix(e.Name(), ".") || e.IsDir() {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(in, e.Name()))
+		if err != nil {
+			return err
+		}
+		if envelope.IsEncrypted(b) {
+			if b, err = envelope.Decrypt(context.Background(), kw, b); err != nil {
+				return fmt.Errorf("%s: %w", e.Name(), err)
+			}
+			fmt.Fprintf(out, "%s: decrypted\n", e.Name())
+		}
+		if err := os.WriteFile(filepath.Join(outDir, e.Name()), b, 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/envelope"
+)
+
+func TestDecrypt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugin is a shell script")
+	}
+
+	plugin := base64Plugin(t)
+	encrypted, err := envelope.Encrypt(context.Background(), envelope.Plugin{Path: plugin}, []byte("client-secret"))
+	require.NoError(t, err)
+
+	// in is laid out like a mounted Secret volume
+	in := t.TempDir()
+	data := filepath.Join(in, "..2024_05_01_12_00_00.000000000")
+	require.NoError(t, os.Mkdir(data, 0o700))
+	for name, value := range map[string][]byte{
+		"CLIENT_ID":     []byte("client-id"),
+		"CLIENT_SECRET": encrypted,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(data, name), value, 0o600))
+		require.NoError(t, os.Symlink(filepath.Join("..data", name), filepath.Join(in, name)))
+	}
+	require.NoError(t, os.Symlink(filepath.Base(data), filepath.Join(in, "..data")))
+
+	t.Run("case=decrypts the encrypted values", func(t *testing.T) {
+		out := t.TempDir()
+
+		printed, err := run(t, "decrypt", "--secret-encryption-plugin", plugin, "--in", in, "--out", out)
+		require.NoError(t, err)
+		assert.Equal(t, "CLIENT_SECRET: decrypted\n", printed)
+
+		entries, err := os.ReadDir(out)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		for name, expected := range map[string]string{"CLIENT_ID": "client-id", "CLIENT_SECRET": "client-secret"} {
+			b, err := os.ReadFile(filepath.Join(out, name))
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(b))
+		}
+	})
+
+	t.Run("case=reports the errors of the plugin", func(t *testing.T) {
+		_, err := run(t, "decrypt", "--secret-encryption-plugin", filepath.Join(t.TempDir(), "missing"), "--in", in, "--out", t.TempDir())
+		require.ErrorContains(t, err, "CLIENT_SECRET: ")
+	})
+
+	t.Run("case=requires all flags", func(t *testing.T) {
+		_, err := run(t, "decrypt", "--in", in, "--out", t.TempDir())
+		require.EqualError(t, err, "--secret-encryption-plugin, --in and --out are required")
+	})
+}
+
+// base64Plugin writes a secret encryption plugin which "wraps" data keys by
+// base64 encoding them and returns its path.
+func base64Plugin(t *testing.T) string {
+	plugin := filepath.Join(t.TempDir(), "plugin.sh")
+	require.NoError(t, os.WriteFile(plugin, []byte(`#!/bin/sh
+case "$1" in
+wrap) base64 ;;
+unwrap) base64 -d ;;
+*) echo "unknown operation $1" >&2; exit 1 ;;
+esac
+`), 0o700))
+	return plugin
+}
@@ -0,0 +1,284 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+const crdName = "oauth2clients.hydra.ory.sh"
+
+// requiredPermissions mirrors the rules of the manager-role ClusterRole.
+var requiredPermissions = []struct {
+	group, resource string
+	verbs           []string
+}{
+	{hydrav1alpha1.GroupVersion.Group, "oauth2clients", []string{"get", "list", "watch", "update", "patch"}},
+	{hydrav1alpha1.GroupVersion.Group, "oauth2clients/status", []string{"get", "update", "patch"}},
+	{"", "secrets", []string{"get", "list", "watch", "create", "update", "delete"}},
+	{"", "configmaps", []string{"get", "create", "update"}},
+}
+
+// finding is the result of a single doctor check.
+type finding struct {
+	ok                  bool
+	check, detail, hint string
+}
+
+func (f finding) print(out io.Writer) {
+	status := "OK"
+	if !f.ok {
+		status = "FAIL"
+	}
+	fmt.Fprintf(out, "[%s] %s: %s\n", status, f.check, f.detail)
+	if !f.ok && f.hint != "" {
+		fmt.Fprintf(out, "       %s\n", f.hint)
+	}
+}
+
+// runDoctor checks the cluster and ORY Hydra for the most common
+// misconfigurations of the controller and prints what to do about them.
+func runDoctor(args []string, out io.Writer) error {
+	var (
+		h              hydraOptions
+		k              kubeOptions
+		serviceAccount string
+	)
+
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	h.addFlags(fs)
+	k.addFlags(fs)
+	fs.StringVar(&serviceAccount, "service-account", "", "Check the permissions of this service account (namespace/name) instead of the current user.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var findings []finding
+
+	kc, ns, err := k.client()
+	if err != nil {
+		findings = append(findings, finding{
+			check:  "kubernetes",
+			detail: err.Error(),
+			hint:   "Check --kubeconfig and the current context.",
+		})
+	} else {
+		findings = append(findings, checkCRD(ctx, kc))
+		findings = append(findings, checkRBAC(ctx, kc, ns, serviceAccount)...)
+	}
+	findings = append(findings, checkHydra(ctx, &h)...)
+
+	var failed int
+	for _, f := range findings {
+		f.print(out)
+		if !f.ok {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(findings))
+	}
+	return nil
+}
+
+func checkCRD(ctx context.Context, k client.Client) finding {
+	f := finding{check: "crd"}
+
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := k.Get(ctx, client.ObjectKey{Name: crdName}, &crd); err != nil {
+		f.detail = err.Error()
+		if apierrors.IsNotFound(err) {
+			f.detail = fmt.Sprintf("%s is not installed", crdName)
+			f.hint = "Apply config/crd/bases/hydra.ory.sh_oauth2clients.yaml or install the Helm chart."
+		}
+		return f
+	}
+
+	served := false
+	for _, v := range crd.Spec.Versions {
+		if v.Name == hydrav1alpha1.GroupVersion.Version && v.Served {
+			served = true
+		}
+	}
+	if !served {
+		f.detail = fmt.Sprintf("%s does not serve %s", crdName, hydrav1alpha1.GroupVersion.Version)
+		f.hint = "Upgrade the CRD to the version shipped with this release."
+		return f
+	}
+
+	for _, cond := range crd.Status.Conditions {
+		if cond.Type == apiextensionsv1.Established && cond.Status != apiextensionsv1.ConditionTrue {
+			f.detail = fmt.Sprintf("%s is not established: %s", crdName, cond.Message)
+			return f
+		}
+	}
+
+	f.ok, f.detail = true, fmt.Sprintf("%s is installed", crdName)
+	return f
+}
+
+func checkRBAC(ctx context.Context, k client.Client, ns, serviceAccount string) []finding {
+	subject := "current user"
+	var user string
+	var groups []string
+	if serviceAccount != "" {
+		saNamespace, saName, ok := strings.Cut(serviceAccount, "/")
+		if !ok || saNamespace == "" || saName == "" {
+			return []finding{{
+				check:  "rbac",
+				detail: fmt.Sprintf("invalid service account %q", serviceAccount),
+				hint:   "Use the namespace/name form.",
+			}}
+		}
+		subject = "service account " + serviceAccount
+		user = fmt.Sprintf("system:serviceaccount:%s:%s", saNamespace, saName)
+		groups = []string{"system:serviceaccounts", "system:serviceaccounts:" + saNamespace, "system:authenticated"}
+	}
+
+	var findings []finding
+	for _, p := range requiredPermissions {
+		var denied []string
+		for _, verb := range p.verbs {
+			attrs := &authorizationv1.ResourceAttributes{Namespace: ns, Verb: verb, Group: p.group}
+			attrs.Resource, attrs.Subresource, _ = strings.Cut(p.resource, "/")
+
+			allowed, err := accessAllowed(ctx, k, attrs, user, groups)
+			if err != nil {
+				return append(findings, finding{check: "rbac", detail: err.Error()})
+			}
+			if !allowed {
+				denied = append(denied, verb)
+			}
+		}
+
+		f := finding{check: "rbac " + p.resource, ok: len(denied) == 0}
+		if f.ok {
+			f.detail = fmt.Sprintf("%s may %s", subject, strings.Join(p.verbs, ", "))
+		} else {
+			f.detail = fmt.Sprintf("%s may not %s in namespace %q", subject, strings.Join(denied, ", "), ns)
+			f.hint = "Bind the manager-role ClusterRole from config/rbac to the controller's service account."
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func accessAllowed(ctx context.Context, k client.Client, attrs *authorizationv1.ResourceAttributes, user string, groups []string) (bool, error) {
+	if user == "" {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attrs},
+		}
+		if err := k.Create(ctx, review); err != nil {
+			return false, err
+		}
+		return review.Status.Allowed, nil
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{ResourceAttributes: attrs, User: user, Groups: groups},
+	}
+	if err := k.Create(ctx, review); err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
+
+func checkHydra(ctx context.Context, h *hydraOptions) []finding {
+	if h.url == "" {
+		return []finding{{
+			check:  "hydra",
+			detail: "--hydra-url is not set",
+			hint:   "Pass the same --hydra-url and --hydra-port as the controller.",
+		}}
+	}
+
+	var findings []finding
+
+	if u, err := url.Parse(h.url); err == nil && u.Scheme == "https" {
+		f := finding{check: "tls", ok: true, detail: "using the system trust store"}
+		switch {
+		case h.insecureSkipVerify:
+			f.detail = "certificate verification is disabled"
+		case h.tlsTrustStore != "":
+			f.ok, f.detail = checkTrustStore(h.tlsTrustStore)
+			f.hint = "--tls-trust-store must point to a PEM file containing the CA of ORY Hydra's admin certificate."
+		}
+		findings = append(findings, f)
+		if !f.ok {
+			return findings
+		}
+	}
+
+	hc, err := h.defaultClient()
+	if err != nil {
+		return append(findings, finding{check: "hydra", detail: err.Error()})
+	}
+
+	address := fmt.Sprintf("%s:%d", h.url, h.port)
+	ready, err := hc.IsReady(ctx)
+	switch {
+	case err != nil:
+		return append(findings, finding{
+			check:  "hydra reachability",
+			detail: err.Error(),
+			hint:   fmt.Sprintf("Check that %s is the admin address of ORY Hydra and that network policies allow the controller to reach it.", address),
+		})
+	case !ready:
+		return append(findings, finding{
+			check:  "hydra reachability",
+			detail: fmt.Sprintf("%s is reachable but not ready", address),
+			hint:   "Check the logs of ORY Hydra, usually for database connection errors.",
+		})
+	}
+	findings = append(findings, finding{check: "hydra reachability", ok: true, detail: fmt.Sprintf("%s is ready", address)})
+
+	if version, err := hc.GetVersion(ctx); err != nil {
+		findings = append(findings, finding{check: "hydra version", detail: err.Error()})
+	} else {
+		findings = append(findings, finding{check: "hydra version", ok: true, detail: version})
+	}
+
+	if _, err := hc.ListOAuth2Client(ctx); errors.Is(err, hydra.ErrUnauthorized) {
+		findings = append(findings, finding{
+			check:  "hydra auth",
+			detail: err.Error(),
+			hint:   "The admin API rejected the request. If ORY Hydra expects TLS termination by a proxy, set --forwarded-proto=https; if an authenticating proxy fronts it, allow the controller through.",
+		})
+	} else if err != nil {
+		findings = append(findings, finding{check: "hydra auth", detail: err.Error()})
+	} else {
+		findings = append(findings, finding{check: "hydra auth", ok: true, detail: "clients can be listed"})
+	}
+
+	return findings
+}
+
+func checkTrustStore(path string) (bool, string) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false, err.Error()
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(b) {
+		return false, fmt.Sprintf("%s contains no PEM certificates", path)
+	}
+	return true, fmt.Sprintf("trusting the certificates in %s", path)
+}
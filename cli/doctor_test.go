@@ -0,0 +1,170 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/ory/hydra-maester/cli"
+)
+
+func TestDoctor(t *testing.T) {
+	crd := func(served bool) *apiextensionsv1.CustomResourceDefinition {
+		return &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "oauth2clients.hydra.ory.sh"},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{Name: "v1alpha1", Served: served}},
+			},
+		}
+	}
+
+	// setup makes doctor check a fake cluster holding objs, which denies
+	// the "verb resource" permissions in denied to everyone, and returns the
+	// flags reaching the cluster and hydra, and the users whose access was
+	// reviewed.
+	setup := func(t *testing.T, s *hydraServer, denied []string, objs ...client.Object) ([]string, *[]string) {
+		reviewed := &[]string{}
+		allowed := func(attrs *authorizationv1.ResourceAttributes) bool {
+			resource := attrs.Resource
+			if attrs.Subresource != "" {
+				resource += "/" + attrs.Subresource
+			}
+			for _, d := range denied {
+				if d == attrs.Verb+" "+resource {
+					return false
+				}
+			}
+			return true
+		}
+		k := fake.NewClientBuilder().WithScheme(cli.NewScheme()).WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				switch review := obj.(type) {
+				case *authorizationv1.SelfSubjectAccessReview:
+					*reviewed = append(*reviewed, "")
+					review.Status.Allowed = allowed(review.Spec.ResourceAttributes)
+					return nil
+				case *authorizationv1.SubjectAccessReview:
+					*reviewed = append(*reviewed, review.Spec.User)
+					review.Status.Allowed = allowed(review.Spec.ResourceAttributes)
+					return nil
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+		cli.SetKubeClient(t, k)
+
+		flags := kubeconfigFlags(t, "team")
+		if s != nil {
+			flags = append(flags, hydraFlags(s)...)
+		}
+		return flags, reviewed
+	}
+
+	t.Run("case=passes all checks", func(t *testing.T) {
+		flags, reviewed := setup(t, newHydraServer(t), nil, crd(true))
+
+		out, err := run(t, "doctor", flags...)
+		require.NoError(t, err)
+		assert.Contains(t, out, "[OK] crd: oauth2clients.hydra.ory.sh is installed\n")
+		assert.Contains(t, out, "[OK] rbac secrets: current user may get, list, watch, create, update, delete\n")
+		assert.Contains(t, out, "[OK] hydra version: "+hydraVersion+"\n")
+		assert.Contains(t, out, "[OK] hydra auth: clients can be listed\n")
+		assert.NotContains(t, out, "[FAIL]")
+		assert.Len(t, *reviewed, 17)
+	})
+
+	for _, tc := range []struct {
+		name     string
+		denied   []string
+		objs     []client.Object
+		expected []string
+	}{
+		{
+			name:     "case=reports a missing CRD",
+			expected: []string{"[FAIL] crd: oauth2clients.hydra.ory.sh is not installed\n       Apply config/crd/bases/hydra.ory.sh_oauth2clients.yaml or install the Helm chart.\n"},
+		},
+		{
+			name:     "case=reports a CRD not serving the version",
+			objs:     []client.Object{crd(false)},
+			expected: []string{"[FAIL] crd: oauth2clients.hydra.ory.sh does not serve v1alpha1\n"},
+		},
+		{
+			name:   "case=reports missing permissions",
+			denied: []string{"delete secrets", "update secrets", "patch oauth2clients/status"},
+			objs:   []client.Object{crd(true)},
+			expected: []string{
+				"[FAIL] rbac secrets: current user may not update, delete in namespace \"team\"\n",
+				"[FAIL] rbac oauth2clients/status: current user may not patch in namespace \"team\"\n",
+				"[OK] rbac oauth2clients: current user may get, list, watch, update, patch\n",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			flags, _ := setup(t, newHydraServer(t), tc.denied, tc.objs...)
+
+			out, err := run(t, "doctor", flags...)
+			require.Error(t, err)
+			assert.Regexp(t, `^\d+ of \d+ checks failed$`, err.Error())
+			for _, expected := range tc.expected {
+				assert.Contains(t, out, expected)
+			}
+		})
+	}
+
+	t.Run("case=checks the permissions of a service account", func(t *testing.T) {
+		flags, reviewed := setup(t, newHydraServer(t), nil, crd(true))
+
+		out, err := run(t, "doctor", append(flags, "--service-account", "hydra/maester")...)
+		require.NoError(t, err)
+		assert.Contains(t, out, "[OK] rbac secrets: service account hydra/maester may get, list, watch, create, update, delete\n")
+		for _, user := range *reviewed {
+			assert.Equal(t, "system:serviceaccount:hydra:maester", user)
+		}
+
+		out, err = run(t, "doctor", append(flags, "--service-account", "maester")...)
+		require.EqualError(t, err, "1 of 5 checks failed")
+		assert.Contains(t, out, "[FAIL] rbac: invalid service account \"maester\"\n")
+	})
+
+	t.Run("case=reports hydra instances which are not ready", func(t *testing.T) {
+		s := newHydraServer(t)
+		s.SetReady(false)
+		flags, _ := setup(t, s, nil, crd(true))
+
+		out, err := run(t, "doctor", flags...)
+		require.EqualError(t, err, "1 of 6 checks failed")
+		assert.Contains(t, out, "is reachable but not ready\n       Check the logs of ORY Hydra, usually for database connection errors.\n")
+	})
+
+	t.Run("case=reports a missing hydra URL", func(t *testing.T) {
+		flags, _ := setup(t, nil, nil, crd(true))
+
+		out, err := run(t, "doctor", flags...)
+		require.EqualError(t, err, "1 of 6 checks failed")
+		assert.Contains(t, out, "[FAIL] hydra: --hydra-url is not set\n")
+	})
+
+	t.Run("case=reports invalid trust stores", func(t *testing.T) {
+		trustStore := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(trustStore, []byte("not a certificate"), 0o600))
+		flags, _ := setup(t, nil, nil, crd(true))
+
+		out, err := run(t, "doctor", append(flags, "--hydra-url", "https://hydra-admin", "--tls-trust-store", trustStore)...)
+		require.EqualError(t, err, "1 of 6 checks failed")
+		assert.Contains(t, out, "[FAIL] tls: "+trustStore+" contains no PEM certificates\n")
+		assert.NotContains(t, out, "hydra reachability")
+	})
+}
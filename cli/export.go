@@ -0,0 +1,112 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// exportDocument is the backup format written by the export command.
+type exportDocument struct {
+	// Clients are the controller-owned clients as returned by ORY Hydra.
+	// ORY Hydra never returns client secrets, they are kept in the
+	// Kubernetes Secrets of the clients.
+	Clients []*hydra.OAuth2ClientJSON `json:"clients"`
+	// Resources are the OAuth2Clients of the cluster, stripped of their
+	// server-populated fields so they can be applied to another cluster.
+	Resources []hydrav1alpha1.OAuth2Client `json:"resources,omitempty"`
+}
+
+// runExport prints all ORY Hydra clients owned by the controller identified by
+// --cluster-name and --controller-id, and optionally the OAuth2Clients of the
+// cluster, for backup or migration purposes.
+func runExport(args []string, out io.Writer) error {
+	var (
+		h             hydraOptions
+		k             kubeOptions
+		o             ownerOptions
+		output        string
+		withResources bool
+	)
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	h.addFlags(fs)
+	o.addFlags(fs)
+	fs.StringVar(&k.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to the KUBECONFIG environment variable or ~/.kube/config.")
+	fs.StringVar(&output, "output", "yaml", "Output format, one of yaml or json.")
+	fs.BoolVar(&withResources, "with-resources", false, "Also export the OAuth2Clients of all namespaces of the cluster.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if output != "yaml" && output != "json" {
+		return fmt.Errorf("unsupported output format %q", output)
+	}
+
+	ctx := context.Background()
+	hc, err := h.defaultClient()
+	if err != nil {
+		return err
+	}
+
+	clients, err := hc.ListOAuth2Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	doc := exportDocument{Clients: []*hydra.OAuth2ClientJSON{}}
+	for _, cJSON := range clients {
+		if o.owns(cJSON.Owner) {
+			doc.Clients = append(doc.Clients, cJSON)
+		}
+	}
+
+	if withResources {
+		kc, _, err := k.client()
+		if err != nil {
+			return err
+		}
+
+		var list hydrav1alpha1.OAuth2ClientList
+		if err := kc.List(ctx, &list); err != nil {
+			return err
+		}
+		for _, c := range list.Items {
+			c.TypeMeta = metav1.TypeMeta{
+				APIVersion: hydrav1alpha1.GroupVersion.String(),
+				Kind:       "OAuth2Client",
+			}
+			c.ObjectMeta = metav1.ObjectMeta{
+				Name:        c.Name,
+				Namespace:   c.Namespace,
+				Labels:      c.Labels,
+				Annotations: c.Annotations,
+			}
+			c.Status = hydrav1alpha1.OAuth2ClientStatus{}
+			doc.Resources = append(doc.Resources, c)
+		}
+	}
+
+	var b []byte
+	if output == "json" {
+		b, err = json.MarshalIndent(doc, "", "  ")
+		b = append(b, '\n')
+	} else {
+		b, err = yaml.Marshal(doc)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(b)
+	return err
+}
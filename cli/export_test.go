@@ -0,0 +1,111 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// exported is the document printed by export.
+type exported struct {
+	Clients   []*hydra.OAuth2ClientJSON    `json:"clients"`
+	Resources []hydrav1alpha1.OAuth2Client `json:"resources"`
+}
+
+// exportedIDs returns the IDs of the exported clients.
+func exportedIDs(doc exported) []string {
+	ids := []string{}
+	for _, c := range doc.Clients {
+		ids = append(ids, *c.ClientID)
+	}
+	return ids
+}
+
+func TestExport(t *testing.T) {
+	s := newHydraServer(t)
+	for id, owner := range map[string]string{
+		"owned":          "a/team",
+		"east":           "a/team/east",
+		"production":     "a/team@production",
+		"unowned":        "",
+		"foreign-format": "someone",
+	} {
+		s.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To(id), Owner: owner})
+	}
+
+	t.Run("case=exports the clients owned by the controller", func(t *testing.T) {
+		out, err := run(t, "export", hydraFlags(s)...)
+		require.NoError(t, err)
+
+		var doc exported
+		require.NoError(t, yaml.Unmarshal([]byte(out), &doc))
+		assert.Equal(t, []string{"owned"}, exportedIDs(doc))
+		assert.Empty(t, doc.Resources)
+	})
+
+	t.Run("case=exports the clients of the given cluster", func(t *testing.T) {
+		out, err := run(t, "export", append(hydraFlags(s), "--cluster-name", "east")...)
+		require.NoError(t, err)
+
+		var doc exported
+		require.NoError(t, yaml.Unmarshal([]byte(out), &doc))
+		assert.Equal(t, []string{"east"}, exportedIDs(doc))
+	})
+
+	t.Run("case=exports the clients of the given controller", func(t *testing.T) {
+		out, err := run(t, "export", append(hydraFlags(s), "--controller-id", "production")...)
+		require.NoError(t, err)
+
+		var doc exported
+		require.NoError(t, yaml.Unmarshal([]byte(out), &doc))
+		assert.Equal(t, []string{"production"}, exportedIDs(doc))
+	})
+
+	t.Run("case=prints json", func(t *testing.T) {
+		out, err := run(t, "export", append(hydraFlags(s), "--output", "json")...)
+		require.NoError(t, err)
+
+		var doc exported
+		require.NoError(t, json.Unmarshal([]byte(out), &doc))
+		assert.Equal(t, []string{"owned"}, exportedIDs(doc))
+
+		_, err = run(t, "export", append(hydraFlags(s), "--output", "xml")...)
+		require.EqualError(t, err, `unsupported output format "xml"`)
+	})
+
+	t.Run("case=exports the OAuth2Clients without their server-populated fields", func(t *testing.T) {
+		kubeFlags, _ := kubeFlags(t, "team", &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "a",
+				Namespace:  "team",
+				Labels:     map[string]string{"app": "a"},
+				Finalizers: []string{"finalizer.ory.hydra.sh"},
+			},
+			Spec:   hydrav1alpha1.OAuth2ClientSpec{SecretName: "a-credentials"},
+			Status: hydrav1alpha1.OAuth2ClientStatus{ObservedGeneration: 1},
+		})
+
+		out, err := run(t, "export", append(append(hydraFlags(s), kubeFlags...), "--with-resources")...)
+		require.NoError(t, err)
+
+		var doc exported
+		require.NoError(t, yaml.Unmarshal([]byte(out), &doc))
+		require.Len(t, doc.Resources, 1)
+		c := doc.Resources[0]
+		assert.Equal(t, "OAuth2Client", c.Kind)
+		assert.Equal(t, metav1.ObjectMeta{Name: "a", Namespace: "team", Labels: map[string]string{"app": "a"}}, c.ObjectMeta)
+		assert.Equal(t, "a-credentials", c.Spec.SecretName)
+		assert.Equal(t, hydrav1alpha1.OAuth2ClientStatus{}, c.Status)
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/helpers"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// runImport lists the clients of an ORY Hydra instance and prints matching
+// OAuth2Client manifests, so existing clients can be brought under the
+// management of the controller.
+func runImport(args []string, out io.Writer) error {
+	var (
+		h                             hydraOptions
+		o                             ownerOptions
+		namespace                     string
+		withSecrets, skipOwned, adopt bool
+	)
+
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	h.addFlags(fs)
+	o.addFlags(fs)
+	fs.StringVar(&namespace, "namespace", "default", "Namespace of the generated manifests, used for clients without a controller owner.")
+	fs.BoolVar(&withSecrets, "with-secrets", false, "Also print a Secret holding the client ID for each client. ORY Hydra never returns client secrets, so the CLIENT_SECRET key must be added before applying them.")
+	fs.BoolVar(&skipOwned, "skip-owned", false, "Skip clients which already have a name/namespace owner.")
+	fs.BoolVar(&adopt, "adopt", false, "Set the owner of each imported client in ORY Hydra to the generated OAuth2Client, so the controller identified by --cluster-name and --controller-id accepts it.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	hc, err := h.defaultClient()
+	if err != nil {
+		return err
+	}
+
+	clients, err := hc.ListOAuth2Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the names of the OAuth2Clients of owned clients are taken
+	taken := map[string]bool{}
+	for _, cJSON := range clients {
+		if name, ns, _, owned := hydra.ParseOwner(cJSON.Owner); owned {
+			taken[ns+"/"+name] = true
+		}
+	}
+
+	for _, cJSON := range clients {
+		name, ns, _, owned := hydra.ParseOwner(cJSON.Owner)
+		if owned && skipOwned {
+			continue
+		}
+		if !owned {
+			name, ns = uniqueManifestName(cJSON, namespace, taken), namespace
+		}
+
+		c, err := hydra.ToOAuth2Client(cJSON)
+		if err != nil {
+			return fmt.Errorf("client %s: %w", *cJSON.ClientID, err)
+		}
+		c.TypeMeta = metav1.TypeMeta{
+			APIVersion: hydrav1alpha1.GroupVersion.String(),
+			Kind:       "OAuth2Client",
+		}
+		c.Name, c.Namespace = name, ns
+		c.Spec.SecretName = helpers.ShortenName(name+"-credentials", validation.DNS1123SubdomainMaxLength)
+
+		if err := writeManifest(out, c); err != nil {
+			return err
+		}
+
+		if withSecrets {
+			secret := &apiv1.Secret{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      c.Spec.SecretName,
+					Namespace: ns,
+				},
+				StringData: map[string]string{
+					controllers.ClientIDKey: *cJSON.ClientID,
+				},
+			}
+			if err := writeManifest(out, secret); err != nil {
+				return err
+			}
+			if c.Spec.TokenEndpointAuthMethod != "none" {
+				fmt.Fprintf(out, "# add the %s key to secret %s/%s before applying it\n", controllers.ClientSecretKey, ns, secret.Name)
+			}
+		}
+
+		if adopt && !owned {
+			cJSON.Owner = o.owner(name, ns)
+			if _, err := hc.PutOAuth2Client(ctx, cJSON); err != nil {
+				return fmt.Errorf("adopting client %s: %w", *cJSON.ClientID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// uniqueManifestName returns the manifestName of o in namespace, suffixed with
+// its client ID if another client has the name already, and marks it taken.
+func uniqueManifestName(o *hydra.OAuth2ClientJSON, namespace string, taken map[string]bool) string {
+	name := manifestName(o)
+	if taken[namespace+"/"+name] && o.ClientID != nil {
+		id := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(*o.ClientID), "-"), "-")
+		name = helpers.ShortenName(name+"-"+id, 52)
+	}
+	taken[namespace+"/"+name] = true
+	return name
+}
+
+// manifestName derives a valid resource name from the client name or ID.
+func manifestName(o *hydra.OAuth2ClientJSON) string {
+	name := o.ClientName
+	if name == "" && o.ClientID != nil {
+		name = *o.ClientID
+	}
+
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	name = helpers.ShortenName(name, 52)
+	if name == "" {
+		name = "imported-client"
+	}
+	return name
+}
+
+// writeManifest prints obj as a YAML document.
+func writeManifest(out io.Writer, obj interface{}) error {
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "---\n%s", b)
+	return err
+}
@@ -0,0 +1,163 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// manifests returns the names and kinds of the documents printed by import,
+// in the namespace/name form.
+func manifests(t *testing.T, out string) []string {
+	var names []string
+	for _, doc := range strings.Split(out, "---\n")[1:] {
+		var m struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(doc), &m))
+		names = append(names, m.Kind+" "+m.Metadata.Namespace+"/"+m.Metadata.Name)
+	}
+	return names
+}
+
+func TestImport(t *testing.T) {
+	clients := []*hydra.OAuth2ClientJSON{
+		{ClientID: ptr.To("a"), ClientName: "Web App", Scope: "read write", GrantTypes: []string{"authorization_code"}},
+		{ClientID: ptr.To("b"), ClientName: "web app"},
+		{ClientID: ptr.To("c"), Owner: "api/team"},
+		{ClientID: ptr.To("d"), ClientName: "spa", TokenEndpointAuthMethod: "none"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name: "case=prints manifests in the namespace of the owner or the default one",
+			expected: []string{
+				"OAuth2Client imported/web-app",
+				"OAuth2Client imported/web-app-b",
+				"OAuth2Client team/api",
+				"OAuth2Client imported/spa",
+			},
+		},
+		{
+			name: "case=skips owned clients",
+			args: []string{"--skip-owned"},
+			expected: []string{
+				"OAuth2Client imported/web-app",
+				"OAuth2Client imported/web-app-b",
+				"OAuth2Client imported/spa",
+			},
+		},
+		{
+			name: "case=prints the secrets of the clients",
+			args: []string{"--with-secrets", "--skip-owned"},
+			expected: []string{
+				"OAuth2Client imported/web-app",
+				"Secret imported/web-app-credentials",
+				"OAuth2Client imported/web-app-b",
+				"Secret imported/web-app-b-credentials",
+				"OAuth2Client imported/spa",
+				"Secret imported/spa-credentials",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newHydraServer(t)
+			for _, c := range clients {
+				s.AddClient(c)
+			}
+
+			out, err := run(t, "import", append(append(hydraFlags(s), "--namespace", "imported"), tc.args...)...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, manifests(t, out))
+			for _, c := range clients {
+				stored, _ := s.GetClient(*c.ClientID)
+				assert.Equal(t, c.Owner, stored.Owner)
+			}
+		})
+	}
+
+	t.Run("case=converts the clients to OAuth2Client specs", func(t *testing.T) {
+		s := newHydraServer(t)
+		s.AddClient(clients[0])
+
+		out, err := run(t, "import", append(hydraFlags(s), "--namespace", "imported")...)
+		require.NoError(t, err)
+
+		var c hydrav1alpha1.OAuth2Client
+		require.NoError(t, yaml.Unmarshal([]byte(strings.TrimPrefix(out, "---\n")), &c))
+		assert.Equal(t, "OAuth2Client", c.Kind)
+		assert.Equal(t, hydrav1alpha1.GroupVersion.String(), c.APIVersion)
+		assert.Equal(t, "Web App", c.Spec.ClientName)
+		assert.Equal(t, []hydrav1alpha1.GrantType{"authorization_code"}, c.Spec.GrantTypes)
+		assert.Equal(t, "web-app-credentials", c.Spec.SecretName)
+	})
+
+	t.Run("case=notes the missing client secrets", func(t *testing.T) {
+		s := newHydraServer(t)
+		s.AddClient(clients[0])
+		s.AddClient(clients[3])
+
+		out, err := run(t, "import", append(hydraFlags(s), "--namespace", "imported", "--with-secrets")...)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(out, "# add the CLIENT_SECRET key"))
+		assert.Contains(t, out, "# add the CLIENT_SECRET key to secret imported/web-app-credentials before applying it\n")
+	})
+
+	t.Run("case=adopts unowned clients", func(t *testing.T) {
+		s := newHydraServer(t)
+		for _, c := range clients {
+			s.AddClient(c)
+		}
+
+		_, err := run(t, "import", append(hydraFlags(s), "--namespace", "imported", "--adopt")...)
+		require.NoError(t, err)
+
+		for id, owner := range map[string]string{
+			"a": "web-app/imported",
+			"b": "web-app-b/imported",
+			"c": "api/team",
+			"d": "spa/imported",
+		} {
+			stored, found := s.GetClient(id)
+			require.True(t, found)
+			assert.Equal(t, owner, stored.Owner, id)
+		}
+	})
+
+	t.Run("case=adopts unowned clients for the given cluster and controller", func(t *testing.T) {
+		s := newHydraServer(t)
+		for _, c := range clients {
+			s.AddClient(c)
+		}
+
+		_, err := run(t, "import", append(hydraFlags(s), "--namespace", "imported", "--adopt", "--cluster-name", "east", "--controller-id", "production")...)
+		require.NoError(t, err)
+
+		stored, found := s.GetClient("d")
+		require.True(t, found)
+		assert.Equal(t, "spa/imported/east@production", stored.Owner)
+	})
+
+	t.Run("case=fails without hydra", func(t *testing.T) {
+		_, err := run(t, "import")
+		require.EqualError(t, err, "--hydra-url can't be empty")
+	})
+}
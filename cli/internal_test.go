@@ -0,0 +1,27 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetKubeClient makes the commands use k instead of connecting to the cluster
+// of the kubeconfig until the end of the test.
+func SetKubeClient(t testing.TB, k client.Client) {
+	previous := newClient
+	newClient = func(*rest.Config, client.Options) (client.Client, error) {
+		return k, nil
+	}
+	t.Cleanup(func() { newClient = previous })
+}
+
+// NewScheme returns the scheme of the Kubernetes client of the commands.
+func NewScheme() *runtime.Scheme {
+	return newScheme()
+}
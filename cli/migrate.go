@@ -0,0 +1,203 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// runMigrate rewrites OAuth2Client manifests to the current form of the API,
+// so GitOps repositories can be upgraded mechanically. v1alpha1 is the only
+// version of the API, so there is no version to convert the manifests to yet;
+// the migration replaces the deprecated fields of v1alpha1 with their
+// successors.
+func runMigrate(args []string, out io.Writer) error {
+	var write bool
+
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.BoolVar(&write, "write", false, "Rewrite the files in place instead of printing the migrated manifests.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("expected at least one file or directory, use - to read from stdin")
+	}
+
+	for _, arg := range fs.Args() {
+		files, err := manifestFiles(arg)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if write && file == "-" {
+				return fmt.Errorf("--write can't be used with stdin")
+			}
+
+			b, migrated, err := migrateFile(file)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+
+			if !write {
+				if _, err := out.Write(b); err != nil {
+					return err
+				}
+				continue
+			}
+			if migrated == 0 {
+				continue
+			}
+			if err := os.WriteFile(file, b, 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%s: migrated %d OAuth2Clients\n", file, migrated)
+		}
+	}
+	return nil
+}
+
+// migrateFile returns the documents of file with all OAuth2Clients migrated,
+// and the number of OAuth2Clients which changed. The documents are edited as
+// YAML nodes, so comments and the order of the keys are kept, and documents
+// of other kinds are kept as they are.
+func migrateFile(file string) ([]byte, int, error) {
+	var b []byte
+	var err error
+	if file == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var buf bytes.Buffer
+	var migrated int
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, 0, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+
+		root := doc.Content[0]
+		apiVersion := mappingValue(root, "apiVersion")
+		kind := mappingValue(root, "kind")
+		if kind != nil && kind.Value == "OAuth2Client" &&
+			apiVersion != nil && strings.HasPrefix(apiVersion.Value, hydrav1alpha1.GroupVersion.Group+"/") {
+			if migrateOAuth2Client(root) {
+				migrated++
+			}
+		}
+
+		if err := encoder.Encode(&doc); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), migrated, nil
+}
+
+// migrateOAuth2Client applies the migrations to the mapping node of an
+// OAuth2Client and reports whether it changed.
+func migrateOAuth2Client(root *yaml.Node) bool {
+	spec := mappingValue(root, "spec")
+	if spec == nil || spec.Kind != yaml.MappingNode {
+		return false
+	}
+
+	// scope is deprecated in favor of scopeArray.
+	i := mappingIndex(spec, "scope")
+	if i < 0 {
+		return false
+	}
+	scopeKey, scope := spec.Content[i], spec.Content[i+1]
+	spec.Content = append(spec.Content[:i:i], spec.Content[i+2:]...)
+
+	var scopes *yaml.Node
+	if j := mappingIndex(spec, "scopeArray"); j >= 0 && spec.Content[j+1].Kind == yaml.SequenceNode {
+		scopes = spec.Content[j+1]
+	} else if j >= 0 {
+		// replace null and other invalid values
+		scopes = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		spec.Content[j+1] = scopes
+	}
+
+	seen := map[string]bool{}
+	if scopes != nil {
+		for _, s := range scopes.Content {
+			seen[s.Value] = true
+		}
+	}
+	var added []*yaml.Node
+	for _, s := range strings.Fields(scope.Value) {
+		if !seen[s] {
+			seen[s] = true
+			added = append(added, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s})
+		}
+	}
+
+	if scopes != nil {
+		scopes.Content = append(scopes.Content, added...)
+		return true
+	}
+	if len(added) == 0 {
+		return true
+	}
+	// scopeArray takes the place and the comments of scope
+	key := &yaml.Node{
+		Kind:        yaml.ScalarNode,
+		Tag:         "!!str",
+		Value:       "scopeArray",
+		HeadComment: scopeKey.HeadComment,
+		LineComment: scopeKey.LineComment + scope.LineComment,
+		FootComment: scopeKey.FootComment,
+	}
+	value := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: added}
+	spec.Content = append(spec.Content[:i], append([]*yaml.Node{key, value}, spec.Content[i:]...)...)
+	return true
+}
+
+// mappingIndex returns the index of the key node of key in the mapping node
+// m, or -1 if m has no such key.
+func mappingIndex(m *yaml.Node, key string) int {
+	if m.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingValue returns the value node of key in the mapping node m, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if i := mappingIndex(m, key); i >= 0 {
+		return m.Content[i+1]
+	}
+	return nil
+}
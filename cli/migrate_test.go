@@ -0,0 +1,150 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, out string
+	}{
+		{
+			name: "case=replaces scope with scopeArray",
+			in: `apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: app
+spec:
+  grantTypes: [client_credentials]
+  # the scopes of the app
+  scope: "read write" # space separated
+  secretName: app-credentials
+`,
+			out: `apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: app
+spec:
+  grantTypes: [client_credentials]
+  # the scopes of the app
+  scopeArray: # space separated
+    - read
+    - write
+  secretName: app-credentials
+`,
+		},
+		{
+			name: "case=merges scope into scopeArray",
+			in: `apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: app
+spec:
+  scopeArray:
+    - read
+  scope: read write
+`,
+			out: `apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: app
+spec:
+  scopeArray:
+    - read
+    - write
+`,
+		},
+		{
+			name: "case=drops empty scopes",
+			in: `apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: app
+spec:
+  scope: ""
+  secretName: app-credentials
+`,
+			out: `apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: app
+spec:
+  secretName: app-credentials
+`,
+		},
+		{
+			name: "case=keeps other documents",
+			in: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  scope: read
+---
+apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: app
+spec:
+  scopeArray: [read]
+`,
+			out: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  scope: read
+---
+apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: app
+spec:
+  scopeArray: [read]
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeManifests(t, map[string]string{"client.yaml": tc.in})
+
+			out, err := run(t, "migrate", filepath.Join(dir, "client.yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.out, out)
+
+			b, err := os.ReadFile(filepath.Join(dir, "client.yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.in, string(b))
+		})
+	}
+
+	t.Run("case=rewrites files", func(t *testing.T) {
+		dir := writeManifests(t, map[string]string{
+			"a.yaml": "apiVersion: hydra.ory.sh/v1alpha1\nkind: OAuth2Client\nmetadata:\n  name: a\nspec:\n  scope: read\n",
+			"b.yaml": "apiVersion: hydra.ory.sh/v1alpha1\nkind: OAuth2Client\nmetadata:\n  name: b\nspec:\n  scopeArray: [read]\n",
+		})
+
+		out, err := run(t, "migrate", "--write", dir)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "a.yaml")+": migrated 1 OAuth2Clients\n", out)
+
+		b, err := os.ReadFile(filepath.Join(dir, "a.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "apiVersion: hydra.ory.sh/v1alpha1\nkind: OAuth2Client\nmetadata:\n  name: a\nspec:\n  scopeArray:\n    - read\n", string(b))
+		b, err = os.ReadFile(filepath.Join(dir, "b.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "apiVersion: hydra.ory.sh/v1alpha1\nkind: OAuth2Client\nmetadata:\n  name: b\nspec:\n  scopeArray: [read]\n", string(b))
+	})
+
+	t.Run("case=refuses to rewrite stdin", func(t *testing.T) {
+		_, err := run(t, "migrate", "--write", "-")
+		require.EqualError(t, err, "--write can't be used with stdin")
+	})
+}
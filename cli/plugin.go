@@ -0,0 +1,345 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/envelope"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+const pluginUsage = `Usage: kubectl hydra <command> [flags]
+
+Commands:
+  list             List OAuth2Clients with their live ORY Hydra state
+  diff <name>      Show the differences between the spec and ORY Hydra
+  sync <name>      Push the spec of the OAuth2Client to ORY Hydra
+  rotate <name>    Generate a new client secret and store it in the Secret`
+
+// KubectlHydra runs the kubectl-hydra plugin.
+func KubectlHydra(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n\n%s", pluginUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return pluginList(args[1:], out)
+	case "diff":
+		return pluginDiff(args[1:], out)
+	case "sync":
+		return pluginSync(args[1:], out)
+	case "rotate":
+		return pluginRotate(args[1:], out)
+	case "help", "-h", "--help":
+		fmt.Fprintln(out, pluginUsage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], pluginUsage)
+	}
+}
+
+// pluginCommand holds the state shared by the plugin commands.
+type pluginCommand struct {
+	fs         *flag.FlagSet
+	hydra      hydraOptions
+	kube       kubeOptions
+	controller controllerOptions
+}
+
+func newPluginCommand(name string) *pluginCommand {
+	cmd := &pluginCommand{fs: flag.NewFlagSet("kubectl hydra "+name, flag.ContinueOnError)}
+	cmd.hydra.addFlags(cmd.fs)
+	cmd.kube.addFlags(cmd.fs)
+	cmd.controller.addFlags(cmd.fs)
+	return cmd
+}
+
+// controllerOptions holds the flags of the controller which shape the clients
+// it registers in ORY Hydra, so the plugin writes the same clients.
+type controllerOptions struct {
+	owner                             ownerOptions
+	mergeMetadata, provenanceMetadata bool
+	clientNameTemplate                string
+}
+
+func (o *controllerOptions) addFlags(fs *flag.FlagSet) {
+	o.owner.addFlags(fs)
+	fs.BoolVar(&o.mergeMetadata, "merge-metadata", true, "The --merge-metadata of the controller.")
+	fs.BoolVar(&o.provenanceMetadata, "provenance-metadata", false, "The --provenance-metadata of the controller.")
+	fs.StringVar(&o.clientNameTemplate, "client-name-template", controllers.DefaultClientNameTemplate, "The --client-name-template of the controller.")
+}
+
+// reconciler returns a reconciler configured like the controller, which
+// converts OAuth2Clients into the clients to register in ORY Hydra.
+func (o *controllerOptions) reconciler(k client.Client) (*controllers.OAuth2ClientReconciler, error) {
+	tmpl, err := controllers.ParseClientNameTemplate(o.clientNameTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --client-name-template: %w", err)
+	}
+	return controllers.New(k, nil, logr.Discard(),
+		controllers.WithClusterName(o.owner.clusterName),
+		controllers.WithControllerID(o.owner.controllerID),
+		controllers.WithMetadataMerge(o.mergeMetadata),
+		controllers.WithProvenanceMetadata(o.provenanceMetadata),
+		controllers.WithClientNameTemplate(tmpl),
+	), nil
+}
+
+// get parses the arguments of a command operating on a single OAuth2Client
+// and fetches it.
+func (cmd *pluginCommand) get(ctx context.Context, args []string) (client.Client, *hydrav1alpha1.OAuth2Client, error) {
+	if err := cmd.fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if cmd.fs.NArg() != 1 {
+		return nil, nil, fmt.Errorf("expected exactly one OAuth2Client name, got %d", cmd.fs.NArg())
+	}
+
+	k, ns, err := cmd.kube.client()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var c hydrav1alpha1.OAuth2Client
+	if err := k.Get(ctx, client.ObjectKey{Name: cmd.fs.Arg(0), Namespace: ns}, &c); err != nil {
+		return nil, nil, err
+	}
+	return k, &c, nil
+}
+
+func pluginList(args []string, out io.Writer) error {
+	ctx := context.Background()
+	cmd := newPluginCommand("list")
+	var allNamespaces bool
+	cmd.fs.BoolVar(&allNamespaces, "all-namespaces", false, "List OAuth2Clients in all namespaces.")
+	cmd.fs.BoolVar(&allNamespaces, "A", false, "Shorthand for --all-namespaces.")
+	if err := cmd.fs.Parse(args); err != nil {
+		return err
+	}
+
+	k, ns, err := cmd.kube.client()
+	if err != nil {
+		return err
+	}
+
+	var opts []client.ListOption
+	if !allNamespaces {
+		opts = append(opts, client.InNamespace(ns))
+	}
+	var list hydrav1alpha1.OAuth2ClientList
+	if err := k.List(ctx, &list, opts...); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tCLIENT ID\tREADY\tIN HYDRA\tDRIFTED FIELDS")
+	for i := range list.Items {
+		c := &list.Items[i]
+		clientID, inHydra, drifted := "-", "-", "-"
+
+		if creds, _, err := credentialsFor(ctx, k, c); err == nil {
+			clientID = string(creds.ID)
+			if diffs, found, err := cmd.diff(ctx, k, c, creds); err != nil {
+				inHydra = "error"
+			} else if found {
+				inHydra, drifted = "yes", fmt.Sprint(len(diffs))
+			} else {
+				inHydra = "no"
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Namespace, c.Name, clientID, readyStatus(c), inHydra, drifted)
+	}
+	return w.Flush()
+}
+
+func pluginDiff(args []string, out io.Writer) error {
+	ctx := context.Background()
+	cmd := newPluginCommand("diff")
+	k, c, err := cmd.get(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	creds, _, err := credentialsFor(ctx, k, c)
+	if err != nil {
+		return err
+	}
+
+	diffs, found, err := cmd.diff(ctx, k, c, creds)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("oauth2 client %s not found in ORY Hydra", creds.ID)
+	}
+
+	if len(diffs) == 0 {
+		fmt.Fprintln(out, "no differences")
+		return nil
+	}
+	for _, d := range diffs {
+		desired, _ := json.Marshal(d.Desired)
+		actual, _ := json.Marshal(d.Actual)
+		fmt.Fprintf(out, "%s:\n  - hydra: %s\n  + spec:  %s\n", d.Field, actual, desired)
+	}
+	return nil
+}
+
+func pluginSync(args []string, out io.Writer) error {
+	ctx := context.Background()
+	cmd := newPluginCommand("sync")
+	k, c, err := cmd.get(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	creds, _, err := credentialsFor(ctx, k, c)
+	if err != nil {
+		return err
+	}
+	if envelope.IsEncrypted(creds.Password) {
+		return fmt.Errorf("the client secret of oauth2client %s/%s is encrypted and can't be synced, let the controller reconcile it", c.Namespace, c.Name)
+	}
+
+	if err := cmd.put(ctx, k, c, creds); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "oauth2client %s/%s synced\n", c.Namespace, c.Name)
+	return nil
+}
+
+func pluginRotate(args []string, out io.Writer) error {
+	ctx := context.Background()
+	cmd := newPluginCommand("rotate")
+	var encryptionPlugin string
+	cmd.fs.StringVar(&encryptionPlugin, "secret-encryption-plugin", "", "Path of the executable wrapping the data keys, the same plugin the controller encrypts with. Required if the client secret is encrypted.")
+	k, c, err := cmd.get(ctx, args)
+	if err != nil {
+		return err
+	}
+	if c.Spec.TokenEndpointAuthMethod == "none" {
+		return fmt.Errorf("oauth2client %s/%s has no client secret to rotate", c.Namespace, c.Name)
+	}
+
+	creds, secret, err := credentialsFor(ctx, k, c)
+	if err != nil {
+		return err
+	}
+	// a plaintext secret would replace the encrypted one the controller and
+	// the applications expect
+	if envelope.IsEncrypted(creds.Password) && encryptionPlugin == "" {
+		return fmt.Errorf("the client secret of oauth2client %s/%s is encrypted, pass the --secret-encryption-plugin of the controller to rotate it", c.Namespace, c.Name)
+	}
+
+	password, err := generateSecret()
+	if err != nil {
+		return err
+	}
+	stored := password
+	if encryptionPlugin != "" {
+		if stored, err = envelope.Encrypt(ctx, envelope.Plugin{Path: encryptionPlugin}, password); err != nil {
+			return fmt.Errorf("unable to encrypt the client secret: %w", err)
+		}
+	}
+	creds.Password = password
+
+	if err := cmd.put(ctx, k, c, creds); err != nil {
+		return err
+	}
+
+	secret.Data[controllers.ClientSecretKey] = stored
+	metav1.SetMetaDataAnnotation(&secret.ObjectMeta, controllers.SecretRotatedAtAnnotation, time.Now().UTC().Format(time.RFC3339))
+	if err := k.Update(ctx, secret); err != nil {
+		return fmt.Errorf("client secret was rotated in ORY Hydra but secret %s/%s could not be updated: %w", secret.Namespace, secret.Name, err)
+	}
+	fmt.Fprintf(out, "oauth2client %s/%s secret rotated\n", c.Namespace, c.Name)
+	return nil
+}
+
+// diff compares the client the controller registers for c with the one
+// registered in ORY Hydra.
+func (cmd *pluginCommand) diff(ctx context.Context, k client.Client, c *hydrav1alpha1.OAuth2Client, creds *hydra.Oauth2ClientCredentials) ([]hydra.FieldDiff, bool, error) {
+	_, desired, current, err := cmd.desired(ctx, k, c, creds)
+	if err != nil || current == nil {
+		return nil, current != nil, err
+	}
+
+	diffs, err := hydra.Diff(desired, current)
+	return diffs, true, err
+}
+
+// put overwrites the client registered in ORY Hydra with the client the
+// controller registers for c.
+func (cmd *pluginCommand) put(ctx context.Context, k client.Client, c *hydrav1alpha1.OAuth2Client, creds *hydra.Oauth2ClientCredentials) error {
+	h, desired, current, err := cmd.desired(ctx, k, c, creds)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("oauth2 client %s not found in ORY Hydra", creds.ID)
+	}
+
+	_, err = h.PutOAuth2Client(ctx, desired.WithCredentials(creds))
+	return err
+}
+
+// desired fetches the client registered in ORY Hydra for creds and returns it
+// with the client the controller registers for c in its place, e.g. with the
+// metadata merged into that of the registered one. The registered client is
+// nil if it doesn't exist.
+func (cmd *pluginCommand) desired(ctx context.Context, k client.Client, c *hydrav1alpha1.OAuth2Client, creds *hydra.Oauth2ClientCredentials) (hydra.Client, *hydra.OAuth2ClientJSON, *hydra.OAuth2ClientJSON, error) {
+	h, err := cmd.hydra.clientFor(c)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	current, found, err := h.GetOAuth2Client(ctx, string(creds.ID))
+	if err != nil || !found {
+		return nil, nil, nil, err
+	}
+
+	r, err := cmd.controller.reconciler(k)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	desired, err := r.DesiredOAuth2Client(c, current)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return h, desired, current, nil
+}
+
+func readyStatus(c *hydrav1alpha1.OAuth2Client) string {
+	for _, cond := range c.Status.Conditions {
+		if cond.Type == hydrav1alpha1.OAuth2ClientConditionReady {
+			return string(cond.Status)
+		}
+	}
+	return string(hydrav1alpha1.ConditionUnknown)
+}
+
+func generateSecret() ([]byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return []byte(base64.RawURLEncoding.EncodeToString(b)), nil
+}
@@ -0,0 +1,207 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/cli"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/envelope"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+func TestKubectlHydra(t *testing.T) {
+	ctx := context.Background()
+
+	// setup returns a fake cluster with the OAuth2Client team/app, whose
+	// client is registered in ORY Hydra with another scope, and the flags
+	// reaching both.
+	setup := func(t *testing.T, authMethod hydrav1alpha1.TokenEndpointAuthMethod) (*hydraServer, client.Client, []string) {
+		s := newHydraServer(t)
+		s.AddClient(&hydra.OAuth2ClientJSON{
+			ClientID:                ptr.To("app-id"),
+			ClientName:              "team/app",
+			Secret:                  ptr.To("secret"),
+			GrantTypes:              []string{"client_credentials"},
+			Scope:                   "read",
+			Owner:                   "app/team",
+			Metadata:                json.RawMessage(`{"other":"kept"}`),
+			TokenEndpointAuthMethod: string(authMethod),
+		})
+
+		kubeFlags, k := kubeFlags(t, "team",
+			&hydrav1alpha1.OAuth2Client{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team"},
+				Spec: hydrav1alpha1.OAuth2ClientSpec{
+					GrantTypes:              []hydrav1alpha1.GrantType{"client_credentials"},
+					ScopeArray:              []string{"read", "write"},
+					SecretName:              "app-credentials",
+					TokenEndpointAuthMethod: authMethod,
+				},
+			},
+			&hydrav1alpha1.OAuth2Client{
+				ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "team"},
+				Spec:       hydrav1alpha1.OAuth2ClientSpec{SecretName: "pending-credentials"},
+			},
+			&apiv1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "team"},
+				Data: map[string][]byte{
+					controllers.ClientIDKey:     []byte("app-id"),
+					controllers.ClientSecretKey: []byte("secret"),
+				},
+			},
+		)
+		return s, k, append(kubeFlags, hydraFlags(s)...)
+	}
+
+	kubectlHydra := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := cli.KubectlHydra(args, &out)
+		return out.String(), err
+	}
+
+	t.Run("case=lists clients with their state in hydra", func(t *testing.T) {
+		_, _, flags := setup(t, "")
+
+		out, err := kubectlHydra(append([]string{"list"}, flags...)...)
+		require.NoError(t, err)
+		assert.Regexp(t, `team\s+app\s+app-id\s+Unknown\s+yes\s+1\n`, out)
+		assert.Regexp(t, `team\s+pending\s+-\s+Unknown\s+-\s+-\n`, out)
+	})
+
+	t.Run("case=shows the drifted fields", func(t *testing.T) {
+		_, _, flags := setup(t, "")
+
+		out, err := kubectlHydra(append(append([]string{"diff"}, flags...), "app")...)
+		require.NoError(t, err)
+		assert.Equal(t, "scope:\n  - hydra: \"read\"\n  + spec:  \"read write\"\n", out)
+	})
+
+	t.Run("case=syncs the spec to hydra", func(t *testing.T) {
+		s, _, flags := setup(t, "")
+
+		out, err := kubectlHydra(append(append([]string{"sync"}, flags...), "app")...)
+		require.NoError(t, err)
+		assert.Equal(t, "oauth2client team/app synced\n", out)
+
+		stored, found := s.GetClient("app-id")
+		require.True(t, found)
+		assert.Equal(t, "read write", stored.Scope)
+		assert.Equal(t, "secret", *stored.Secret)
+
+		out, err = kubectlHydra(append(append([]string{"diff"}, flags...), "app")...)
+		require.NoError(t, err)
+		assert.Equal(t, "no differences\n", out)
+	})
+
+	t.Run("case=syncs the client the controller registers", func(t *testing.T) {
+		s, k, flags := setup(t, "")
+		var c hydrav1alpha1.OAuth2Client
+		require.NoError(t, k.Get(ctx, client.ObjectKey{Name: "app", Namespace: "team"}, &c))
+		c.Spec.Metadata = map[string]apiextensionsv1.JSON{"team": {Raw: []byte(`"a"`)}}
+		require.NoError(t, k.Update(ctx, &c))
+
+		flags = append(flags, "--cluster-name", "east", "--controller-id", "production", "--provenance-metadata", "--client-name-template", "{{ .Cluster }}-{{ .Name }}")
+		_, err := kubectlHydra(append(append([]string{"sync"}, flags...), "app")...)
+		require.NoError(t, err)
+
+		stored, found := s.GetClient("app-id")
+		require.True(t, found)
+		assert.Equal(t, "app/team/east@production", stored.Owner)
+		assert.Equal(t, "east-app", stored.ClientName)
+		assert.JSONEq(t, `{"other":"kept","team":"a","k8s":{"namespace":"team","name":"app","uid":"","cluster":"east"}}`, string(stored.Metadata))
+
+		out, err := kubectlHydra(append(append([]string{"diff"}, flags...), "app")...)
+		require.NoError(t, err)
+		assert.Equal(t, "no differences\n", out)
+	})
+
+	t.Run("case=rotates the client secret in hydra and the secret", func(t *testing.T) {
+		s, k, flags := setup(t, "")
+
+		out, err := kubectlHydra(append(append([]string{"rotate"}, flags...), "app")...)
+		require.NoError(t, err)
+		assert.Equal(t, "oauth2client team/app secret rotated\n", out)
+
+		var secret apiv1.Secret
+		require.NoError(t, k.Get(ctx, client.ObjectKey{Name: "app-credentials", Namespace: "team"}, &secret))
+		rotated := string(secret.Data[controllers.ClientSecretKey])
+		assert.NotEqual(t, "secret", rotated)
+
+		stored, found := s.GetClient("app-id")
+		require.True(t, found)
+		assert.Equal(t, rotated, *stored.Secret)
+	})
+
+	t.Run("case=refuses to rotate encrypted secrets without the encryption plugin", func(t *testing.T) {
+		s, k, flags := setup(t, "")
+		var secret apiv1.Secret
+		require.NoError(t, k.Get(ctx, client.ObjectKey{Name: "app-credentials", Namespace: "team"}, &secret))
+		secret.Data[controllers.ClientSecretKey] = []byte(envelope.Prefix + "key:sealed")
+		require.NoError(t, k.Update(ctx, &secret))
+
+		_, err := kubectlHydra(append(append([]string{"rotate"}, flags...), "app")...)
+		require.EqualError(t, err, "the client secret of oauth2client team/app is encrypted, pass the --secret-encryption-plugin of the controller to rotate it")
+		stored, found := s.GetClient("app-id")
+		require.True(t, found)
+		assert.Equal(t, "secret", *stored.Secret)
+	})
+
+	t.Run("case=encrypts rotated secrets with the encryption plugin", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the plugin is a shell script")
+		}
+		s, k, flags := setup(t, "")
+		plugin := base64Plugin(t)
+
+		_, err := kubectlHydra(append(append([]string{"rotate"}, flags...), "--secret-encryption-plugin", plugin, "app")...)
+		require.NoError(t, err)
+
+		var secret apiv1.Secret
+		require.NoError(t, k.Get(ctx, client.ObjectKey{Name: "app-credentials", Namespace: "team"}, &secret))
+		require.True(t, envelope.IsEncrypted(secret.Data[controllers.ClientSecretKey]))
+		rotated, err := envelope.Decrypt(ctx, envelope.Plugin{Path: plugin}, secret.Data[controllers.ClientSecretKey])
+		require.NoError(t, err)
+
+		stored, found := s.GetClient("app-id")
+		require.True(t, found)
+		assert.Equal(t, string(rotated), *stored.Secret)
+	})
+
+	t.Run("case=refuses to rotate the secret of public clients", func(t *testing.T) {
+		s, _, flags := setup(t, "none")
+
+		_, err := kubectlHydra(append(append([]string{"rotate"}, flags...), "app")...)
+		require.EqualError(t, err, "oauth2client team/app has no client secret to rotate")
+		stored, found := s.GetClient("app-id")
+		require.True(t, found)
+		assert.Equal(t, "secret", *stored.Secret)
+	})
+
+	t.Run("case=fails for unknown OAuth2Clients", func(t *testing.T) {
+		_, _, flags := setup(t, "")
+
+		_, err := kubectlHydra(append(append([]string{"diff"}, flags...), "unknown")...)
+		require.Error(t, err)
+	})
+
+	t.Run("case=fails for unknown commands", func(t *testing.T) {
+		_, err := kubectlHydra("unknown")
+		require.ErrorContains(t, err, `unknown command "unknown"`)
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/envelope"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// runRestore replays the clients of a backup into an ORY Hydra instance,
+// e.g. after it was recreated with an empty database. The client secrets are
+// read from the Secrets of the OAuth2Clients, so the existing credentials
+// keep working. Clients whose secret can't be read are not restored, since
+// ORY Hydra would generate a secret nobody knows.
+func runRestore(args []string, out io.Writer) error {
+	var (
+		h                             hydraOptions
+		k                             kubeOptions
+		file, fromSecret, clusterName string
+	)
+
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	h.addFlags(fs)
+	fs.StringVar(&k.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to the KUBECONFIG environment variable or ~/.kube/config.")
+	fs.StringVar(&file, "file", "", "Restore from a file written by the export command.")
+	fs.StringVar(&fromSecret, "from-secret", "", "Restore from a backup Secret written by the controller, in the namespace/name form.")
+	fs.StringVar(&clusterName, "cluster-name", "", "The --cluster-name of the controller. Client secrets are only restored for clients of this cluster.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (file == "") == (fromSecret == "") {
+		return fmt.Errorf("exactly one of --file and --from-secret must be set")
+	}
+
+	ctx := context.Background()
+	kc, _, err := k.client()
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	if file != "" {
+		b, err = os.ReadFile(file)
+	} else {
+		b, err = readBackupSecret(ctx, kc, fromSecret)
+	}
+	if err != nil {
+		return err
+	}
+
+	var doc exportDocument
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	hc, err := h.defaultClient()
+	if err != nil {
+		return err
+	}
+
+	var restored, skipped, failed int
+	for _, cJSON := range doc.Clients {
+		if cJSON.ClientID == nil {
+			continue
+		}
+
+		if _, found, err := hc.GetOAuth2Client(ctx, *cJSON.ClientID); err != nil {
+			return err
+		} else if found {
+			skipped++
+			continue
+		}
+
+		cJSON.Secret = nil
+		password, err := restoredSecret(ctx, kc, cJSON, clusterName)
+		if err != nil {
+			fmt.Fprintf(out, "client %s: %s, not restored\n", *cJSON.ClientID, err)
+			failed++
+			continue
+		}
+		if password != nil {
+			cJSON.Secret = ptr.To(string(password))
+		}
+
+		if _, err := hc.PostOAuth2Client(ctx, cJSON); err != nil {
+			return fmt.Errorf("restoring client %s: %w", *cJSON.ClientID, err)
+		}
+		restored++
+	}
+
+	fmt.Fprintf(out, "restored %d clients, %d already existed\n", restored, skipped)
+	if failed > 0 {
+		return fmt.Errorf("%d clients could not be restored", failed)
+	}
+	return nil
+}
+
+func readBackupSecret(ctx context.Context, k client.Client, ref string) ([]byte, error) {
+	ns, name, ok := strings.Cut(ref, "/")
+	if !ok || ns == "" || name == "" {
+		return nil, fmt.Errorf("--from-secret must have the namespace/name form")
+	}
+
+	var secret apiv1.Secret
+	if err := k.Get(ctx, client.ObjectKey{Namespace: ns, Name: name}, &secret); err != nil {
+		return nil, err
+	}
+	b, found := secret.Data[controllers.BackupKey]
+	if !found {
+		return nil, fmt.Errorf("secret %s: %s property missing", ref, controllers.BackupKey)
+	}
+	return b, nil
+}
+
+// restoredSecret reads the client secret of cJSON from the Secret of its
+// OAuth2Client. It returns nil for public clients.
+func restoredSecret(ctx context.Context, k client.Client, cJSON *hydra.OAuth2ClientJSON, clusterName string) ([]byte, error) {
+	if cJSON.TokenEndpointAuthMethod == "none" {
+		return nil, nil
+	}
+
+	name, ns, cluster, ok := hydra.ParseOwner(cJSON.Owner)
+	if !ok || cluster != clusterName {
+		return nil, fmt.Errorf("owner %q is not in this cluster", cJSON.Owner)
+	}
+
+	var c hydrav1alpha1.OAuth2Client
+	if err := k.Get(ctx, client.ObjectKey{Namespace: ns, Name: name}, &c); err != nil {
+		return nil, err
+	}
+	creds, _, err := credentialsFor(ctx, k, &c)
+	if err != nil {
+		return nil, err
+	}
+	if string(creds.ID) != *cJSON.ClientID {
+		return nil, fmt.Errorf("secret of %s/%s holds another client ID", ns, name)
+	}
+	if envelope.IsEncrypted(creds.Password) {
+		return nil, fmt.Errorf("secret of %s/%s holds an encrypted client secret", ns, name)
+	}
+	if len(creds.Password) == 0 {
+		return nil, fmt.Errorf("secret of %s/%s holds no client secret", ns, name)
+	}
+	return creds.Password, nil
+}
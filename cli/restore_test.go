@@ -0,0 +1,155 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+
+	// oauth2Client returns the OAuth2Client team/name and its Secret holding
+	// the client ID and secret.
+	oauth2Client := func(name, clientID, secret string) []client.Object {
+		return []client.Object{
+			&hydrav1alpha1.OAuth2Client{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team"},
+				Spec:       hydrav1alpha1.OAuth2ClientSpec{SecretName: name + "-credentials"},
+			},
+			&apiv1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-credentials", Namespace: "team"},
+				Data: map[string][]byte{
+					controllers.ClientIDKey:     []byte(clientID),
+					controllers.ClientSecretKey: []byte(secret),
+				},
+			},
+		}
+	}
+
+	// setup returns the instance which was backed up, holding the clients of
+	// the OAuth2Clients team/app, team/moved, team/spa and of another
+	// cluster, and the empty instance to restore to.
+	setup := func(t *testing.T) (backedUp, restored *hydraServer) {
+		backedUp, restored = newHydraServer(t), newHydraServer(t)
+
+		backedUp.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("app-id"), Scope: "read", Owner: "app/team"})
+		backedUp.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("moved-id"), Owner: "moved/team"})
+		backedUp.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("spa-id"), TokenEndpointAuthMethod: "none", Owner: "spa/team"})
+		backedUp.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("remote-id"), Owner: "remote/team/west"})
+		return backedUp, restored
+	}
+
+	objs := append(append(oauth2Client("app", "app-id", "app-secret"), oauth2Client("moved", "other-id", "moved-secret")...), oauth2Client("spa", "spa-id", "")...)
+
+	// assertRestored asserts that restored holds the clients of the
+	// OAuth2Clients in this cluster with the secrets of their Secrets, and
+	// not the client whose Secret holds another client ID.
+	assertRestored := func(t *testing.T, restored *hydraServer) {
+		stored, found := restored.GetClient("app-id")
+		require.True(t, found)
+		assert.Equal(t, "read", stored.Scope)
+		assert.Equal(t, "app-secret", *stored.Secret)
+		assert.Equal(t, "app/team", stored.Owner)
+
+		_, found = restored.GetClient("moved-id")
+		assert.False(t, found)
+
+		stored, found = restored.GetClient("spa-id")
+		require.True(t, found)
+		assert.Nil(t, stored.Secret)
+	}
+
+	t.Run("case=restores an export", func(t *testing.T) {
+		backedUp, restored := setup(t)
+		exported, err := run(t, "export", hydraFlags(backedUp)...)
+		require.NoError(t, err)
+		file := filepath.Join(t.TempDir(), "backup.yaml")
+		require.NoError(t, os.WriteFile(file, []byte(exported), 0o600))
+
+		kubeFlags, _ := kubeFlags(t, "team", objs...)
+		out, err := run(t, "restore", append(append(hydraFlags(restored), kubeFlags...), "--file", file)...)
+		require.EqualError(t, err, "1 clients could not be restored")
+		assert.Equal(t, "client moved-id: secret of team/moved holds another client ID, not restored\nrestored 2 clients, 0 already existed\n", out)
+		assertRestored(t, restored)
+		_, found := restored.GetClient("remote-id")
+		assert.False(t, found)
+
+		out, err = run(t, "restore", append(append(hydraFlags(restored), kubeFlags...), "--file", file)...)
+		require.EqualError(t, err, "1 clients could not be restored")
+		assert.Equal(t, "client moved-id: secret of team/moved holds another client ID, not restored\nrestored 0 clients, 2 already existed\n", out)
+	})
+
+	t.Run("case=restores the backup secret of the controller", func(t *testing.T) {
+		backedUp, restored := setup(t)
+		u, err := url.Parse(backedUp.URL + clientsEndpoint)
+		require.NoError(t, err)
+		hc := &hydra.InternalClient{HydraURL: *u, HTTPClient: http.DefaultClient}
+		kubeFlags, k := kubeFlags(t, "team", objs...)
+		backup := &controllers.Backup{
+			Client:      k,
+			HydraClient: func() hydra.Client { return hc },
+			Secret:      types.NamespacedName{Name: "backup", Namespace: "hydra"},
+			Log:         logr.Discard(),
+			Reconcilers: []*controllers.OAuth2ClientReconciler{controllers.New(k, hc, logr.Discard())},
+		}
+		require.NoError(t, backup.Snapshot(ctx))
+
+		// the backup holds the clients of this cluster only
+		out, err := run(t, "restore", append(append(hydraFlags(restored), kubeFlags...), "--from-secret", "hydra/backup")...)
+		require.EqualError(t, err, "1 clients could not be restored")
+		assert.Equal(t, "client moved-id: secret of team/moved holds another client ID, not restored\nrestored 2 clients, 0 already existed\n", out)
+		assertRestored(t, restored)
+		_, found := restored.GetClient("remote-id")
+		assert.False(t, found)
+	})
+
+	t.Run("case=restores secrets of the cluster only", func(t *testing.T) {
+		backedUp, restored := setup(t)
+		exported, err := run(t, "export", append(hydraFlags(backedUp), "--cluster-name", "west")...)
+		require.NoError(t, err)
+		file := filepath.Join(t.TempDir(), "backup.yaml")
+		require.NoError(t, os.WriteFile(file, []byte(exported), 0o600))
+
+		kubeFlags, _ := kubeFlags(t, "team", oauth2Client("remote", "remote-id", "remote-secret")...)
+		out, err := run(t, "restore", append(append(hydraFlags(restored), kubeFlags...), "--file", file)...)
+		require.EqualError(t, err, "1 clients could not be restored")
+		assert.Equal(t, "client remote-id: owner \"remote/team/west\" is not in this cluster, not restored\nrestored 0 clients, 0 already existed\n", out)
+		_, found := restored.GetClient("remote-id")
+		assert.False(t, found)
+
+		restored = newHydraServer(t)
+		out, err = run(t, "restore", append(append(hydraFlags(restored), kubeFlags...), "--file", file, "--cluster-name", "west")...)
+		require.NoError(t, err)
+		assert.Equal(t, "restored 1 clients, 0 already existed\n", out)
+		stored, found := restored.GetClient("remote-id")
+		require.True(t, found)
+		assert.Equal(t, "remote-secret", *stored.Secret)
+	})
+
+	t.Run("case=requires exactly one source", func(t *testing.T) {
+		for _, args := range [][]string{nil, {"--file", "backup.yaml", "--from-secret", "hydra/backup"}} {
+			_, err := run(t, "restore", args...)
+			require.EqualError(t, err, "exactly one of --file and --from-secret must be set")
+		}
+	})
+}
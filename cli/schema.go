@@ -0,0 +1,152 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// CRD is the manifest of the OAuth2Client CustomResourceDefinition, set by
+// the binary embedding it.
+var CRD []byte
+
+// runSchema prints the JSON Schema of OAuth2Client manifests, so editors and
+// CI pipelines validating raw YAML apply the rules of the CRD schema and of
+// the admission webhook.
+func runSchema(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	schema, err := oauth2ClientJSONSchema(CRD)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+// oauth2ClientJSONSchema converts the structural schema of the served version
+// of crd to a JSON Schema and adds the rules of the admission webhook.
+func oauth2ClientJSONSchema(crd []byte) (map[string]interface{}, error) {
+	var def apiextensionsv1.CustomResourceDefinition
+	if err := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(crd), 4096).Decode(&def); err != nil {
+		return nil, fmt.Errorf("decoding the CRD: %w", err)
+	}
+
+	var structural *apiextensionsv1.JSONSchemaProps
+	for _, v := range def.Spec.Versions {
+		if v.Name == hydrav1alpha1.GroupVersion.Version && v.Schema != nil {
+			structural = v.Schema.OpenAPIV3Schema
+		}
+	}
+	if structural == nil {
+		return nil, fmt.Errorf("the CRD has no schema for %s", hydrav1alpha1.GroupVersion.Version)
+	}
+
+	raw, err := json.Marshal(structural)
+	if err != nil {
+		return nil, err
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, err
+	}
+	toJSONSchema(schema)
+
+	for _, rule := range hydrav1alpha1.SchemaRules() {
+		node, err := schemaAt(schema, rule.Path)
+		if err != nil {
+			return nil, err
+		}
+		allOf, _ := node["allOf"].([]interface{})
+		node["allOf"] = append(allOf, rule.Schema)
+	}
+
+	props := schema["properties"].(map[string]interface{})
+	props["apiVersion"].(map[string]interface{})["const"] = hydrav1alpha1.GroupVersion.String()
+	props["kind"].(map[string]interface{})["const"] = def.Spec.Names.Kind
+	schema["required"] = []string{"apiVersion", "kind", "spec"}
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = def.Spec.Names.Kind
+	return schema, nil
+}
+
+// toJSONSchema rewrites the Kubernetes extensions of a structural schema in
+// place. Unknown fields are rejected unless the schema preserves them, as
+// kubectl does with strict field validation.
+func toJSONSchema(node map[string]interface{}) {
+	if node["nullable"] == true {
+		if t, ok := node["type"].(string); ok {
+			node["type"] = []string{t, "null"}
+		}
+	}
+	if node["x-kubernetes-int-or-string"] == true {
+		delete(node, "type")
+		node["anyOf"] = []interface{}{
+			map[string]interface{}{"type": "integer"},
+			map[string]interface{}{"type": "string"},
+		}
+	}
+	if props, ok := node["properties"].(map[string]interface{}); ok {
+		if node["x-kubernetes-preserve-unknown-fields"] != true && node["additionalProperties"] == nil {
+			node["additionalProperties"] = false
+		}
+		for _, p := range props {
+			toJSONSchema(p.(map[string]interface{}))
+		}
+	}
+	for key, value := range node {
+		if strings.HasPrefix(key, "x-kubernetes-") || key == "nullable" {
+			delete(node, key)
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if key == "items" || key == "additionalProperties" || key == "not" {
+				toJSONSchema(v)
+			}
+		case []interface{}:
+			if key == "allOf" || key == "anyOf" || key == "oneOf" {
+				for _, s := range v {
+					toJSONSchema(s.(map[string]interface{}))
+				}
+			}
+		}
+	}
+}
+
+// schemaAt returns the schema of the field at path, e.g. spec.redirectUris[]
+// for the items of spec.redirectUris.
+func schemaAt(schema map[string]interface{}, path string) (map[string]interface{}, error) {
+	node := schema
+	for _, name := range strings.Split(path, ".") {
+		name, items := strings.CutSuffix(name, "[]")
+		props, _ := node["properties"].(map[string]interface{})
+		next, ok := props[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the CRD schema has no field %s", path)
+		}
+		node = next
+		if items {
+			if node, ok = node["items"].(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("the CRD schema field %s is not an array", path)
+			}
+		}
+	}
+	return node, nil
+}
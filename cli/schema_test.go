@@ -0,0 +1,82 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/cli"
+)
+
+func TestSchema(t *testing.T) {
+	crd, err := os.ReadFile("../config/crd/bases/hydra.ory.sh_oauth2clients.yaml")
+	require.NoError(t, err)
+	previous := cli.CRD
+	cli.CRD = crd
+	t.Cleanup(func() { cli.CRD = previous })
+
+	out, err := run(t, "schema")
+	require.NoError(t, err)
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &schema))
+
+	// at returns the schema of the property at path.
+	at := func(t *testing.T, path ...string) map[string]interface{} {
+		node := schema
+		for _, name := range path {
+			props, ok := node["properties"].(map[string]interface{})
+			require.True(t, ok, "%s has no properties", strings.Join(path, "."))
+			node, ok = props[name].(map[string]interface{})
+			require.True(t, ok, "%s not found", strings.Join(path, "."))
+		}
+		return node
+	}
+
+	t.Run("case=describes OAuth2Client manifests", func(t *testing.T) {
+		assert.Equal(t, "http://json-schema.org/draft-07/schema#", schema["$schema"])
+		assert.Equal(t, "OAuth2Client", schema["title"])
+		assert.Equal(t, []interface{}{"apiVersion", "kind", "spec"}, schema["required"])
+		assert.Equal(t, "hydra.ory.sh/v1alpha1", at(t, "apiVersion")["const"])
+		assert.Equal(t, "OAuth2Client", at(t, "kind")["const"])
+	})
+
+	t.Run("case=rejects unknown fields unless preserved", func(t *testing.T) {
+		assert.Equal(t, false, at(t, "spec")["additionalProperties"])
+		assert.Equal(t, false, at(t, "spec", "hydraAdmin")["additionalProperties"])
+		// the values of metadata are any JSON
+		assert.Equal(t, map[string]interface{}{}, at(t, "spec", "metadata")["additionalProperties"])
+		assert.Equal(t, []interface{}{"object", "null"}, at(t, "spec", "metadata")["type"])
+	})
+
+	t.Run("case=adds the rules of the admission webhook", func(t *testing.T) {
+		allOf, ok := at(t, "spec", "secretName")["allOf"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, allOf, 1)
+		assert.Contains(t, allOf[0], "pattern")
+
+		items, ok := at(t, "spec", "redirectUris")["items"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Contains(t, items, "allOf")
+		assert.Contains(t, at(t, "spec"), "allOf")
+	})
+
+	t.Run("case=drops the kubernetes extensions", func(t *testing.T) {
+		assert.NotContains(t, out, `"x-kubernetes-`)
+		assert.NotContains(t, out, `"nullable"`)
+	})
+
+	t.Run("case=fails without a schema of the served version", func(t *testing.T) {
+		cli.CRD = []byte("apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nspec:\n  versions:\n  - name: v1beta1\n")
+		t.Cleanup(func() { cli.CRD = crd })
+
+		_, err := run(t, "schema")
+		require.EqualError(t, err, "the CRD has no schema for v1alpha1")
+	})
+}
@@ -0,0 +1,137 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// runValidate checks the OAuth2Client manifests found in the given files and
+// directories, so invalid resources can be rejected before they are applied.
+// The warnings of valid manifests are printed as the webhook returns them.
+func runValidate(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("expected at least one file or directory, use - to read from stdin")
+	}
+
+	var checked, invalid int
+	for _, arg := range fs.Args() {
+		files, err := manifestFiles(arg)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			clients, err := readOAuth2Clients(file)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			for _, c := range clients {
+				checked++
+				errs := c.Validate()
+				if len(errs) == 0 {
+					for _, w := range c.Warnings() {
+						fmt.Fprintf(out, "%s: %s/%s: warning: %s\n", file, c.Namespace, c.Name, w)
+					}
+					continue
+				}
+				invalid++
+				for _, e := range errs {
+					fmt.Fprintf(out, "%s: %s/%s: %s\n", file, c.Namespace, c.Name, e)
+				}
+			}
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d OAuth2Clients are invalid", invalid, checked)
+	}
+	fmt.Fprintf(out, "%d OAuth2Clients are valid\n", checked)
+	return nil
+}
+
+// manifestFiles returns path if it is a file, or the YAML and JSON files
+// found under it if it is a directory.
+func manifestFiles(path string) ([]string, error) {
+	if path == "-" {
+		return []string{path}, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+			if !d.IsDir() {
+				files = append(files, p)
+			}
+		}
+		return nil
+	})
+	return files, err
+}
+
+// readOAuth2Clients decodes the OAuth2Client documents of a YAML or JSON file,
+// ignoring documents of other kinds.
+func readOAuth2Clients(file string) ([]hydrav1alpha1.OAuth2Client, error) {
+	var b []byte
+	var err error
+	if file == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var clients []hydrav1alpha1.OAuth2Client
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(b), 4096)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return clients, nil
+			}
+			return nil, err
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		var c hydrav1alpha1.OAuth2Client
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, err
+		}
+		if c.Kind != "OAuth2Client" || !strings.HasPrefix(c.APIVersion, hydrav1alpha1.GroupVersion.Group+"/") {
+			continue
+		}
+		clients = append(clients, c)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validManifest = `apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: valid
+  namespace: team
+spec:
+  grantTypes: [client_credentials]
+  scopeArray: [read]
+  secretName: valid-credentials
+`
+
+const invalidManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: invalid
+  namespace: team
+spec:
+  grantTypes: [password]
+  secretName: Invalid_Name
+`
+
+const warningManifest = `apiVersion: hydra.ory.sh/v1alpha1
+kind: OAuth2Client
+metadata:
+  name: verifier
+  namespace: team
+spec:
+  grantTypes: [authorization_code]
+  responseTypes: [code, vp_token]
+  redirectUris: [https://verifier.example.com/callback]
+  secretName: verifier-credentials
+`
+
+// writeManifests writes the files to a new directory and returns it.
+func writeManifests(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"valid.yaml":          validManifest,
+		"nested/valid.json":   `{"apiVersion": "hydra.ory.sh/v1alpha1", "kind": "OAuth2Client", "metadata": {"name": "json"}, "spec": {"grantTypes": ["client_credentials"], "secretName": "json"}}`,
+		"invalid/client.yaml": invalidManifest,
+		"warning.yaml":        warningManifest,
+		"README.md":           "not a manifest",
+	})
+
+	for _, tc := range []struct {
+		name, err string
+		args      []string
+		out       []string
+	}{
+		{
+			name: "case=accepts valid manifests",
+			args: []string{filepath.Join(dir, "valid.yaml"), filepath.Join(dir, "nested")},
+			out:  []string{"2 OAuth2Clients are valid\n"},
+		},
+		{
+			name: "case=reports invalid manifests",
+			args: []string{filepath.Join(dir, "invalid", "client.yaml")},
+			out: []string{
+				filepath.Join(dir, "invalid", "client.yaml") + `: team/invalid: spec.secretName: Invalid value: "Invalid_Name"`,
+				filepath.Join(dir, "invalid", "client.yaml") + `: team/invalid: spec.grantTypes[0]: Unsupported value: "password"`,
+			},
+			err: "1 of 1 OAuth2Clients are invalid",
+		},
+		{
+			name: "case=prints warnings of valid manifests",
+			args: []string{filepath.Join(dir, "warning.yaml")},
+			out: []string{
+				filepath.Join(dir, "warning.yaml") + ": team/verifier: warning: spec.responseTypes[1]: vp_token is not one of the response types ORY Hydra supports without extensions",
+				"1 OAuth2Clients are valid\n",
+			},
+		},
+		{
+			name: "case=walks directories",
+			args: []string{dir},
+			err:  "1 of 4 OAuth2Clients are invalid",
+		},
+		{
+			name: "case=requires files",
+			err:  "expected at least one file or directory, use - to read from stdin",
+		},
+		{
+			name: "case=fails for missing files",
+			args: []string{filepath.Join(dir, "missing.yaml")},
+			err:  "stat " + filepath.Join(dir, "missing.yaml") + ": no such file or directory",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := run(t, "validate", tc.args...)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tc.out {
+				assert.Contains(t, out, expected)
+			}
+		})
+	}
+}
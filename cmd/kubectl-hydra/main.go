@@ -0,0 +1,21 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// kubectl-hydra is a kubectl plugin to inspect and operate the OAuth2Clients
+// managed by hydra-maester. Install it anywhere in your PATH and run
+// `kubectl hydra`.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ory/hydra-maester/cli"
+)
+
+func main() {
+	if err := cli.KubectlHydra(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/oathkeeper"
+)
+
+// OathkeeperRuleAnnotation opts an OAuth2Client into the generation of an ORY
+// Oathkeeper Rule for its client, set to "true", see WithAccessRules. The
+// Rule has the name of the OAuth2Client and is deleted with it or when the
+// annotation is removed.
+const OathkeeperRuleAnnotation = "hydra.ory.sh/oathkeeper-rule"
+
+// +kubebuilder:rbac:groups=oathkeeper.ory.sh,resources=rules,verbs=get;create;update;delete
+
+// ensureAccessRule creates or updates the Oathkeeper Rule of the client
+// clientID of c if c has the OathkeeperRuleAnnotation, and deletes the Rule
+// it owns otherwise.
+func (r *OAuth2ClientReconciler) ensureAccessRule(ctx context.Context, c *hydrav1alpha1.OAuth2Client, clientID, scope string) error {
+	if r.accessRules == nil {
+		return nil
+	}
+
+	rule := &unstructured.Unstructured{}
+	rule.SetGroupVersionKind(oathkeeper.RuleGroupVersionKind)
+	rule.SetName(c.Name)
+	rule.SetNamespace(c.Namespace)
+
+	if c.Annotations[OathkeeperRuleAnnotation] != "true" {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(rule), rule); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				return nil
+			}
+			return err
+		}
+		if !ownedBy(rule, c) {
+			return nil
+		}
+		return client.IgnoreNotFound(r.Delete(ctx, rule))
+	}
+
+	spec, err := r.accessRules.Generate(ctx, oathkeeper.RuleData{
+		ClientID:    clientID,
+		Namespace:   c.Namespace,
+		Name:        c.Name,
+		Cluster:     r.ClusterName,
+		Scopes:      strings.Fields(scope),
+		Annotations: c.Annotations,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, rule, func() error {
+		if rule.GetResourceVersion() != "" && !ownedBy(rule, c) {
+			return fmt.Errorf("oathkeeper rule %s/%s exists and is not owned by the OAuth2Client", rule.GetNamespace(), rule.GetName())
+		}
+		rule.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: hydrav1alpha1.GroupVersion.String(),
+			Kind:       "OAuth2Client",
+			Name:       c.Name,
+			UID:        c.UID,
+		}})
+		return unstructured.SetNestedMap(rule.Object, spec, "spec")
+	})
+	return err
+}
+
+// ownedBy reports whether o is owned by the OAuth2Client c.
+func ownedBy(o metav1.Object, c *hydrav1alpha1.OAuth2Client) bool {
+	for _, ref := range o.GetOwnerReferences() {
+		if ref.UID == c.UID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+	"github.com/ory/hydra-maester/oathkeeper"
+)
+
+var _ = Describe("Oathkeeper access rules", func() {
+
+	var server *hydratest.Server
+	var generated []oathkeeper.RuleData
+	BeforeEach(func() {
+		server = hydratest.NewServer()
+		generated = nil
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	key := types.NamespacedName{Name: "app", Namespace: "default"}
+
+	generator := oathkeeper.GeneratorFunc(func(_ context.Context, data oathkeeper.RuleData) (map[string]interface{}, error) {
+		generated = append(generated, data)
+		return map[string]interface{}{
+			"match": map[string]interface{}{"url": "https://api.example.com/" + data.Name + "/<**>"},
+		}, nil
+	})
+
+	// reconcileApp reconciles default/app on c and returns it.
+	reconcileApp := func(c client.Client) *hydrav1alpha1.OAuth2Client {
+		r := controllers.New(c, server.Client(), logr.Discard(), controllers.WithAccessRules(generator))
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		var oauth2client hydrav1alpha1.OAuth2Client
+		Expect(c.Get(context.Background(), key, &oauth2client)).To(Succeed())
+		return &oauth2client
+	}
+
+	newOAuth2Client := func(annotations map[string]string) *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, UID: "app-uid", Annotations: annotations},
+			Spec: hydrav1alpha1.OAuth2ClientSpec{
+				GrantTypes: []hydrav1alpha1.GrantType{"client_credentials"},
+				ScopeArray: []string{"read", "write"},
+				SecretName: "app-credentials",
+			},
+		}
+	}
+
+	getRule := func(c client.Client) (*unstructured.Unstructured, error) {
+		rule := &unstructured.Unstructured{}
+		rule.SetGroupVersionKind(oathkeeper.RuleGroupVersionKind)
+		return rule, c.Get(context.Background(), key, rule)
+	}
+
+	It("creates the rule of annotated OAuth2Clients", func() {
+		c := newFakeClient(newOAuth2Client(map[string]string{controllers.OathkeeperRuleAnnotation: "true"}))
+		Expect(reconcileApp(c).Status.ReconciliationError.Code).To(BeEmpty())
+
+		rule, err := getRule(c)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rule.GetOwnerReferences()).To(Equal([]metav1.OwnerReference{{
+			APIVersion: hydrav1alpha1.GroupVersion.String(),
+			Kind:       "OAuth2Client",
+			Name:       "app",
+			UID:        "app-uid",
+		}}))
+		Expect(rule.Object["spec"]).To(Equal(map[string]interface{}{
+			"match": map[string]interface{}{"url": "https://api.example.com/app/<**>"},
+		}))
+
+		clients := server.ClientsOwnedBy("app/default")
+		Expect(clients).To(HaveLen(1))
+		Expect(generated).To(HaveLen(1))
+		Expect(generated[0].ClientID).To(Equal(*clients[0].ClientID))
+		Expect(generated[0].Scopes).To(Equal([]string{"read", "write"}))
+		Expect(generated[0].Annotations).To(HaveKeyWithValue(controllers.OathkeeperRuleAnnotation, "true"))
+	})
+
+	It("creates no rule without the annotation", func() {
+		c := newFakeClient(newOAuth2Client(nil))
+		Expect(reconcileApp(c).Status.ReconciliationError.Code).To(BeEmpty())
+
+		_, err := getRule(c)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(generated).To(BeEmpty())
+	})
+
+	It("creates and deletes the rule when the annotation changes", func() {
+		c := newFakeClient(newOAuth2Client(nil))
+		reconcileApp(c)
+
+		oauth2client := reconcileApp(c)
+		oauth2client.Annotations = map[string]string{controllers.OathkeeperRuleAnnotation: "true"}
+		Expect(c.Update(context.Background(), oauth2client)).To(Succeed())
+		reconcileApp(c)
+		_, err := getRule(c)
+		Expect(err).NotTo(HaveOccurred())
+
+		oauth2client = reconcileApp(c)
+		oauth2client.Annotations = nil
+		Expect(c.Update(context.Background(), oauth2client)).To(Succeed())
+		reconcileApp(c)
+		_, err = getRule(c)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("does not take over rules of others", func() {
+		other := &unstructured.Unstructured{}
+		other.SetGroupVersionKind(oathkeeper.RuleGroupVersionKind)
+		other.SetName(key.Name)
+		other.SetNamespace(key.Namespace)
+		c := newFakeClient(newOAuth2Client(map[string]string{controllers.OathkeeperRuleAnnotation: "true"}), other)
+
+		Expect(reconcileApp(c).Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusAccessRuleFailed))
+		rule, err := getRule(c)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rule.GetOwnerReferences()).To(BeEmpty())
+		Expect(rule.Object).NotTo(HaveKey("spec"))
+	})
+})
@@ -0,0 +1,76 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+var _ = Describe("Adaptive concurrency", func() {
+
+	var server *hydratest.Server
+	BeforeEach(func() {
+		server = hydratest.NewServer()
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	key := types.NamespacedName{Name: "app", Namespace: "default"}
+
+	newOAuth2Client := func(admin hydrav1alpha1.HydraAdmin) *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: hydrav1alpha1.OAuth2ClientSpec{
+				GrantTypes: []hydrav1alpha1.GrantType{"client_credentials"},
+				SecretName: "app-credentials",
+				HydraAdmin: admin,
+			},
+		}
+	}
+
+	// reconcileApp reconciles default/app on a client holding oauth2client
+	// with adaptive concurrency and returns the registry of its metrics.
+	reconcileApp := func(oauth2client *hydrav1alpha1.OAuth2Client) *prometheus.Registry {
+		reg := prometheus.NewRegistry()
+		r := controllers.New(newFakeClient(oauth2client), server.Client(), logr.Discard(),
+			controllers.WithMetricsRegisterer(reg),
+			controllers.WithAdaptiveConcurrency(hydra.ThrottleConfig{MinLimit: 2, MaxLimit: 8}),
+			controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+				return server.Client(), nil
+			}))
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return reg
+	}
+
+	It("limits the requests to the instance of spec.hydraAdmin", func() {
+		reg := reconcileApp(newOAuth2Client(hydrav1alpha1.HydraAdmin{URL: "http://hydra-admin.remote.example.com:4445/clients"}))
+
+		Expect(server.ClientsOwnedBy("app/default")).To(HaveLen(1))
+		Expect(metricValue(reg, "hydra_maester_hydra_api_concurrency_limit", map[string]string{
+			"instance": "http://hydra-admin.remote.example.com:4445",
+		})).To(Equal(2.0))
+	})
+
+	It("leaves the default client as it is", func() {
+		reg := reconcileApp(newOAuth2Client(hydrav1alpha1.HydraAdmin{}))
+
+		Expect(server.ClientsOwnedBy("app/default")).To(HaveLen(1))
+		Expect(metricValue(reg, "hydra_maester_hydra_api_concurrency_limit", nil)).To(Equal(-1.0))
+	})
+})
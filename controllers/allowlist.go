@@ -0,0 +1,87 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// defaultPorts are the ports of admin URLs without one, by scheme.
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// HydraURLAllowlist restricts the ORY Hydra admin addresses OAuth2Clients may
+// set in spec.hydraAdmin, so credentials are not sent to arbitrary URLs. The
+// patterns are matched with path.Match against the scheme, host and port of
+// the URL, e.g. https://*.ory.svc.cluster.local:4445, or against the URL and
+// the port for URLs without a host, like those of unix sockets. An empty
+// allowlist allows all addresses. The path of URLs with a host is not
+// matched, so the patterns apply to URLs with the port and path in
+// spec.hydraAdmin.url and to those setting the deprecated port and endpoint
+// alike.
+type HydraURLAllowlist []string
+
+// ParseHydraURLAllowlist returns an allowlist of patterns, or an error if a
+// pattern is malformed.
+func ParseHydraURLAllowlist(patterns []string) (HydraURLAllowlist, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid hydra URL pattern %q: %w", p, err)
+		}
+	}
+	return HydraURLAllowlist(patterns), nil
+}
+
+// Allows reports whether the admin address of rawURL and port matches a
+// pattern of the allowlist. The port of rawURL takes precedence over port,
+// and the default port of the scheme is used if neither is set. URLs with
+// user info, a query or a fragment are never allowed, as they could disguise
+// the host.
+func (l HydraURLAllowlist) Allows(rawURL string, port int) bool {
+	if len(l) == 0 {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return false
+	}
+	address := fmt.Sprintf("%s://%s:%d", strings.ToLower(u.Scheme), u.Path, port)
+	if u.Host != "" {
+		if u, err = (hydrav1alpha1.HydraAdmin{URL: rawURL, Port: port}).AdminURL(); err != nil {
+			return false
+		}
+		p := u.Port()
+		if p == "" {
+			p = defaultPorts[strings.ToLower(u.Scheme)]
+		}
+		address = strings.ToLower(u.Scheme + "://" + net.JoinHostPort(u.Hostname(), p))
+	}
+	for _, p := range l {
+		if ok, _ := path.Match(p, address); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DisallowedHydraURLError is returned for OAuth2Clients whose ORY Hydra admin
+// address is not in the allowlist, or which set one although per-resource
+// addresses are disabled.
+type DisallowedHydraURLError struct {
+	Address string
+	// Disabled is set if per-resource addresses are disabled.
+	Disabled bool
+}
+
+func (e *DisallowedHydraURLError) Error() string {
+	if e.Disabled {
+		return fmt.Sprintf("hydra address %s is not allowed, the controller only uses its default ORY Hydra", e.Address)
+	}
+	return fmt.Sprintf("hydra address %s is not allowed by the controller", e.Address)
+}
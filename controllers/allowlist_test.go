@@ -0,0 +1,54 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/ory/hydra-maester/controllers"
+)
+
+var _ = Describe("HydraURLAllowlist", func() {
+
+	It("allows all addresses when empty", func() {
+		Expect(controllers.HydraURLAllowlist(nil).Allows("http://evil.example.com", 80)).To(BeTrue())
+	})
+
+	It("matches the URL and the port against the patterns", func() {
+		l, err := controllers.ParseHydraURLAllowlist([]string{
+			"http://*.ory.svc.cluster.local:4445",
+			"https://hydra.example.com:*",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(l.Allows("http://hydra-admin.ory.svc.cluster.local", 4445)).To(BeTrue())
+		Expect(l.Allows("https://hydra.example.com", 443)).To(BeTrue())
+		Expect(l.Allows("http://hydra-admin.ory.svc.cluster.local", 80)).To(BeFalse())
+		Expect(l.Allows("http://evil.example.com/x.ory.svc.cluster.local", 4445)).To(BeFalse())
+		Expect(l.Allows("https://hydra.example.com.evil.com", 443)).To(BeFalse())
+		Expect(l.Allows("https://hydra.example.com:1@evil.com", 443)).To(BeFalse())
+		Expect(l.Allows("HTTPS://Hydra.Example.com", 443)).To(BeTrue())
+	})
+
+	It("matches the port and ignores the path of full URLs", func() {
+		l, err := controllers.ParseHydraURLAllowlist([]string{
+			"http://*.ory.svc.cluster.local:4445",
+			`http://\[fd00::1\]:4445`,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(l.Allows("http://hydra-admin.ory.svc.cluster.local:4445/admin/clients", 0)).To(BeTrue())
+		Expect(l.Allows("http://hydra-admin.ory.svc.cluster.local:4445", 80)).To(BeTrue())
+		Expect(l.Allows("http://hydra-admin.ory.svc.cluster.local/admin/clients", 0)).To(BeFalse())
+		Expect(l.Allows("http://[fd00::1]:4445/clients", 0)).To(BeTrue())
+		Expect(l.Allows("http://[FD00::1]", 4445)).To(BeTrue())
+		Expect(l.Allows("http://[fd00::2]:4445", 0)).To(BeFalse())
+	})
+
+	It("rejects malformed patterns", func() {
+		_, err := controllers.ParseHydraURLAllowlist([]string{"http://[hydra:4445"})
+		Expect(err).To(HaveOccurred())
+	})
+})
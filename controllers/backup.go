@@ -0,0 +1,124 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-logr/logr"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/ory/hydra-maester/hydra"
+)
+
+const (
+	// BackupKey is the key of the snapshot in the backup Secret. The snapshot
+	// has the format of the export command.
+	BackupKey = "clients.json"
+	// BackupTimeAnnotation records when the snapshot was taken.
+	BackupTimeAnnotation = "hydra.ory.sh/backup-time"
+)
+
+// Backup periodically snapshots the controller-owned clients of the default
+// ORY Hydra instance into a Secret, so they can be replayed into a fresh
+// instance with the restore command after a disaster.
+type Backup struct {
+	client.Client
+	// HydraClient returns the current default hydra client.
+	HydraClient func() hydra.Client
+	Secret      types.NamespacedName
+	Interval    time.Duration
+	Log         logr.Logger
+	// Reconcilers manage the OAuth2Clients of the controller. The snapshots
+	// hold the clients whose owner carries the cluster and controller
+	// identity of one of them, and the clock of the first one times them.
+	Reconcilers []*OAuth2ClientReconciler
+	// Shard restricts the snapshots to the clients of the OAuth2Clients in
+	// the namespaces of the shard, see WithShard.
+	Shard Shard
+}
+
+// Start takes a snapshot right away and then every interval until ctx is
+// done. Failed snapshots are logged and retried at the next interval.
+func (b *Backup) Start(ctx context.Context) error {
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.Snapshot(ctx); err != nil {
+			b.Log.Error(err, "backup of hydra clients failed", "secret", b.Secret)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Snapshot writes the controller-owned clients to the backup Secret.
+func (b *Backup) Snapshot(ctx context.Context) error {
+	clients, err := b.HydraClient().ListOAuth2Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot := struct {
+		Clients []*hydra.OAuth2ClientJSON `json:"clients"`
+	}{Clients: []*hydra.OAuth2ClientJSON{}}
+	for _, c := range clients {
+		if _, namespace, _, _ := hydra.ParseOwner(c.Owner); !b.owned(c.Owner) || !b.Shard.Owns(namespace) {
+			continue
+		}
+		snapshot.Clients = append(snapshot.Clients, c)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	secret := apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: b.Secret.Name, Namespace: b.Secret.Namespace},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, b.Client, &secret, func() error {
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[BackupTimeAnnotation] = b.now().UTC().Format(time.RFC3339)
+		secret.Data = map[string][]byte{BackupKey: data}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.Log.Info("backed up hydra clients", "secret", b.Secret, "clients", len(snapshot.Clients))
+	return nil
+}
+
+// owned reports whether owner names an OAuth2Client of one of the
+// reconcilers.
+func (b *Backup) owned(owner string) bool {
+	for _, r := range b.Reconcilers {
+		if r.owns(owner) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Backup) now() time.Time {
+	if len(b.Reconcilers) == 0 {
+		return time.Now()
+	}
+	return b.Reconcilers[0].clock.Now()
+}
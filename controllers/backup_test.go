@@ -0,0 +1,78 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+var _ = Describe("Backup", func() {
+
+	It("snapshots the clients of the controller in the namespaces of its shard", func() {
+		server := hydratest.NewServer()
+		defer server.Close()
+
+		shard := controllers.Shard{Index: 0, Total: 2}
+		var owned []string
+		for i := 0; i < 6; i++ {
+			ns := fmt.Sprintf("namespace-%d", i)
+			id := fmt.Sprintf("client-%d", i)
+			server.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To(id), Owner: hydra.Owner("client", ns, "east", "production")})
+			server.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To(id + "-west"), Owner: hydra.Owner("client", ns, "west", "production")})
+			server.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To(id + "-staging"), Owner: hydra.Owner("client", ns, "east", "staging")})
+			if shard.Owns(ns) {
+				owned = append(owned, id)
+			}
+		}
+		server.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("unmanaged"), Owner: "someone"})
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+		clock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+		r := controllers.New(c, nil, logr.Discard(),
+			controllers.WithClusterName("east"),
+			controllers.WithControllerID("production"),
+			controllers.WithClock(clock),
+		)
+		backup := &controllers.Backup{
+			Client:      c,
+			HydraClient: func() hydra.Client { return server.Client() },
+			Secret:      types.NamespacedName{Namespace: "hydra", Name: shard.Name("backup")},
+			Log:         logr.Discard(),
+			Reconcilers: []*controllers.OAuth2ClientReconciler{r},
+			Shard:       shard,
+		}
+		Expect(backup.Snapshot(context.Background())).To(Succeed())
+
+		var secret apiv1.Secret
+		Expect(c.Get(context.Background(), backup.Secret, &secret)).To(Succeed())
+		Expect(secret.Name).To(Equal("backup-shard-0-of-2"))
+		Expect(secret.Annotations).To(HaveKeyWithValue(controllers.BackupTimeAnnotation, "2024-05-01T12:00:00Z"))
+		var snapshot struct {
+			Clients []*hydra.OAuth2ClientJSON `json:"clients"`
+		}
+		Expect(json.Unmarshal(secret.Data[controllers.BackupKey], &snapshot)).To(Succeed())
+		var ids []string
+		for _, client := range snapshot.Clients {
+			ids = append(ids, *client.ClientID)
+		}
+		Expect(ids).To(ConsistOf(owned))
+	})
+})
@@ -0,0 +1,66 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// DefaultClientNameTemplate names clients after the namespace and name of
+// their OAuth2Client, e.g. payments/checkout.
+const DefaultClientNameTemplate = "{{ .Namespace }}/{{ .Name }}"
+
+// ClientNameData are the fields available to client name templates.
+type ClientNameData struct {
+	Namespace string
+	Name      string
+	// Cluster is the cluster name of the reconciler, see WithClusterName.
+	Cluster string
+}
+
+// ClientNameTemplate renders the client_name of the clients of OAuth2Clients
+// which don't set spec.clientName, so they can be told apart in the consent
+// UI and the client listings of ORY Hydra. A nil ClientNameTemplate leaves
+// the name empty.
+type ClientNameTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseClientNameTemplate parses text, a Go template executed with
+// ClientNameData. It returns nil for an empty text. Templates referring to
+// unknown fields are rejected.
+func ParseClientNameTemplate(text string) (*ClientNameTemplate, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("clientName").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t := &ClientNameTemplate{tmpl: tmpl}
+	if _, err := t.render(ClientNameData{}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Execute returns the client name of c in the cluster named cluster.
+func (t *ClientNameTemplate) Execute(c *hydrav1alpha1.OAuth2Client, cluster string) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	return t.render(ClientNameData{Namespace: c.Namespace, Name: c.Name, Cluster: cluster})
+}
+
+func (t *ClientNameTemplate) render(data ClientNameData) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("unable to render the client name: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,42 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+)
+
+var _ = Describe("ClientNameTemplate", func() {
+
+	c := &hydrav1alpha1.OAuth2Client{ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "payments"}}
+
+	It("names clients after their OAuth2Client by default", func() {
+		t, err := controllers.ParseClientNameTemplate(controllers.DefaultClientNameTemplate)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.Execute(c, "eu-1")).To(Equal("payments/checkout"))
+	})
+
+	It("renders the cluster name", func() {
+		t, err := controllers.ParseClientNameTemplate("{{ .Cluster }}: {{ .Namespace }}/{{ .Name }}")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.Execute(c, "eu-1")).To(Equal("eu-1: payments/checkout"))
+	})
+
+	It("leaves the name empty without a template", func() {
+		t, err := controllers.ParseClientNameTemplate("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t).To(BeNil())
+		Expect(t.Execute(c, "eu-1")).To(BeEmpty())
+	})
+
+	It("rejects unknown fields", func() {
+		_, err := controllers.ParseClientNameTemplate("{{ .Team }}")
+		Expect(err).To(HaveOccurred())
+	})
+})
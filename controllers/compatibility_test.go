@@ -0,0 +1,95 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+var _ = Describe("Hydra version compatibility", func() {
+
+	var server *hydratest.Server
+	var recorder *record.FakeRecorder
+	BeforeEach(func() {
+		server = hydratest.NewServer()
+		recorder = record.NewFakeRecorder(10)
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	key := types.NamespacedName{Name: "app", Namespace: "default"}
+
+	// reconcileApp reconciles default/app on c and returns its Incompatible
+	// condition.
+	reconcileApp := func(c client.Client) *hydrav1alpha1.OAuth2ClientCondition {
+		hydraClient := server.Client()
+		r := controllers.New(c, hydraClient, logr.Discard(), controllers.WithVersionCheck(controllers.VersionCheckWarn), controllers.WithEventRecorder(recorder))
+		Expect(r.CheckVersion(context.Background(), hydraClient, server.URL)).To(Succeed())
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		var oauth2client hydrav1alpha1.OAuth2Client
+		Expect(c.Get(context.Background(), key, &oauth2client)).To(Succeed())
+		Expect(oauth2client.Status.ReconciliationError.Code).To(BeEmpty())
+		for _, condition := range oauth2client.Status.Conditions {
+			if condition.Type == hydrav1alpha1.OAuth2ClientConditionIncompatible {
+				return &condition
+			}
+		}
+		return nil
+	}
+
+	newOAuth2Client := func() *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: hydrav1alpha1.OAuth2ClientSpec{
+				GrantTypes:  []hydrav1alpha1.GrantType{"client_credentials"},
+				SecretName:  "app-credentials",
+				SkipConsent: true,
+			},
+		}
+	}
+
+	It("warns about fields older versions ignore", func() {
+		server.SetVersion("v2.1.2")
+		c := newFakeClient(newOAuth2Client())
+
+		Expect(reconcileApp(c)).To(Equal(&hydrav1alpha1.OAuth2ClientCondition{
+			Type:    hydrav1alpha1.OAuth2ClientConditionIncompatible,
+			Status:  hydrav1alpha1.ConditionTrue,
+			Reason:  hydrav1alpha1.ReasonVersionSkew,
+			Message: "skip_consent is ignored by ORY Hydra v2.1.2, it needs v2.2.0 or newer",
+		}))
+		Expect(recorder.Events).To(Receive(Equal("Warning IncompatibleFields skip_consent is ignored by ORY Hydra v2.1.2, it needs v2.2.0 or newer")))
+
+		// the condition is kept while the client is not written again
+		Expect(reconcileApp(c).Status).To(Equal(hydrav1alpha1.ConditionTrue))
+	})
+
+	It("accepts fields the version supports", func() {
+		c := newFakeClient(newOAuth2Client())
+
+		Expect(reconcileApp(c)).To(Equal(&hydrav1alpha1.OAuth2ClientCondition{
+			Type:   hydrav1alpha1.OAuth2ClientConditionIncompatible,
+			Status: hydrav1alpha1.ConditionFalse,
+		}))
+		for len(recorder.Events) > 0 {
+			Expect(<-recorder.Events).NotTo(ContainSubstring(controllers.IncompatibleFieldsReason))
+		}
+	})
+})
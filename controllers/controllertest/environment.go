@@ -0,0 +1,207 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package controllertest runs the OAuth2Client reconciler against envtest
+// and a fake ORY Hydra, for integration tests of operators built on top of
+// hydra-maester.
+package controllertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/go-logr/logr"
+	apiv1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+// Environment is a Kubernetes API server with the hydra.ory.sh CRDs
+// installed and an OAuth2Client reconciler registering clients in a fake
+// ORY Hydra.
+type Environment struct {
+	// Config is the configuration of the API server.
+	Config *rest.Config
+	// Client is a client of the API server. It does not read from the
+	// manager's cache, so created objects are visible immediately.
+	Client client.Client
+	// Scheme contains the core and hydra.ory.sh types.
+	Scheme *k8sruntime.Scheme
+	// Hydra is the fake ORY Hydra the reconciler registers clients in.
+	Hydra *hydratest.Server
+	// Reconciler is the running OAuth2Client reconciler.
+	Reconciler *controllers.OAuth2ClientReconciler
+
+	env    *envtest.Environment
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// Options represent options to pass to Start.
+type Options struct {
+	CRDDirectoryPaths []string
+	ReconcilerOptions []controllers.Option
+	Log               logr.Logger
+}
+
+// Option is a functional option for Start.
+type Option func(*Options)
+
+// WithCRDDirectoryPaths replaces the directories the CRDs are installed
+// from. The default are the CRDs of this module, which requires its sources
+// to be available, as they are in the module cache.
+func WithCRDDirectoryPaths(paths ...string) Option {
+	return func(o *Options) {
+		o.CRDDirectoryPaths = paths
+	}
+}
+
+// WithReconcilerOptions sets the options the reconciler is created with.
+func WithReconcilerOptions(opts ...controllers.Option) Option {
+	return func(o *Options) {
+		o.ReconcilerOptions = append(o.ReconcilerOptions, opts...)
+	}
+}
+
+// WithLogger sets the logger of the manager and the reconciler. Logs are
+// discarded by default.
+func WithLogger(log logr.Logger) Option {
+	return func(o *Options) {
+		o.Log = log
+	}
+}
+
+// Start starts the API server, the fake ORY Hydra and a manager running the
+// reconciler. The envtest binaries are located as described by envtest,
+// usually through the KUBEBUILDER_ASSETS environment variable. The caller
+// must call Stop when finished.
+func Start(opts ...Option) (*Environment, error) {
+	options := &Options{
+		CRDDirectoryPaths: []string{crdDirectory()},
+		Log:               logr.Discard(),
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	scheme := k8sruntime.NewScheme()
+	if err := apiv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := hydrav1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	e := &Environment{
+		Scheme: scheme,
+		env: &envtest.Environment{
+			CRDDirectoryPaths:     options.CRDDirectoryPaths,
+			ErrorIfCRDPathMissing: true,
+			Scheme:                scheme,
+		},
+		done: make(chan error, 1),
+	}
+
+	var err error
+	e.Config, err = e.env.Start()
+	if err != nil {
+		return nil, fmt.Errorf("unable to start the test environment: %w", err)
+	}
+	e.Hydra = hydratest.NewServer()
+
+	if err := e.setup(options); err != nil {
+		return nil, errors.Join(err, e.Stop())
+	}
+	return e, nil
+}
+
+func (e *Environment) setup(options *Options) error {
+	var err error
+	e.Client, err = client.New(e.Config, client.Options{Scheme: e.Scheme})
+	if err != nil {
+		return err
+	}
+
+	mgr, err := ctrl.NewManager(e.Config, ctrl.Options{
+		Scheme:  e.Scheme,
+		Logger:  options.Log,
+		Metrics: server.Options{BindAddress: "0"},
+	})
+	if err != nil {
+		return err
+	}
+
+	reconcilerOptions := append([]controllers.Option{
+		controllers.WithEventRecorder(mgr.GetEventRecorderFor("hydra-maester")),
+	}, options.ReconcilerOptions...)
+	e.Reconciler = controllers.New(
+		mgr.GetClient(),
+		e.Hydra.Client(),
+		options.Log.WithName("controllers").WithName("OAuth2Client"),
+		reconcilerOptions...,
+	)
+	if err := e.Reconciler.SetupWithManager(mgr); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	go func() {
+		e.done <- mgr.Start(ctx)
+	}()
+
+	if !mgr.GetCache().WaitForCacheSync(ctx) {
+		return errors.New("unable to sync the manager's cache")
+	}
+	return nil
+}
+
+// Stop stops the manager, the fake ORY Hydra and the API server.
+func (e *Environment) Stop() error {
+	var err error
+	if e.cancel != nil {
+		e.cancel()
+		err = <-e.done
+		e.cancel = nil
+	}
+	if e.Hydra != nil {
+		e.Hydra.Close()
+	}
+	return errors.Join(err, e.env.Stop())
+}
+
+// Setup is like Start but fails t on errors and stops the environment when
+// the test finishes.
+func Setup(t testing.TB, opts ...Option) *Environment {
+	t.Helper()
+
+	e, err := Start(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := e.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	return e
+}
+
+// crdDirectory returns the directory of the CRDs in the sources of this
+// module.
+func crdDirectory() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "config", "crd", "bases")
+}
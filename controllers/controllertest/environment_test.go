@@ -0,0 +1,37 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllertest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/controllers/controllertest"
+)
+
+func TestEnvironment(t *testing.T) {
+	ctx := context.Background()
+	env := controllertest.Setup(t)
+
+	c := controllertest.NewOAuth2Client("test", "default")
+	require.NoError(t, env.Client.Create(ctx, c))
+
+	reconciled, err := env.WaitForReconciled(ctx, client.ObjectKeyFromObject(c))
+	require.NoError(t, err)
+	assert.Empty(t, reconciled.Status.ReconciliationError.Code)
+
+	var secret apiv1.Secret
+	require.NoError(t, env.Client.Get(ctx, client.ObjectKeyFromObject(c), &secret))
+
+	registered, ok := env.Hydra.GetClient(string(secret.Data[controllers.ClientIDKey]))
+	require.True(t, ok)
+	assert.Equal(t, "test/default", registered.Owner)
+	assert.Equal(t, string(secret.Data[controllers.ClientSecretKey]), *registered.Secret)
+}
@@ -0,0 +1,50 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllertest
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// NewOAuth2Client returns a valid client_credentials OAuth2Client storing
+// its credentials in a Secret of the same name. It is registered in the
+// reconciler's default ORY Hydra, which is the Environment's fake.
+func NewOAuth2Client(name, namespace string) *hydrav1alpha1.OAuth2Client {
+	return &hydrav1alpha1.OAuth2Client{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: hydrav1alpha1.OAuth2ClientSpec{
+			GrantTypes:    []hydrav1alpha1.GrantType{"client_credentials"},
+			ResponseTypes: []hydrav1alpha1.ResponseType{"token"},
+			Scope:         "read write",
+			SecretName:    name,
+		},
+	}
+}
+
+// WaitForReconciled waits until the reconciler has observed the current
+// generation of the OAuth2Client identified by key, and returns it. The
+// returned client may carry a reconciliation error.
+func (e *Environment) WaitForReconciled(ctx context.Context, key client.ObjectKey) (*hydrav1alpha1.OAuth2Client, error) {
+	var c hydrav1alpha1.OAuth2Client
+	err := wait.PollUntilContextTimeout(ctx, 100*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		if err := e.Client.Get(ctx, key, &c); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return c.Generation != 0 && c.Status.ObservedGeneration == c.Generation, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
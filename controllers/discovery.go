@@ -0,0 +1,116 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// Keys of the discovery ConfigMap published for an OAuth2Client.
+const (
+	DiscoveryIssuerKey                = "ISSUER_URL"
+	DiscoveryAuthorizationEndpointKey = "AUTHORIZATION_ENDPOINT"
+	DiscoveryTokenEndpointKey         = "TOKEN_ENDPOINT"
+	DiscoveryClientIDKey              = "CLIENT_ID"
+	DiscoveryScopeKey                 = "SCOPE"
+
+	authorizationEndpointPath = "/oauth2/auth"
+	tokenEndpointPath         = "/oauth2/token"
+)
+
+// ensureDiscoveryConfigMap creates or updates the discovery ConfigMap of the
+// client if spec.discoveryConfigMapName is set, and deletes the discovery
+// ConfigMaps it published under another name before.
+func (r *OAuth2ClientReconciler) ensureDiscoveryConfigMap(ctx context.Context, c *hydrav1alpha1.OAuth2Client, clientID, scope string) error {
+	if err := r.deleteStaleDiscoveryConfigMaps(ctx, c); err != nil {
+		return err
+	}
+	if c.Spec.DiscoveryConfigMapName == "" {
+		return nil
+	}
+
+	data := map[string]string{
+		DiscoveryClientIDKey: clientID,
+		DiscoveryScopeKey:    scope,
+	}
+
+	r.mu.Lock()
+	publicURL := r.HydraPublicURL
+	r.mu.Unlock()
+	if c.Spec.HydraAdmin.PublicURL != "" {
+		publicURL = c.Spec.HydraAdmin.PublicURL
+	} else if c.Spec.HydraAdmin.URL == "" && c.Spec.HydraAdmin.ServiceRef == nil {
+		admin, err := r.namespaceHydraAdmin(ctx, c.Namespace)
+		if err != nil {
+			return err
+		}
+		if admin != nil && admin.PublicURL != "" {
+			publicURL = admin.PublicURL
+		}
+	}
+	if publicURL != "" {
+		issuer, err := url.Parse(publicURL)
+		if err != nil {
+			return err
+		}
+		data[DiscoveryIssuerKey] = issuer.String()
+		data[DiscoveryAuthorizationEndpointKey] = issuer.JoinPath(authorizationEndpointPath).String()
+		data[DiscoveryTokenEndpointKey] = issuer.JoinPath(tokenEndpointPath).String()
+	} else {
+		r.Log.V(1).Info("no hydra public URL configured, publishing discovery ConfigMap without endpoints", "oauth2client", c.Name, "namespace", c.Namespace)
+	}
+
+	cm := apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.Spec.DiscoveryConfigMapName,
+			Namespace: c.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, &cm, func() error {
+		if cm.ResourceVersion != "" && !ownedBy(&cm, c) {
+			return fmt.Errorf("configmap %s/%s exists and is not owned by the OAuth2Client", cm.Namespace, cm.Name)
+		}
+		cm.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: hydrav1alpha1.GroupVersion.String(),
+			Kind:       "OAuth2Client",
+			Name:       c.Name,
+			UID:        c.UID,
+		}}
+		cm.Data = data
+		return nil
+	})
+	return err
+}
+
+// deleteStaleDiscoveryConfigMaps deletes the discovery ConfigMaps owned by c
+// which are not named by spec.discoveryConfigMapName, i.e. the ConfigMaps
+// published before the name was changed or cleared.
+func (r *OAuth2ClientReconciler) deleteStaleDiscoveryConfigMaps(ctx context.Context, c *hydrav1alpha1.OAuth2Client) error {
+	var cms apiv1.ConfigMapList
+	if err := r.List(ctx, &cms, client.InNamespace(c.Namespace)); err != nil {
+		return err
+	}
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+		if cm.Name == c.Spec.DiscoveryConfigMapName || !ownedBy(cm, c) {
+			continue
+		}
+		if _, ok := cm.Data[DiscoveryClientIDKey]; !ok {
+			continue
+		}
+		if err := r.Delete(ctx, cm); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
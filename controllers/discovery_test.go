@@ -0,0 +1,187 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/stretchr/testify/mock"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	mocks "github.com/ory/hydra-maester/controllers/mocks/hydra"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// newFakeClient returns a fake client holding objs, which serves the status
+// of OAuth2Clients as a subresource like the API server.
+func newFakeClient(objs ...client.Object) client.Client {
+	s := runtime.NewScheme()
+	Expect(hydrav1alpha1.AddToScheme(s)).To(Succeed())
+	Expect(apiv1.AddToScheme(s)).To(Succeed())
+	return fake.NewClientBuilder().
+		WithScheme(s).
+		WithStatusSubresource(&hydrav1alpha1.OAuth2Client{}).
+		WithObjects(objs...).
+		Build()
+}
+
+var _ = Describe("Discovery ConfigMap", func() {
+
+	key := types.NamespacedName{Name: "app", Namespace: "default"}
+
+	// reconcileAppWith reconciles the OAuth2Client default/app with spec
+	// next to objs and returns the client holding them.
+	reconcileAppWith := func(objs []client.Object, spec hydrav1alpha1.OAuth2ClientSpec, opts ...controllers.Option) client.Client {
+		spec.GrantTypes = []hydrav1alpha1.GrantType{"client_credentials"}
+		spec.ScopeArray = []string{"read", "write"}
+		spec.SecretName = "app-credentials"
+		c := newFakeClient(append(objs, &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, UID: "app-uid"},
+			Spec:       spec,
+		})...)
+
+		mch := &mocks.Client{}
+		mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+		mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+		mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+			registered := *o
+			registered.ClientID = ptr.To("app-id")
+			registered.Secret = ptr.To("app-secret")
+			return &registered
+		}, nil)
+
+		r := controllers.New(c, mch, logr.Discard(), opts...)
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return c
+	}
+
+	// reconcileApp reconciles the OAuth2Client default/app with spec and
+	// returns the client holding it.
+	reconcileApp := func(spec hydrav1alpha1.OAuth2ClientSpec, opts ...controllers.Option) client.Client {
+		return reconcileAppWith(nil, spec, opts...)
+	}
+
+	// ownedByApp are the owner references of the ConfigMaps published for
+	// default/app.
+	ownedByApp := []metav1.OwnerReference{{
+		APIVersion: hydrav1alpha1.GroupVersion.String(),
+		Kind:       "OAuth2Client",
+		Name:       "app",
+		UID:        "app-uid",
+	}}
+
+	// discoveryData returns the data of the discovery ConfigMap of
+	// default/app, which must be owned by it.
+	discoveryData := func(c client.Client) map[string]string {
+		var cm apiv1.ConfigMap
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "app-discovery", Namespace: "default"}, &cm)).To(Succeed())
+		Expect(cm.OwnerReferences).To(Equal(ownedByApp))
+		return cm.Data
+	}
+
+	It("publishes the issuer and endpoints of the public URL of the controller", func() {
+		c := reconcileApp(hydrav1alpha1.OAuth2ClientSpec{DiscoveryConfigMapName: "app-discovery"},
+			controllers.WithHydraPublicURL("https://auth.example.com/"))
+
+		Expect(discoveryData(c)).To(Equal(map[string]string{
+			controllers.DiscoveryClientIDKey:              "app-id",
+			controllers.DiscoveryScopeKey:                 "read write",
+			controllers.DiscoveryIssuerKey:                "https://auth.example.com/",
+			controllers.DiscoveryAuthorizationEndpointKey: "https://auth.example.com/oauth2/auth",
+			controllers.DiscoveryTokenEndpointKey:         "https://auth.example.com/oauth2/token",
+		}))
+	})
+
+	It("prefers the public URL of the client", func() {
+		c := reconcileApp(hydrav1alpha1.OAuth2ClientSpec{
+			DiscoveryConfigMapName: "app-discovery",
+			HydraAdmin:             hydrav1alpha1.HydraAdmin{PublicURL: "https://tenant.example.com"},
+		}, controllers.WithHydraPublicURL("https://auth.example.com/"))
+
+		data := discoveryData(c)
+		Expect(data).To(HaveKeyWithValue(controllers.DiscoveryIssuerKey, "https://tenant.example.com"))
+		Expect(data).To(HaveKeyWithValue(controllers.DiscoveryTokenEndpointKey, "https://tenant.example.com/oauth2/token"))
+	})
+
+	It("publishes the client without endpoints without a public URL", func() {
+		c := reconcileApp(hydrav1alpha1.OAuth2ClientSpec{DiscoveryConfigMapName: "app-discovery"})
+
+		Expect(discoveryData(c)).To(Equal(map[string]string{
+			controllers.DiscoveryClientIDKey: "app-id",
+			controllers.DiscoveryScopeKey:    "read write",
+		}))
+	})
+
+	It("publishes nothing without a ConfigMap name", func() {
+		c := reconcileApp(hydrav1alpha1.OAuth2ClientSpec{}, controllers.WithHydraPublicURL("https://auth.example.com/"))
+
+		var list apiv1.ConfigMapList
+		Expect(c.List(context.Background(), &list)).To(Succeed())
+		Expect(list.Items).To(BeEmpty())
+	})
+
+	It("refuses to overwrite a ConfigMap not owned by the client", func() {
+		foreign := &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-discovery", Namespace: "default"},
+			Data:       map[string]string{"key": "value"},
+		}
+		c := reconcileAppWith([]client.Object{foreign}, hydrav1alpha1.OAuth2ClientSpec{DiscoveryConfigMapName: "app-discovery"})
+
+		var cm apiv1.ConfigMap
+		Expect(c.Get(context.Background(), client.ObjectKeyFromObject(foreign), &cm)).To(Succeed())
+		Expect(cm.OwnerReferences).To(BeEmpty())
+		Expect(cm.Data).To(Equal(map[string]string{"key": "value"}))
+
+		var app hydrav1alpha1.OAuth2Client
+		Expect(c.Get(context.Background(), key, &app)).To(Succeed())
+		Expect(app.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusCreateConfigMapFailed))
+		Expect(app.Status.ReconciliationError.Description).To(ContainSubstring("not owned by the OAuth2Client"))
+	})
+
+	It("deletes the ConfigMap published under the previous name", func() {
+		previous := &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-old-discovery", Namespace: "default", OwnerReferences: ownedByApp},
+			Data:       map[string]string{controllers.DiscoveryClientIDKey: "app-id"},
+		}
+		unrelated := &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-settings", Namespace: "default", OwnerReferences: ownedByApp},
+			Data:       map[string]string{"key": "value"},
+		}
+		c := reconcileAppWith([]client.Object{previous, unrelated}, hydrav1alpha1.OAuth2ClientSpec{DiscoveryConfigMapName: "app-discovery"})
+
+		Expect(discoveryData(c)).To(HaveKeyWithValue(controllers.DiscoveryClientIDKey, "app-id"))
+		var list apiv1.ConfigMapList
+		Expect(c.List(context.Background(), &list)).To(Succeed())
+		names := []string{}
+		for _, cm := range list.Items {
+			names = append(names, cm.Name)
+		}
+		Expect(names).To(ConsistOf("app-discovery", "app-settings"))
+	})
+
+	It("deletes the ConfigMap once the name is cleared", func() {
+		previous := &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-discovery", Namespace: "default", OwnerReferences: ownedByApp},
+			Data:       map[string]string{controllers.DiscoveryClientIDKey: "app-id"},
+		}
+		c := reconcileAppWith([]client.Object{previous}, hydrav1alpha1.OAuth2ClientSpec{})
+
+		var list apiv1.ConfigMapList
+		Expect(c.List(context.Background(), &list)).To(Succeed())
+		Expect(list.Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,83 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+var _ = Describe("Hydra admin DNS servers", func() {
+
+	var server *hydratest.Server
+	var used [][]string
+	BeforeEach(func() {
+		server = hydratest.NewServer()
+		used = nil
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	key := types.NamespacedName{Name: "app", Namespace: "default"}
+
+	// reconcileApp reconciles default/app on c with the default DNS servers
+	// and records the DNS servers of the hydra clients in used.
+	reconcileApp := func(c client.Client, servers ...string) {
+		r := controllers.New(c, nil, logr.Discard(), controllers.WithDNSServers(servers), controllers.WithClientFactory(func(spec hydrav1alpha1.OAuth2ClientSpec, _ string, _ bool) (hydra.Client, error) {
+			used = append(used, spec.HydraAdmin.DNSServers)
+			return server.Client(), nil
+		}))
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	newOAuth2Client := func(admin hydrav1alpha1.HydraAdmin) *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: hydrav1alpha1.OAuth2ClientSpec{
+				GrantTypes: []hydrav1alpha1.GrantType{"client_credentials"},
+				SecretName: "app-credentials",
+				HydraAdmin: admin,
+			},
+		}
+	}
+
+	It("uses the default DNS servers", func() {
+		c := newFakeClient(newOAuth2Client(hydrav1alpha1.HydraAdmin{URL: "http://hydra-admin.corp.internal:4445/clients"}))
+		reconcileApp(c, "10.0.0.53")
+		Expect(used).To(Equal([][]string{{"10.0.0.53"}}))
+	})
+
+	It("prefers the DNS servers of the OAuth2Client", func() {
+		c := newFakeClient(newOAuth2Client(hydrav1alpha1.HydraAdmin{URL: "http://hydra-admin.corp.internal:4445/clients", DNSServers: []string{"10.1.0.53", "10.1.0.54"}}))
+		reconcileApp(c, "10.0.0.53")
+		Expect(used).To(Equal([][]string{{"10.1.0.53", "10.1.0.54"}}))
+	})
+
+	It("uses the DNS servers of the namespace defaults", func() {
+		c := newFakeClient(newOAuth2Client(hydrav1alpha1.HydraAdmin{}), &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: controllers.NamespaceDefaultsConfigMap, Namespace: key.Namespace},
+			Data: map[string]string{
+				controllers.NamespaceDefaultsURLKey:        "http://hydra-admin.tenant.internal:4445/clients",
+				controllers.NamespaceDefaultsDNSServersKey: "10.2.0.53, [fd00::53]:5353",
+			},
+		})
+		reconcileApp(c, "10.0.0.53")
+		Expect(used).To(Equal([][]string{{"10.2.0.53", "[fd00::53]:5353"}}))
+	})
+})
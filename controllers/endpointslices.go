@@ -0,0 +1,114 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list
+
+// EndpointSliceResolver resolves ORY Hydra instances from the ready endpoints
+// of the EndpointSlices of a Service, e.g. the pods of a sharded ORY Hydra
+// StatefulSet behind a headless Service.
+type EndpointSliceResolver struct {
+	// Reader lists the EndpointSlices. It should not be cached, so the
+	// controller doesn't watch all EndpointSlices.
+	Reader    client.Reader
+	Namespace string
+	Service   string
+	// Port is the name of the admin port. It may be empty if the
+	// EndpointSlices have a single port.
+	Port string
+	// Scheme of the admin addresses, http if empty.
+	Scheme string
+}
+
+func (r *EndpointSliceResolver) Resolve(ctx context.Context) ([]string, error) {
+	var list discoveryv1.EndpointSliceList
+	err := r.Reader.List(ctx, &list,
+		client.InNamespace(r.Namespace),
+		client.MatchingLabels{discoveryv1.LabelServiceName: r.Service})
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, s := range list.Items {
+		port, ok := r.port(s)
+		if !ok {
+			continue
+		}
+		for _, e := range s.Endpoints {
+			if e.Conditions.Ready != nil && !*e.Conditions.Ready {
+				continue
+			}
+			for _, address := range e.Addresses {
+				addresses = append(addresses, hydra.InstanceAddress(r.Scheme, address, port))
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// port returns the number of the admin port of s.
+func (r *EndpointSliceResolver) port(s discoveryv1.EndpointSlice) (int, bool) {
+	for _, p := range s.Ports {
+		if p.Port == nil {
+			continue
+		}
+		if (r.Port == "" && len(s.Ports) == 1) || (p.Name != nil && *p.Name == r.Port) {
+			return int(*p.Port), true
+		}
+	}
+	return 0, false
+}
+
+// ParseHydraDiscovery parses how the ORY Hydra instances of the default
+// client are discovered: dns+srv://<record> resolves the targets of a DNS SRV
+// record, and endpointslice://<namespace>/<service> the ready endpoints of
+// the EndpointSlices of a Service, read with reader. The port query parameter
+// names the admin port of EndpointSlices with several ports, and the scheme
+// query parameter sets the scheme of the admin addresses, http by default.
+func ParseHydraDiscovery(spec string, reader client.Reader) (hydra.Resolver, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hydra discovery %q: %w", spec, err)
+	}
+	scheme := u.Query().Get("scheme")
+	if !slices.Contains([]string{"", "http", "https"}, scheme) {
+		return nil, fmt.Errorf("hydra discovery %q: scheme must be http or https", spec)
+	}
+
+	switch u.Scheme {
+	case "dns+srv":
+		if u.Host == "" {
+			return nil, fmt.Errorf("hydra discovery %q must have the dns+srv://<record> form", spec)
+		}
+		return hydra.SRVResolver{Name: u.Host, Scheme: scheme}, nil
+	case "endpointslice":
+		service := strings.Trim(u.Path, "/")
+		if u.Host == "" || service == "" || strings.Contains(service, "/") {
+			return nil, fmt.Errorf("hydra discovery %q must have the endpointslice://<namespace>/<service> form", spec)
+		}
+		return &EndpointSliceResolver{
+			Reader:    reader,
+			Namespace: u.Host,
+			Service:   service,
+			Port:      u.Query().Get("port"),
+			Scheme:    scheme,
+		}, nil
+	default:
+		return nil, fmt.Errorf("hydra discovery %q must start with dns+srv:// or endpointslice://", spec)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+var _ = Describe("ParseHydraDiscovery", func() {
+
+	It("parses DNS SRV records", func() {
+		resolver, err := controllers.ParseHydraDiscovery("dns+srv://_http-admin._tcp.hydra-admin.hydra.svc.cluster.local?scheme=https", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolver).To(Equal(hydra.SRVResolver{Name: "_http-admin._tcp.hydra-admin.hydra.svc.cluster.local", Scheme: "https"}))
+	})
+
+	It("parses EndpointSlices of a Service", func() {
+		resolver, err := controllers.ParseHydraDiscovery("endpointslice://hydra/hydra-admin?port=http-admin", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolver).To(Equal(&controllers.EndpointSliceResolver{Namespace: "hydra", Service: "hydra-admin", Port: "http-admin"}))
+	})
+
+	It("rejects malformed specs", func() {
+		for _, spec := range []string{
+			"hydra-admin:4445",
+			"dns+srv://",
+			"endpointslice://hydra",
+			"endpointslice://hydra/a/b",
+			"endpointslice://hydra/hydra-admin?scheme=ftp",
+		} {
+			_, err := controllers.ParseHydraDiscovery(spec, nil)
+			Expect(err).To(HaveOccurred(), spec)
+		}
+	})
+})
+
+var _ = Describe("EndpointSliceResolver", func() {
+
+	slice := func(name, service string, ports []discoveryv1.EndpointPort, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "hydra",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Ports:       ports,
+			Endpoints:   endpoints,
+		}
+	}
+	port := func(name string, number int32) discoveryv1.EndpointPort {
+		return discoveryv1.EndpointPort{Name: ptr.To(name), Port: ptr.To(number)}
+	}
+
+	It("resolves the ready endpoints of the admin port of the Service", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			slice("hydra-admin-a", "hydra-admin", []discoveryv1.EndpointPort{port("http-public", 4444), port("http-admin", 4445)},
+				discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}},
+				discoveryv1.Endpoint{Addresses: []string{"10.0.0.2"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)}},
+			),
+			slice("hydra-admin-b", "hydra-admin", []discoveryv1.EndpointPort{port("http-admin", 4445)},
+				discoveryv1.Endpoint{Addresses: []string{"fd00::3"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}},
+			),
+			slice("other", "other", []discoveryv1.EndpointPort{port("http-admin", 4445)},
+				discoveryv1.Endpoint{Addresses: []string{"10.0.0.4"}},
+			),
+		).Build()
+
+		resolver := &controllers.EndpointSliceResolver{Reader: c, Namespace: "hydra", Service: "hydra-admin", Port: "http-admin"}
+		addresses, err := resolver.Resolve(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addresses).To(ConsistOf("http://10.0.0.1:4445", "http://[fd00::3]:4445"))
+	})
+
+	It("uses the only port without a port name", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			slice("hydra-admin", "hydra-admin", []discoveryv1.EndpointPort{port("", 4445)},
+				discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}},
+			),
+		).Build()
+
+		resolver := &controllers.EndpointSliceResolver{Reader: c, Namespace: "hydra", Service: "hydra-admin", Scheme: "https"}
+		addresses, err := resolver.Resolve(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addresses).To(ConsistOf("https://10.0.0.1:4445"))
+	})
+})
@@ -0,0 +1,136 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// ExistenceProbe periodically checks that the clients of the reconciled
+// OAuth2Clients still exist in ORY Hydra, and reconciles the OAuth2Clients
+// whose clients were deleted out of band, e.g. with the hydra CLI. The
+// reconciliation registers them again with the credentials of their Secret.
+type ExistenceProbe struct {
+	Reconciler *OAuth2ClientReconciler
+	Interval   time.Duration
+	Log        logr.Logger
+}
+
+// Start probes every interval until ctx is done. Failed probes are logged
+// and retried at the next interval.
+func (p *ExistenceProbe) Start(ctx context.Context) error {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		missing, err := p.Probe(ctx)
+		if err != nil {
+			p.Log.Error(err, "existence probe of hydra clients failed")
+		}
+		for _, name := range missing {
+			p.Log.Info("client is missing in hydra, reconciling", "oauth2client", name)
+			c := &hydrav1alpha1.OAuth2Client{}
+			c.Name, c.Namespace = name.Name, name.Namespace
+			select {
+			case p.Reconciler.probes <- event.GenericEvent{Object: c}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// Probe returns the OAuth2Clients whose clients are missing in ORY Hydra.
+// Only reconciled OAuth2Clients with a Secret are probed; the clients are
+// listed once per ORY Hydra instance and those not listed are looked up
+// before being reported, as the list may be paginated.
+func (p *ExistenceProbe) Probe(ctx context.Context) ([]types.NamespacedName, error) {
+	r := p.Reconciler
+
+	var opts []client.ListOption
+	if r.ControllerNamespace != "" {
+		opts = append(opts, client.InNamespace(r.ControllerNamespace))
+	}
+	var list hydrav1alpha1.OAuth2ClientList
+	if err := r.List(ctx, &list, opts...); err != nil {
+		return nil, err
+	}
+
+	type probed struct {
+		name types.NamespacedName
+		id   string
+	}
+	byHydra := map[hydra.Client][]probed{}
+	for _, c := range list.Items {
+		if !r.watches(c.Namespace) ||
+			!c.DeletionTimestamp.IsZero() ||
+			c.Status.ObservedGeneration == 0 ||
+			c.Generation != c.Status.ObservedGeneration ||
+			c.Status.ReconciliationError.Code != "" {
+			continue
+		}
+
+		var secret apiv1.Secret
+		if err := r.Get(ctx, types.NamespacedName{Name: c.Spec.SecretName, Namespace: c.Namespace}, &secret); err != nil {
+			continue
+		}
+		id, ok := secret.Data[ClientIDKey]
+		if !ok {
+			continue
+		}
+
+		hydraClient, err := r.getHydraClientForClient(ctx, c)
+		if err != nil {
+			continue
+		}
+		byHydra[hydraClient] = append(byHydra[hydraClient], probed{
+			name: types.NamespacedName{Name: c.Name, Namespace: c.Namespace},
+			id:   string(id),
+		})
+	}
+
+	var missing []types.NamespacedName
+	for hydraClient, clients := range byHydra {
+		registered, err := hydraClient.ListOAuth2Client(ctx)
+		if err != nil {
+			return missing, err
+		}
+		ids := make(map[string]bool, len(registered))
+		for _, o := range registered {
+			if o.ClientID != nil {
+				ids[*o.ClientID] = true
+			}
+		}
+
+		for _, c := range clients {
+			if ids[c.id] {
+				continue
+			}
+			_, found, err := hydraClient.GetOAuth2Client(ctx, c.id)
+			if err != nil {
+				return missing, err
+			}
+			if !found {
+				missing = append(missing, c.name)
+			}
+		}
+	}
+	return missing, nil
+}
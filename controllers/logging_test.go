@@ -0,0 +1,62 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+var _ = Describe("Reconcile logging", func() {
+
+	var server *hydratest.Server
+	BeforeEach(func() {
+		server = hydratest.NewServer()
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	// reconcileLogged reconciles the OAuth2Client namespace/app with a logger of
+	// verbosity and returns the logged messages.
+	reconcileLogged := func(namespace string, verbosity int) []string {
+		var messages []string
+		log := funcr.New(func(prefix, args string) {
+			messages = append(messages, args)
+		}, funcr.Options{Verbosity: verbosity})
+
+		key := types.NamespacedName{Name: "app", Namespace: namespace}
+		c := newFakeClient(&hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: hydrav1alpha1.OAuth2ClientSpec{
+				GrantTypes: []hydrav1alpha1.GrantType{"client_credentials"},
+				SecretName: "app-credentials",
+			},
+		})
+		r := controllers.New(c, server.Client(), log, controllers.WithNamespace("default"))
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return messages
+	}
+
+	It("logs ignored namespaces at the debug level only", func() {
+		Expect(reconcileLogged("team", 0)).To(BeEmpty())
+		Expect(reconcileLogged("team", 1)).To(ContainElement(ContainSubstring("Requested resource team/app is not in namespace: default and will be ignored")))
+	})
+
+	It("logs using the default client at the debug level only", func() {
+		Expect(reconcileLogged("default", 0)).NotTo(ContainElement(ContainSubstring("Using default client")))
+		Expect(reconcileLogged("default", 1)).To(ContainElement(ContainSubstring("Using default client")))
+	})
+})
@@ -0,0 +1,89 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// DefaultMetadataFromKey is the key of the ConfigMap referenced by
+// spec.metadataFrom.configMapRef if it doesn't set one.
+const DefaultMetadataFromKey = "metadata"
+
+// MetadataFromError is returned if the metadata referenced by an OAuth2Client
+// can't be read.
+type MetadataFromError struct {
+	ConfigMap types.NamespacedName
+	Key       string
+	Err       error
+}
+
+func (e *MetadataFromError) Error() string {
+	return fmt.Sprintf("unable to read the metadata of key %s of ConfigMap %s: %s", e.Key, e.ConfigMap, e.Err)
+}
+
+func (e *MetadataFromError) Unwrap() error {
+	return e.Err
+}
+
+// mergeMetadataFrom merges the metadata referenced by spec.metadataFrom of c
+// into spec.metadata, which wins, and returns the resource version of the
+// referenced ConfigMap. It returns an empty version if c doesn't reference
+// metadata. Like the namespace defaults, the merged metadata is only kept in
+// memory.
+func (r *OAuth2ClientReconciler) mergeMetadataFrom(ctx context.Context, c *hydrav1alpha1.OAuth2Client) (string, error) {
+	if c.Spec.MetadataFrom == nil || c.Spec.MetadataFrom.ConfigMapRef == nil {
+		return "", nil
+	}
+
+	ref := c.Spec.MetadataFrom.ConfigMapRef
+	key := ref.Key
+	if key == "" {
+		key = DefaultMetadataFromKey
+	}
+	name := types.NamespacedName{Name: ref.Name, Namespace: c.Namespace}
+
+	var cm apiv1.ConfigMap
+	if err := r.Get(ctx, name, &cm); err != nil {
+		return "", &MetadataFromError{ConfigMap: name, Key: key, Err: err}
+	}
+	data, ok := cm.Data[key]
+	if !ok {
+		return "", &MetadataFromError{ConfigMap: name, Key: key, Err: fmt.Errorf("the key is not set")}
+	}
+
+	// YAML is a superset of JSON, both are converted to JSON
+	raw, err := yaml.YAMLToJSON([]byte(data))
+	if err != nil {
+		return "", &MetadataFromError{ConfigMap: name, Key: key, Err: err}
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(raw, &object); err != nil || object == nil {
+		return "", &MetadataFromError{ConfigMap: name, Key: key, Err: fmt.Errorf("the value is not an object")}
+	}
+
+	// keys set to null in spec.metadata are kept, so they still remove the
+	// keys of the client in ORY Hydra on updates
+	spec, err := json.Marshal(c.Spec.Metadata)
+	if err != nil {
+		return "", &MetadataFromError{ConfigMap: name, Key: key, Err: err}
+	}
+	merged, err := hydra.ComposeMetadata(raw, spec)
+	if err != nil {
+		return "", &MetadataFromError{ConfigMap: name, Key: key, Err: err}
+	}
+	if c.Spec.Metadata, err = hydra.DecodeMetadata(merged); err != nil {
+		return "", &MetadataFromError{ConfigMap: name, Key: key, Err: err}
+	}
+	return cm.ResourceVersion, nil
+}
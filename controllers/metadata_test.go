@@ -0,0 +1,102 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+var _ = Describe("Client metadata", func() {
+
+	var server *hydratest.Server
+	BeforeEach(func() {
+		server = hydratest.NewServer()
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	key := types.NamespacedName{Name: "app", Namespace: "default"}
+
+	// reconcileApp reconciles default/app on c and returns the metadata of
+	// its client in ORY Hydra.
+	reconcileApp := func(c client.Client, opts ...controllers.Option) string {
+		r := controllers.New(c, server.Client(), logr.Discard(), opts...)
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		clients := server.ClientsOwnedBy("app/default")
+		Expect(clients).To(HaveLen(1))
+		return string(clients[0].Metadata)
+	}
+
+	// setMetadata replaces the spec.metadata of default/app on c and bumps
+	// its generation, which the fake client doesn't.
+	setMetadata := func(c client.Client, metadata string) {
+		var oauth2client hydrav1alpha1.OAuth2Client
+		Expect(c.Get(context.Background(), key, &oauth2client)).To(Succeed())
+		oauth2client.Spec.Metadata = nil
+		Expect(json.Unmarshal([]byte(metadata), &oauth2client.Spec.Metadata)).To(Succeed())
+		oauth2client.Generation++
+		Expect(c.Update(context.Background(), &oauth2client)).To(Succeed())
+	}
+
+	// annotate adds the metadata key automation as a system writing to ORY
+	// Hydra would.
+	annotate := func() {
+		stored := server.ClientsOwnedBy("app/default")[0]
+		var err error
+		stored.Metadata, err = hydra.SetMetadata(stored.Metadata, "automation", map[string]bool{"synced": true})
+		Expect(err).NotTo(HaveOccurred())
+		server.AddClient(stored)
+	}
+
+	newOAuth2Client := func() *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: hydrav1alpha1.OAuth2ClientSpec{
+				GrantTypes: []hydrav1alpha1.GrantType{"client_credentials"},
+				SecretName: "app-credentials",
+				Metadata: map[string]apiextensionsv1.JSON{
+					"team":     {Raw: []byte(`"payments"`)},
+					"legacyId": {Raw: []byte(`7`)},
+					"removed":  {},
+				},
+			},
+		}
+	}
+
+	It("deep merges spec.metadata into the metadata in ORY Hydra", func() {
+		c := newFakeClient(newOAuth2Client())
+		Expect(reconcileApp(c)).To(MatchJSON(`{"team":"payments","legacyId":7}`))
+
+		annotate()
+		setMetadata(c, `{"team":"checkout","legacyId":null}`)
+		Expect(reconcileApp(c)).To(MatchJSON(`{"team":"checkout","automation":{"synced":true}}`))
+	})
+
+	It("replaces the metadata without merging", func() {
+		c := newFakeClient(newOAuth2Client())
+		reconcileApp(c, controllers.WithMetadataMerge(false))
+
+		annotate()
+		setMetadata(c, `{"team":"checkout"}`)
+		Expect(reconcileApp(c, controllers.WithMetadataMerge(false))).To(MatchJSON(`{"team":"checkout"}`))
+	})
+})
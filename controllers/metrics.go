@@ -0,0 +1,383 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// The status labels of the hydra_maester_clients gauge.
+const (
+	ClientStatusSynced  = "synced"
+	ClientStatusError   = "error"
+	ClientStatusPending = "pending"
+)
+
+// metrics are the collectors of a reconciler. Reconcilers registering with
+// the same registerer share them.
+type metrics struct {
+	reconciliations *prometheus.CounterVec
+	errors          *prometheus.CounterVec
+	driftRepairs    *prometheus.CounterVec
+	recreations     *prometheus.CounterVec
+	hydraErrors     *prometheus.CounterVec
+	panics          *prometheus.CounterVec
+	divergences     *prometheus.CounterVec
+	hydraPhases     *prometheus.HistogramVec
+	hydraLimits     *prometheus.GaugeVec
+}
+
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		reconciliations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "hydra_maester",
+			Name:      "oauth2client_reconciliations_total",
+			Help:      "Number of OAuth2Client reconciliations that registered or updated a client in ORY Hydra.",
+		}, []string{"cluster"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "hydra_maester",
+			Name:      "oauth2client_reconciliation_errors_total",
+			Help:      "Number of OAuth2Client reconciliation errors recorded in the status, by status code.",
+		}, []string{"cluster", "code"}),
+		driftRepairs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "hydra_maester",
+			Name:      "oauth2client_drift_repairs_total",
+			Help:      "Number of clients in ORY Hydra which were changed out of band and restored to their OAuth2Client.",
+		}, []string{"cluster"}),
+		recreations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "hydra_maester",
+			Name:      "oauth2client_recreations_total",
+			Help:      "Number of clients which were deleted in ORY Hydra out of band and registered again.",
+		}, []string{"cluster"}),
+		hydraErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "hydra_maester",
+			Name:      "hydra_api_errors_total",
+			Help:      "Number of failed requests to the ORY Hydra admin API, by method, endpoint and HTTP status code, or error if no response was received.",
+		}, []string{"method", "endpoint", "code"}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "hydra_maester",
+			Name:      "oauth2client_reconcile_panics_total",
+			Help:      "Number of OAuth2Client reconciliations which panicked and were recovered.",
+		}, []string{"cluster"}),
+		divergences: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "hydra_maester",
+			Name:      "mirror_divergences_total",
+			Help:      "Number of writes which the mirror ORY Hydra instance failed or stored differently than the primary one, by method and kind: error or diff.",
+		}, []string{"method", "kind"}),
+		hydraPhases: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "hydra_maester",
+			Name:      "hydra_api_request_phase_seconds",
+			Help:      "Duration of the phases of requests to the ORY Hydra admin API, by endpoint and phase: dns, connect, tls or first_byte.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "phase"}),
+		hydraLimits: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "hydra_maester",
+			Name:      "hydra_api_concurrency_limit",
+			Help:      "Adaptive limit of the concurrent requests to an ORY Hydra admin API, by instance.",
+		}, []string{"instance"}),
+	}
+	if reg == nil {
+		return m, nil
+	}
+
+	var err error
+	if m.reconciliations, err = register(reg, m.reconciliations); err != nil {
+		return nil, err
+	}
+	if m.errors, err = register(reg, m.errors); err != nil {
+		return nil, err
+	}
+	if m.driftRepairs, err = register(reg, m.driftRepairs); err != nil {
+		return nil, err
+	}
+	if m.recreations, err = register(reg, m.recreations); err != nil {
+		return nil, err
+	}
+	if m.hydraErrors, err = register(reg, m.hydraErrors); err != nil {
+		return nil, err
+	}
+	if m.panics, err = register(reg, m.panics); err != nil {
+		return nil, err
+	}
+	if m.divergences, err = register(reg, m.divergences); err != nil {
+		return nil, err
+	}
+	if m.hydraPhases, err = register(reg, m.hydraPhases); err != nil {
+		return nil, err
+	}
+	if m.hydraLimits, err = register(reg, m.hydraLimits); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// HydraRequestObserver returns an observer for hydra.WithRequestObserver
+// which counts the failed requests of a client in the
+// hydra_maester_hydra_api_errors_total metric registered with reg. The
+// clients created by reconcilers with the default client factory are
+// observed already.
+func HydraRequestObserver(reg prometheus.Registerer) (hydra.RequestObserver, error) {
+	m, err := newMetrics(reg)
+	if err != nil {
+		return nil, err
+	}
+	return m.observeHydraRequest, nil
+}
+
+// HydraRequestTracer returns a tracer for hydra.WithRequestTracer which
+// records the phases of the requests of a client in the
+// hydra_maester_hydra_api_request_phase_seconds histogram registered with
+// reg. The clients created by reconcilers with the default client factory
+// are traced already.
+func HydraRequestTracer(reg prometheus.Registerer) (hydra.RequestTracer, error) {
+	m, err := newMetrics(reg)
+	if err != nil {
+		return nil, err
+	}
+	return m.traceHydraRequest, nil
+}
+
+// HydraConcurrencyLimitReporter returns a function setting the
+// hydra_maester_hydra_api_concurrency_limit metric registered with reg to the
+// limit of the instance at address, for the OnLimit of the
+// hydra.ThrottleConfig of a client. The clients of spec.hydraAdmin are
+// reported already, see WithAdaptiveConcurrency.
+func HydraConcurrencyLimitReporter(reg prometheus.Registerer) (func(address string, limit int), error) {
+	m, err := newMetrics(reg)
+	if err != nil {
+		return nil, err
+	}
+	return func(address string, limit int) {
+		m.hydraLimits.WithLabelValues(address).Set(float64(limit))
+	}, nil
+}
+
+// MirrorDivergenceReporter returns a reporter for hydra.Mirror which logs the
+// divergences of the mirror with log and counts them in the
+// hydra_maester_mirror_divergences_total metric registered with reg.
+func MirrorDivergenceReporter(reg prometheus.Registerer, log logr.Logger) (func(hydra.MirrorDivergence), error) {
+	m, err := newMetrics(reg)
+	if err != nil {
+		return nil, err
+	}
+	return func(d hydra.MirrorDivergence) {
+		if d.Err != nil {
+			m.divergences.WithLabelValues(d.Method, "error").Inc()
+			log.Error(d.Err, "mirror hydra failed the write", "method", d.Method, "client_id", d.ClientID)
+			return
+		}
+		m.divergences.WithLabelValues(d.Method, "diff").Inc()
+		log.Info("mirror hydra stored the client differently", "method", d.Method, "client_id", d.ClientID, "diff", d.Diffs)
+	}, nil
+}
+
+// UnmanagedClientsGauge returns the hydra_maester_unmanaged_clients gauge
+// registered with reg, which an UnmanagedClientScanner sets to the number of
+// clients in ORY Hydra without a controller owner.
+func UnmanagedClientsGauge(reg prometheus.Registerer) (prometheus.Gauge, error) {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "hydra_maester",
+		Name:      "unmanaged_clients",
+		Help:      "Number of clients in the default ORY Hydra instance which are not owned by an OAuth2Client.",
+	})
+	err := reg.Register(g)
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(prometheus.Gauge); ok {
+			return existing, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// observeHydraRequest counts attempts which failed or were answered with an
+// error status.
+func (m *metrics) observeHydraRequest(method, endpoint string, status int, err error) {
+	switch {
+	case status >= 400:
+		m.hydraErrors.WithLabelValues(method, endpoint, strconv.Itoa(status)).Inc()
+	case err != nil:
+		m.hydraErrors.WithLabelValues(method, endpoint, "error").Inc()
+	}
+}
+
+// traceHydraRequest records the duration of a phase of a request.
+func (m *metrics) traceHydraRequest(endpoint, phase string, d time.Duration) {
+	m.hydraPhases.WithLabelValues(endpoint, phase).Observe(d.Seconds())
+}
+
+// register registers c with reg and returns it, or the collector registered
+// before under the same name.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	if err != nil {
+		var zero C
+		return zero, err
+	}
+	return c, nil
+}
+
+// clientsCollector exports the number of OAuth2Clients of a reconciler by
+// namespace and status, the number of degraded ones, and the age of their
+// client secrets. The
+// OAuth2Clients and Secrets are read from the cache of the reconciler on
+// every scrape.
+type clientsCollector struct {
+	r           *OAuth2ClientReconciler
+	desc        *prometheus.Desc
+	degraded    *prometheus.Desc
+	secretAge   *prometheus.Desc
+	rotationDue *prometheus.Desc
+}
+
+func newClientsCollector(r *OAuth2ClientReconciler) *clientsCollector {
+	return &clientsCollector{
+		r: r,
+		desc: prometheus.NewDesc(
+			"hydra_maester_clients",
+			"Number of OAuth2Clients managed by the controller, by namespace and status: synced, error or pending.",
+			[]string{"namespace", "status"},
+			prometheus.Labels{"cluster": r.ClusterName},
+		),
+		degraded: prometheus.NewDesc(
+			"hydra_maester_degraded_clients",
+			"Number of OAuth2Clients whose Degraded condition is true, by namespace.",
+			[]string{"namespace"},
+			prometheus.Labels{"cluster": r.ClusterName},
+		),
+		secretAge: prometheus.NewDesc(
+			"hydra_maester_client_secret_age_seconds",
+			"Time since the client secret in the Secret of an OAuth2Client was generated.",
+			[]string{"namespace", "oauth2client", "secret"},
+			prometheus.Labels{"cluster": r.ClusterName},
+		),
+		rotationDue: prometheus.NewDesc(
+			"hydra_maester_client_secret_rotation_due_seconds",
+			"Time until the client secret of an OAuth2Client is due to be rotated according to its hydra.ory.sh/secret-rotation-period annotation, negative if overdue.",
+			[]string{"namespace", "oauth2client", "secret"},
+			prometheus.Labels{"cluster": r.ClusterName},
+		),
+	}
+}
+
+func (c *clientsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+	ch <- c.degraded
+	ch <- c.secretAge
+	ch <- c.rotationDue
+}
+
+func (c *clientsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var opts []client.ListOption
+	if c.r.ControllerNamespace != "" {
+		opts = append(opts, client.InNamespace(c.r.ControllerNamespace))
+	}
+	var list hydrav1alpha1.OAuth2ClientList
+	if err := c.r.List(ctx, &list, opts...); err != nil {
+		c.r.Log.Error(err, "unable to count OAuth2Clients for metrics")
+		return
+	}
+
+	counts := map[string]map[string]int{}
+	degraded := map[string]int{}
+	for _, o := range list.Items {
+		if !c.r.watches(o.Namespace) {
+			continue
+		}
+		if counts[o.Namespace] == nil {
+			counts[o.Namespace] = map[string]int{ClientStatusSynced: 0, ClientStatusError: 0, ClientStatusPending: 0}
+		}
+		counts[o.Namespace][clientStatus(&o)]++
+		if isDegraded(&o) {
+			degraded[o.Namespace]++
+		}
+		c.collectSecret(ctx, ch, &o)
+	}
+
+	for ns, statuses := range counts {
+		for status, n := range statuses {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), ns, status)
+		}
+		ch <- prometheus.MustNewConstMetric(c.degraded, prometheus.GaugeValue, float64(degraded[ns]), ns)
+	}
+}
+
+// collectSecret exports the age of the client secret of o and, if o has a
+// rotation period, the time until its rotation is due.
+func (c *clientsCollector) collectSecret(ctx context.Context, ch chan<- prometheus.Metric, o *hydrav1alpha1.OAuth2Client) {
+	var secret apiv1.Secret
+	if err := c.r.Get(ctx, types.NamespacedName{Name: o.Spec.SecretName, Namespace: o.Namespace}, &secret); err != nil {
+		return
+	}
+	if _, ok := secret.Data[ClientSecretKey]; !ok {
+		return
+	}
+
+	rotatedAt := secret.CreationTimestamp.Time
+	if value, ok := secret.Annotations[SecretRotatedAtAnnotation]; ok {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			rotatedAt = t
+		}
+	}
+	now := c.r.clock.Now()
+	ch <- prometheus.MustNewConstMetric(c.secretAge, prometheus.GaugeValue, now.Sub(rotatedAt).Seconds(), o.Namespace, o.Name, secret.Name)
+
+	value, ok := o.Annotations[SecretRotationPeriodAnnotation]
+	if !ok {
+		return
+	}
+	period, err := time.ParseDuration(value)
+	if err != nil || period <= 0 {
+		c.r.Log.V(1).Info(fmt.Sprintf("ignoring invalid %s annotation of %s/%s", SecretRotationPeriodAnnotation, o.Namespace, o.Name), "value", value)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.rotationDue, prometheus.GaugeValue, rotatedAt.Add(period).Sub(now).Seconds(), o.Namespace, o.Name, secret.Name)
+}
+
+// clientStatus returns the status label of c.
+func clientStatus(c *hydrav1alpha1.OAuth2Client) string {
+	switch {
+	case c.Status.ReconciliationError.Code != "":
+		return ClientStatusError
+	case c.Status.ObservedGeneration != 0 && c.Status.ObservedGeneration == c.Generation:
+		return ClientStatusSynced
+	default:
+		return ClientStatusPending
+	}
+}
+
+// isDegraded returns whether the Degraded condition of c is true.
+func isDegraded(c *hydrav1alpha1.OAuth2Client) bool {
+	for _, condition := range c.Status.Conditions {
+		if condition.Type == hydrav1alpha1.OAuth2ClientConditionDegraded {
+			return condition.Status == hydrav1alpha1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -6,6 +6,8 @@
 package mocks
 
 import (
+	context "context"
+
 	hydra "github.com/ory/hydra-maester/hydra"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -15,13 +17,13 @@ type Client struct {
 	mock.Mock
 }
 
-// DeleteOAuth2Client provides a mock function with given fields: id
-func (_m *Client) DeleteOAuth2Client(id string) error {
-	ret := _m.Called(id)
+// DeleteOAuth2Client provides a mock function with given fields: ctx, id
+func (_m *Client) DeleteOAuth2Client(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -29,13 +31,13 @@ func (_m *Client) DeleteOAuth2Client(id string) error {
 	return r0
 }
 
-// GetOAuth2Client provides a mock function with given fields: id
-func (_m *Client) GetOAuth2Client(id string) (*hydra.OAuth2ClientJSON, bool, error) {
-	ret := _m.Called(id)
+// GetOAuth2Client provides a mock function with given fields: ctx, id
+func (_m *Client) GetOAuth2Client(ctx context.Context, id string) (*hydra.OAuth2ClientJSON, bool, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 *hydra.OAuth2ClientJSON
-	if rf, ok := ret.Get(0).(func(string) *hydra.OAuth2ClientJSON); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *hydra.OAuth2ClientJSON); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*hydra.OAuth2ClientJSON)
@@ -43,15 +45,15 @@ func (_m *Client) GetOAuth2Client(id string) (*hydra.OAuth2ClientJSON, bool, err
 	}
 
 	var r1 bool
-	if rf, ok := ret.Get(1).(func(string) bool); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Get(1).(bool)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(string) error); ok {
-		r2 = rf(id)
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, id)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -59,13 +61,55 @@ func (_m *Client) GetOAuth2Client(id string) (*hydra.OAuth2ClientJSON, bool, err
 	return r0, r1, r2
 }
 
-// ListOAuth2Client provides a mock function with given fields:
-func (_m *Client) ListOAuth2Client() ([]*hydra.OAuth2ClientJSON, error) {
-	ret := _m.Called()
+// GetVersion provides a mock function with given fields: ctx
+func (_m *Client) GetVersion(ctx context.Context) (string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsReady provides a mock function with given fields: ctx
+func (_m *Client) IsReady(ctx context.Context) (bool, error) {
+	ret := _m.Called(ctx)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context) bool); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListOAuth2Client provides a mock function with given fields: ctx
+func (_m *Client) ListOAuth2Client(ctx context.Context) ([]*hydra.OAuth2ClientJSON, error) {
+	ret := _m.Called(ctx)
 
 	var r0 []*hydra.OAuth2ClientJSON
-	if rf, ok := ret.Get(0).(func() []*hydra.OAuth2ClientJSON); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []*hydra.OAuth2ClientJSON); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*hydra.OAuth2ClientJSON)
@@ -73,8 +117,31 @@ func (_m *Client) ListOAuth2Client() ([]*hydra.OAuth2ClientJSON, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchOAuth2Client provides a mock function with given fields: ctx, id, patch
+func (_m *Client) PatchOAuth2Client(ctx context.Context, id string, patch []hydra.PatchOperation) (*hydra.OAuth2ClientJSON, error) {
+	ret := _m.Called(ctx, id, patch)
+
+	var r0 *hydra.OAuth2ClientJSON
+	if rf, ok := ret.Get(0).(func(context.Context, string, []hydra.PatchOperation) *hydra.OAuth2ClientJSON); ok {
+		r0 = rf(ctx, id, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*hydra.OAuth2ClientJSON)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []hydra.PatchOperation) error); ok {
+		r1 = rf(ctx, id, patch)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -82,13 +149,13 @@ func (_m *Client) ListOAuth2Client() ([]*hydra.OAuth2ClientJSON, error) {
 	return r0, r1
 }
 
-// PostOAuth2Client provides a mock function with given fields: o
-func (_m *Client) PostOAuth2Client(o *hydra.OAuth2ClientJSON) (*hydra.OAuth2ClientJSON, error) {
-	ret := _m.Called(o)
+// PostOAuth2Client provides a mock function with given fields: ctx, o
+func (_m *Client) PostOAuth2Client(ctx context.Context, o *hydra.OAuth2ClientJSON) (*hydra.OAuth2ClientJSON, error) {
+	ret := _m.Called(ctx, o)
 
 	var r0 *hydra.OAuth2ClientJSON
-	if rf, ok := ret.Get(0).(func(*hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON); ok {
-		r0 = rf(o)
+	if rf, ok := ret.Get(0).(func(context.Context, *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON); ok {
+		r0 = rf(ctx, o)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*hydra.OAuth2ClientJSON)
@@ -96,8 +163,8 @@ func (_m *Client) PostOAuth2Client(o *hydra.OAuth2ClientJSON) (*hydra.OAuth2Clie
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*hydra.OAuth2ClientJSON) error); ok {
-		r1 = rf(o)
+	if rf, ok := ret.Get(1).(func(context.Context, *hydra.OAuth2ClientJSON) error); ok {
+		r1 = rf(ctx, o)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -105,13 +172,13 @@ func (_m *Client) PostOAuth2Client(o *hydra.OAuth2ClientJSON) (*hydra.OAuth2Clie
 	return r0, r1
 }
 
-// PutOAuth2Client provides a mock function with given fields: o
-func (_m *Client) PutOAuth2Client(o *hydra.OAuth2ClientJSON) (*hydra.OAuth2ClientJSON, error) {
-	ret := _m.Called(o)
+// PutOAuth2Client provides a mock function with given fields: ctx, o
+func (_m *Client) PutOAuth2Client(ctx context.Context, o *hydra.OAuth2ClientJSON) (*hydra.OAuth2ClientJSON, error) {
+	ret := _m.Called(ctx, o)
 
 	var r0 *hydra.OAuth2ClientJSON
-	if rf, ok := ret.Get(0).(func(*hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON); ok {
-		r0 = rf(o)
+	if rf, ok := ret.Get(0).(func(context.Context, *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON); ok {
+		r0 = rf(ctx, o)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*hydra.OAuth2ClientJSON)
@@ -119,8 +186,8 @@ func (_m *Client) PutOAuth2Client(o *hydra.OAuth2ClientJSON) (*hydra.OAuth2Clien
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*hydra.OAuth2ClientJSON) error); ok {
-		r1 = rf(o)
+	if rf, ok := ret.Get(1).(func(context.Context, *hydra.OAuth2ClientJSON) error); ok {
+		r1 = rf(ctx, o)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,229 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// NamespaceDefaultsConfigMap is the name of the ConfigMap which configures
+// the defaults of the OAuth2Clients of its namespace: the ORY Hydra admin
+// endpoint of those that don't set spec.hydraAdmin.url, with keys named like
+// the fields of spec.hydraAdmin, and the scope, grant types, audience and
+// token lifespans of those that leave them empty.
+const NamespaceDefaultsConfigMap = "hydra-maester-defaults"
+
+// Keys of the namespace defaults ConfigMap.
+const (
+	NamespaceDefaultsURLKey            = "url"
+	NamespaceDefaultsPortKey           = "port"
+	NamespaceDefaultsEndpointKey       = "endpoint"
+	NamespaceDefaultsForwardedProtoKey = "forwardedProto"
+	NamespaceDefaultsPublicURLKey      = "publicUrl"
+	NamespaceDefaultsFallbackURLKey    = "fallbackUrl"
+	NamespaceDefaultsFallbackPortKey   = "fallbackPort"
+	// NamespaceDefaultsDNSServersKey are the comma-separated DNS servers.
+	NamespaceDefaultsDNSServersKey = "dnsServers"
+	// NamespaceDefaultsRequestTimeoutKey is a duration such as 30s.
+	NamespaceDefaultsRequestTimeoutKey = "requestTimeout"
+
+	// NamespaceDefaultsScopeKey is the space-separated default scope.
+	NamespaceDefaultsScopeKey = "scope"
+	// NamespaceDefaultsGrantTypesKey are the comma-separated default grant
+	// types.
+	NamespaceDefaultsGrantTypesKey = "grantTypes"
+	// NamespaceDefaultsAudienceKey is the comma-separated default audience.
+	NamespaceDefaultsAudienceKey = "audience"
+	// NamespaceDefaultsTokenLifespansPrefix prefixes the default token
+	// lifespans, which are named like the fields of spec.tokenLifespans, e.g.
+	// tokenLifespans.refresh_token_grant_refresh_token_lifespan.
+	NamespaceDefaultsTokenLifespansPrefix = "tokenLifespans."
+)
+
+// namespaceDefaults returns the defaults ConfigMap of namespace, or nil if
+// there is none.
+func (r *OAuth2ClientReconciler) namespaceDefaults(ctx context.Context, namespace string) (*apiv1.ConfigMap, error) {
+	var cm apiv1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Name: NamespaceDefaultsConfigMap, Namespace: namespace}, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cm, nil
+}
+
+// namespaceHydraAdmin returns the ORY Hydra admin endpoint configured by the
+// defaults ConfigMap of namespace, or nil if there is none or it only sets
+// spec defaults.
+func (r *OAuth2ClientReconciler) namespaceHydraAdmin(ctx context.Context, namespace string) (*hydrav1alpha1.HydraAdmin, error) {
+	cm, err := r.namespaceDefaults(ctx, namespace)
+	if err != nil || cm == nil {
+		return nil, err
+	}
+
+	admin := &hydrav1alpha1.HydraAdmin{
+		URL:            cm.Data[NamespaceDefaultsURLKey],
+		Endpoint:       cm.Data[NamespaceDefaultsEndpointKey],
+		ForwardedProto: cm.Data[NamespaceDefaultsForwardedProtoKey],
+		PublicURL:      cm.Data[NamespaceDefaultsPublicURLKey],
+		FallbackURL:    cm.Data[NamespaceDefaultsFallbackURLKey],
+		DNSServers:     splitList(cm.Data[NamespaceDefaultsDNSServersKey]),
+		RequestTimeout: cm.Data[NamespaceDefaultsRequestTimeoutKey],
+	}
+	if admin.URL == "" {
+		if admin.Endpoint == "" && admin.ForwardedProto == "" && admin.PublicURL == "" && admin.FallbackURL == "" && len(admin.DNSServers) == 0 && admin.RequestTimeout == "" &&
+			cm.Data[NamespaceDefaultsPortKey] == "" && cm.Data[NamespaceDefaultsFallbackPortKey] == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ConfigMap %s/%s does not set %s", namespace, NamespaceDefaultsConfigMap, NamespaceDefaultsURLKey)
+	}
+	if port := cm.Data[NamespaceDefaultsPortKey]; port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("ConfigMap %s/%s sets an invalid %s: %w", namespace, NamespaceDefaultsConfigMap, NamespaceDefaultsPortKey, err)
+		}
+		admin.Port = p
+	}
+	if port := cm.Data[NamespaceDefaultsFallbackPortKey]; port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("ConfigMap %s/%s sets an invalid %s: %w", namespace, NamespaceDefaultsConfigMap, NamespaceDefaultsFallbackPortKey, err)
+		}
+		admin.FallbackPort = p
+	}
+	if _, err := admin.Timeout(); err != nil {
+		return nil, fmt.Errorf("ConfigMap %s/%s sets an invalid %s: %w", namespace, NamespaceDefaultsConfigMap, NamespaceDefaultsRequestTimeoutKey, err)
+	}
+	return admin, nil
+}
+
+// namespaceSpecDefaults returns the spec defaults configured by the defaults
+// ConfigMap of namespace, or nil if there is none. The defaults are validated
+// like the fields of OAuth2Clients.
+func (r *OAuth2ClientReconciler) namespaceSpecDefaults(ctx context.Context, namespace string) (*hydrav1alpha1.OAuth2ClientSpec, error) {
+	cm, err := r.namespaceDefaults(ctx, namespace)
+	if err != nil || cm == nil {
+		return nil, err
+	}
+
+	defaults := &hydrav1alpha1.OAuth2ClientSpec{
+		Scope:    strings.Join(strings.Fields(cm.Data[NamespaceDefaultsScopeKey]), " "),
+		Audience: splitList(cm.Data[NamespaceDefaultsAudienceKey]),
+	}
+	for _, gt := range splitList(cm.Data[NamespaceDefaultsGrantTypesKey]) {
+		defaults.GrantTypes = append(defaults.GrantTypes, hydrav1alpha1.GrantType(gt))
+	}
+
+	lifespans := map[string]string{}
+	for key, value := range cm.Data {
+		if name, ok := strings.CutPrefix(key, NamespaceDefaultsTokenLifespansPrefix); ok {
+			lifespans[name] = value
+		}
+	}
+	if len(lifespans) > 0 {
+		raw, err := json.Marshal(lifespans)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&defaults.TokenLifespans); err != nil {
+			return nil, fmt.Errorf("ConfigMap %s/%s sets unknown token lifespans: %w", namespace, NamespaceDefaultsConfigMap, err)
+		}
+	}
+
+	// the secret name is required, the defaults are validated on their own
+	probe := hydrav1alpha1.OAuth2Client{Spec: *defaults}
+	probe.Spec.SecretName = NamespaceDefaultsConfigMap
+	if errs := probe.Validate(); len(errs) > 0 {
+		return nil, fmt.Errorf("ConfigMap %s/%s sets invalid defaults: %w", namespace, NamespaceDefaultsConfigMap, errs.ToAggregate())
+	}
+	return defaults, nil
+}
+
+// applyNamespaceDefaults sets the fields of c which are empty to those of
+// defaults and returns the values it set, keyed like the defaults ConfigMap,
+// or nil if it set none. The scope is only set if c sets neither scope nor
+// scopeArray, and then as scopeArray.
+func applyNamespaceDefaults(c *hydrav1alpha1.OAuth2Client, defaults *hydrav1alpha1.OAuth2ClientSpec) (map[string]string, error) {
+	if defaults == nil {
+		return nil, nil
+	}
+	applied := map[string]string{}
+
+	if c.Spec.Scope == "" && len(c.Spec.ScopeArray) == 0 && defaults.Scope != "" {
+		c.Spec.ScopeArray = strings.Fields(defaults.Scope)
+		applied[NamespaceDefaultsScopeKey] = defaults.Scope
+	}
+	if len(c.Spec.GrantTypes) == 0 && len(defaults.GrantTypes) > 0 {
+		c.Spec.GrantTypes = append([]hydrav1alpha1.GrantType(nil), defaults.GrantTypes...)
+		grantTypes := make([]string, len(defaults.GrantTypes))
+		for i, gt := range defaults.GrantTypes {
+			grantTypes[i] = string(gt)
+		}
+		applied[NamespaceDefaultsGrantTypesKey] = strings.Join(grantTypes, ",")
+	}
+	if len(c.Spec.Audience) == 0 && len(defaults.Audience) > 0 {
+		c.Spec.Audience = append([]string(nil), defaults.Audience...)
+		applied[NamespaceDefaultsAudienceKey] = strings.Join(defaults.Audience, ",")
+	}
+
+	// the token lifespans are all strings, merge them by their JSON names
+	var current, fallback map[string]string
+	if err := roundTrip(c.Spec.TokenLifespans, &current); err != nil {
+		return nil, err
+	}
+	if err := roundTrip(defaults.TokenLifespans, &fallback); err != nil {
+		return nil, err
+	}
+	for name, value := range fallback {
+		if current[name] == "" && value != "" {
+			if current == nil {
+				current = map[string]string{}
+			}
+			current[name] = value
+			applied[NamespaceDefaultsTokenLifespansPrefix+name] = value
+		}
+	}
+	if err := roundTrip(current, &c.Spec.TokenLifespans); err != nil {
+		return nil, err
+	}
+
+	if len(applied) == 0 {
+		return nil, nil
+	}
+	return applied, nil
+}
+
+// roundTrip converts in to out through JSON.
+func roundTrip(in, out interface{}) error {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
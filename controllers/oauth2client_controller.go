@@ -5,21 +5,44 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
 	apiv1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/audit"
+	"github.com/ory/hydra-maester/envelope"
 	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/keto"
+	"github.com/ory/hydra-maester/notify"
+	"github.com/ory/hydra-maester/oathkeeper"
 )
 
 const (
@@ -27,19 +50,202 @@ const (
 	DefaultSecretKey = "CLIENT_SECRET"
 	FinalizerName    = "finalizer.ory.hydra.sh"
 
+	// OrphanOnUnreachableAnnotation lets OAuth2Clients be deleted while their
+	// ORY Hydra instance is unreachable, set to "true". The client in ORY
+	// Hydra, if any, is orphaned.
+	OrphanOnUnreachableAnnotation = "hydra.ory.sh/orphan-on-unreachable"
+	// ProtectAnnotation protects the client in ORY Hydra from being deleted,
+	// set to "true". Deleting the OAuth2Client is blocked until the
+	// annotation is removed.
+	ProtectAnnotation = hydrav1alpha1.ProtectAnnotation
+	// ResyncIntervalAnnotation sets after how long an OAuth2Client is
+	// reconciled again, e.g. "5m", overriding the RequeuePolicy. Shorter
+	// intervals than MinResyncInterval are raised to it.
+	ResyncIntervalAnnotation = "hydra.ory.sh/resync-interval"
+	// PreviousOwnerAnnotation names the OAuth2Client, as name/namespace, an
+	// OAuth2Client was renamed or moved from. Its client in ORY Hydra, whose
+	// ID the Secret holds, is taken over instead of being reported as a
+	// conflict. Moves between namespaces need the consent of the previous
+	// OAuth2Client, see TransferToAnnotation.
+	PreviousOwnerAnnotation = "hydra.ory.sh/previous-owner"
+	// TransferToAnnotation names the OAuth2Client, as name/namespace, which
+	// may take over the client of an OAuth2Client from another namespace.
+	TransferToAnnotation = "hydra.ory.sh/transfer-to"
+	// SecretRotationPeriodAnnotation sets how often the client secret of an
+	// OAuth2Client is due to be rotated, as a duration like 2160h. It only
+	// feeds the hydra_maester_client_secret_rotation_due_seconds metric; the
+	// secret is not rotated automatically.
+	SecretRotationPeriodAnnotation = "hydra.ory.sh/secret-rotation-period"
+	// SecretRotatedAtAnnotation records on the Secret when its client secret
+	// was generated, in RFC 3339 format. Secrets without it are as old as
+	// the Secret.
+	SecretRotatedAtAnnotation = "hydra.ory.sh/secret-rotated-at"
+	// MaxRetriesAnnotation sets after how many consecutive failed
+	// reconciliations the controller stops retrying an OAuth2Client, e.g.
+	// "20". Its failures are counted again once its spec or the annotation
+	// changes.
+	MaxRetriesAnnotation = "hydra.ory.sh/max-retries"
+	// DriftRepairedReason is the reason of the events of clients which were
+	// changed in ORY Hydra and restored, see WithDriftRepair.
+	DriftRepairedReason = "DriftRepaired"
+	// OwnershipTransferredReason is the reason of the events of clients which
+	// were taken over from a previous owner, see PreviousOwnerAnnotation.
+	OwnershipTransferredReason = "OwnershipTransferred"
+	// RecreatedReason is the reason of the events of clients which were
+	// deleted in ORY Hydra and registered again, see ExistenceProbe.
+	RecreatedReason = "Recreated"
+	// RetriesExhaustedReason is the reason of the events of OAuth2Clients
+	// which are no longer retried, see MaxRetriesAnnotation.
+	RetriesExhaustedReason = "RetriesExhausted"
+	// DeprecatedFieldReason is the reason of the events of OAuth2Clients
+	// which set deprecated fields, e.g. `scope`.
+	DeprecatedFieldReason = "DeprecatedField"
+	// IncompatibleFieldsReason is the reason of the warning events of
+	// OAuth2Clients whose spec sets fields the ORY Hydra instance ignores or
+	// deprecates.
+	IncompatibleFieldsReason = "IncompatibleFields"
+
 	DefaultNamespace = "default"
 )
 
+// ControllerName is the name of the OAuth2Client controller, which labels
+// its workqueue and reconciliation metrics. The controllers of remote
+// clusters are named after the cluster, see SetupWithCluster.
+const ControllerName = "oauth2client"
+
+// The keys of the baggage of requests to ORY Hydra, see WithRequestBaggage.
+const (
+	BaggageNamespaceKey = "k8s.namespace.name"
+	BaggageNameKey      = "hydra-maester.oauth2client.name"
+	BaggageUIDKey       = "hydra-maester.oauth2client.uid"
+)
+
+// MinResyncInterval is the shortest interval of the ResyncIntervalAnnotation,
+// which keeps a single OAuth2Client from flooding ORY Hydra with requests.
+const MinResyncInterval = 30 * time.Second
+
+// DefaultEventDedupWindow is the default window in which repeated warning
+// events of an OAuth2Client are suppressed, see WithEventDedupWindow.
+const DefaultEventDedupWindow = 5 * time.Minute
+
+// HydraReadinessInterval is how often WaitForHydra polls the readiness of
+// ORY Hydra.
+const HydraReadinessInterval = time.Second
+
 var (
 	ClientIDKey     = DefaultClientID
 	ClientSecretKey = DefaultSecretKey
 )
 
+// eventKey identifies the warning events of an OAuth2Client which are
+// deduplicated together.
+type eventKey struct {
+	uid    types.UID
+	name   types.NamespacedName
+	reason string
+}
+
+// eventWindow tracks the suppressed warning events of an eventKey.
+type eventWindow struct {
+	start      time.Time
+	suppressed int
+}
+
 type clientKey struct {
 	url            string
 	port           int
 	endpoint       string
 	forwardedProto string
+	fallbackURL    string
+	fallbackPort   int
+	dnsServers     string
+	requestTimeout string
+}
+
+// versionCheckFailure is a failed version check of the hydra instance of a
+// clientKey, which isn't repeated until retryAt.
+type versionCheckFailure struct {
+	err      error
+	failures int
+	retryAt  time.Time
+}
+
+const (
+	// versionCheckBackoff is how long the version check of an ORY Hydra
+	// instance isn't repeated after it failed once, doubled with each
+	// further failure up to maxVersionCheckBackoff.
+	versionCheckBackoff    = 5 * time.Second
+	maxVersionCheckBackoff = 5 * time.Minute
+)
+
+// VersionCheck determines what happens when an ORY Hydra instance reports a
+// version outside the supported range.
+type VersionCheck string
+
+const (
+	// VersionCheckOff skips querying the version.
+	VersionCheckOff VersionCheck = "off"
+	// VersionCheckWarn logs unsupported versions.
+	VersionCheckWarn VersionCheck = "warn"
+	// VersionCheckEnforce refuses to use instances with unsupported versions.
+	VersionCheckEnforce VersionCheck = "enforce"
+)
+
+// RetryPolicy determines which failed ORY Hydra requests are retried. The
+// failure is recorded in the status of the OAuth2Client either way.
+type RetryPolicy string
+
+const (
+	// RetryTransient retries server errors, timeouts and network errors.
+	RetryTransient RetryPolicy = "transient"
+	// RetryAlways retries all failures.
+	RetryAlways RetryPolicy = "always"
+	// RetryNever never retries, the resource is reconciled again when it
+	// changes or on the next sync period.
+	RetryNever RetryPolicy = "never"
+)
+
+func (p RetryPolicy) retries(err error) bool {
+	switch p {
+	case RetryAlways:
+		return true
+	case RetryNever:
+		return false
+	default:
+		return hydra.IsRetryable(err)
+	}
+}
+
+// RequeuePolicy returns after how long a successfully reconciled
+// OAuth2Client is reconciled again although it did not change, which
+// restores clients deleted from ORY Hydra by other means. Zero disables
+// requeueing.
+type RequeuePolicy func(c *hydrav1alpha1.OAuth2Client) time.Duration
+
+// RequeueNever reconciles OAuth2Clients only when they change or on the
+// next sync period of the manager.
+func RequeueNever(*hydrav1alpha1.OAuth2Client) time.Duration {
+	return 0
+}
+
+// RequeueAfter returns a RequeuePolicy reconciling OAuth2Clients again after
+// d.
+func RequeueAfter(d time.Duration) RequeuePolicy {
+	return func(*hydrav1alpha1.OAuth2Client) time.Duration {
+		return d
+	}
+}
+
+// RequeueJittered returns a RequeuePolicy reconciling OAuth2Clients again
+// after d plus a random duration of up to jitter times d, which spreads the
+// requests of OAuth2Clients reconciled at the same time, e.g. on startup.
+func RequeueJittered(d time.Duration, jitter float64) RequeuePolicy {
+	if jitter <= 0 {
+		return RequeueAfter(d)
+	}
+	return func(*hydrav1alpha1.OAuth2Client) time.Duration {
+		return wait.Jitter(d, jitter)
+	}
 }
 
 // OAuth2ClientFactory is a function that creates oauth2 client.
@@ -57,9 +263,53 @@ type OAuth2ClientReconciler struct {
 	HydraClient         hydra.Client
 	Log                 logr.Logger
 	ControllerNamespace string
+	HydraPublicURL      string
+	ClusterName         string
 
 	oauth2Clients       map[clientKey]hydra.Client
+	versionFailures     map[clientKey]*versionCheckFailure
+	hydraVersions       map[clientKey]string
 	oauth2ClientFactory OAuth2ClientFactory
+	clientFactories     map[string]OAuth2ClientFactory
+	versionCheck        VersionCheck
+	shard               Shard
+	retryPolicy         RetryPolicy
+	conflictPolicy      hydrav1alpha1.ConflictPolicy
+	maxFinalization     time.Duration
+	shutdownGracePeriod time.Duration
+	degradedThreshold   int32
+	recorder            record.EventRecorder
+	metrics             *metrics
+	clock               clock.PassiveClock
+	requeuePolicy       RequeuePolicy
+	metadataSchema      *hydra.MetadataSchema
+	mergeMetadata       bool
+	provenanceMetadata  bool
+	clientNameTemplate  *ClientNameTemplate
+	requestBaggage      bool
+	allowedHydraURLs    HydraURLAllowlist
+	dnsServers          []string
+	requestTimeout      time.Duration
+	throttle            *hydra.ThrottleConfig
+	disableHydraAdmin   bool
+	disableFinalizers   bool
+	controllerID        string
+	scopePolicy         ScopePolicy
+	redirectURIDomains  RedirectURIDomains
+	wildcardRedirects   bool
+	namespaces          []string
+	auditor             audit.Auditor
+	notifier            notify.Notifier
+	ketoWriter          keto.Writer
+	relationTuples      []*keto.Template
+	accessRules         oathkeeper.Generator
+	secretEncryption    envelope.KeyWrapper
+	driftRepair         bool
+	patchUpdates        bool
+	probes              chan event.GenericEvent
+	eventDedupWindow    time.Duration
+	eventWindows        map[eventKey]*eventWindow
+	eventsMu            sync.Mutex
 	mu                  sync.Mutex
 }
 
@@ -67,6 +317,46 @@ type OAuth2ClientReconciler struct {
 type Options struct {
 	Namespace           string
 	OAuth2ClientFactory OAuth2ClientFactory
+	ClientFactories     map[string]OAuth2ClientFactory
+	HydraClientOptions  []hydra.Option
+	HydraPublicURL      string
+	VersionCheck        VersionCheck
+	Shard               Shard
+	ClusterName         string
+	RetryPolicy         RetryPolicy
+	ConflictPolicy      hydrav1alpha1.ConflictPolicy
+	MaxFinalization     time.Duration
+	ShutdownGracePeriod time.Duration
+	DegradedThreshold   int32
+	EventRecorder       record.EventRecorder
+	MetricsRegisterer   prometheus.Registerer
+	Clock               clock.PassiveClock
+	RequeuePolicy       RequeuePolicy
+	MetadataSchema      *hydra.MetadataSchema
+	MergeMetadata       bool
+	ProvenanceMetadata  bool
+	ClientNameTemplate  *ClientNameTemplate
+	RequestBaggage      bool
+	AllowedHydraURLs    HydraURLAllowlist
+	DNSServers          []string
+	RequestTimeout      time.Duration
+	Throttle            *hydra.ThrottleConfig
+	DisableHydraAdmin   bool
+	DisableFinalizers   bool
+	ControllerID        string
+	ScopePolicy         ScopePolicy
+	RedirectURIDomains  RedirectURIDomains
+	WildcardRedirects   bool
+	Namespaces          []string
+	Auditor             audit.Auditor
+	Notifier            notify.Notifier
+	KetoWriter          keto.Writer
+	RelationTuples      []*keto.Template
+	AccessRules         oathkeeper.Generator
+	SecretEncryption    envelope.KeyWrapper
+	DriftRepair         bool
+	PatchUpdates        bool
+	EventDedupWindow    time.Duration
 }
 
 // Option is a functional option.
@@ -89,6 +379,14 @@ func WithNamespace(ns string) Option {
 	}
 }
 
+// WithNamespaces restricts the controller to the OAuth2Clients of namespaces.
+// Other namespaces are ignored.
+func WithNamespaces(namespaces []string) Option {
+	return func(o *Options) {
+		o.Namespaces = namespaces
+	}
+}
+
 // WithClientFactory sets a function to create new oauth2 clients during the reconciliation logic.
 func WithClientFactory(factory OAuth2ClientFactory) Option {
 	return func(o *Options) {
@@ -96,32 +394,495 @@ func WithClientFactory(factory OAuth2ClientFactory) Option {
 	}
 }
 
+// WithHydraClientOptions adds opts to the options of the ORY Hydra clients
+// created by the default client factory, e.g. hydra.WithFaultInjection.
+func WithHydraClientOptions(opts ...hydra.Option) Option {
+	return func(o *Options) {
+		o.HydraClientOptions = append(o.HydraClientOptions, opts...)
+	}
+}
+
+// WithSchemeClientFactory registers factory for the HydraAdmin URLs with the
+// given scheme, such as unix, so other means of connecting to ORY Hydra can
+// be added. URLs with the http and https schemes use the factory set with
+// WithClientFactory unless another one is registered for them.
+func WithSchemeClientFactory(scheme string, factory OAuth2ClientFactory) Option {
+	return func(o *Options) {
+		if o.ClientFactories == nil {
+			o.ClientFactories = make(map[string]OAuth2ClientFactory)
+		}
+		o.ClientFactories[strings.ToLower(scheme)] = factory
+	}
+}
+
+// WithHydraPublicURL sets the public URL of the default hydra instance, used
+// to publish the issuer and OAuth2 endpoints to discovery ConfigMaps.
+func WithHydraPublicURL(u string) Option {
+	return func(o *Options) {
+		o.HydraPublicURL = u
+	}
+}
+
+// WithVersionCheck sets how the versions of the ORY Hydra instances are
+// verified. The default is VersionCheckOff.
+func WithVersionCheck(check VersionCheck) Option {
+	return func(o *Options) {
+		o.VersionCheck = check
+	}
+}
+
+// WithShard restricts the reconciler to the namespaces of the given shard.
+func WithShard(shard Shard) Option {
+	return func(o *Options) {
+		o.Shard = shard
+	}
+}
+
+// WithClusterName sets the name of the cluster the reconciler watches. It is
+// appended to the owner of the clients in ORY Hydra, so several clusters can
+// share an instance without conflicts. The default is empty, which keeps the
+// name/namespace owner.
+func WithClusterName(name string) Option {
+	return func(o *Options) {
+		o.ClusterName = name
+	}
+}
+
+// WithRetryPolicy sets which failed ORY Hydra requests are retried. The
+// default is RetryTransient.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *Options) {
+		o.RetryPolicy = policy
+	}
+}
+
+// WithConflictPolicy sets how client IDs which are already taken in ORY
+// Hydra by another owner are handled, unless the OAuth2Client sets its own
+// conflictPolicy. The default is ConflictPolicyFail.
+func WithConflictPolicy(policy hydrav1alpha1.ConflictPolicy) Option {
+	return func(o *Options) {
+		o.ConflictPolicy = policy
+	}
+}
+
+// WithMaxFinalizationDuration sets how long the deletion of an OAuth2Client
+// waits for its client to be deleted from ORY Hydra. Afterwards the finalizer
+// is removed and the client in ORY Hydra is orphaned. The default is zero,
+// which waits forever.
+func WithMaxFinalizationDuration(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxFinalization = d
+	}
+}
+
+// WithDegradedThreshold sets after how many consecutive failed
+// reconciliations the Degraded condition of an OAuth2Client becomes true. It
+// is false again after the next successful reconciliation. The default is
+// zero, which does not set the Degraded condition.
+func WithDegradedThreshold(n int32) Option {
+	return func(o *Options) {
+		o.DegradedThreshold = n
+	}
+}
+
+// WithShutdownGracePeriod lets reconciliations in flight when the manager
+// stops run for up to d, so their requests to ORY Hydra and the matching
+// status writes complete. No reconciliations are started once the manager
+// stops. The manager's GracefulShutdownTimeout must exceed d. By default,
+// reconciliations are cancelled right away.
+func WithShutdownGracePeriod(d time.Duration) Option {
+	return func(o *Options) {
+		o.ShutdownGracePeriod = d
+	}
+}
+
+// WithEventRecorder sets the recorder of the events emitted for
+// OAuth2Clients. By default no events are emitted.
+func WithEventRecorder(recorder record.EventRecorder) Option {
+	return func(o *Options) {
+		o.EventRecorder = recorder
+	}
+}
+
+// WithEventDedupWindow sets the window in which warning events with the same
+// reason are emitted only once per OAuth2Client, so a flapping ORY Hydra does
+// not flood the cluster with events. The first event after the window notes
+// how many were suppressed. Zero disables the deduplication. The default is
+// DefaultEventDedupWindow.
+func WithEventDedupWindow(window time.Duration) Option {
+	return func(o *Options) {
+		o.EventDedupWindow = window
+	}
+}
+
+// WithMetricsRegisterer sets the registerer of the reconciler's metrics. By
+// default the metrics are not registered.
+func WithMetricsRegisterer(reg prometheus.Registerer) Option {
+	return func(o *Options) {
+		o.MetricsRegisterer = reg
+	}
+}
+
+// WithClock sets the clock of the reconciler, e.g. of pending deletions,
+// secret rotations, version check backoffs, audit records, notifications and
+// the sync report. The default is the real clock.
+func WithClock(c clock.PassiveClock) Option {
+	return func(o *Options) {
+		o.Clock = c
+	}
+}
+
+// WithRequeuePolicy sets when successfully reconciled OAuth2Clients are
+// reconciled again. The default is RequeueNever.
+func WithRequeuePolicy(policy RequeuePolicy) Option {
+	return func(o *Options) {
+		o.RequeuePolicy = policy
+	}
+}
+
+// WithMetadataSchema validates spec.metadata against schema. Clients with
+// invalid metadata are not registered and their status records the
+// violations.
+func WithMetadataSchema(schema *hydra.MetadataSchema) Option {
+	return func(o *Options) {
+		o.MetadataSchema = schema
+	}
+}
+
+// WithMetadataMerge deep merges spec.metadata into the metadata of the client
+// in ORY Hydra on updates, see hydra.MergeMetadata, so keys written by other
+// systems are kept. Keys are removed by setting them to null. Without it,
+// the metadata of the client is replaced. The default is true.
+func WithMetadataMerge(merge bool) Option {
+	return func(o *Options) {
+		o.MergeMetadata = merge
+	}
+}
+
+// WithProvenanceMetadata records the namespace, name and UID of OAuth2Clients
+// and the cluster name in the metadata of their clients in ORY Hydra, under
+// the hydra.ProvenanceMetadataKey key. It replaces the value of spec.metadata
+// for that key.
+func WithProvenanceMetadata(provenance bool) Option {
+	return func(o *Options) {
+		o.ProvenanceMetadata = provenance
+	}
+}
+
+// WithClientNameTemplate names the clients of OAuth2Clients which don't set
+// spec.clientName with tmpl, e.g. ParseClientNameTemplate of
+// DefaultClientNameTemplate. By default their client_name is left empty.
+func WithClientNameTemplate(tmpl *ClientNameTemplate) Option {
+	return func(o *Options) {
+		o.ClientNameTemplate = tmpl
+	}
+}
+
+// WithRequestBaggage sends the namespace, name and UID of the reconciled
+// OAuth2Client in the W3C baggage header of the requests to ORY Hydra, so its
+// traces and access logs can be joined with the controller's activity. Clients
+// of custom factories need to be built on the hydra package for this.
+func WithRequestBaggage(baggage bool) Option {
+	return func(o *Options) {
+		o.RequestBaggage = baggage
+	}
+}
+
+// WithAllowedHydraURLs restricts the ORY Hydra admin addresses OAuth2Clients
+// may set in spec.hydraAdmin. OAuth2Clients with other addresses are not
+// reconciled and their status records the HYDRA_ADDRESS_NOT_ALLOWED code.
+func WithAllowedHydraURLs(allowlist HydraURLAllowlist) Option {
+	return func(o *Options) {
+		o.AllowedHydraURLs = allowlist
+	}
+}
+
+// WithDNSServers sets the DNS servers resolving the ORY Hydra admin hosts of
+// OAuth2Clients which don't set spec.hydraAdmin.dnsServers, see
+// HydraAdmin.DNSServers. The default client is configured on its own.
+func WithDNSServers(servers []string) Option {
+	return func(o *Options) {
+		o.DNSServers = servers
+	}
+}
+
+// WithRequestTimeout sets the timeout of the requests to the ORY Hydra
+// instances of OAuth2Clients which don't set spec.hydraAdmin.requestTimeout,
+// see HydraAdmin.RequestTimeout. Zero disables it. The default client is
+// configured on its own.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.RequestTimeout = timeout
+	}
+}
+
+// WithAdaptiveConcurrency limits the concurrent requests to the ORY Hydra
+// instance of each spec.hydraAdmin with a limit which adapts to its latency
+// and errors, see hydra.Throttle, so reconciliations back off from an
+// overloaded instance. The limits are exported in the
+// hydra_maester_hydra_api_concurrency_limit metric. The default client is
+// configured on its own, see HydraConcurrencyLimitReporter.
+func WithAdaptiveConcurrency(config hydra.ThrottleConfig) Option {
+	return func(o *Options) {
+		o.Throttle = &config
+	}
+}
+
+// WithFinalizersDisabled stops adding the finalizer to OAuth2Clients and
+// removes it from those which have it, as if all had the Detach deletion
+// policy. Their clients are left in ORY Hydra when they are deleted.
+func WithFinalizersDisabled(disabled bool) Option {
+	return func(o *Options) {
+		o.DisableFinalizers = disabled
+	}
+}
+
+// WithPerResourceHydraAdminDisabled forces all OAuth2Clients through the
+// default ORY Hydra client. OAuth2Clients setting spec.hydraAdmin.url are not
+// reconciled and their status records the HYDRA_ADDRESS_NOT_ALLOWED code.
+func WithPerResourceHydraAdminDisabled(disabled bool) Option {
+	return func(o *Options) {
+		o.DisableHydraAdmin = disabled
+	}
+}
+
+// WithControllerID sets the identity of the controller, which is appended to
+// the owner of the clients in ORY Hydra. Controllers with different
+// identities sharing an ORY Hydra instance never update or delete each
+// other's clients, even for OAuth2Clients of the same name and namespace.
+func WithControllerID(id string) Option {
+	return func(o *Options) {
+		o.ControllerID = id
+	}
+}
+
+// WithScopePolicy restricts the scopes OAuth2Clients may request.
+// OAuth2Clients requesting other scopes are not registered and their status
+// records the SCOPE_NOT_ALLOWED code.
+func WithScopePolicy(policy ScopePolicy) Option {
+	return func(o *Options) {
+		o.ScopePolicy = policy
+	}
+}
+
+// WithRedirectURIDomains restricts the hosts of the redirect URIs of
+// OAuth2Clients. OAuth2Clients with other redirect URIs are not registered and
+// their status records the REDIRECT_URI_NOT_ALLOWED code.
+func WithRedirectURIDomains(domains []string) Option {
+	return func(o *Options) {
+		o.RedirectURIDomains = domains
+	}
+}
+
+// WithWildcardRedirectURIs allows a wildcard as the leftmost label of the
+// host of https redirect URIs, e.g. https://*.example.com/callback, for ORY
+// Hydra deployments matching them. By default, OAuth2Clients with wildcards
+// in their redirect URIs are not registered and their status records the
+// REDIRECT_URI_NOT_ALLOWED code.
+func WithWildcardRedirectURIs(allow bool) Option {
+	return func(o *Options) {
+		o.WildcardRedirects = allow
+	}
+}
+
+// WithAuditor sets the auditor receiving a record of every change made to
+// clients in ORY Hydra, e.g. an audit.Exporter.
+func WithAuditor(auditor audit.Auditor) Option {
+	return func(o *Options) {
+		o.Auditor = auditor
+	}
+}
+
+// WithNotifier sets the notifier which is told when an OAuth2Client becomes
+// degraded and when it recovers, e.g. a notify.Dispatcher. Without a degraded
+// threshold, every failure after a success is notified.
+func WithNotifier(notifier notify.Notifier) Option {
+	return func(o *Options) {
+		o.Notifier = notifier
+	}
+}
+
+// WithRelationTuples writes the relation tuples rendered from templates for
+// every registered client to ORY Keto with writer, e.g. to grant the team of
+// the namespace access to the client. The tuples are recorded in the status,
+// rewritten when the templates change and deleted with the client.
+func WithRelationTuples(writer keto.Writer, templates ...*keto.Template) Option {
+	return func(o *Options) {
+		o.KetoWriter = writer
+		o.RelationTuples = templates
+	}
+}
+
+// WithAccessRules creates an ORY Oathkeeper Rule with the spec generated by
+// generator for the client of every OAuth2Client with the
+// OathkeeperRuleAnnotation, e.g. an oathkeeper.Template. The Rules are owned
+// by their OAuth2Clients and updated with them.
+func WithAccessRules(generator oathkeeper.Generator) Option {
+	return func(o *Options) {
+		o.AccessRules = generator
+	}
+}
+
+// WithSecretEncryption encrypts the client secrets written to Kubernetes
+// Secrets with envelope encryption, wrapping the data keys with kw. Encrypted
+// client secrets are decrypted with kw before they are sent to ORY Hydra.
+func WithSecretEncryption(kw envelope.KeyWrapper) Option {
+	return func(o *Options) {
+		o.SecretEncryption = kw
+	}
+}
+
+// WithDriftRepair compares unchanged OAuth2Clients with their clients in ORY
+// Hydra on every resync, and puts the spec back if the client was changed out
+// of band, e.g. with the ORY Hydra CLI. Repairs emit a DriftRepaired event.
+func WithDriftRepair(repair bool) Option {
+	return func(o *Options) {
+		o.DriftRepair = repair
+	}
+}
+
+// WithPatchUpdates updates clients in ORY Hydra with a JSON Patch of the
+// fields which differ from the OAuth2Client instead of replacing them with a
+// PUT, so fields set in ORY Hydra which the controller does not manage are
+// kept. Clients adopted from another owner are still replaced, and so are
+// clients whose hydra.Client does not implement hydra.Patcher.
+func WithPatchUpdates(patch bool) Option {
+	return func(o *Options) {
+		o.PatchUpdates = patch
+	}
+}
+
 // New returns a new Oauth2ClientReconciler.
 func New(c client.Client, hydraClient hydra.Client, log logr.Logger, opts ...Option) *OAuth2ClientReconciler {
+	var m *metrics
+	var clientOpts []hydra.Option
+	defaultFactory := func(spec hydrav1alpha1.OAuth2ClientSpec, tlsTrustStore string, insecureSkipVerify bool) (hydra.Client, error) {
+		return hydra.New(spec, tlsTrustStore, insecureSkipVerify, append([]hydra.Option{hydra.WithLogger(log.WithName("hydra")), hydra.WithRequestObserver(m.observeHydraRequest), hydra.WithRequestTracer(m.traceHydraRequest)}, clientOpts...)...)
+	}
 	options := &Options{
 		Namespace:           DefaultNamespace,
-		OAuth2ClientFactory: hydra.New,
+		OAuth2ClientFactory: defaultFactory,
+		VersionCheck:        VersionCheckOff,
+		MergeMetadata:       true,
+		RetryPolicy:         RetryTransient,
+		ConflictPolicy:      hydrav1alpha1.ConflictPolicyFail,
+		Clock:               clock.RealClock{},
+		RequeuePolicy:       RequeueNever,
+		EventDedupWindow:    DefaultEventDedupWindow,
 	}
 	for _, opt := range opts {
 		opt(options)
 	}
+	clientOpts = options.HydraClientOptions
 
-	return &OAuth2ClientReconciler{
+	m, err := newMetrics(options.MetricsRegisterer)
+	if err != nil {
+		log.Error(err, "unable to register metrics")
+		m, _ = newMetrics(nil)
+	}
+
+	r := &OAuth2ClientReconciler{
 		Client:              c,
 		HydraClient:         hydraClient,
 		Log:                 log,
 		ControllerNamespace: options.Namespace,
+		HydraPublicURL:      options.HydraPublicURL,
+		ClusterName:         options.ClusterName,
 		oauth2Clients:       make(map[clientKey]hydra.Client, 0),
+		versionFailures:     make(map[clientKey]*versionCheckFailure),
+		hydraVersions:       make(map[clientKey]string),
 		oauth2ClientFactory: options.OAuth2ClientFactory,
+		clientFactories:     options.ClientFactories,
+		versionCheck:        options.VersionCheck,
+		shard:               options.Shard,
+		retryPolicy:         options.RetryPolicy,
+		conflictPolicy:      options.ConflictPolicy,
+		maxFinalization:     options.MaxFinalization,
+		shutdownGracePeriod: options.ShutdownGracePeriod,
+		degradedThreshold:   options.DegradedThreshold,
+		recorder:            options.EventRecorder,
+		metrics:             m,
+		clock:               options.Clock,
+		requeuePolicy:       options.RequeuePolicy,
+		metadataSchema:      options.MetadataSchema,
+		mergeMetadata:       options.MergeMetadata,
+		provenanceMetadata:  options.ProvenanceMetadata,
+		clientNameTemplate:  options.ClientNameTemplate,
+		requestBaggage:      options.RequestBaggage,
+		allowedHydraURLs:    options.AllowedHydraURLs,
+		dnsServers:          options.DNSServers,
+		requestTimeout:      options.RequestTimeout,
+		throttle:            options.Throttle,
+		disableHydraAdmin:   options.DisableHydraAdmin,
+		disableFinalizers:   options.DisableFinalizers,
+		controllerID:        options.ControllerID,
+		scopePolicy:         options.ScopePolicy,
+		redirectURIDomains:  options.RedirectURIDomains,
+		wildcardRedirects:   options.WildcardRedirects,
+		namespaces:          options.Namespaces,
+		auditor:             options.Auditor,
+		notifier:            options.Notifier,
+		ketoWriter:          options.KetoWriter,
+		relationTuples:      options.RelationTuples,
+		accessRules:         options.AccessRules,
+		secretEncryption:    options.SecretEncryption,
+		driftRepair:         options.DriftRepair,
+		patchUpdates:        options.PatchUpdates,
+		probes:              make(chan event.GenericEvent),
+		eventDedupWindow:    options.EventDedupWindow,
+		eventWindows:        make(map[eventKey]*eventWindow),
+	}
+	if options.MetricsRegisterer != nil {
+		if err := options.MetricsRegisterer.Register(newClientsCollector(r)); err != nil {
+			log.Error(err, "unable to register metrics")
+		}
 	}
+	return r
+}
+
+// SetDefaults replaces the default hydra client and public URL, so changes of
+// the settings file apply without restarting the controller.
+func (r *OAuth2ClientReconciler) SetDefaults(hydraClient hydra.Client, publicURL string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.HydraClient = hydraClient
+	r.HydraPublicURL = publicURL
+}
+
+// DefaultHydraClient returns the current default hydra client.
+func (r *OAuth2ClientReconciler) DefaultHydraClient() hydra.Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.HydraClient
 }
 
 // +kubebuilder:rbac:groups=hydra.ory.sh,resources=oauth2clients,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=hydra.ory.sh,resources=oauth2clients/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
+// Reconcile reconciles the OAuth2Client of req and logs a summary of the
+// changes it made to ORY Hydra. Once ctx is cancelled, no reconciliation is
+// started, see WithShutdownGracePeriod. Panics are returned as PanicError.
 func (r *OAuth2ClientReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = r.Log.WithValues("oauth2client", req.NamespacedName)
+	if ctx.Err() != nil {
+		return ctrl.Result{}, nil
+	}
+	ctx, cancel := r.drain(ctx)
+	defer cancel()
+
+	s := &reconcileSummary{}
+	start := r.clock.Now()
+	result, err := r.reconcileRecovering(context.WithValue(ctx, summaryKey{}, s), req)
+	r.logSummary(req.NamespacedName, s, result, err, r.clock.Since(start))
+	return result, err
+}
+
+func (r *OAuth2ClientReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if !r.shard.Owns(req.Namespace) {
+		return ctrl.Result{}, nil
+	}
 
 	var oauth2client hydrav1alpha1.OAuth2Client
 	if err := r.Get(ctx, req.NamespacedName, &oauth2client); err != nil {
@@ -133,25 +894,48 @@ func (r *OAuth2ClientReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 		return ctrl.Result{}, err
 	}
+	if r.requestBaggage {
+		ctx = hydra.ContextWithBaggage(ctx, BaggageNamespaceKey, oauth2client.Namespace)
+		ctx = hydra.ContextWithBaggage(ctx, BaggageNameKey, oauth2client.Name)
+		ctx = hydra.ContextWithBaggage(ctx, BaggageUIDKey, string(oauth2client.UID))
+	}
 
 	// Check request namespace
 	if r.ControllerNamespace != "" {
-		r.Log.Info(fmt.Sprintf("ControllerNamespace is set to: %s, working only on items in this namespace. Other namespaces are ignored.", r.ControllerNamespace))
+		r.Log.V(1).Info(fmt.Sprintf("ControllerNamespace is set to: %s, working only on items in this namespace. Other namespaces are ignored.", r.ControllerNamespace))
 		if req.NamespacedName.Namespace != r.ControllerNamespace {
-			r.Log.Info(fmt.Sprintf("Requested resource %s is not in namespace: %s and will be ignored", req.String(), r.ControllerNamespace))
+			r.Log.V(1).Info(fmt.Sprintf("Requested resource %s is not in namespace: %s and will be ignored", req.String(), r.ControllerNamespace))
 			return ctrl.Result{}, nil
 		}
 	}
+	if len(r.namespaces) > 0 && !containsString(r.namespaces, req.Namespace) {
+		r.Log.V(1).Info(fmt.Sprintf("Requested resource %s is not in namespaces: %s and will be ignored", req.String(), strings.Join(r.namespaces, ", ")))
+		return ctrl.Result{}, nil
+	}
 
 	// examine DeletionTimestamp to determine if object is under deletion
 	if oauth2client.ObjectMeta.DeletionTimestamp.IsZero() {
 		// The object is not being deleted, so if it does not have our finalizer,
 		// then lets add the finalizer and update the object. This is equivalent
 		// registering our finalizer.
-		if !containsString(oauth2client.ObjectMeta.Finalizers, FinalizerName) {
+		if !r.finalizes(&oauth2client) {
+			// detached OAuth2Clients lose the finalizer they got before
+			if containsString(oauth2client.ObjectMeta.Finalizers, FinalizerName) {
+				typeMeta := oauth2client.TypeMeta
+				if err := r.updateWithRetry(ctx, &oauth2client, func() {
+					oauth2client.ObjectMeta.Finalizers = removeString(oauth2client.ObjectMeta.Finalizers, FinalizerName)
+				}); err != nil {
+					return ctrl.Result{}, err
+				}
+				oauth2client.TypeMeta = typeMeta
+			}
+		} else if !containsString(oauth2client.ObjectMeta.Finalizers, FinalizerName) {
 			typeMeta := oauth2client.TypeMeta
-			oauth2client.ObjectMeta.Finalizers = append(oauth2client.ObjectMeta.Finalizers, FinalizerName)
-			if err := r.Update(ctx, &oauth2client); err != nil {
+			if err := r.updateWithRetry(ctx, &oauth2client, func() {
+				if !containsString(oauth2client.ObjectMeta.Finalizers, FinalizerName) {
+					oauth2client.ObjectMeta.Finalizers = append(oauth2client.ObjectMeta.Finalizers, FinalizerName)
+				}
+			}); err != nil {
 				return ctrl.Result{}, err
 			}
 			// restore the TypeMeta object as it is removed during Update, but need to be accessed later
@@ -160,16 +944,33 @@ func (r *OAuth2ClientReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	} else {
 		// The object is being deleted
 		if containsString(oauth2client.ObjectMeta.Finalizers, FinalizerName) {
+			// keep the finalizer until the protection is lifted, the update
+			// removing the annotation triggers the next reconciliation
+			if r.finalizes(&oauth2client) && isProtected(&oauth2client) {
+				protectedErr := fmt.Errorf("deletion is blocked by the %s annotation, remove it to delete the client from ORY Hydra", ProtectAnnotation)
+				if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusDeletionProtected, protectedErr); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, nil
+			}
+
 			// our finalizer is present, so lets handle any external dependency
-			if err := r.unregisterOAuth2Clients(ctx, &oauth2client); err != nil {
+			// unless it is left over from before it was disabled
+			if !r.finalizes(&oauth2client) {
+				r.Log.Info(fmt.Sprintf("client %s/%s is detached, leave it in ORY Hydra", oauth2client.Name, oauth2client.Namespace))
+			} else if err := r.unregisterOAuth2Clients(ctx, &oauth2client); err != nil {
 				// if fail to delete the external dependency here, return with error
-				// so that it can be retried
-				return ctrl.Result{}, err
+				// so that it can be retried, unless the deletion is given up
+				if !r.abandonFinalization(&oauth2client, err) {
+					return ctrl.Result{}, err
+				}
+				r.Log.Error(err, fmt.Sprintf("giving up deleting the client of %s/%s from ORY Hydra, it is orphaned", oauth2client.Name, oauth2client.Namespace))
 			}
 
 			// remove our finalizer from the list and update it.
-			oauth2client.ObjectMeta.Finalizers = removeString(oauth2client.ObjectMeta.Finalizers, FinalizerName)
-			if err := r.Update(ctx, &oauth2client); err != nil {
+			if err := r.updateWithRetry(ctx, &oauth2client, func() {
+				oauth2client.ObjectMeta.Finalizers = removeString(oauth2client.ObjectMeta.Finalizers, FinalizerName)
+			}); client.IgnoreNotFound(err) != nil {
 				return ctrl.Result{}, err
 			}
 		}
@@ -178,101 +979,292 @@ func (r *OAuth2ClientReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	}
 
-	var secret apiv1.Secret
-	if err := r.Get(ctx, types.NamespacedName{Name: oauth2client.Spec.SecretName, Namespace: req.Namespace}, &secret); err != nil {
-		if apierrs.IsNotFound(err) {
-			if registerErr := r.registerOAuth2Client(ctx, &oauth2client); registerErr != nil {
-				return ctrl.Result{}, registerErr
-			}
-			return ctrl.Result{}, nil
-		}
-		return ctrl.Result{}, err
-	}
-
-	credentials, err := parseSecret(secret, oauth2client.Spec.TokenEndpointAuthMethod)
-	if err != nil {
-		r.Log.Error(err, fmt.Sprintf("secret %s/%s is invalid", secret.Name, secret.Namespace))
-		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidSecret, err); updateErr != nil {
-			return ctrl.Result{}, updateErr
-		}
+	if r.retriesExhausted(&oauth2client) {
 		return ctrl.Result{}, nil
 	}
 
-	hydraClient, err := r.getHydraClientForClient(oauth2client)
+	// the defaults are only applied in memory, the spec is never updated
+	defaults, err := r.namespaceSpecDefaults(ctx, oauth2client.Namespace)
 	if err != nil {
-		r.Log.Error(err, fmt.Sprintf(
-			"hydra address %s:%d%s is invalid",
-			oauth2client.Spec.HydraAdmin.URL,
-			oauth2client.Spec.HydraAdmin.Port,
-			oauth2client.Spec.HydraAdmin.Endpoint,
-		))
-		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidHydraAddress, err); updateErr != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidSpec, err); updateErr != nil {
 			return ctrl.Result{}, updateErr
 		}
 		return ctrl.Result{}, nil
 	}
-
-	fetched, found, err := hydraClient.GetOAuth2Client(string(credentials.ID))
+	applied, err := applyNamespaceDefaults(&oauth2client, defaults)
 	if err != nil {
 		return ctrl.Result{}, err
-	} else if !found {
-		return ctrl.Result{}, fmt.Errorf("oauth2 client %s not found", credentials.ID)
 	}
-
-	if found {
-		//conclude reconciliation if the client exists and has not been updated
-		if oauth2client.Generation == oauth2client.Status.ObservedGeneration {
-			return ctrl.Result{}, nil
+	defaultsChanged := !reflect.DeepEqual(applied, oauth2client.Status.AppliedDefaults)
+	oauth2client.Status.AppliedDefaults = applied
+	if len(oauth2client.Spec.GrantTypes) == 0 {
+		grantTypesErr := fmt.Errorf("spec.grantTypes is empty and the %s ConfigMap does not set %s", NamespaceDefaultsConfigMap, NamespaceDefaultsGrantTypesKey)
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidSpec, grantTypesErr); updateErr != nil {
+			return ctrl.Result{}, updateErr
 		}
+		return ctrl.Result{}, nil
+	}
 
-		if fetched.Owner != fmt.Sprintf("%s/%s", oauth2client.Name, oauth2client.Namespace) {
-			conflictErr := fmt.Errorf("ID provided in secret %s/%s is assigned to another resource", secret.Name, secret.Namespace)
-			if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidSecret, conflictErr); updateErr != nil {
+	if oauth2client.Spec.Scope != "" {
+		if len(oauth2client.Spec.ScopeArray) > 0 {
+			if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidSpec, hydra.ErrScopeAndScopeArray); updateErr != nil {
 				return ctrl.Result{}, updateErr
 			}
 			return ctrl.Result{}, nil
 		}
+		if oauth2client.Generation != oauth2client.Status.ObservedGeneration {
+			r.event(&oauth2client, apiv1.EventTypeNormal, DeprecatedFieldReason, "spec.scope is deprecated, use spec.scopeArray")
+		}
+	}
 
-		if updateErr := r.updateRegisteredOAuth2Client(ctx, &oauth2client, credentials); updateErr != nil {
+	metadataFromVersion, err := r.mergeMetadataFrom(ctx, &oauth2client)
+	if err != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidSpec, err); updateErr != nil {
 			return ctrl.Result{}, updateErr
 		}
+		// retry until the referenced ConfigMap is created
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, r.retry(&oauth2client, err)
+		}
 		return ctrl.Result{}, nil
 	}
+	metadataFromChanged := metadataFromVersion != oauth2client.Status.MetadataFromVersion
+	oauth2client.Status.MetadataFromVersion = metadataFromVersion
 
-	return ctrl.Result{}, nil
-}
+	if err := r.scopePolicy.Check(&oauth2client); err != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusScopeNotAllowed, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, nil
+	}
 
-func (r *OAuth2ClientReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := CheckWildcardRedirectURIs(&oauth2client, r.wildcardRedirects); err != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusRedirectURINotAllowed, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.redirectURIDomains.Check(&oauth2client); err != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusRedirectURINotAllowed, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, nil
+	}
+
+	var secret apiv1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: oauth2client.Spec.SecretName, Namespace: req.Namespace}, &secret); err != nil {
+		if apierrs.IsNotFound(err) {
+			if registerErr := r.registerOAuth2Client(ctx, &oauth2client, nil); registerErr != nil {
+				return ctrl.Result{}, registerErr
+			}
+			return r.requeue(&oauth2client), nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	credentials, err := parseSecret(secret, oauth2client.Spec.TokenEndpointAuthMethod)
+	if err != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidSecret, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, nil
+	}
+	if credentials.Password, err = r.decryptSecret(ctx, credentials.Password); err != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidSecret, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, err
+	}
+
+	hydraClient, err := r.getHydraClientForClient(ctx, oauth2client)
+	var disallowed *DisallowedHydraURLError
+	if errors.As(err, &disallowed) {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusHydraAddressForbidden, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, nil
+	}
+	var unsupported *hydra.UnsupportedVersionError
+	if errors.As(err, &unsupported) {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusUnsupportedHydra, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusInvalidHydraAddress, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		// retry until the referenced hydra admin Service is created
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, r.retry(&oauth2client, err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	fetched, found, err := hydraClient.GetOAuth2Client(ctx, string(credentials.ID))
+	// clients missing in some of the discovered ORY Hydra instances are
+	// completed by an update
+	incomplete := found && errors.Is(err, hydra.ErrIncomplete)
+	if err != nil && !incomplete {
+		return ctrl.Result{}, r.handleHydraError(ctx, &oauth2client, hydrav1alpha1.StatusUpdateFailed, err)
+	} else if !found {
+		registered := oauth2client.Status.ObservedGeneration != 0
+		if registerErr := r.registerOAuth2Client(ctx, &oauth2client, credentials); registerErr != nil {
+			return ctrl.Result{}, registerErr
+		}
+		if registered && oauth2client.Status.ReconciliationError.Code == "" {
+			r.metrics.recreations.WithLabelValues(r.ClusterName).Inc()
+			r.event(&oauth2client, apiv1.EventTypeWarning, RecreatedReason, "client was deleted in ORY Hydra, registered it again")
+		}
+		return r.requeue(&oauth2client), nil
+	}
+
+	if found {
+		// clients registered before the controller identity was set are
+		// taken over right away, so they are deleted with the OAuth2Client
+		legacy := r.controllerID != "" && fetched.Owner == r.legacyOwnerOf(&oauth2client)
+
+		// clients of the OAuth2Client c was renamed or moved from are taken
+		// over as well
+		transfer, err := r.transfersFrom(ctx, &oauth2client, fetched.Owner)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		//conclude reconciliation if the client exists and neither it nor the
+		//namespace defaults, metadata and relation tuples it uses have been
+		//updated
+		tuplesChanged := r.relationTuplesChanged(&oauth2client, string(credentials.ID))
+		if oauth2client.Generation == oauth2client.Status.ObservedGeneration && !defaultsChanged && !metadataFromChanged && !tuplesChanged && !legacy && !transfer && !incomplete {
+			// the annotation opting into access rules doesn't change the
+			// generation
+			if err := r.ensureAccessRule(ctx, &oauth2client, string(credentials.ID), fetched.Scope); err != nil {
+				return ctrl.Result{}, r.updateReconciliationStatusError(ctx, &oauth2client, hydrav1alpha1.StatusAccessRuleFailed, err)
+			}
+			if oauth2client.Status.ReconciliationError.Code == hydrav1alpha1.StatusAccessRuleFailed {
+				if err := r.ensureEmptyStatusError(ctx, &oauth2client); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			if r.driftRepair && fetched.Owner == r.ownerOf(&oauth2client) {
+				if repairErr := r.repairDrift(ctx, &oauth2client, credentials, fetched); repairErr != nil {
+					return ctrl.Result{}, repairErr
+				}
+			}
+			return r.requeue(&oauth2client), nil
+		}
+
+		if fetched.Owner != r.ownerOf(&oauth2client) && !legacy && !transfer {
+			conflictErr := fmt.Errorf("ID provided in secret %s/%s is assigned to another resource", secret.Name, secret.Namespace)
+			if resolveErr := r.resolveConflict(ctx, &oauth2client, credentials, hydrav1alpha1.StatusConflict, conflictErr); resolveErr != nil {
+				return ctrl.Result{}, resolveErr
+			}
+			return r.requeue(&oauth2client), nil
+		}
+
+		if updateErr := r.updateRegisteredOAuth2Client(ctx, &oauth2client, credentials, fetched); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		if transfer && oauth2client.Status.ReconciliationError.Code == "" {
+			if transferErr := r.transferOwnership(ctx, &oauth2client, &secret, fetched.Owner); transferErr != nil {
+				return ctrl.Result{}, transferErr
+			}
+		}
+		return r.requeue(&oauth2client), nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *OAuth2ClientReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return r.SetupWithManagerOptions(mgr, controller.Options{})
+}
+
+// SetupWithManagerOptions is like SetupWithManager but configures the
+// controller with opts, e.g. to limit concurrent reconciliations when
+// embedded in another operator's manager.
+func (r *OAuth2ClientReconciler) SetupWithManagerOptions(mgr ctrl.Manager, opts controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
 		For(&hydrav1alpha1.OAuth2Client{}).
+		Named(ControllerName).
+		WithOptions(opts).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return r.shard.Owns(o.GetNamespace())
+		})).
+		WatchesRawSource(source.Channel(r.probes, &handler.EnqueueRequestForObject{})).
 		Complete(r)
 }
 
-func (r *OAuth2ClientReconciler) registerOAuth2Client(ctx context.Context, c *hydrav1alpha1.OAuth2Client) error {
+// registerOAuth2Client creates the client of c in ORY Hydra. With credentials,
+// the client is registered with the ID and secret of the existing Secret,
+// otherwise ORY Hydra generates them and the Secret is created.
+func (r *OAuth2ClientReconciler) registerOAuth2Client(ctx context.Context, c *hydrav1alpha1.OAuth2Client, credentials *hydra.Oauth2ClientCredentials) error {
 	if err := r.unregisterOAuth2Clients(ctx, c); err != nil {
+		var disallowed *DisallowedHydraURLError
+		if errors.As(err, &disallowed) {
+			return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusHydraAddressForbidden, err)
+		}
+		// retry until the referenced hydra admin Service is created
+		if apierrs.IsNotFound(err) {
+			if updateErr := r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusInvalidHydraAddress, err); updateErr != nil {
+				return updateErr
+			}
+			return r.retry(c, err)
+		}
 		return err
 	}
 
-	hydraClient, err := r.getHydraClientForClient(*c)
+	hydraClient, err := r.getHydraClientForClient(ctx, *c)
 	if err != nil {
 		return err
 	}
 
-	oauth2client, err := hydra.FromOAuth2Client(c)
+	oauth2client, err := r.DesiredOAuth2Client(c, nil)
 	if err != nil {
-		if updateErr := r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusRegistrationFailed, err); updateErr != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusInvalidSpec, err); updateErr != nil {
 			return updateErr
 		}
 
 		return fmt.Errorf("failed to construct hydra client for object: %w", err)
 	}
 
-	created, err := hydraClient.PostOAuth2Client(oauth2client)
+	if credentials != nil {
+		oauth2client.WithCredentials(credentials)
+	}
+
+	summaryFrom(ctx).changed(oauth2client, nil)
+	created, err := hydraClient.PostOAuth2Client(ctx, oauth2client)
+	r.audit(ctx, c, audit.ActionCreate, createdClientID(created, credentials), err)
+	if errors.Is(err, hydra.ErrConflict) && credentials != nil {
+		return r.resolveConflict(ctx, c, credentials, hydrav1alpha1.StatusConflict, err)
+	}
+	// a client created in some of the discovered ORY Hydra instances only is
+	// stored like any other and completed by the retry
+	var incompleteErr error
+	if created != nil && errors.Is(err, hydra.ErrIncomplete) {
+		incompleteErr, err = err, nil
+	}
 	if err != nil {
-		if updateErr := r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusRegistrationFailed, err); updateErr != nil {
-			return updateErr
+		return r.handleHydraError(ctx, c, hydrav1alpha1.StatusRegistrationFailed, err)
+	}
+	r.checkCompatibility(ctx, c, oauth2client, created)
+
+	if credentials != nil {
+		if err := r.ensureDiscoveryConfigMap(ctx, c, *created.ClientID, created.Scope); err != nil {
+			return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusCreateConfigMapFailed, err)
 		}
-		return nil
+		if err := r.ensureAccessRule(ctx, c, *created.ClientID, created.Scope); err != nil {
+			return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusAccessRuleFailed, err)
+		}
+		if err := r.ensureRelationTuples(ctx, c, *created.ClientID); err != nil {
+			return r.handleRelationTuplesError(ctx, c, err)
+		}
+		if incompleteErr != nil {
+			return r.handleHydraError(ctx, c, hydrav1alpha1.StatusRegistrationFailed, incompleteErr)
+		}
+		return r.ensureEmptyStatusError(ctx, c)
 	}
 
 	clientSecret := apiv1.Secret{
@@ -292,7 +1284,12 @@ func (r *OAuth2ClientReconciler) registerOAuth2Client(ctx context.Context, c *hy
 	}
 
 	if created.Secret != nil {
-		clientSecret.Data[ClientSecretKey] = []byte(*created.Secret)
+		password, err := r.encryptSecret(ctx, []byte(*created.Secret))
+		if err != nil {
+			return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusCreateSecretFailed, err)
+		}
+		clientSecret.Data[ClientSecretKey] = password
+		metav1.SetMetaDataAnnotation(&clientSecret.ObjectMeta, SecretRotatedAtAnnotation, r.clock.Now().UTC().Format(time.RFC3339))
 	}
 
 	if err := r.Create(ctx, &clientSecret); err != nil {
@@ -301,110 +1298,811 @@ func (r *OAuth2ClientReconciler) registerOAuth2Client(ctx context.Context, c *hy
 		}
 	}
 
+	if err := r.ensureDiscoveryConfigMap(ctx, c, *created.ClientID, created.Scope); err != nil {
+		return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusCreateConfigMapFailed, err)
+	}
+	if err := r.ensureAccessRule(ctx, c, *created.ClientID, created.Scope); err != nil {
+		return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusAccessRuleFailed, err)
+	}
+	if err := r.ensureRelationTuples(ctx, c, *created.ClientID); err != nil {
+		return r.handleRelationTuplesError(ctx, c, err)
+	}
+	if incompleteErr != nil {
+		return r.handleHydraError(ctx, c, hydrav1alpha1.StatusRegistrationFailed, incompleteErr)
+	}
+
 	return r.ensureEmptyStatusError(ctx, c)
 }
 
-func (r *OAuth2ClientReconciler) updateRegisteredOAuth2Client(ctx context.Context, c *hydrav1alpha1.OAuth2Client, credentials *hydra.Oauth2ClientCredentials) error {
-	hydraClient, err := r.getHydraClientForClient(*c)
+// updateRegisteredOAuth2Client updates the client of c in ORY Hydra. current
+// is the client registered in ORY Hydra, if it was fetched.
+func (r *OAuth2ClientReconciler) updateRegisteredOAuth2Client(ctx context.Context, c *hydrav1alpha1.OAuth2Client, credentials *hydra.Oauth2ClientCredentials, current *hydra.OAuth2ClientJSON) error {
+	hydraClient, err := r.getHydraClientForClient(ctx, *c)
 	if err != nil {
 		return err
 	}
 
-	oauth2client, err := hydra.FromOAuth2Client(c)
+	oauth2client, err := r.DesiredOAuth2Client(c, current)
 	if err != nil {
-		if updateErr := r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusUpdateFailed, err); updateErr != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusInvalidSpec, err); updateErr != nil {
 			return updateErr
 		}
 
 		return fmt.Errorf("failed to construct hydra client for object: %w", err)
 	}
 
-	if _, err := hydraClient.PutOAuth2Client(oauth2client.WithCredentials(credentials)); err != nil {
-		if updateErr := r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusUpdateFailed, err); updateErr != nil {
+	summaryFrom(ctx).changed(oauth2client, current)
+	stored, err := r.writeOAuth2Client(ctx, hydraClient, oauth2client.WithCredentials(credentials), current)
+	r.audit(ctx, c, audit.ActionUpdate, string(credentials.ID), err)
+	if err != nil {
+		return r.handleHydraError(ctx, c, hydrav1alpha1.StatusUpdateFailed, err)
+	}
+	r.checkCompatibility(ctx, c, oauth2client, stored)
+
+	if err := r.ensureDiscoveryConfigMap(ctx, c, string(credentials.ID), oauth2client.Scope); err != nil {
+		return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusCreateConfigMapFailed, err)
+	}
+	if err := r.ensureAccessRule(ctx, c, string(credentials.ID), oauth2client.Scope); err != nil {
+		return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusAccessRuleFailed, err)
+	}
+	if err := r.ensureRelationTuples(ctx, c, string(credentials.ID)); err != nil {
+		return r.handleRelationTuplesError(ctx, c, err)
+	}
+
+	return r.ensureEmptyStatusError(ctx, c)
+}
+
+// writeOAuth2Client replaces the client in ORY Hydra with desired, or patches
+// the fields which differ from current with patch updates. It returns the
+// client ORY Hydra stored, which is nil if nothing was patched.
+func (r *OAuth2ClientReconciler) writeOAuth2Client(ctx context.Context, hydraClient hydra.Client, desired, current *hydra.OAuth2ClientJSON) (*hydra.OAuth2ClientJSON, error) {
+	patcher, ok := hydraClient.(hydra.Patcher)
+	if !r.patchUpdates || !ok || current == nil {
+		return hydraClient.PutOAuth2Client(ctx, desired)
+	}
+
+	patch, err := hydra.Patch(desired, current)
+	if err != nil || len(patch) == 0 {
+		return nil, err
+	}
+	return patcher.PatchOAuth2Client(ctx, *desired.ClientID, patch)
+}
+
+// checkCompatibility records the fields of sent, the client written to ORY
+// Hydra for c, which the detected version of ORY Hydra ignores or
+// deprecates, for the Incompatible condition, and emits a warning event
+// about them. stored is the client ORY Hydra returned, if any.
+func (r *OAuth2ClientReconciler) checkCompatibility(ctx context.Context, c *hydrav1alpha1.OAuth2Client, sent, stored *hydra.OAuth2ClientJSON) {
+	s := summaryFrom(ctx)
+	found, err := hydra.Compatibility(s.hydraVersion(), sent, stored)
+	if err != nil {
+		r.Log.V(1).Info("unable to check the compatibility with ORY Hydra", "reason", err.Error())
+		return
+	}
+	s.incompatible(found)
+	if len(found) > 0 {
+		r.event(c, apiv1.EventTypeWarning, IncompatibleFieldsReason, incompatibilityMessage(found))
+	}
+}
+
+// incompatibleCondition returns the Incompatible condition of c, computed
+// from the fields checked by this reconciliation, or the one in the status
+// of c if no client was written.
+func incompatibleCondition(ctx context.Context, c *hydrav1alpha1.OAuth2Client) (hydrav1alpha1.OAuth2ClientCondition, bool) {
+	s := summaryFrom(ctx)
+	if s == nil || !s.checked {
+		for _, condition := range c.Status.Conditions {
+			if condition.Type == hydrav1alpha1.OAuth2ClientConditionIncompatible {
+				return condition, true
+			}
+		}
+		return hydrav1alpha1.OAuth2ClientCondition{}, false
+	}
+	if len(s.incompatibilities) == 0 {
+		return hydrav1alpha1.OAuth2ClientCondition{Type: hydrav1alpha1.OAuth2ClientConditionIncompatible, Status: hydrav1alpha1.ConditionFalse}, true
+	}
+	return hydrav1alpha1.OAuth2ClientCondition{
+		Type:    hydrav1alpha1.OAuth2ClientConditionIncompatible,
+		Status:  hydrav1alpha1.ConditionTrue,
+		Reason:  hydrav1alpha1.ReasonVersionSkew,
+		Message: incompatibilityMessage(s.incompatibilities),
+	}, true
+}
+
+// incompatibilityMessage joins the messages of found.
+func incompatibilityMessage(found []hydra.Incompatibility) string {
+	messages := make([]string, 0, len(found))
+	for _, i := range found {
+		messages = append(messages, i.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// repairDrift puts the spec of c back into ORY Hydra if fetched, the client
+// registered there, was changed out of band.
+func (r *OAuth2ClientReconciler) repairDrift(ctx context.Context, c *hydrav1alpha1.OAuth2Client, credentials *hydra.Oauth2ClientCredentials, fetched *hydra.OAuth2ClientJSON) error {
+	desired, err := r.DesiredOAuth2Client(c, fetched)
+	if err != nil {
+		// invalid specs are reported when the OAuth2Client changes
+		return nil
+	}
+	drift, err := hydra.Drift(desired, fetched)
+	if err != nil || len(drift) == 0 {
+		return err
+	}
+
+	fields := make([]string, 0, len(drift))
+	for _, d := range drift {
+		fields = append(fields, d.Field)
+	}
+	r.metrics.driftRepairs.WithLabelValues(r.ClusterName).Inc()
+	r.event(c, apiv1.EventTypeWarning, DriftRepairedReason, fmt.Sprintf("client was changed in ORY Hydra, restored %s", strings.Join(fields, ", ")))
+
+	return r.updateRegisteredOAuth2Client(ctx, c, credentials, fetched)
+}
+
+// DesiredOAuth2Client converts c into the client to register in ORY Hydra,
+// validating its metadata against the metadata schema. With metadata merging,
+// the metadata of c is merged into that of current, the client registered in
+// ORY Hydra, if it is not nil. The provenance is set last, so it always wins.
+func (r *OAuth2ClientReconciler) DesiredOAuth2Client(c *hydrav1alpha1.OAuth2Client, current *hydra.OAuth2ClientJSON) (*hydra.OAuth2ClientJSON, error) {
+	oauth2client, err := hydra.FromOAuth2Client(c)
+	if err != nil {
+		return nil, err
+	}
+	oauth2client.Owner = r.ownerOf(c)
+
+	if oauth2client.ClientName == "" {
+		if oauth2client.ClientName, err = r.clientNameTemplate.Execute(c, r.ClusterName); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.metadataSchema != nil {
+		if err := r.metadataSchema.Validate(oauth2client.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.mergeMetadata && current != nil {
+		// unlike oauth2client.Metadata, spec.metadata keeps the keys set to
+		// null, which remove the keys of the client
+		patch, err := json.Marshal(c.Spec.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if oauth2client.Metadata, err = hydra.MergeMetadata(current.Metadata, patch); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.provenanceMetadata {
+		oauth2client.Metadata, err = hydra.SetMetadata(oauth2client.Metadata, hydra.ProvenanceMetadataKey, hydra.Provenance{
+			Namespace: c.Namespace,
+			Name:      c.Name,
+			UID:       string(c.UID),
+			Cluster:   r.ClusterName,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return oauth2client, nil
+}
+
+// transfersFrom reports whether c takes over the client of owner, because
+// owner is the OAuth2Client named by the PreviousOwnerAnnotation of c. An
+// OAuth2Client in another namespace must still exist and name c in its
+// TransferToAnnotation, so clients can't be taken over from other tenants by
+// their ID alone.
+func (r *OAuth2ClientReconciler) transfersFrom(ctx context.Context, c *hydrav1alpha1.OAuth2Client, owner string) (bool, error) {
+	previous, ok := c.Annotations[PreviousOwnerAnnotation]
+	if !ok {
+		return false, nil
+	}
+	name, namespace, cluster, ok := hydra.ParseOwner(previous)
+	if !ok || cluster != "" || strings.Contains(previous, "@") {
+		r.Log.Info(fmt.Sprintf("ignoring invalid %s annotation of %s/%s, it must be name/namespace", PreviousOwnerAnnotation, c.Namespace, c.Name), "value", previous)
+		return false, nil
+	}
+	if owner != hydra.Owner(name, namespace, r.ClusterName, r.controllerID) && owner != hydra.Owner(name, namespace, r.ClusterName, "") {
+		return false, nil
+	}
+	if namespace == c.Namespace {
+		return true, nil
+	}
+
+	var from hydrav1alpha1.OAuth2Client
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &from); err != nil {
+		if apierrs.IsNotFound(err) {
+			r.Log.Info(fmt.Sprintf("not taking over the client of %s: the OAuth2Client does not exist", previous))
+			return false, nil
+		}
+		return false, err
+	}
+	if from.Annotations[TransferToAnnotation] != c.Name+"/"+c.Namespace {
+		r.Log.Info(fmt.Sprintf("not taking over the client of %s: its %s annotation does not name %s/%s", previous, TransferToAnnotation, c.Name, c.Namespace))
+		return false, nil
+	}
+	return true, nil
+}
+
+// transferOwnership completes taking over the client of previousOwner: the
+// Secret of c is owned by c from now on, so it is not garbage collected with
+// the previous OAuth2Client.
+func (r *OAuth2ClientReconciler) transferOwnership(ctx context.Context, c *hydrav1alpha1.OAuth2Client, secret *apiv1.Secret, previousOwner string) error {
+	r.Log.Info(fmt.Sprintf("took over the client of %s for %s/%s", previousOwner, c.Namespace, c.Name), "clientID", string(secret.Data[ClientIDKey]))
+	r.event(c, apiv1.EventTypeNormal, OwnershipTransferredReason, fmt.Sprintf("client was taken over from %s", previousOwner))
+
+	refs := make([]metav1.OwnerReference, 0, len(secret.OwnerReferences)+1)
+	for _, ref := range secret.OwnerReferences {
+		if ref.Kind != "OAuth2Client" {
+			refs = append(refs, ref)
+		}
+	}
+	secret.OwnerReferences = append(refs, metav1.OwnerReference{
+		APIVersion: hydrav1alpha1.GroupVersion.String(),
+		Kind:       "OAuth2Client",
+		Name:       c.Name,
+		UID:        c.UID,
+	})
+	if err := r.Update(ctx, secret); err != nil {
+		return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusUpdateSecretFailed, err)
+	}
+	return nil
+}
+
+// isProtected reports whether the client of c must not be deleted from ORY
+// Hydra.
+func isProtected(c *hydrav1alpha1.OAuth2Client) bool {
+	return c.Annotations[ProtectAnnotation] == "true"
+}
+
+// abandonFinalization reports whether the deletion of c proceeds although its
+// client could not be deleted from ORY Hydra because of err.
+func (r *OAuth2ClientReconciler) abandonFinalization(c *hydrav1alpha1.OAuth2Client, err error) bool {
+	if c.Annotations[OrphanOnUnreachableAnnotation] == "true" && hydraStatusCode("", err) == hydrav1alpha1.StatusHydraUnreachable {
+		return true
+	}
+	return r.maxFinalization > 0 && r.clock.Since(c.DeletionTimestamp.Time) > r.maxFinalization
+}
+
+// resolveConflict handles the client ID of the Secret of c being taken in ORY
+// Hydra by another owner, according to the conflict policy of c. With
+// ConflictPolicyFail, err is recorded in the status with code.
+func (r *OAuth2ClientReconciler) resolveConflict(ctx context.Context, c *hydrav1alpha1.OAuth2Client, credentials *hydra.Oauth2ClientCredentials, code hydrav1alpha1.StatusCode, err error) error {
+	policy := r.conflictPolicy
+	if c.Spec.ConflictPolicy != "" {
+		policy = c.Spec.ConflictPolicy
+	}
+
+	switch policy {
+	case hydrav1alpha1.ConflictPolicyAdopt:
+		r.Log.Info("adopting oauth2 client of another owner", "oauth2client", c.Namespace+"/"+c.Name, "clientID", string(credentials.ID))
+		return r.updateRegisteredOAuth2Client(ctx, c, credentials, nil)
+	case hydrav1alpha1.ConflictPolicyRegenerate:
+		r.Log.Info("registering oauth2 client with a new ID", "oauth2client", c.Namespace+"/"+c.Name, "clientID", string(credentials.ID))
+		return r.regenerateOAuth2Client(ctx, c)
+	default:
+		return r.updateReconciliationStatusError(ctx, c, code, err)
+	}
+}
+
+// regenerateOAuth2Client registers the client of c with an ID and secret
+// generated by ORY Hydra and writes them to the existing Secret.
+func (r *OAuth2ClientReconciler) regenerateOAuth2Client(ctx context.Context, c *hydrav1alpha1.OAuth2Client) error {
+	hydraClient, err := r.getHydraClientForClient(ctx, *c)
+	if err != nil {
+		return err
+	}
+
+	oauth2client, err := r.DesiredOAuth2Client(c, nil)
+	if err != nil {
+		if updateErr := r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusInvalidSpec, err); updateErr != nil {
 			return updateErr
 		}
+
+		return fmt.Errorf("failed to construct hydra client for object: %w", err)
+	}
+
+	summaryFrom(ctx).changed(oauth2client, nil)
+	created, err := hydraClient.PostOAuth2Client(ctx, oauth2client)
+	r.audit(ctx, c, audit.ActionCreate, createdClientID(created, nil), err)
+	if err != nil {
+		return r.handleHydraError(ctx, c, hydrav1alpha1.StatusRegistrationFailed, err)
+	}
+
+	var password []byte
+	if created.Secret != nil {
+		if password, err = r.encryptSecret(ctx, []byte(*created.Secret)); err != nil {
+			return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusUpdateSecretFailed, err)
+		}
+	}
+
+	var secret apiv1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: c.Spec.SecretName, Namespace: c.Namespace}, &secret); err != nil {
+		return err
 	}
+
+	if err := r.updateWithRetry(ctx, &secret, func() {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[ClientIDKey] = []byte(*created.ClientID)
+		delete(secret.Data, ClientSecretKey)
+		if password != nil {
+			secret.Data[ClientSecretKey] = password
+			metav1.SetMetaDataAnnotation(&secret.ObjectMeta, SecretRotatedAtAnnotation, r.clock.Now().UTC().Format(time.RFC3339))
+		}
+	}); err != nil {
+		return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusUpdateSecretFailed, err)
+	}
+
+	if err := r.ensureDiscoveryConfigMap(ctx, c, *created.ClientID, created.Scope); err != nil {
+		return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusCreateConfigMapFailed, err)
+	}
+	if err := r.ensureAccessRule(ctx, c, *created.ClientID, created.Scope); err != nil {
+		return r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusAccessRuleFailed, err)
+	}
+	if err := r.ensureRelationTuples(ctx, c, *created.ClientID); err != nil {
+		return r.handleRelationTuplesError(ctx, c, err)
+	}
+
 	return r.ensureEmptyStatusError(ctx, c)
 }
 
 func (r *OAuth2ClientReconciler) unregisterOAuth2Clients(ctx context.Context, c *hydrav1alpha1.OAuth2Client) error {
-	// if a required field is empty, that means this is deleted after
+	// if the required secret name is empty, that means this is deleted after
 	// the finalizers have done their job, so just return
-	if c.Spec.Scope == "" || c.Spec.SecretName == "" {
+	if c.Spec.SecretName == "" {
+		return nil
+	}
+
+	if isProtected(c) {
+		r.Log.Info(fmt.Sprintf("client %s/%s is protected by the %s annotation, leave it in ORY Hydra", c.Name, c.Namespace, ProtectAnnotation))
 		return nil
 	}
 
-	h, err := r.getHydraClientForClient(*c)
+	h, err := r.getHydraClientForClient(ctx, *c)
 	if err != nil {
 		return err
 	}
 
-	clients, err := h.ListOAuth2Client()
+	clients, err := h.ListOAuth2Client(ctx)
 	if err != nil {
 		return err
 	}
 
 	for _, cJSON := range clients {
-		if cJSON.Owner == fmt.Sprintf("%s/%s", c.Name, c.Namespace) {
+		if cJSON.Owner == r.ownerOf(c) {
 			if c.Spec.DeletionPolicy == hydrav1alpha1.OAuth2ClientDeletionPolicyOrphan {
 				// Do not delete the OAuth2 client.
 				r.Log.Info("oauth2 client deletion, leave the row orphan")
 				return nil
 			}
-			if err := h.DeleteOAuth2Client(*cJSON.ClientID); err != nil {
+			err := h.DeleteOAuth2Client(ctx, *cJSON.ClientID)
+			r.audit(ctx, c, audit.ActionDelete, *cJSON.ClientID, err)
+			if err != nil {
 				return err
 			}
 		}
 	}
 
+	return r.deleteRelationTuples(ctx, c)
+}
+
+// hydraStatusCode refines code, the status code of a failed ORY Hydra request,
+// by the kind of failure.
+func hydraStatusCode(code hydrav1alpha1.StatusCode, err error) hydrav1alpha1.StatusCode {
+	switch {
+	case errors.Is(err, hydra.ErrUnauthorized):
+		return hydrav1alpha1.StatusUnauthorized
+	case errors.Is(err, hydra.ErrConflict):
+		return hydrav1alpha1.StatusConflict
+	}
+
+	var hydraErr *hydra.RequestError
+	if !errors.As(err, &hydraErr) {
+		if hydra.IsRetryable(err) {
+			return hydrav1alpha1.StatusHydraUnreachable
+		}
+		return code
+	}
+
+	switch hydraErr.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return hydrav1alpha1.StatusHydraUnreachable
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return hydrav1alpha1.StatusInvalidSpec
+	default:
+		return code
+	}
+}
+
+// updateWithRetry applies mutate to obj and updates it. On conflicts, obj is
+// fetched again and mutate is reapplied, so concurrent edits by users or other
+// controllers don't abort the reconciliation halfway.
+func (r *OAuth2ClientReconciler) updateWithRetry(ctx context.Context, obj client.Object, mutate func()) error {
+	refetch := false
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if refetch {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+				return err
+			}
+		}
+		refetch = true
+
+		mutate()
+		return r.Update(ctx, obj)
+	})
+}
+
+// ownerOf returns the owner of the client of c in ORY Hydra.
+func (r *OAuth2ClientReconciler) ownerOf(c *hydrav1alpha1.OAuth2Client) string {
+	return hydra.Owner(c.Name, c.Namespace, r.ClusterName, r.controllerID)
+}
+
+// owns reports whether owner names an OAuth2Client of the reconciler, i.e.
+// carries its cluster and controller identity, or no identity for clients
+// written before it was set, see legacyOwnerOf.
+func (r *OAuth2ClientReconciler) owns(owner string) bool {
+	_, _, cluster, ok := hydra.ParseOwner(owner)
+	if !ok || cluster != r.ClusterName {
+		return false
+	}
+	_, controller := hydra.SplitOwnerController(owner)
+	return controller == r.controllerID || controller == ""
+}
+
+// legacyOwnerOf returns the owner of the client of c without the controller
+// identity, as written before the identity was set. Such clients are taken
+// over on updates, but never deleted.
+func (r *OAuth2ClientReconciler) legacyOwnerOf(c *hydrav1alpha1.OAuth2Client) string {
+	return hydra.Owner(c.Name, c.Namespace, r.ClusterName, "")
+}
+
+// handleHydraError records a failed ORY Hydra request in the status of c. It
+// returns err if the retry policy retries the failure, so the reconciliation
+// is requeued with backoff, and nil otherwise.
+func (r *OAuth2ClientReconciler) handleHydraError(ctx context.Context, c *hydrav1alpha1.OAuth2Client, code hydrav1alpha1.StatusCode, err error) error {
+	if updateErr := r.updateReconciliationStatusError(ctx, c, hydraStatusCode(code, err), err); updateErr != nil {
+		return updateErr
+	}
+	if r.retryPolicy.retries(err) {
+		return r.retry(c, err)
+	}
 	return nil
 }
 
 func (r *OAuth2ClientReconciler) updateReconciliationStatusError(ctx context.Context, c *hydrav1alpha1.OAuth2Client, code hydrav1alpha1.StatusCode, err error) error {
-	r.Log.Error(err, fmt.Sprintf("error processing client %s/%s ", c.Name, c.Namespace), "oauth2client", "register")
+	summaryFrom(ctx).fail(code, err)
+	r.metrics.errors.WithLabelValues(r.ClusterName, string(code)).Inc()
+	if r.recordedError(c, code, hydra.Sanitize(err.Error())) {
+		return nil
+	}
+	r.event(c, apiv1.EventTypeWarning, string(code.Reason()), hydra.Sanitize(err.Error()))
 
+	// CreateOrPatch fetches c again, keep the spec with the namespace defaults
+	// and the referenced metadata, and the relation tuples written to ORY Keto
+	spec, applied, metadataFromVersion, tuples := c.Spec, c.Status.AppliedDefaults, c.Status.MetadataFromVersion, c.Status.RelationTuples
+	defer func() { c.Spec = spec }()
 	_, err = controllerutil.CreateOrPatch(ctx, r.Client, c, func() error {
+		// the failures are counted again once the spec or the
+		// MaxRetriesAnnotation changes, so they are retried again
+		maxRetries, _ := r.maxRetries(c)
+		if c.Status.ObservedGeneration != c.Generation || c.Status.MaxRetries != maxRetries {
+			c.Status.ConsecutiveFailures = 0
+		}
 		c.Status.ObservedGeneration = c.Generation
+		c.Status.MaxRetries = maxRetries
+		c.Status.AppliedDefaults = applied
+		c.Status.MetadataFromVersion = metadataFromVersion
+		c.Status.RelationTuples = tuples
 		c.Status.ReconciliationError = hydrav1alpha1.ReconciliationError{
 			Code:        code,
-			Description: err.Error(),
+			Description: hydra.Sanitize(err.Error()),
 		}
-		c.Status.Conditions = []hydrav1alpha1.OAuth2ClientCondition{
-			{
-				Type:   hydrav1alpha1.OAuth2ClientConditionReady,
-				Status: hydrav1alpha1.ConditionFalse,
-			},
+		var hydraErr *hydra.RequestError
+		if errors.As(err, &hydraErr) {
+			c.Status.ReconciliationError.HTTPStatusCode = hydraErr.StatusCode
+			c.Status.ReconciliationError.HydraResponse = hydra.Sanitize(hydraErr.Body)
 		}
+		c.Status.ConsecutiveFailures++
+		c.Status.Conditions = r.conditions(ctx, c, hydrav1alpha1.OAuth2ClientCondition{
+			Type:   hydrav1alpha1.OAuth2ClientConditionReady,
+			Status: hydrav1alpha1.ConditionFalse,
+			Reason: code.Reason(),
+		})
 
 		return nil
 	})
 	if err != nil {
 		r.Log.Error(err, fmt.Sprintf("status update failed for client %s/%s ", c.Name, c.Namespace), "oauth2client", "update status")
+		return err
 	}
 
-	return err
+	if maxRetries, ok := r.maxRetries(c); ok && c.Status.ConsecutiveFailures == maxRetries {
+		r.event(c, apiv1.EventTypeWarning, RetriesExhaustedReason, fmt.Sprintf("giving up after %d failed reconciliations, change the spec or the %s annotation to retry", maxRetries, MaxRetriesAnnotation))
+	}
+	if c.Status.ConsecutiveFailures == max(r.degradedThreshold, 1) {
+		r.notify(ctx, c, notify.TransitionFailed, c.Status.ConsecutiveFailures)
+	}
+	return nil
 }
 
 func (r *OAuth2ClientReconciler) ensureEmptyStatusError(ctx context.Context, c *hydrav1alpha1.OAuth2Client) error {
+	// CreateOrPatch fetches c again, keep the spec with the namespace defaults
+	// and the referenced metadata, and the relation tuples written to ORY Keto
+	spec, applied, metadataFromVersion, tuples := c.Spec, c.Status.AppliedDefaults, c.Status.MetadataFromVersion, c.Status.RelationTuples
+	endpoint, failures := r.hydraEndpoint(ctx, c), c.Status.ConsecutiveFailures
+	defer func() { c.Spec = spec }()
 	_, err := controllerutil.CreateOrPatch(ctx, r.Client, c, func() error {
 		c.Status.ObservedGeneration = c.Generation
+		c.Status.AppliedDefaults = applied
+		c.Status.MetadataFromVersion = metadataFromVersion
+		c.Status.RelationTuples = tuples
+		c.Status.HydraEndpoint = endpoint
 		c.Status.ReconciliationError = hydrav1alpha1.ReconciliationError{}
-		c.Status.Conditions = []hydrav1alpha1.OAuth2ClientCondition{
-			{
-				Type:   hydrav1alpha1.OAuth2ClientConditionReady,
-				Status: hydrav1alpha1.ConditionTrue,
-			},
-		}
+		c.Status.ConsecutiveFailures = 0
+		c.Status.MaxRetries, _ = r.maxRetries(c)
+		c.Status.Conditions = r.conditions(ctx, c, hydrav1alpha1.OAuth2ClientCondition{
+			Type:   hydrav1alpha1.OAuth2ClientConditionReady,
+			Status: hydrav1alpha1.ConditionTrue,
+			Reason: hydrav1alpha1.ReasonReconciled,
+		})
 
 		return nil
 	})
 	if err != nil {
 		r.Log.Error(err, fmt.Sprintf("status update failed for client %s/%s ", c.Name, c.Namespace), "oauth2client", "update status")
+		return err
+	}
+
+	r.metrics.reconciliations.WithLabelValues(r.ClusterName).Inc()
+	r.event(c, apiv1.EventTypeNormal, string(hydrav1alpha1.ReasonReconciled), "client is registered in ORY Hydra")
+	if failures >= max(r.degradedThreshold, 1) {
+		r.notify(ctx, c, notify.TransitionRecovered, failures)
+	}
+	return nil
+}
+
+// notify tells the notifier that c failed or recovered after failures
+// consecutive failed reconciliations.
+func (r *OAuth2ClientReconciler) notify(ctx context.Context, c *hydrav1alpha1.OAuth2Client, transition notify.Transition, failures int32) {
+	if r.notifier == nil {
+		return
+	}
+	n := notify.Notification{
+		Time:                r.clock.Now().UTC(),
+		Transition:          transition,
+		Cluster:             r.ClusterName,
+		Namespace:           c.Namespace,
+		Name:                c.Name,
+		ConsecutiveFailures: failures,
+	}
+	if transition == notify.TransitionFailed {
+		n.Reason = string(c.Status.ReconciliationError.Code.Reason())
+		n.Error = c.Status.ReconciliationError.Description
+	}
+	r.notifier.Notify(ctx, n)
+}
+
+// finalizes reports whether c gets the finalizer which deletes its client
+// from ORY Hydra, see WithFinalizersDisabled and the Detach deletion policy.
+func (r *OAuth2ClientReconciler) finalizes(c *hydrav1alpha1.OAuth2Client) bool {
+	return !r.disableFinalizers && c.Spec.DeletionPolicy != hydrav1alpha1.OAuth2ClientDeletionPolicyDetach
+}
+
+// recordedError reports whether the status of c already records the error with
+// code and description, with enough consecutive failures to reach both the
+// degraded threshold and the MaxRetriesAnnotation. Such failures are neither
+// written to the status nor emitted as events again, so retries don't churn
+// the API server while ORY Hydra is down.
+func (r *OAuth2ClientReconciler) recordedError(c *hydrav1alpha1.OAuth2Client, code hydrav1alpha1.StatusCode, description string) bool {
+	threshold := max(r.degradedThreshold, 1)
+	maxRetries, ok := r.maxRetries(c)
+	if ok {
+		threshold = max(threshold, maxRetries)
+	}
+	return c.Status.ObservedGeneration == c.Generation &&
+		c.Status.MaxRetries == maxRetries &&
+		c.Status.ReconciliationError.Code == code &&
+		c.Status.ReconciliationError.Description == description &&
+		c.Status.ConsecutiveFailures >= threshold
+}
+
+// conditions returns the conditions of c with the ready condition and, if a
+// degraded threshold is set, the Degraded condition, which is true with the
+// reason of the ready condition once the consecutive failures reach it. The
+// RetriesExhausted condition of OAuth2Clients with the MaxRetriesAnnotation
+// is true likewise once the failures reach the annotation. The Incompatible
+// condition is kept once a client was written, see incompatibleCondition.
+func (r *OAuth2ClientReconciler) conditions(ctx context.Context, c *hydrav1alpha1.OAuth2Client, ready hydrav1alpha1.OAuth2ClientCondition) []hydrav1alpha1.OAuth2ClientCondition {
+	conditions := []hydrav1alpha1.OAuth2ClientCondition{ready}
+	if r.degradedThreshold > 0 {
+		conditions = append(conditions, thresholdCondition(hydrav1alpha1.OAuth2ClientConditionDegraded, c.Status.ConsecutiveFailures, r.degradedThreshold, ready.Reason))
+	}
+	if maxRetries, ok := r.maxRetries(c); ok {
+		conditions = append(conditions, thresholdCondition(hydrav1alpha1.OAuth2ClientConditionRetriesExhausted, c.Status.ConsecutiveFailures, maxRetries, ready.Reason))
+	}
+	if incompatible, ok := incompatibleCondition(ctx, c); ok {
+		conditions = append(conditions, incompatible)
 	}
+	return conditions
+}
 
+// thresholdCondition returns the condition of type t, which is true with
+// reason once failures reach threshold.
+func thresholdCondition(t hydrav1alpha1.OAuth2ClientConditionType, failures, threshold int32, reason hydrav1alpha1.ConditionReason) hydrav1alpha1.OAuth2ClientCondition {
+	if failures >= threshold {
+		return hydrav1alpha1.OAuth2ClientCondition{Type: t, Status: hydrav1alpha1.ConditionTrue, Reason: reason}
+	}
+	return hydrav1alpha1.OAuth2ClientCondition{Type: t, Status: hydrav1alpha1.ConditionFalse}
+}
+
+// maxRetries returns the number of retries set by the MaxRetriesAnnotation
+// of c, if it is valid.
+func (r *OAuth2ClientReconciler) maxRetries(c *hydrav1alpha1.OAuth2Client) (int32, bool) {
+	value, ok := c.Annotations[MaxRetriesAnnotation]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || n <= 0 {
+		r.Log.Info(fmt.Sprintf("ignoring invalid %s annotation of %s/%s", MaxRetriesAnnotation, c.Namespace, c.Name), "value", value)
+		return 0, false
+	}
+	return int32(n), true
+}
+
+// retriesExhausted reports whether c failed as many times in a row as its
+// MaxRetriesAnnotation allows, since its spec or the annotation last changed.
+func (r *OAuth2ClientReconciler) retriesExhausted(c *hydrav1alpha1.OAuth2Client) bool {
+	maxRetries, ok := r.maxRetries(c)
+	return ok && c.Status.ObservedGeneration == c.Generation &&
+		c.Status.MaxRetries == maxRetries &&
+		c.Status.ConsecutiveFailures >= maxRetries
+}
+
+// retry returns err, which retries the reconciliation of c with backoff,
+// unless c exhausted its retries.
+func (r *OAuth2ClientReconciler) retry(c *hydrav1alpha1.OAuth2Client, err error) error {
+	if r.retriesExhausted(c) {
+		return nil
+	}
 	return err
 }
 
+// event emits an event for c if the reconciler has an event recorder. Warning
+// events are deduplicated, see WithEventDedupWindow.
+func (r *OAuth2ClientReconciler) event(c *hydrav1alpha1.OAuth2Client, eventType, reason, message string) {
+	if r.recorder == nil {
+		return
+	}
+	if eventType == apiv1.EventTypeWarning && r.eventDedupWindow > 0 {
+		suppressed, ok := r.dedupEvent(eventKey{uid: c.UID, name: types.NamespacedName{Name: c.Name, Namespace: c.Namespace}, reason: reason})
+		if !ok {
+			return
+		}
+		if suppressed > 0 {
+			message = fmt.Sprintf("%s (%d similar events suppressed)", message, suppressed)
+		}
+	}
+	r.recorder.Event(c, eventType, reason, message)
+}
+
+// dedupEvent reports whether the event of key should be emitted and how many
+// events of key were suppressed since the last one. Windows which have passed
+// are dropped, so the state of deleted OAuth2Clients does not pile up.
+func (r *OAuth2ClientReconciler) dedupEvent(key eventKey) (int, bool) {
+	r.eventsMu.Lock()
+	defer r.eventsMu.Unlock()
+
+	now := r.clock.Now()
+	if w, ok := r.eventWindows[key]; ok && now.Sub(w.start) < r.eventDedupWindow {
+		w.suppressed++
+		return 0, false
+	}
+
+	var suppressed int
+	if w, ok := r.eventWindows[key]; ok {
+		suppressed = w.suppressed
+	}
+	for k, w := range r.eventWindows {
+		if now.Sub(w.start) >= r.eventDedupWindow {
+			delete(r.eventWindows, k)
+		}
+	}
+	r.eventWindows[key] = &eventWindow{start: now}
+	return suppressed, true
+}
+
+// audit adds a change made to the client of c in ORY Hydra to the summary of
+// the reconciliation and passes it to the auditor. err is the error of the
+// change, if it failed.
+func (r *OAuth2ClientReconciler) audit(ctx context.Context, c *hydrav1alpha1.OAuth2Client, action audit.Action, clientID string, err error) {
+	record := audit.Record{
+		Time:      r.clock.Now().UTC(),
+		Action:    action,
+		ClientID:  clientID,
+		Owner:     r.ownerOf(c),
+		Cluster:   r.ClusterName,
+		Namespace: c.Namespace,
+		Name:      c.Name,
+	}
+	if err != nil {
+		record.Error = hydra.Sanitize(err.Error())
+	}
+	summaryFrom(ctx).record(action, clientID)
+
+	if r.auditor != nil {
+		r.auditor.Audit(ctx, record)
+	}
+}
+
+// createdClientID returns the ID of created, or that of credentials if the
+// client was not created.
+func createdClientID(created *hydra.OAuth2ClientJSON, credentials *hydra.Oauth2ClientCredentials) string {
+	switch {
+	case created != nil && created.ClientID != nil:
+		return *created.ClientID
+	case credentials != nil:
+		return string(credentials.ID)
+	default:
+		return ""
+	}
+}
+
+// requeue returns the result of a reconciliation of c that did not fail,
+// requeueing c according to the requeue policy unless its status records an
+// error.
+func (r *OAuth2ClientReconciler) requeue(c *hydrav1alpha1.OAuth2Client) ctrl.Result {
+	if c.Status.ReconciliationError.Code != "" {
+		return ctrl.Result{}
+	}
+	if interval, ok := r.resyncInterval(c); ok {
+		return ctrl.Result{RequeueAfter: interval}
+	}
+	return ctrl.Result{RequeueAfter: r.requeuePolicy(c)}
+}
+
+// resyncInterval returns the interval set by the ResyncIntervalAnnotation of
+// c, if it is valid.
+func (r *OAuth2ClientReconciler) resyncInterval(c *hydrav1alpha1.OAuth2Client) (time.Duration, bool) {
+	value, ok := c.Annotations[ResyncIntervalAnnotation]
+	if !ok {
+		return 0, false
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		r.Log.Info(fmt.Sprintf("ignoring invalid %s annotation of %s/%s", ResyncIntervalAnnotation, c.Namespace, c.Name), "value", value)
+		return 0, false
+	}
+	return max(interval, MinResyncInterval), true
+}
+
+// encryptSecret encrypts the client secret password for its Kubernetes Secret
+// if secret encryption is configured.
+func (r *OAuth2ClientReconciler) encryptSecret(ctx context.Context, password []byte) ([]byte, error) {
+	if r.secretEncryption == nil {
+		return password, nil
+	}
+	return envelope.Encrypt(ctx, r.secretEncryption, password)
+}
+
+// decryptSecret returns the plaintext of the client secret password read from
+// a Kubernetes Secret.
+func (r *OAuth2ClientReconciler) decryptSecret(ctx context.Context, password []byte) ([]byte, error) {
+	if !envelope.IsEncrypted(password) {
+		return password, nil
+	}
+	if r.secretEncryption == nil {
+		return nil, fmt.Errorf("%s is encrypted, but secret encryption is not configured", ClientSecretKey)
+	}
+	return envelope.Decrypt(ctx, r.secretEncryption, password)
+}
+
 func parseSecret(secret apiv1.Secret, authMethod hydrav1alpha1.TokenEndpointAuthMethod) (*hydra.Oauth2ClientCredentials, error) {
 	id, found := secret.Data[ClientIDKey]
 	if !found {
@@ -422,41 +2120,276 @@ func parseSecret(secret apiv1.Secret, authMethod hydrav1alpha1.TokenEndpointAuth
 	}, nil
 }
 
+// CheckVersion verifies the version of the ORY Hydra instance behind c, the
+// default hydra client, according to the configured VersionCheck. It only
+// returns an error for unsupported versions when the check is enforced;
+// failures to determine the version are logged. The detected version is
+// compared with the fields of the clients written to c, see
+// hydra.Compatibility.
+func (r *OAuth2ClientReconciler) CheckVersion(ctx context.Context, c hydra.Client, address string) error {
+	version, err := r.checkVersion(ctx, c, address)
+	if err == nil {
+		r.mu.Lock()
+		r.hydraVersions[clientKey{}] = version
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// checkVersion verifies the version of the ORY Hydra instance behind c like
+// CheckVersion and returns it, or "" if it is unknown.
+func (r *OAuth2ClientReconciler) checkVersion(ctx context.Context, c hydra.Client, address string) (string, error) {
+	if r.versionCheck == VersionCheckOff {
+		return "", nil
+	}
+
+	version, err := hydra.CheckVersion(ctx, c)
+	var unsupported *hydra.UnsupportedVersionError
+	switch {
+	case errors.As(err, &unsupported):
+		if r.versionCheck == VersionCheckEnforce {
+			return version, err
+		}
+		r.Log.Info("ORY Hydra version is not supported", "address", address, "version", version)
+	case err != nil:
+		r.Log.Info("unable to verify ORY Hydra version", "address", address, "version", version, "reason", err.Error())
+		return "", nil
+	default:
+		r.Log.Info("detected ORY Hydra version", "address", address, "version", version)
+	}
+	return version, nil
+}
+
+// WaitForHydra polls the readiness endpoint of the ORY Hydra instance behind c
+// until it is ready or timeout passes, so the controller does not fail to
+// register clients while ORY Hydra is starting up with it. It reports whether
+// ORY Hydra became ready; the controller starts either way.
+func WaitForHydra(ctx context.Context, c hydra.Client, address string, timeout time.Duration, log logr.Logger) bool {
+	err := wait.PollUntilContextTimeout(ctx, HydraReadinessInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		ready, err := c.IsReady(ctx)
+		if err != nil {
+			log.V(1).Info("ORY Hydra is not reachable yet", "address", address, "reason", err.Error())
+			return false, nil
+		}
+		return ready, nil
+	})
+	if err != nil {
+		log.Info("ORY Hydra did not become ready, starting anyway", "address", address, "timeout", timeout.String())
+		return false
+	}
+	log.Info("ORY Hydra is ready", "address", address)
+	return true
+}
+
+// watches reports whether the reconciler manages the OAuth2Clients of
+// namespace, see WithShard, WithNamespace and WithNamespaces.
+func (r *OAuth2ClientReconciler) watches(namespace string) bool {
+	return r.shard.Owns(namespace) &&
+		(r.ControllerNamespace == "" || namespace == r.ControllerNamespace) &&
+		(len(r.namespaces) == 0 || containsString(r.namespaces, namespace))
+}
+
 func (r *OAuth2ClientReconciler) getHydraClientForClient(
-	oauth2client hydrav1alpha1.OAuth2Client) (hydra.Client, error) {
+	ctx context.Context, oauth2client hydrav1alpha1.OAuth2Client) (hydra.Client, error) {
 	spec := oauth2client.Spec
+	if spec.HydraAdmin.URL == "" && spec.HydraAdmin.ServiceRef == nil {
+		admin, err := r.namespaceHydraAdmin(ctx, oauth2client.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if admin != nil {
+			spec.HydraAdmin = *admin
+		}
+	}
+	admin, err := r.resolveServiceRef(ctx, oauth2client.Namespace, spec.HydraAdmin)
+	if err != nil {
+		return nil, err
+	}
+	spec.HydraAdmin = admin
+
 	if spec.HydraAdmin.URL != "" {
+		if len(spec.HydraAdmin.DNSServers) == 0 {
+			spec.HydraAdmin.DNSServers = r.dnsServers
+		}
+		if spec.HydraAdmin.RequestTimeout == "" && r.requestTimeout > 0 {
+			spec.HydraAdmin.RequestTimeout = r.requestTimeout.String()
+		}
+		address := spec.HydraAdmin.Address()
+		if r.disableHydraAdmin {
+			return nil, &DisallowedHydraURLError{Address: address, Disabled: true}
+		}
+		if !r.allowedHydraURLs.Allows(spec.HydraAdmin.URL, spec.HydraAdmin.Port) {
+			return nil, &DisallowedHydraURLError{Address: address}
+		}
+		fallback := fallbackSpec(spec)
+		if fallback != nil && !r.allowedHydraURLs.Allows(fallback.HydraAdmin.URL, fallback.HydraAdmin.Port) {
+			return nil, &DisallowedHydraURLError{Address: fallback.HydraAdmin.Address()}
+		}
+
 		key := clientKey{
 			url:            spec.HydraAdmin.URL,
 			port:           spec.HydraAdmin.Port,
 			endpoint:       spec.HydraAdmin.Endpoint,
 			forwardedProto: spec.HydraAdmin.ForwardedProto,
+			fallbackURL:    spec.HydraAdmin.FallbackURL,
+			fallbackPort:   spec.HydraAdmin.FallbackPort,
+			dnsServers:     strings.Join(spec.HydraAdmin.DNSServers, ","),
+			requestTimeout: spec.HydraAdmin.RequestTimeout,
 		}
-		r.mu.Lock()
-		defer r.mu.Unlock()
-		if c, ok := r.oauth2Clients[key]; ok {
-			return c, nil
+		if c, ok, err := r.cachedHydraClient(ctx, key); ok {
+			return c, err
 		}
 
-		c, err := r.oauth2ClientFactory(spec, "", false)
+		factory, err := r.clientFactoryFor(spec.HydraAdmin.URL)
+		if err != nil {
+			return nil, err
+		}
+		c, err := factory(spec, "", false)
 		if err != nil {
 			return nil, fmt.Errorf("cannot create oauth2 c from CRD: %w", err)
 		}
+		if fallback != nil {
+			fallbackFactory, err := r.clientFactoryFor(fallback.HydraAdmin.URL)
+			if err != nil {
+				return nil, err
+			}
+			fc, err := fallbackFactory(*fallback, "", false)
+			if err != nil {
+				return nil, fmt.Errorf("cannot create fallback client from CRD: %w", err)
+			}
+			c = hydra.Failover(
+				hydra.Endpoint{Address: address, Client: c},
+				hydra.Endpoint{Address: fallback.HydraAdmin.Address(), Client: fc},
+				0)
+		}
+		if r.throttle != nil {
+			c = hydra.Throttle(c, r.throttleConfig(address))
+		}
 
-		r.oauth2Clients[key] = c
-		return c, nil
+		// the version is checked without holding the lock, so a slow
+		// instance doesn't block the reconciliations using other instances
+		version, err := r.checkVersion(ctx, c, spec.HydraAdmin.URL)
+		return r.cacheHydraClient(ctx, key, c, version, err)
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if r.HydraClient == nil {
 		return nil, fmt.Errorf("no default client configured")
 	}
+	summaryFrom(ctx).detected(r.hydraVersions[clientKey{}])
 
-	r.Log.Info("Using default client")
+	r.Log.V(1).Info("Using default client")
 
 	return r.HydraClient, nil
 
 }
 
+// throttleConfig returns the throttle configuration of the hydra client of
+// the instance at address, which exports its limit.
+func (r *OAuth2ClientReconciler) throttleConfig(address string) hydra.ThrottleConfig {
+	config := *r.throttle
+	onLimit := config.OnLimit
+	config.OnLimit = func(limit int) {
+		r.metrics.hydraLimits.WithLabelValues(address).Set(float64(limit))
+		if onLimit != nil {
+			onLimit(limit)
+		}
+	}
+	return config
+}
+
+// cachedHydraClient returns the cached hydra client of key, or the error of
+// its version check if it failed recently.
+func (r *OAuth2ClientReconciler) cachedHydraClient(ctx context.Context, key clientKey) (hydra.Client, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.oauth2Clients[key]; ok {
+		summaryFrom(ctx).detected(r.hydraVersions[key])
+		return c, true, nil
+	}
+	if f, ok := r.versionFailures[key]; ok && r.clock.Now().Before(f.retryAt) {
+		return nil, true, f.err
+	}
+	return nil, false, nil
+}
+
+// cacheHydraClient caches c as the hydra client of key with its version,
+// unless its version check failed with err, which is cached with backoff
+// instead. If another reconciliation cached a client for key meanwhile, that
+// one is returned.
+func (r *OAuth2ClientReconciler) cacheHydraClient(ctx context.Context, key clientKey, c hydra.Client, version string, err error) (hydra.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.oauth2Clients[key]; ok {
+		summaryFrom(ctx).detected(r.hydraVersions[key])
+		return cached, nil
+	}
+	if err != nil {
+		f, ok := r.versionFailures[key]
+		if !ok {
+			f = &versionCheckFailure{}
+			r.versionFailures[key] = f
+		}
+		backoff := min(versionCheckBackoff<<min(f.failures, 10), maxVersionCheckBackoff)
+		f.err, f.failures, f.retryAt = err, f.failures+1, r.clock.Now().Add(backoff)
+		return nil, err
+	}
+	delete(r.versionFailures, key)
+	r.oauth2Clients[key] = c
+	r.hydraVersions[key] = version
+	summaryFrom(ctx).detected(version)
+	return c, nil
+}
+
+// fallbackSpec returns spec with the hydraAdmin address replaced by its
+// fallback instance, or nil if it has none. The fallback instance has the
+// port of the first one unless FallbackURL or FallbackPort set one, and the
+// same endpoint.
+func fallbackSpec(spec hydrav1alpha1.OAuth2ClientSpec) *hydrav1alpha1.OAuth2ClientSpec {
+	if spec.HydraAdmin.FallbackURL == "" {
+		return nil
+	}
+	if u, err := spec.HydraAdmin.AdminURL(); err == nil {
+		spec.HydraAdmin.Port, _ = strconv.Atoi(u.Port())
+		spec.HydraAdmin.Endpoint = u.Path
+	}
+	spec.HydraAdmin.URL = spec.HydraAdmin.FallbackURL
+	if spec.HydraAdmin.FallbackPort != 0 {
+		spec.HydraAdmin.Port = spec.HydraAdmin.FallbackPort
+	}
+	return &spec
+}
+
+// hydraEndpoint returns the address of the ORY Hydra instance c is
+// reconciled against if its client fails over to a fallback instance, and ""
+// otherwise.
+func (r *OAuth2ClientReconciler) hydraEndpoint(ctx context.Context, c *hydrav1alpha1.OAuth2Client) string {
+	hydraClient, err := r.getHydraClientForClient(ctx, *c)
+	if err != nil {
+		return ""
+	}
+	endpoint, _ := hydra.ActiveEndpoint(hydraClient)
+	return endpoint
+}
+
+// clientFactoryFor returns the factory creating clients for the HydraAdmin
+// URL u, selected by its scheme.
+func (r *OAuth2ClientReconciler) clientFactoryFor(u string) (OAuth2ClientFactory, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if factory, ok := r.clientFactories[scheme]; ok {
+		return factory, nil
+	}
+	if scheme == "http" || scheme == "https" {
+		return r.oauth2ClientFactory, nil
+	}
+	return nil, fmt.Errorf("no client factory is registered for the scheme %q of %s", scheme, u)
+}
+
 // Helper functions to check and remove string from a slice of strings.
 func containsString(slice []string, s string) bool {
 	for _, item := range slice {
@@ -5,31 +5,47 @@ package controllers_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/go-logr/logr/funcr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
 	. "github.com/stretchr/testify/mock"
 	apiv1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
 	"k8s.io/utils/ptr"
+	"net/http"
+	"net/http/httptest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	"time"
 
 	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/audit"
 	"github.com/ory/hydra-maester/controllers"
 	mocks "github.com/ory/hydra-maester/controllers/mocks/hydra"
+	"github.com/ory/hydra-maester/envelope"
 	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/admin"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+	"github.com/ory/hydra-maester/keto"
+	"github.com/ory/hydra-maester/notify"
 )
 
 const (
@@ -68,10 +84,10 @@ var _ = Describe("OAuth2Client Controller", func() {
 				c := mgr.GetClient()
 
 				mch := &mocks.Client{}
-				mch.On("GetOAuth2Client", Anything).Return(nil, false, nil)
-				mch.On("DeleteOAuth2Client", Anything).Return(nil)
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("DeleteOAuth2Client", Anything, Anything).Return(nil)
 				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
-				mch.On("PostOAuth2Client", AnythingOfType("*hydra.OAuth2ClientJSON")).Return(func(o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
 					return &hydra.OAuth2ClientJSON{
 						ClientID:      &tstClientID,
 						Secret:        ptr.To(tstSecret),
@@ -82,7 +98,7 @@ var _ = Describe("OAuth2Client Controller", func() {
 						Audience:      o.Audience,
 						Owner:         o.Owner,
 					}
-				}, func(o *hydra.OAuth2ClientJSON) error {
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
 					return nil
 				})
 
@@ -151,9 +167,9 @@ var _ = Describe("OAuth2Client Controller", func() {
 				c := mgr.GetClient()
 
 				mch := &mocks.Client{}
-				mch.On("GetOAuth2Client", Anything).Return(nil, false, nil)
-				mch.On("PostOAuth2Client", Anything).Return(nil, errors.New("error"))
-				mch.On("DeleteOAuth2Client", Anything).Return(nil)
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("PostOAuth2Client", Anything, Anything).Return(nil, errors.New("error"))
+				mch.On("DeleteOAuth2Client", Anything, Anything).Return(nil)
 				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
 
 				recFn, requests := SetupTestReconcile(getAPIReconciler(mgr, mch))
@@ -198,6 +214,73 @@ var _ = Describe("OAuth2Client Controller", func() {
 				stopMgr.Done()
 			})
 
+			It("retry the registration if it failed transiently", func() {
+
+				tstName, tstClientID, tstSecretName := "test-retry", "testClientID-retry", "my-secret-retry"
+
+				s := runtime.NewScheme()
+				err := hydrav1alpha1.AddToScheme(s)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = apiv1.AddToScheme(s)
+				Expect(err).NotTo(HaveOccurred())
+
+				mgr, err := manager.New(cfg, manager.Options{Scheme: s,
+					Metrics: server.Options{
+						BindAddress: ":8088",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				c := mgr.GetClient()
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("DeleteOAuth2Client", Anything, Anything).Return(nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, Anything).Return(nil, &hydra.RequestError{StatusCode: 503, Status: "503 Service Unavailable"}).Once()
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID:   &tstClientID,
+						Secret:     ptr.To(tstSecret),
+						GrantTypes: o.GrantTypes,
+						Scope:      o.Scope,
+						Owner:      o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				recFn, requests := SetupTestReconcile(getAPIReconciler(mgr, mch))
+				go func() {
+					// the registration is reconciled several times, drain all requests
+					for range requests {
+					}
+				}()
+
+				Expect(add(mgr, recFn)).To(Succeed())
+
+				//Start the manager and the controller
+				stopMgr := StartTestManager(mgr)
+
+				instance := testInstance(tstName, tstSecretName)
+				err = c.Create(context.TODO(), instance)
+				Expect(err).NotTo(HaveOccurred())
+
+				//Verify the Secret is created by the retried registration
+				var createdSecret apiv1.Secret
+				ok := client.ObjectKey{Name: tstSecretName, Namespace: tstNamespace}
+				Eventually(func() error {
+					return k8sClient.Get(context.TODO(), ok, &createdSecret)
+				}, timeout).Should(Succeed())
+				Expect(createdSecret.Data[controllers.ClientIDKey]).To(Equal([]byte(tstClientID)))
+
+				//delete instance
+				c.Delete(context.TODO(), instance)
+
+				//Ensure manager is stopped properly
+				stopMgr.Done()
+			})
+
 			It("use provided Secret if it exists", func() {
 
 				tstName, tstClientID, tstSecretName := "test3", "testClientID-3", "my-secret-789"
@@ -221,77 +304,2797 @@ var _ = Describe("OAuth2Client Controller", func() {
 				c := mgr.GetClient()
 
 				mch := mocks.Client{}
-				mch.On("GetOAuth2Client", Anything).Return(nil, false, nil)
-				mch.On("DeleteOAuth2Client", Anything).Return(nil)
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("DeleteOAuth2Client", Anything, Anything).Return(nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					postedClient = &hydra.OAuth2ClientJSON{
+						ClientID:      o.ClientID,
+						Secret:        o.Secret,
+						GrantTypes:    o.GrantTypes,
+						ResponseTypes: o.ResponseTypes,
+						RedirectURIs:  o.RedirectURIs,
+						Audience:      o.Audience,
+						Scope:         o.Scope,
+						Owner:         o.Owner,
+					}
+					return postedClient
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				recFn, requests := SetupTestReconcile(getAPIReconciler(mgr, &mch))
+
+				Expect(add(mgr, recFn)).To(Succeed())
+
+				//Start the manager and the controller
+				stopMgr := StartTestManager(mgr)
+
+				//ensure secret exists
+				secret := apiv1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tstSecretName,
+						Namespace: tstNamespace,
+					},
+					Data: map[string][]byte{
+						controllers.ClientIDKey:     []byte(tstClientID),
+						controllers.ClientSecretKey: []byte(tstSecret),
+					},
+				}
+				err = c.Create(context.TODO(), &secret)
+				Expect(err).NotTo(HaveOccurred())
+
+				instance := testInstance(tstName, tstSecretName)
+				err = c.Create(context.TODO(), instance)
+				// The instance object may not be a valid object because it might be missing some required fields.
+				// Please modify the instance object by adding required fields and then remove the following if statement.
+				if apierrors.IsInvalid(err) {
+					Fail(fmt.Sprintf("failed to create object, got an invalid object error: %v", err))
+					return
+				}
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(requests, timeout).Should(Receive(Equal(*expectedRequest)))
+
+				//Verify the created CR instance status
+				var retrieved hydrav1alpha1.OAuth2Client
+				ok := client.ObjectKey{Name: tstName, Namespace: tstNamespace}
+				err = c.Get(context.TODO(), ok, &retrieved)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(retrieved.Status.ReconciliationError.Code).To(BeEmpty())
+				Expect(retrieved.Status.ReconciliationError.Description).To(BeEmpty())
+
+				// Ensure that the client is registered with the provided credentials
+				Expect(postedClient).NotTo(BeNil())
+				Expect(*postedClient.ClientID).To(Equal(tstClientID))
+
+				// Ensure that secret doesn't have OwnerReference set
+				ok = client.ObjectKey{Name: tstSecretName, Namespace: tstNamespace}
+				err = k8sClient.Get(context.TODO(), ok, &secret)
+				Expect(err).To(BeNil())
+				Expect(len(secret.OwnerReferences)).To(Equal(0))
+
+				//delete instance
+				c.Delete(context.TODO(), instance)
+
+				//Ensure manager is stopped properly
+				stopMgr.Done()
+			})
+
+			It("adopt the existing client on a conflict if the policy says so", func() {
+
+				tstName, tstClientID, tstSecretName := "test-conflict", "testClientID-conflict", "my-secret-conflict"
+				var putClient *hydra.OAuth2ClientJSON
+
+				s := runtime.NewScheme()
+				err := hydrav1alpha1.AddToScheme(s)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = apiv1.AddToScheme(s)
+				Expect(err).NotTo(HaveOccurred())
+
+				mgr, err := manager.New(cfg, manager.Options{Scheme: s,
+					Metrics: server.Options{
+						BindAddress: ":8089",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				c := mgr.GetClient()
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("DeleteOAuth2Client", Anything, Anything).Return(nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, Anything).Return(nil, &hydra.RequestError{StatusCode: 409, Status: "409 Conflict", Reason: "requested ID already exists"})
+				mch.On("PutOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					putClient = o
+					return o
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				recFn, requests := SetupTestReconcile(getAPIReconciler(mgr, mch))
+				go func() {
+					for range requests {
+					}
+				}()
+
+				Expect(add(mgr, recFn)).To(Succeed())
+
+				//Start the manager and the controller
+				stopMgr := StartTestManager(mgr)
+
+				//ensure secret exists
+				secret := apiv1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tstSecretName,
+						Namespace: tstNamespace,
+					},
+					Data: map[string][]byte{
+						controllers.ClientIDKey:     []byte(tstClientID),
+						controllers.ClientSecretKey: []byte(tstSecret),
+					},
+				}
+				err = c.Create(context.TODO(), &secret)
+				Expect(err).NotTo(HaveOccurred())
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.ConflictPolicy = hydrav1alpha1.ConflictPolicyAdopt
+				err = c.Create(context.TODO(), instance)
+				Expect(err).NotTo(HaveOccurred())
+
+				//Verify the existing client is taken over
+				Eventually(func() *hydra.OAuth2ClientJSON {
+					return putClient
+				}, timeout).ShouldNot(BeNil())
+				Expect(*putClient.ClientID).To(Equal(tstClientID))
+				Expect(putClient.Owner).To(Equal(fmt.Sprintf("%s/%s", tstName, tstNamespace)))
+
+				//delete instance
+				c.Delete(context.TODO(), instance)
+
+				//Ensure manager is stopped properly
+				stopMgr.Done()
+			})
+
+			It("add the finalizer despite concurrent edits", func() {
+
+				tstName, tstClientID, tstSecretName := "test-concurrent", "testClientID-concurrent", "my-secret-concurrent"
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				// Edit the resource right before the first update of the
+				// controller, so that update fails with a conflict.
+				wc, err := client.NewWithWatch(cfg, client.Options{Scheme: k8sClient.Scheme()})
+				Expect(err).NotTo(HaveOccurred())
+				edited := false
+				c := interceptor.NewClient(wc, interceptor.Funcs{
+					Update: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+						if _, ok := obj.(*hydrav1alpha1.OAuth2Client); ok && !edited {
+							edited = true
+							var current hydrav1alpha1.OAuth2Client
+							Expect(cl.Get(ctx, client.ObjectKeyFromObject(obj), &current)).To(Succeed())
+							current.Labels = map[string]string{"edited": "true"}
+							Expect(cl.Update(ctx, &current)).To(Succeed())
+						}
+						return cl.Update(ctx, obj, opts...)
+					},
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					c,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(edited).To(BeTrue())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Finalizers).To(ContainElement(controllers.FinalizerName))
+				Expect(retrieved.Labels).To(HaveKeyWithValue("edited", "true"))
+
+				//delete instance
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("emit events and requeue according to the requeue policy", func() {
+
+				tstName, tstClientID, tstSecretName := "test-requeue", "testClientID-requeue", "my-secret-requeue"
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				recorder := record.NewFakeRecorder(10)
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithEventRecorder(recorder),
+					controllers.WithRequeuePolicy(controllers.RequeueAfter(time.Hour)),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(time.Hour))
+				Expect(recorder.Events).To(Receive(Equal("Normal Reconciled client is registered in ORY Hydra")))
+
+				//delete instance
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("select the client factory by the scheme of the hydra URL", func() {
+
+				tstName, tstClientID, tstSecretName := "test-scheme", "testClientID-scheme", "my-secret-scheme"
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				var factorySpec *hydrav1alpha1.OAuth2ClientSpec
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithSchemeClientFactory("unix", func(spec hydrav1alpha1.OAuth2ClientSpec, _ string, _ bool) (hydra.Client, error) {
+						factorySpec = &spec
+						return mch, nil
+					}),
+				)
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.HydraAdmin = hydrav1alpha1.HydraAdmin{URL: "unix:///var/run/hydra.sock"}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(factorySpec).NotTo(BeNil())
+				Expect(factorySpec.HydraAdmin.URL).To(Equal("unix:///var/run/hydra.sock"))
+				mch.AssertCalled(GinkgoT(), "PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{}))
+
+				// a scheme without a registered factory fails
+				unknown := testInstance(tstName+"-unknown", tstSecretName+"-unknown")
+				unknown.Spec.HydraAdmin = hydrav1alpha1.HydraAdmin{URL: "orycloud://project"}
+				Expect(k8sClient.Create(context.TODO(), unknown)).To(Succeed())
+
+				unknownKey := types.NamespacedName{Name: unknown.Name, Namespace: tstNamespace}
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: unknownKey})
+				Expect(err).To(MatchError(ContainSubstring(`no client factory is registered for the scheme "orycloud"`)))
+
+				//delete instances
+				var retrieved hydrav1alpha1.OAuth2Client
+				for _, k := range []types.NamespacedName{key, unknownKey} {
+					Expect(k8sClient.Get(context.TODO(), k, &retrieved)).To(Succeed())
+					retrieved.Finalizers = nil
+					Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+					Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+				}
+			})
+
+			It("validate and merge the metadata", func() {
+
+				tstName, tstClientID, tstSecretName := "test-metadata", "testClientID-metadata", "my-secret-metadata"
+				var putClient *hydra.OAuth2ClientJSON
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(&hydra.OAuth2ClientJSON{
+					ClientID: &tstClientID,
+					Owner:    fmt.Sprintf("%s/%s", tstName, tstNamespace),
+					Metadata: []byte(`{"external":"x","team":"old"}`),
+				}, true, nil)
+				mch.On("PutOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					putClient = o
+					return o
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				secret := apiv1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tstSecretName,
+						Namespace: tstNamespace,
+					},
+					Data: map[string][]byte{
+						controllers.ClientIDKey:     []byte(tstClientID),
+						controllers.ClientSecretKey: []byte(tstSecret),
+					},
+				}
+				Expect(k8sClient.Create(context.TODO(), &secret)).To(Succeed())
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.Metadata = map[string]apiextensionsv1.JSON{"team": {Raw: []byte(`1`)}}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				schema, err := hydra.NewMetadataSchema([]byte(`{"type":"object","properties":{"team":{"type":"string"}}}`))
+				Expect(err).NotTo(HaveOccurred())
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithMetadataSchema(schema),
+					controllers.WithMetadataMerge(true),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+
+				//metadata not matching the schema is rejected
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).To(HaveOccurred())
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusInvalidSpec))
+				Expect(putClient).To(BeNil())
+
+				//valid metadata is merged into the metadata in ORY Hydra
+				retrieved.Spec.Metadata = map[string]apiextensionsv1.JSON{"team": {Raw: []byte(`"payments"`)}}
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(putClient).NotTo(BeNil())
+				Expect(string(putClient.Metadata)).To(MatchJSON(`{"external":"x","team":"payments"}`))
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("refuse hydra URLs which are not allowed", func() {
+
+				tstName, tstClientID, tstSecretName := "test-allowlist", "testClientID-allowlist", "my-secret-allowlist"
+
+				secret := apiv1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tstSecretName,
+						Namespace: tstNamespace,
+					},
+					Data: map[string][]byte{
+						controllers.ClientIDKey:     []byte(tstClientID),
+						controllers.ClientSecretKey: []byte(tstSecret),
+					},
+				}
+				Expect(k8sClient.Create(context.TODO(), &secret)).To(Succeed())
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				allowlist, err := controllers.ParseHydraURLAllowlist([]string{"https://*.ory.svc.cluster.local:4445"})
+				Expect(err).NotTo(HaveOccurred())
+				factoryCalled := false
+				r := controllers.New(
+					k8sClient,
+					&mocks.Client{},
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						factoryCalled = true
+						return &mocks.Client{}, nil
+					}),
+					controllers.WithAllowedHydraURLs(allowlist),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusHydraAddressForbidden))
+				Expect(factoryCalled).To(BeFalse())
+
+				//the address is refused without a Secret too
+				Expect(k8sClient.Delete(context.TODO(), &secret)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusHydraAddressForbidden))
+				Expect(factoryCalled).To(BeFalse())
+
+				//per-resource addresses can be disabled entirely
+				r = controllers.New(
+					k8sClient,
+					&mocks.Client{},
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						factoryCalled = true
+						return &mocks.Client{}, nil
+					}),
+					controllers.WithPerResourceHydraAdminDisabled(true),
+				)
+				retrieved.Spec.HydraAdmin.URL = "https://hydra.ory.svc.cluster.local"
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusHydraAddressForbidden))
+				Expect(retrieved.Status.ReconciliationError.Description).To(ContainSubstring("only uses its default ORY Hydra"))
+				Expect(factoryCalled).To(BeFalse())
+
+				//delete instance
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("refuse scopes which are not allowed", func() {
+
+				tstName, tstClientID, tstSecretName := "test-scope-policy", "testClientID-scope-policy", "my-secret-scope-policy"
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.Scope = "openid admin"
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				mch := &mocks.Client{}
+				policy := controllers.ScopePolicy{Allowed: []string{"openid", "profile"}}
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithScopePolicy(policy),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusScopeNotAllowed))
+				Expect(retrieved.Status.ReconciliationError.Description).To(ContainSubstring("admin"))
+				mch.AssertNotCalled(GinkgoT(), "PostOAuth2Client", Anything, Anything)
+
+				//exempt namespaces may request any scope
+				policy.ExemptNamespaces = []string{tstNamespace}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				r = controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithScopePolicy(policy),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertCalled(GinkgoT(), "PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{}))
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: tstSecretName, Namespace: tstNamespace}})).To(Succeed())
+			})
+
+			It("refuse redirect URIs outside the allowed domains", func() {
+
+				tstName, tstSecretName := "test-redirect-domains", "my-secret-redirect-domains"
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.RedirectURIs = append(instance.Spec.RedirectURIs, "https://attacker.example.org/callback")
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				mch := &mocks.Client{}
+				recorder := record.NewFakeRecorder(10)
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithRedirectURIDomains([]string{"example.com"}),
+					controllers.WithEventRecorder(recorder),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusRedirectURINotAllowed))
+				Expect(retrieved.Status.ReconciliationError.Description).To(ContainSubstring("https://attacker.example.org/callback"))
+				Expect(recorder.Events).To(Receive(ContainSubstring("https://attacker.example.org/callback")))
+				mch.AssertNotCalled(GinkgoT(), "PostOAuth2Client", Anything, Anything)
+
+				//delete instance
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("ignore clients outside of the namespaces", func() {
+
+				tstName, tstSecretName := "test-namespaces", "my-secret-namespaces"
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				mch := &mocks.Client{}
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithNamespaces([]string{"tenant-x", "tenant-y"}),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Finalizers).To(BeEmpty())
+				mch.AssertNotCalled(GinkgoT(), "ListOAuth2Client", Anything)
+				mch.AssertNotCalled(GinkgoT(), "PostOAuth2Client", Anything, Anything)
+
+				//delete instance
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("audit the changes of clients in hydra", func() {
+
+				tstName, tstClientID, tstSecretName := "test-audit", "testClientID-audit", "my-secret-audit"
+
+				var registered []*hydra.OAuth2ClientJSON
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(func(context.Context) []*hydra.OAuth2ClientJSON {
+					return registered
+				}, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+					registered = append(registered, created)
+					return created
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("DeleteOAuth2Client", Anything, tstClientID).Return(nil)
+
+				auditor := &recordingAuditor{}
+				clock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithAuditor(auditor),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithClock(clock),
+				)
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(auditor.records).To(HaveLen(1))
+				Expect(auditor.records[0].Action).To(Equal(audit.ActionCreate))
+				Expect(auditor.records[0].ClientID).To(Equal(tstClientID))
+				Expect(auditor.records[0].Namespace).To(Equal(tstNamespace))
+				Expect(auditor.records[0].Name).To(Equal(tstName))
+				Expect(auditor.records[0].Error).To(BeEmpty())
+				Expect(auditor.records[0].Time).To(Equal(clock.Now()))
+
+				//deleting the instance deletes the client
+				Expect(k8sClient.Delete(context.TODO(), instance)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(auditor.records).To(HaveLen(2))
+				Expect(auditor.records[1].Action).To(Equal(audit.ActionDelete))
+				Expect(auditor.records[1].ClientID).To(Equal(tstClientID))
+
+				Expect(k8sClient.Delete(context.TODO(), &apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: tstSecretName, Namespace: tstNamespace}})).To(Succeed())
+			})
+
+			It("register and delete clients with a scope array", func() {
+
+				tstName, tstClientID, tstSecretName := "test-scope-array", "testClientID-scope-array", "my-secret-scope-array"
+
+				var registered []*hydra.OAuth2ClientJSON
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(func(context.Context) []*hydra.OAuth2ClientJSON {
+					return registered
+				}, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+					registered = append(registered, created)
+					return created
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("DeleteOAuth2Client", Anything, tstClientID).Return(nil)
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.Scope = ""
+				instance.Spec.ScopeArray = []string{"read:users", "https://api.example.com/read"}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(registered).To(HaveLen(1))
+				Expect(registered[0].Scope).To(Equal("https://api.example.com/read read:users"))
+
+				//deleting the instance deletes the client
+				Expect(k8sClient.Delete(context.TODO(), instance)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertCalled(GinkgoT(), "DeleteOAuth2Client", Anything, tstClientID)
+				err = k8sClient.Get(context.TODO(), key, &hydrav1alpha1.OAuth2Client{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+				Expect(k8sClient.Delete(context.TODO(), &apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: tstSecretName, Namespace: tstNamespace}})).To(Succeed())
+			})
+
+			It("refuse clients with both a scope and a scope array", func() {
+
+				tstName, tstSecretName := "test-scope-and-array", "my-secret-scope-and-array"
+
+				mch := &mocks.Client{}
+				recorder := record.NewFakeRecorder(10)
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithEventRecorder(recorder),
+				)
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.Scope = "a b"
+				instance.Spec.ScopeArray = []string{"c"}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusInvalidSpec))
+				mch.AssertNotCalled(GinkgoT(), "PostOAuth2Client", Anything, Anything)
+				for len(recorder.Events) > 0 {
+					<-recorder.Events
+				}
+
+				// the deprecated scope alone is registered with an event
+				retrieved.Spec.ScopeArray = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(&hydra.OAuth2ClientJSON{
+					ClientID: ptr.To("testClientID-scope-and-array"),
+					Secret:   ptr.To(tstSecret),
+				}, nil)
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(recorder.Events).To(Receive(ContainSubstring(controllers.DeprecatedFieldReason)))
+
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("encrypt the client secret in the Secret", func() {
+
+				tstName, tstClientID, tstSecretName := "test-encryption", "testClientID-encryption", "my-secret-encryption"
+
+				var put *hydra.OAuth2ClientJSON
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("GetOAuth2Client", Anything, tstClientID).Return(&hydra.OAuth2ClientJSON{
+					ClientID: &tstClientID,
+					Owner:    fmt.Sprintf("%s/%s", tstName, tstNamespace),
+				}, true, nil)
+				mch.On("PutOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					put = o
+					return o
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithSecretEncryption(xorKeyWrapper(0x5a)),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var secret apiv1.Secret
+				Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: tstSecretName, Namespace: tstNamespace}, &secret)).To(Succeed())
+				Expect(secret.Data[controllers.ClientIDKey]).To(Equal([]byte(tstClientID)))
+				Expect(envelope.IsEncrypted(secret.Data[controllers.ClientSecretKey])).To(BeTrue())
+				decrypted, err := envelope.Decrypt(context.TODO(), xorKeyWrapper(0x5a), secret.Data[controllers.ClientSecretKey])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decrypted).To(Equal([]byte(tstSecret)))
+
+				//updates send the decrypted client secret to hydra
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Spec.Scope = "a b"
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(put).NotTo(BeNil())
+				Expect(*put.Secret).To(Equal(tstSecret))
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &secret)).To(Succeed())
+			})
+
+			It("repair clients which were changed in hydra on resync", func() {
+
+				tstName, tstClientID, tstSecretName := "test-drift", "testClientID-drift", "my-secret-drift"
+
+				var posted, put *hydra.OAuth2ClientJSON
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := *o
+					created.ClientID = &tstClientID
+					created.Secret = ptr.To(tstSecret)
+					posted = &created
+					return posted
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("PutOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					put = o
+					return o
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				recorder := record.NewFakeRecorder(10)
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithEventRecorder(recorder),
+					controllers.WithDriftRepair(true),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(posted).NotTo(BeNil())
+				Expect(recorder.Events).To(Receive(ContainSubstring("Reconciled")))
+
+				//the scope was edited in hydra
+				changed := *posted
+				changed.Secret = nil
+				changed.Scope = "a b c admin"
+				mch.On("GetOAuth2Client", Anything, tstClientID).Return(&changed, true, nil)
+
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(put).NotTo(BeNil())
+				Expect(put.Scope).To(Equal("a b c"))
+				Expect(recorder.Events).To(Receive(Equal("Warning DriftRepaired client was changed in ORY Hydra, restored scope")))
+
+				//delete instance
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("patch the changed fields of clients in hydra", func() {
+
+				tstName, tstClientID, tstSecretName := "test-patch", "testClientID-patch", "my-secret-patch"
+
+				var posted *hydra.OAuth2ClientJSON
+				var patch []hydra.PatchOperation
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := *o
+					created.ClientID = &tstClientID
+					created.Secret = ptr.To(tstSecret)
+					posted = &created
+					return posted
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("PatchOAuth2Client", Anything, tstClientID, IsType([]hydra.PatchOperation{})).Return(func(_ context.Context, _ string, p []hydra.PatchOperation) *hydra.OAuth2ClientJSON {
+					patch = p
+					return posted
+				}, func(_ context.Context, _ string, p []hydra.PatchOperation) error {
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithPatchUpdates(true),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(posted).NotTo(BeNil())
+
+				registered := *posted
+				registered.Secret = nil
+				mch.On("GetOAuth2Client", Anything, tstClientID).Return(&registered, true, nil)
+
+				//change the scope
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Spec.Scope = "a b"
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(patch).To(Equal([]hydra.PatchOperation{
+					{Op: "replace", Path: "/scope", Value: "a b"},
+					{Op: "add", Path: "/client_secret", Value: tstSecret},
+				}))
+				mch.AssertNotCalled(GinkgoT(), "PutOAuth2Client", Anything, Anything)
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("requeue according to the resync interval annotation", func() {
+
+				tstName, tstClientID, tstSecretName := "test-resync-interval", "testClientID-resync-interval", "my-secret-resync-interval"
+
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("GetOAuth2Client", Anything, tstClientID).Return(&hydra.OAuth2ClientJSON{
+					ClientID: &tstClientID,
+					Owner:    fmt.Sprintf("%s/%s", tstName, tstNamespace),
+				}, true, nil)
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Annotations = map[string]string{controllers.ResyncIntervalAnnotation: "5m"}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithRequeuePolicy(controllers.RequeueAfter(time.Hour)),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+
+				//short intervals are raised to the minimum
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Annotations[controllers.ResyncIntervalAnnotation] = "1s"
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				result, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(controllers.MinResyncInterval))
+
+				//invalid intervals fall back to the requeue policy
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Annotations[controllers.ResyncIntervalAnnotation] = "soon"
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				result, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(time.Hour))
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("record the provenance in the metadata of clients", func() {
+
+				tstName, tstClientID, tstSecretName := "test-provenance", "testClientID-provenance", "my-secret-provenance"
+
+				var posted *hydra.OAuth2ClientJSON
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					posted = o
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.Metadata = map[string]apiextensionsv1.JSON{"team": {Raw: []byte(`"payments"`)}, "k8s": {Raw: []byte(`"spoofed"`)}}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithClusterName("eu-west-1"),
+					controllers.WithProvenanceMetadata(true),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(posted).NotTo(BeNil())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				provenance, ok, err := hydra.GetMetadata[hydra.Provenance](posted.Metadata, hydra.ProvenanceMetadataKey)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(provenance).To(Equal(hydra.Provenance{
+					Namespace: tstNamespace,
+					Name:      tstName,
+					UID:       string(retrieved.UID),
+					Cluster:   "eu-west-1",
+				}))
+				team, _, err := hydra.GetMetadata[string](posted.Metadata, "team")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(team).To(Equal("payments"))
+
+				//delete instance
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("take over the client of the previous owner", func() {
+
+				tstName, tstClientID, tstSecretName, ns := "test-transfer", "testClientID-transfer", "my-secret-transfer", "tenant-transfer"
+
+				var put *hydra.OAuth2ClientJSON
+				owner := "test-transfer-old/" + tstNamespace
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, tstClientID).Return(func(context.Context, string) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{ClientID: &tstClientID, Owner: owner}
+				}, true, nil)
+				mch.On("PutOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					put = o
+					owner = o.Owner
+					return o
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				recorder := record.NewFakeRecorder(10)
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithEventRecorder(recorder),
+				)
+
+				//the Secret of the previous owner is kept by a rename
+				secret := &apiv1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tstSecretName,
+						Namespace: tstNamespace,
+						OwnerReferences: []metav1.OwnerReference{{
+							APIVersion: hydrav1alpha1.GroupVersion.String(),
+							Kind:       "OAuth2Client",
+							Name:       "test-transfer-old",
+							UID:        "6f1d2b2c-6b0e-4a3e-8f55-000000000000",
+						}},
+					},
+					Data: map[string][]byte{
+						controllers.ClientIDKey:     []byte(tstClientID),
+						controllers.ClientSecretKey: []byte(tstSecret),
+					},
+				}
+				Expect(k8sClient.Create(context.TODO(), secret)).To(Succeed())
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Annotations = map[string]string{controllers.PreviousOwnerAnnotation: "test-transfer-old/" + tstNamespace}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(put).NotTo(BeNil())
+				Expect(put.Owner).To(Equal(fmt.Sprintf("%s/%s", tstName, tstNamespace)))
+				Expect(recorder.Events).To(Receive(Equal("Normal OwnershipTransferred client was taken over from test-transfer-old/" + tstNamespace)))
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(BeEmpty())
+				Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+				Expect(secret.OwnerReferences).To(HaveLen(1))
+				Expect(secret.OwnerReferences[0].Name).To(Equal(tstName))
+				Expect(secret.OwnerReferences[0].UID).To(Equal(retrieved.UID))
+
+				//moves from other namespaces need the consent of the previous owner
+				Expect(k8sClient.Create(context.TODO(), &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}})).To(Succeed())
+				moved := testInstance(tstName, tstSecretName)
+				moved.Namespace = ns
+				moved.Annotations = map[string]string{controllers.PreviousOwnerAnnotation: fmt.Sprintf("%s/%s", tstName, tstNamespace)}
+				Expect(k8sClient.Create(context.TODO(), moved)).To(Succeed())
+				movedSecret := &apiv1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: tstSecretName, Namespace: ns},
+					Data:       secret.Data,
+				}
+				Expect(k8sClient.Create(context.TODO(), movedSecret)).To(Succeed())
+
+				movedKey := types.NamespacedName{Name: tstName, Namespace: ns}
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: movedKey})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(context.TODO(), movedKey, moved)).To(Succeed())
+				Expect(moved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusConflict))
+				Expect(owner).To(Equal(fmt.Sprintf("%s/%s", tstName, tstNamespace)))
+
+				retrieved.Annotations = map[string]string{controllers.TransferToAnnotation: fmt.Sprintf("%s/%s", tstName, ns)}
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: movedKey})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(owner).To(Equal(fmt.Sprintf("%s/%s", tstName, ns)))
+
+				//delete instances
+				for _, c := range []types.NamespacedName{key, movedKey} {
+					Expect(k8sClient.Get(context.TODO(), c, &retrieved)).To(Succeed())
+					retrieved.Finalizers = nil
+					Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+					Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+				}
+			})
+
+			It("recreate clients which were deleted in hydra", func() {
+
+				tstName, tstClientID, tstSecretName := "test-probe", "testClientID-probe", "my-secret-probe"
+
+				var listed []*hydra.OAuth2ClientJSON
+				var requested *hydra.OAuth2ClientJSON
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(func(context.Context) []*hydra.OAuth2ClientJSON {
+					return listed
+				}, nil)
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					requested = o
+					created := *o
+					created.ClientID = &tstClientID
+					created.Secret = ptr.To(tstSecret)
+					return &created
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				recorder := record.NewFakeRecorder(10)
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithEventRecorder(recorder),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(requested).NotTo(BeNil())
+				Expect(recorder.Events).To(Receive(ContainSubstring("Reconciled")))
+
+				probe := &controllers.ExistenceProbe{Reconciler: r, Log: ctrl.Log.WithName("existence-probe")}
+
+				//the client exists in hydra
+				listed = []*hydra.OAuth2ClientJSON{{ClientID: &tstClientID}}
+				missing, err := probe.Probe(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(missing).NotTo(ContainElement(key))
+
+				//the client was deleted in hydra
+				listed = nil
+				missing, err = probe.Probe(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(missing).To(ContainElement(key))
+
+				requested = nil
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(requested).NotTo(BeNil())
+				Expect(*requested.ClientID).To(Equal(tstClientID))
+				Expect(*requested.Secret).To(Equal(tstSecret))
+				Expect(recorder.Events).To(Receive(ContainSubstring("Reconciled")))
+				Expect(recorder.Events).To(Receive(Equal("Warning Recreated client was deleted in ORY Hydra, registered it again")))
+
+				//delete instance
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("stop retrying clients which exhausted their retries", func() {
+
+				tstName, tstSecretName := "test-max-retries", "my-secret-max-retries"
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, Anything).Return(nil, &hydra.RequestError{StatusCode: 503, Status: "503 Service Unavailable"})
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Annotations = map[string]string{controllers.MaxRetriesAnnotation: "2"}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				recorder := record.NewFakeRecorder(10)
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithEventRecorder(recorder),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				exhausted := func() hydrav1alpha1.ConditionStatus {
+					var retrieved hydrav1alpha1.OAuth2Client
+					Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+					for _, condition := range retrieved.Status.Conditions {
+						if condition.Type == hydrav1alpha1.OAuth2ClientConditionRetriesExhausted {
+							return condition.Status
+						}
+					}
+					return ""
+				}
+
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).To(HaveOccurred())
+				Expect(exhausted()).To(Equal(hydrav1alpha1.ConditionFalse))
+
+				//the last retry is not requeued
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exhausted()).To(Equal(hydrav1alpha1.ConditionTrue))
+				Eventually(recorder.Events).Should(Receive(HavePrefix("Warning RetriesExhausted giving up after 2 failed reconciliations")))
+				mch.AssertNumberOfCalls(GinkgoT(), "PostOAuth2Client", 2)
+
+				//nor reconciled again
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertNumberOfCalls(GinkgoT(), "PostOAuth2Client", 2)
+
+				//until the annotation changes, which counts the failures again
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Annotations[controllers.MaxRetriesAnnotation] = "3"
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				for i := 0; i < 2; i++ {
+					_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+					Expect(err).To(HaveOccurred())
+					Expect(exhausted()).To(Equal(hydrav1alpha1.ConditionFalse))
+				}
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertNumberOfCalls(GinkgoT(), "PostOAuth2Client", 5)
+				Expect(exhausted()).To(Equal(hydrav1alpha1.ConditionTrue))
+
+				//or the spec changes
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Spec.ClientName = "test-max-retries-edited"
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				for i := 0; i < 2; i++ {
+					_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+					Expect(err).To(HaveOccurred())
+					Expect(exhausted()).To(Equal(hydrav1alpha1.ConditionFalse))
+				}
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertNumberOfCalls(GinkgoT(), "PostOAuth2Client", 8)
+				Expect(exhausted()).To(Equal(hydrav1alpha1.ConditionTrue))
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertNumberOfCalls(GinkgoT(), "PostOAuth2Client", 8)
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("not rewrite the status with an error recorded already", func() {
+
+				tstName, tstSecretName := "test-recorded-error", "my-secret-recorded-error"
+				body := "request 1"
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, Anything).Return(nil, func(context.Context, *hydra.OAuth2ClientJSON) error {
+					return &hydra.RequestError{StatusCode: 503, Status: "503 Service Unavailable", Body: body}
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				recorder := record.NewFakeRecorder(10)
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithEventRecorder(recorder),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(recorder.Events).To(Receive(HavePrefix("Warning ")))
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusHydraUnreachable))
+				Expect(retrieved.Status.ReconciliationError.HydraResponse).To(Equal("request 1"))
+				version := retrieved.ResourceVersion
+
+				// only the response of hydra changed
+				body = "request 2"
+				r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.ResourceVersion).To(Equal(version))
+				Expect(retrieved.Status.ReconciliationError.HydraResponse).To(Equal("request 1"))
+				Expect(retrieved.Status.ConsecutiveFailures).To(Equal(int32(1)))
+				Expect(recorder.Events).NotTo(Receive())
+
+				//delete instance
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("reconcile failed clients once hydra is ready again", func() {
+
+				tstName, tstSecretName := "test-recovery", "my-secret-recovery"
+				ready := false
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, Anything).Return(nil, &hydra.RequestError{StatusCode: 503, Status: "503 Service Unavailable"})
+				mch.On("IsReady", Anything).Return(func(context.Context) bool {
+					return ready
+				}, nil)
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusHydraUnreachable))
+
+				watch := &controllers.RecoveryWatch{Reconciler: r, Log: ctrl.Log.WithName("recovery-watch")}
+
+				//hydra is still down
+				recovered, err := watch.Check(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(recovered).NotTo(ContainElement(key))
+
+				//hydra is ready again
+				ready = true
+				recovered, err = watch.Check(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(recovered).To(ContainElement(key))
+
+				//the client is only reconciled once per recovery
+				recovered, err = watch.Check(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(recovered).NotTo(ContainElement(key))
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("export the number of clients by namespace and status", func() {
+
+				tstName, tstClientID, tstSecretName, ns := "test-gauge", "testClientID-gauge", "my-secret-gauge", "tenant-gauge"
+
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := *o
+					created.ClientID = &tstClientID
+					created.Secret = ptr.To(tstSecret)
+					return &created
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				Expect(k8sClient.Create(context.TODO(), &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}})).To(Succeed())
+				instance := testInstance(tstName, tstSecretName)
+				instance.Namespace = ns
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				reg := prometheus.NewRegistry()
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithNamespace(ns),
+					controllers.WithMetricsRegisterer(reg),
+				)
+				gauge := func(status string) float64 {
+					return metricValue(reg, "hydra_maester_clients", map[string]string{"namespace": ns, "status": status})
+				}
+
+				Expect(gauge(controllers.ClientStatusPending)).To(Equal(float64(1)))
+				Expect(gauge(controllers.ClientStatusSynced)).To(Equal(float64(0)))
+
+				key := types.NamespacedName{Name: tstName, Namespace: ns}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gauge(controllers.ClientStatusPending)).To(Equal(float64(0)))
+				Expect(gauge(controllers.ClientStatusSynced)).To(Equal(float64(1)))
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Status.ReconciliationError = hydrav1alpha1.ReconciliationError{Code: hydrav1alpha1.StatusUpdateFailed, Description: "hydra is down"}
+				Expect(k8sClient.Status().Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(gauge(controllers.ClientStatusError)).To(Equal(float64(1)))
+				Expect(gauge(controllers.ClientStatusSynced)).To(Equal(float64(0)))
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("recover from panics of reconciliations", func() {
+
+				tstName, tstSecretName := "test-panic", "my-secret-panic"
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, Anything).Run(func(Arguments) {
+					panic("unexpected payload")
+				}).Return(nil, nil)
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				reg := prometheus.NewRegistry()
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithClusterName("panic"),
+					controllers.WithMetricsRegisterer(reg),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				var err error
+				Expect(func() {
+					_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				}).NotTo(Panic())
+
+				var panicErr *controllers.PanicError
+				Expect(errors.As(err, &panicErr)).To(BeTrue())
+				Expect(panicErr.Value).To(Equal("unexpected payload"))
+				Expect(string(panicErr.Stack)).To(ContainSubstring("registerOAuth2Client"))
+				Expect(metricValue(reg, "hydra_maester_oauth2client_reconcile_panics_total", map[string]string{"cluster": "panic"})).To(Equal(float64(1)))
+
+				//delete instance
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("deduplicate warning events of flapping hydra instances", func() {
+
+				tstName, tstClientID, tstSecretName := "test-dedup", "testClientID-dedup", "my-secret-dedup"
+
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("GetOAuth2Client", Anything, tstClientID).Return(nil, false, errors.New("connection refused"))
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := *o
+					created.ClientID = &tstClientID
+					created.Secret = ptr.To(tstSecret)
+					return &created
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				recorder := record.NewFakeRecorder(10)
+				clock := clocktesting.NewFakeClock(time.Now())
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithEventRecorder(recorder),
+					controllers.WithClock(clock),
+					// failures below the threshold are written to the status
+					// and emit events, recorded ones don't
+					controllers.WithDegradedThreshold(10),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(recorder.Events).To(Receive(ContainSubstring("Reconciled")))
+
+				//hydra keeps failing
+				for i := 0; i < 3; i++ {
+					_, _ = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				}
+				Expect(recorder.Events).To(Receive(HavePrefix("Warning ")))
+				Expect(recorder.Events).NotTo(Receive())
+
+				clock.Step(controllers.DefaultEventDedupWindow)
+				_, _ = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(recorder.Events).To(Receive(HaveSuffix("connection refused (2 similar events suppressed)")))
+
+				//delete instance
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("export the age of client secrets", func() {
+
+				tstName, tstClientID, tstSecretName, ns := "test-secret-age", "testClientID-secret-age", "my-secret-secret-age", "tenant-secret-age"
+
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := *o
+					created.ClientID = &tstClientID
+					created.Secret = ptr.To(tstSecret)
+					return &created
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				Expect(k8sClient.Create(context.TODO(), &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}})).To(Succeed())
+				instance := testInstance(tstName, tstSecretName)
+				instance.Namespace = ns
+				instance.Annotations = map[string]string{controllers.SecretRotationPeriodAnnotation: "24h"}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				reg := prometheus.NewRegistry()
+				clock := clocktesting.NewFakeClock(time.Now().Truncate(time.Second))
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithNamespace(ns),
+					controllers.WithMetricsRegisterer(reg),
+					controllers.WithClock(clock),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: ns}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var secret apiv1.Secret
+				Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: tstSecretName, Namespace: ns}, &secret)).To(Succeed())
+				Expect(secret.Annotations).To(HaveKeyWithValue(controllers.SecretRotatedAtAnnotation, clock.Now().UTC().Format(time.RFC3339)))
+
+				clock.Step(time.Hour)
+				labels := map[string]string{"namespace": ns, "oauth2client": tstName, "secret": tstSecretName}
+				Expect(metricValue(reg, "hydra_maester_client_secret_age_seconds", labels)).To(Equal(time.Hour.Seconds()))
+				Expect(metricValue(reg, "hydra_maester_client_secret_rotation_due_seconds", labels)).To(Equal((23 * time.Hour).Seconds()))
+
+				//delete instance
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("send the identity of OAuth2Clients as baggage to hydra", func() {
+
+				tstName, tstSecretName := "test-baggage", "my-secret-baggage"
+
+				var baggage []string
+				s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					baggage = append(baggage, r.Header.Get("Baggage"))
+					if r.Method == http.MethodPost {
+						w.WriteHeader(http.StatusCreated)
+						w.Write([]byte(`{"client_id":"testClientID-baggage","client_secret":"testSecret"}`))
+						return
+					}
+					w.Write([]byte(`[]`))
+				}))
+				defer s.Close()
+				hc, err := admin.New(s.URL + "/admin/clients")
+				Expect(err).NotTo(HaveOccurred())
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					hc,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return hc, nil
+					}),
+					controllers.WithRequestBaggage(true),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(baggage).NotTo(BeEmpty())
+				for _, b := range baggage {
+					Expect(b).To(Equal(fmt.Sprintf("%s=%s,%s=%s,%s=%s",
+						controllers.BaggageNamespaceKey, tstNamespace,
+						controllers.BaggageNameKey, tstName,
+						controllers.BaggageUIDKey, retrieved.UID)))
+				}
+
+				//delete instance
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("export workqueue metrics labeled with the controller name", func() {
+
+				tstName, tstClientID, tstSecretName := "test-workqueue", "testClientID-workqueue", "my-secret-workqueue"
+
+				s := runtime.NewScheme()
+				Expect(hydrav1alpha1.AddToScheme(s)).To(Succeed())
+				Expect(apiv1.AddToScheme(s)).To(Succeed())
+				mgr, err := manager.New(cfg, manager.Options{Scheme: s, Metrics: server.Options{BindAddress: "0"}})
+				Expect(err).NotTo(HaveOccurred())
+
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := *o
+					created.ClientID = &tstClientID
+					created.Secret = ptr.To(tstSecret)
+					return &created
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("GetOAuth2Client", Anything, tstClientID).Return(&hydra.OAuth2ClientJSON{ClientID: &tstClientID}, true, nil)
+
+				r := controllers.New(
+					mgr.GetClient(),
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				Expect(r.SetupWithManager(mgr)).To(Succeed())
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				go func() {
+					defer GinkgoRecover()
+					Expect(mgr.Start(ctx)).To(Succeed())
+				}()
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				Eventually(func() error {
+					return k8sClient.Get(context.TODO(), types.NamespacedName{Name: tstSecretName, Namespace: tstNamespace}, &apiv1.Secret{})
+				}, timeout).Should(Succeed())
+
+				labels := map[string]string{"name": controllers.ControllerName}
+				Expect(metricValue(ctrlmetrics.Registry, "workqueue_depth", labels)).To(BeNumerically(">=", 0))
+				Expect(metricValue(ctrlmetrics.Registry, "workqueue_retries_total", labels)).To(BeNumerically(">=", 0))
+				Expect(metricValue(ctrlmetrics.Registry, "workqueue_longest_running_processor_seconds", labels)).To(BeNumerically(">=", 0))
+				Expect(metricValue(ctrlmetrics.Registry, "controller_runtime_reconcile_total", map[string]string{"controller": controllers.ControllerName})).To(BeNumerically(">", 0))
+
+				//delete instance
+				var retrieved hydrav1alpha1.OAuth2Client
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("log one summary with the changes of each reconciliation", func() {
+
+				tstName, tstClientID, tstSecretName := "test-summary", "testClientID-summary", "my-secret-summary"
+
+				var lines []string
+				log := funcr.NewJSON(func(obj string) {
+					lines = append(lines, obj)
+				}, funcr.Options{Verbosity: 1})
+				summaries := func() []map[string]interface{} {
+					var found []map[string]interface{}
+					for _, l := range lines {
+						var entry map[string]interface{}
+						Expect(json.Unmarshal([]byte(l), &entry)).To(Succeed())
+						if entry["msg"] == "reconciled client" {
+							found = append(found, entry)
+						}
+					}
+					return found
+				}
+
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := *o
+					created.ClientID = &tstClientID
+					created.Secret = ptr.To(tstSecret)
+					return &created
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("GetOAuth2Client", Anything, tstClientID).Return(&hydra.OAuth2ClientJSON{ClientID: &tstClientID}, true, nil)
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					log,
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(summaries()).To(HaveLen(1))
+				created := summaries()[0]
+				Expect(created["level"]).To(BeEquivalentTo(0))
+				Expect(created["action"]).To(Equal("create"))
+				Expect(created["clientID"]).To(Equal(tstClientID))
+				Expect(created["changes"]).To(ContainElement(HaveKeyWithValue("field", "scope")))
+				for _, l := range lines {
+					Expect(l).NotTo(ContainSubstring(tstSecret))
+				}
+
+				// the unchanged OAuth2Client is only logged at the debug level
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(summaries()).To(HaveLen(2))
+				unchanged := summaries()[1]
+				Expect(unchanged["level"]).To(BeEquivalentTo(1))
+				Expect(unchanged["action"]).To(Equal(controllers.ActionNone))
+
+				//delete instance
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("merge the metadata of the ConfigMap referenced by metadataFrom", func() {
+
+				tstName, tstClientID, tstSecretName := "test-metadata-from", "testClientID-metadata-from", "my-secret-metadata-from"
+				var posted *hydra.OAuth2ClientJSON
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					posted = o
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				Expect(k8sClient.Create(context.TODO(), &apiv1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "platform-metadata",
+						Namespace: tstNamespace,
+					},
+					Data: map[string]string{
+						"metadata.yaml": "costCenter: cc-42\nteam: platform\nlabels:\n  tier: gold\n",
+					},
+				})).To(Succeed())
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.Metadata = map[string]apiextensionsv1.JSON{"team": {Raw: []byte(`"payments"`)}, "labels": {Raw: []byte(`{"app":"checkout"}`)}}
+				instance.Spec.MetadataFrom = &hydrav1alpha1.MetadataSource{
+					ConfigMapRef: &hydrav1alpha1.ConfigMapKeyRef{Name: "platform-metadata", Key: "metadata.yaml"},
+				}
+				missing := testInstance(tstName+"-missing", tstSecretName+"-missing")
+				missing.Spec.MetadataFrom = &hydrav1alpha1.MetadataSource{
+					ConfigMapRef: &hydrav1alpha1.ConfigMapKeyRef{Name: "missing-metadata"},
+				}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				Expect(k8sClient.Create(context.TODO(), missing)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				key := types.NamespacedName{Name: instance.Name, Namespace: tstNamespace}
+				missingKey := types.NamespacedName{Name: missing.Name, Namespace: tstNamespace}
+
+				//spec.metadata wins over the referenced metadata
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(posted).NotTo(BeNil())
+				Expect(string(posted.Metadata)).To(MatchJSON(`{"costCenter":"cc-42","team":"payments","labels":{"tier":"gold","app":"checkout"}}`))
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.MetadataFromVersion).NotTo(BeEmpty())
+				Expect(json.Marshal(retrieved.Spec.Metadata)).To(MatchJSON(`{"team":"payments","labels":{"app":"checkout"}}`))
+
+				//missing ConfigMaps are retried
+				posted = nil
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: missingKey})
+				Expect(err).To(HaveOccurred())
+				Expect(posted).To(BeNil())
+				Expect(k8sClient.Get(context.TODO(), missingKey, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusInvalidSpec))
+
+				//delete instances
+				for _, k := range []types.NamespacedName{key, missingKey} {
+					Expect(k8sClient.Get(context.TODO(), k, &retrieved)).To(Succeed())
+					retrieved.Finalizers = nil
+					Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+					Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+				}
+			})
+
+			It("set the degraded condition after consecutive failures", func() {
+
+				tstName, tstClientID, tstSecretName := "test-degraded", "testClientID-degraded", "my-secret-degraded"
+				failures := 3
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					if failures > 0 {
+						return nil
+					}
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					if failures > 0 {
+						failures--
+						return errors.New("error")
+					}
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithDegradedThreshold(2),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				degraded := func() hydrav1alpha1.OAuth2ClientCondition {
+					var retrieved hydrav1alpha1.OAuth2Client
+					Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+					for _, condition := range retrieved.Status.Conditions {
+						if condition.Type == hydrav1alpha1.OAuth2ClientConditionDegraded {
+							return condition
+						}
+					}
+					Fail("no Degraded condition")
+					return hydrav1alpha1.OAuth2ClientCondition{}
+				}
+
+				r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(degraded().Status).To(Equal(hydrav1alpha1.ConditionFalse))
+
+				r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(degraded().Status).To(Equal(hydrav1alpha1.ConditionTrue))
+				Expect(degraded().Reason).To(Equal(hydrav1alpha1.ReasonHydraError))
+
+				// the same error is not counted past the threshold
+				r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ConsecutiveFailures).To(Equal(int32(2)))
+				Expect(degraded().Status).To(Equal(hydrav1alpha1.ConditionTrue))
+
+				// a successful reconciliation resets the failures
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ConsecutiveFailures).To(BeZero())
+				Expect(degraded().Status).To(Equal(hydrav1alpha1.ConditionFalse))
+				Expect(degraded().Reason).To(BeEmpty())
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("notify when OAuth2 clients become degraded and when they recover", func() {
+
+				tstName, tstClientID, tstSecretName := "test-notify", "testClientID-notify", "my-secret-notify"
+				failures := 3
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					if failures > 0 {
+						return nil
+					}
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					if failures > 0 {
+						failures--
+						return errors.New("error")
+					}
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				notifier := &recordingNotifier{}
+				clock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithDegradedThreshold(2),
+					controllers.WithNotifier(notifier),
+					controllers.WithClock(clock),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+
+				r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(notifier.notifications).To(BeEmpty())
+
+				r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(notifier.notifications).To(HaveLen(1))
+				Expect(notifier.notifications[0].Transition).To(Equal(notify.TransitionFailed))
+				Expect(notifier.notifications[0].Time).To(Equal(clock.Now()))
+				Expect(notifier.notifications[0].Namespace).To(Equal(tstNamespace))
+				Expect(notifier.notifications[0].Name).To(Equal(tstName))
+				Expect(notifier.notifications[0].Reason).To(Equal(string(hydrav1alpha1.ReasonHydraError)))
+				Expect(notifier.notifications[0].ConsecutiveFailures).To(Equal(int32(2)))
+
+				// failures past the threshold are not notified again
+				r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(notifier.notifications).To(HaveLen(1))
+
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(notifier.notifications).To(HaveLen(2))
+				Expect(notifier.notifications[1].Transition).To(Equal(notify.TransitionRecovered))
+				Expect(notifier.notifications[1].ConsecutiveFailures).To(Equal(int32(2)))
+
+				//delete instance
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("write the relation tuples of clients to ORY Keto", func() {
+
+				tstName, tstClientID, tstSecretName := "test-keto", "testClientID-keto", "my-secret-keto"
+
+				var registered []*hydra.OAuth2ClientJSON
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, tstClientID).Return(func(context.Context, string) *hydra.OAuth2ClientJSON {
+					return registered[0]
+				}, true, nil)
+				mch.On("ListOAuth2Client", Anything).Return(func(context.Context) []*hydra.OAuth2ClientJSON {
+					return registered
+				}, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					created := &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+					registered = append(registered, created)
+					return created
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("PutOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return o
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+				mch.On("DeleteOAuth2Client", Anything, tstClientID).Return(nil)
+
+				writer := &recordingKetoWriter{tuples: map[string]bool{}}
+				newReconciler := func(template string) *controllers.OAuth2ClientReconciler {
+					tmpl, err := keto.ParseTemplate(template)
+					Expect(err).NotTo(HaveOccurred())
+					return controllers.New(
+						k8sClient,
+						mch,
+						ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+						controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+							return mch, nil
+						}),
+						controllers.WithRelationTuples(writer, tmpl),
+					)
+				}
+				owners := fmt.Sprintf("OAuth2Client:%s#owners@Team:%s#members", tstClientID, tstNamespace)
+				viewers := fmt.Sprintf("OAuth2Client:%s#viewers@Team:%s#members", tstClientID, tstNamespace)
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+
+				r := newReconciler("OAuth2Client:{{.ClientID}}#owners@Team:{{.Namespace}}#members")
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(writer.tuples).To(Equal(map[string]bool{owners: true}))
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.RelationTuples).To(Equal([]string{owners}))
+
+				// unchanged tuples are not written again
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(writer.writes).To(Equal(1))
+
+				// changed templates replace the tuples
+				r = newReconciler("OAuth2Client:{{.ClientID}}#viewers@Team:{{.Namespace}}#members")
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(writer.tuples).To(Equal(map[string]bool{viewers: true}))
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.RelationTuples).To(Equal([]string{viewers}))
+
+				// failed writes are recorded in the status
+				writer.fail = true
+				r = newReconciler("OAuth2Client:{{.ClientID}}#editors@Team:{{.Namespace}}#members")
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusRelationTuplesFailed))
+				Expect(retrieved.Status.RelationTuples).To(Equal([]string{viewers}))
+				writer.fail = false
+
+				//deleting the instance deletes the tuples with the client
+				r = newReconciler("OAuth2Client:{{.ClientID}}#viewers@Team:{{.Namespace}}#members")
+				Expect(k8sClient.Delete(context.TODO(), instance)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertCalled(GinkgoT(), "DeleteOAuth2Client", Anything, tstClientID)
+				Expect(writer.tuples).To(BeEmpty())
+
+				Expect(k8sClient.Delete(context.TODO(), &apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: tstSecretName, Namespace: tstNamespace}})).To(Succeed())
+			})
+
+			It("complete reconciliations in flight when the manager stops", func() {
+
+				tstName, tstClientID, tstSecretName := "test-shutdown", "testClientID-shutdown", "my-secret-shutdown"
+				ctx, stop := context.WithCancel(context.TODO())
+				var posted int
+				var postErr error
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(reqCtx context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					// the manager stops while the client is created
+					stop()
+					posted++
+					postErr = reqCtx.Err()
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithShutdownGracePeriod(time.Minute),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(posted).To(Equal(1))
+				Expect(postErr).NotTo(HaveOccurred())
+
+				// the secret and status were written after the manager stopped
+				var secret apiv1.Secret
+				Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: tstSecretName, Namespace: tstNamespace}, &secret)).To(Succeed())
+				Expect(secret.Data[controllers.ClientIDKey]).To(Equal([]byte(tstClientID)))
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(BeEmpty())
+
+				// no reconciliation is started once the manager stopped
+				retrieved.Spec.Scope = "a b c d"
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(posted).To(Equal(1))
+				mch.AssertNotCalled(GinkgoT(), "PutOAuth2Client", Anything, Anything)
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("name clients without a client name after their OAuth2Client", func() {
+
+				tstName, tstClientID, tstSecretName := "test-client-name", "testClientID-client-name", "my-secret-client-name"
+				var posted []*hydra.OAuth2ClientJSON
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
 				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
-				mch.On("GetOAuth2Client", Anything).Return(nil, false, nil)
-				mch.On("PostOAuth2Client", AnythingOfType("*hydra.OAuth2ClientJSON")).Return(func(o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
-					postedClient = &hydra.OAuth2ClientJSON{
-						ClientID:      o.ClientID,
-						Secret:        o.Secret,
-						GrantTypes:    o.GrantTypes,
-						ResponseTypes: o.ResponseTypes,
-						RedirectURIs:  o.RedirectURIs,
-						Audience:      o.Audience,
-						Scope:         o.Scope,
-						Owner:         o.Owner,
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					posted = append(posted, o)
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Owner:    o.Owner,
 					}
-					return postedClient
-				}, func(o *hydra.OAuth2ClientJSON) error {
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
 					return nil
 				})
 
-				recFn, requests := SetupTestReconcile(getAPIReconciler(mgr, &mch))
+				named := testInstance(tstName+"-named", tstSecretName+"-named")
+				named.Spec.ClientName = "Checkout"
+				unnamed := testInstance(tstName, tstSecretName)
+				Expect(k8sClient.Create(context.TODO(), named)).To(Succeed())
+				Expect(k8sClient.Create(context.TODO(), unnamed)).To(Succeed())
 
-				Expect(add(mgr, recFn)).To(Succeed())
+				tmpl, err := controllers.ParseClientNameTemplate(controllers.DefaultClientNameTemplate)
+				Expect(err).NotTo(HaveOccurred())
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithClientNameTemplate(tmpl),
+				)
+				keys := []types.NamespacedName{
+					{Name: named.Name, Namespace: tstNamespace},
+					{Name: unnamed.Name, Namespace: tstNamespace},
+				}
+				for _, key := range keys {
+					_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+					Expect(err).NotTo(HaveOccurred())
+				}
 
-				//Start the manager and the controller
-				stopMgr := StartTestManager(mgr)
+				Expect(posted).To(HaveLen(2))
+				Expect(posted[0].ClientName).To(Equal("Checkout"))
+				Expect(posted[1].ClientName).To(Equal(tstNamespace + "/" + tstName))
 
-				//ensure secret exists
-				secret := apiv1.Secret{
+				//delete instances
+				var retrieved hydrav1alpha1.OAuth2Client
+				for _, key := range keys {
+					Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+					retrieved.Finalizers = nil
+					Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+					Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+				}
+			})
+
+			It("merge the spec defaults of the namespace defaults ConfigMap", func() {
+
+				tstName, tstClientID, tstSecretName, ns := "test-spec-defaults", "testClientID-spec-defaults", "my-secret-spec-defaults", "tenant-defaults"
+				var putClient *hydra.OAuth2ClientJSON
+
+				Expect(k8sClient.Create(context.TODO(), &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}})).To(Succeed())
+				defaults := &apiv1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      controllers.NamespaceDefaultsConfigMap,
+						Namespace: ns,
+					},
+					Data: map[string]string{
+						controllers.NamespaceDefaultsScopeKey:                                                            "openid offline",
+						controllers.NamespaceDefaultsGrantTypesKey:                                                       "authorization_code, refresh_token",
+						controllers.NamespaceDefaultsAudienceKey:                                                         "https://api.example.com",
+						controllers.NamespaceDefaultsTokenLifespansPrefix + "refresh_token_grant_refresh_token_lifespan": "720h",
+					},
+				}
+				Expect(k8sClient.Create(context.TODO(), defaults)).To(Succeed())
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(&hydra.OAuth2ClientJSON{
+					ClientID: &tstClientID,
+					Owner:    fmt.Sprintf("%s/%s", tstName, ns),
+				}, true, nil)
+				mch.On("PutOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					putClient = o
+					return o
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				Expect(k8sClient.Create(context.TODO(), &apiv1.Secret{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      tstSecretName,
-						Namespace: tstNamespace,
+						Namespace: ns,
 					},
 					Data: map[string][]byte{
 						controllers.ClientIDKey:     []byte(tstClientID),
 						controllers.ClientSecretKey: []byte(tstSecret),
 					},
-				}
-				err = c.Create(context.TODO(), &secret)
-				Expect(err).NotTo(HaveOccurred())
+				})).To(Succeed())
 
 				instance := testInstance(tstName, tstSecretName)
-				err = c.Create(context.TODO(), instance)
-				// The instance object may not be a valid object because it might be missing some required fields.
-				// Please modify the instance object by adding required fields and then remove the following if statement.
-				if apierrors.IsInvalid(err) {
-					Fail(fmt.Sprintf("failed to create object, got an invalid object error: %v", err))
-					return
-				}
+				instance.Namespace = ns
+				instance.Spec.Scope = ""
+				instance.Spec.GrantTypes = nil
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: ns}
+
+				//the defaults fill the empty fields only
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
 				Expect(err).NotTo(HaveOccurred())
-				Eventually(requests, timeout).Should(Receive(Equal(*expectedRequest)))
+				Expect(putClient).NotTo(BeNil())
+				Expect(putClient.Scope).To(Equal("offline openid"))
+				Expect(putClient.GrantTypes).To(Equal([]string{"authorization_code", "refresh_token"}))
+				Expect(putClient.Audience).To(Equal([]string{"audience-a"}))
+				Expect(putClient.RefreshTokenGrantRefreshTokenLifespan).To(Equal("720h"))
 
-				//Verify the created CR instance status
 				var retrieved hydrav1alpha1.OAuth2Client
-				ok := client.ObjectKey{Name: tstName, Namespace: tstNamespace}
-				err = c.Get(context.TODO(), ok, &retrieved)
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(BeEmpty())
+				Expect(retrieved.Status.AppliedDefaults).To(Equal(map[string]string{
+					controllers.NamespaceDefaultsScopeKey:                                                            "openid offline",
+					controllers.NamespaceDefaultsGrantTypesKey:                                                       "authorization_code,refresh_token",
+					controllers.NamespaceDefaultsTokenLifespansPrefix + "refresh_token_grant_refresh_token_lifespan": "720h",
+				}))
+				Expect(retrieved.Spec.Scope).To(BeEmpty())
+				Expect(retrieved.Spec.ScopeArray).To(BeEmpty())
+				Expect(retrieved.Spec.GrantTypes).To(BeEmpty())
+
+				//unchanged defaults don't update the client again
+				putClient = nil
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(putClient).To(BeNil())
+
+				//changed defaults update the client
+				defaults.Data[controllers.NamespaceDefaultsScopeKey] = "openid"
+				Expect(k8sClient.Update(context.TODO(), defaults)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(putClient).NotTo(BeNil())
+				Expect(putClient.Scope).To(Equal("openid"))
+
+				//invalid defaults are reported
+				defaults.Data[controllers.NamespaceDefaultsGrantTypesKey] = "password"
+				Expect(k8sClient.Update(context.TODO(), defaults)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusInvalidSpec))
+
+				//delete instance
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("use the hydra endpoint of the namespace defaults ConfigMap", func() {
+
+				tstName, tstClientID, tstSecretName, ns := "test-ns-defaults", "testClientID-ns-defaults", "my-secret-ns-defaults", "tenant-a"
+
+				Expect(k8sClient.Create(context.TODO(), &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}})).To(Succeed())
+				Expect(k8sClient.Create(context.TODO(), &apiv1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      controllers.NamespaceDefaultsConfigMap,
+						Namespace: ns,
+					},
+					Data: map[string]string{
+						controllers.NamespaceDefaultsURLKey:      "http://hydra-tenant-a",
+						controllers.NamespaceDefaultsPortKey:     "4445",
+						controllers.NamespaceDefaultsEndpointKey: "/admin/clients",
+					},
+				})).To(Succeed())
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Namespace = ns
+				instance.Spec.HydraAdmin = hydrav1alpha1.HydraAdmin{}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				var used hydrav1alpha1.HydraAdmin
+				r := controllers.New(
+					k8sClient,
+					nil,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(spec hydrav1alpha1.OAuth2ClientSpec, _ string, _ bool) (hydra.Client, error) {
+						used = spec.HydraAdmin
+						return mch, nil
+					}),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: ns}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
 				Expect(err).NotTo(HaveOccurred())
+
+				Expect(used.URL).To(Equal("http://hydra-tenant-a"))
+				Expect(used.Port).To(Equal(4445))
+				Expect(used.Endpoint).To(Equal("/admin/clients"))
+				mch.AssertCalled(GinkgoT(), "PostOAuth2Client", Anything, Anything)
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
 				Expect(retrieved.Status.ReconciliationError.Code).To(BeEmpty())
-				Expect(retrieved.Status.ReconciliationError.Description).To(BeEmpty())
+				Expect(retrieved.Spec.HydraAdmin.URL).To(BeEmpty())
 
-				// Ensure that secret doesn't have OwnerReference set
-				ok = client.ObjectKey{Name: tstSecretName, Namespace: tstNamespace}
-				err = k8sClient.Get(context.TODO(), ok, &secret)
-				Expect(err).To(BeNil())
-				Expect(len(secret.OwnerReferences)).To(Equal(0))
+				//delete instance
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
+
+			It("fail over to the fallback hydra instance while the primary is not ready", func() {
+
+				tstName, tstClientID, tstSecretName := "test-failover", "testClientID-failover", "my-secret-failover"
+
+				primary := &mocks.Client{}
+				primary.On("IsReady", Anything).Return(false, nil)
+
+				fallback := &mocks.Client{}
+				fallback.On("GetVersion", Anything).Return("v2.2.0", nil)
+				fallback.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				fallback.On("ListOAuth2Client", Anything).Return(nil, nil)
+				fallback.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Scope:    o.Scope,
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.HydraAdmin.FallbackURL = "http://hydra-admin-fallback"
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					nil,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(spec hydrav1alpha1.OAuth2ClientSpec, _ string, _ bool) (hydra.Client, error) {
+						if spec.HydraAdmin.URL == "http://hydra-admin-fallback" {
+							return fallback, nil
+						}
+						return primary, nil
+					}),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				primary.AssertNotCalled(GinkgoT(), "PostOAuth2Client", Anything, Anything)
+				fallback.AssertCalled(GinkgoT(), "PostOAuth2Client", Anything, Anything)
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(BeEmpty())
+				Expect(retrieved.Status.HydraEndpoint).To(Equal("http://hydra-admin-fallback:4445"))
 
 				//delete instance
-				c.Delete(context.TODO(), instance)
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
 
-				//Ensure manager is stopped properly
-				stopMgr.Done()
+			It("write the sync state of OAuth2 clients to the sync report ConfigMap", func() {
+
+				tstClientID := "testClientID-report"
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					if o.ClientName == "fail" {
+						return nil
+					}
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					if o.ClientName == "fail" {
+						return errors.New("error")
+					}
+					return nil
+				})
+
+				synced := testInstance("test-report-synced", "my-secret-report-synced")
+				failed := testInstance("test-report-failed", "my-secret-report-failed")
+				failed.Spec.ClientName = "fail"
+				pending := testInstance("test-report-pending", "my-secret-report-pending")
+				pending.Spec.HydraAdmin = hydrav1alpha1.HydraAdmin{}
+				for _, instance := range []*hydrav1alpha1.OAuth2Client{synced, failed, pending} {
+					Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				}
+
+				clock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithClusterName("local"),
+					controllers.WithClock(clock),
+				)
+				for _, instance := range []*hydrav1alpha1.OAuth2Client{synced, failed} {
+					r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: tstNamespace}})
+				}
+
+				report := &controllers.SyncReport{
+					Client:      k8sClient,
+					Reconcilers: []*controllers.OAuth2ClientReconciler{r},
+					ConfigMap:   types.NamespacedName{Name: "hydra-maester-report", Namespace: tstNamespace},
+					Log:         ctrl.Log.WithName("sync-report"),
+				}
+				Expect(report.Write(context.TODO())).To(Succeed())
+
+				var cm apiv1.ConfigMap
+				Expect(k8sClient.Get(context.TODO(), report.ConfigMap, &cm)).To(Succeed())
+				Expect(cm.Annotations).To(HaveKeyWithValue(controllers.SyncReportTimeAnnotation, "2024-05-01T12:00:00Z"))
+				var data controllers.SyncReportData
+				Expect(json.Unmarshal([]byte(cm.Data[controllers.SyncReportKey]), &data)).To(Succeed())
+
+				entries := map[string]controllers.SyncReportEntry{}
+				for _, entry := range data.Clients {
+					entries[entry.Name] = entry
+				}
+				Expect(entries).To(HaveKey(synced.Name))
+				Expect(entries[synced.Name].State).To(Equal(controllers.SyncStateSynced))
+				Expect(entries[synced.Name].Cluster).To(Equal("local"))
+				Expect(entries[synced.Name].HydraEndpoint).To(Equal("http://hydra-admin:4445"))
+				Expect(entries[failed.Name].State).To(Equal(controllers.SyncStateFailed))
+				Expect(entries[failed.Name].LastError).NotTo(BeNil())
+				Expect(entries[failed.Name].LastError.Code).To(Equal(hydrav1alpha1.StatusRegistrationFailed))
+				Expect(entries[pending.Name].State).To(Equal(controllers.SyncStatePending))
+				Expect(entries[pending.Name].HydraEndpoint).To(Equal("default"))
+				Expect(data.Summary.Total).To(Equal(len(data.Clients)))
+				Expect(data.Summary.Failed).To(BeNumerically(">=", 1))
+
+				// an unchanged report is not written again
+				written := cm.ResourceVersion
+				Expect(report.Write(context.TODO())).To(Succeed())
+				Expect(k8sClient.Get(context.TODO(), report.ConfigMap, &cm)).To(Succeed())
+				Expect(cm.ResourceVersion).To(Equal(written))
+
+				// reports exceeding the maximum size omit the synced clients first
+				report.MaxSize = len(cm.Data[controllers.SyncReportKey]) - 50
+				Expect(report.Write(context.TODO())).To(Succeed())
+				Expect(k8sClient.Get(context.TODO(), report.ConfigMap, &cm)).To(Succeed())
+				Expect(len(cm.Data[controllers.SyncReportKey])).To(BeNumerically("<=", report.MaxSize))
+				var truncated controllers.SyncReportData
+				Expect(json.Unmarshal([]byte(cm.Data[controllers.SyncReportKey]), &truncated)).To(Succeed())
+				Expect(truncated.Omitted).To(BeNumerically(">=", 1))
+				Expect(truncated.Summary).To(Equal(data.Summary))
+				Expect(truncated.Clients).To(ContainElement(HaveField("Name", failed.Name)))
+
+				//delete instances
+				Expect(k8sClient.Delete(context.TODO(), &cm)).To(Succeed())
+				for _, instance := range []*hydrav1alpha1.OAuth2Client{synced, failed, pending} {
+					var retrieved hydrav1alpha1.OAuth2Client
+					Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: tstNamespace}, &retrieved)).To(Succeed())
+					retrieved.Finalizers = nil
+					Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+					Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+				}
+			})
+
+			It("serve the sync state of OAuth2 clients with the state API", func() {
+
+				tstClientID := "testClientID-state-api"
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				synced := testInstance("test-state-api-synced", "my-secret-state-api-synced")
+				pending := testInstance("test-state-api-pending", "my-secret-state-api-pending")
+				for _, instance := range []*hydrav1alpha1.OAuth2Client{synced, pending} {
+					Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				}
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: synced.Name, Namespace: tstNamespace}})
+				Expect(err).NotTo(HaveOccurred())
+
+				handler := controllers.StateAPIHandler([]*controllers.OAuth2ClientReconciler{r}, "token", ctrl.Log.WithName("state-api"))
+				get := func(target, token string) *httptest.ResponseRecorder {
+					req := httptest.NewRequest(http.MethodGet, target, nil)
+					if token != "" {
+						req.Header.Set("Authorization", "Bearer "+token)
+					}
+					rec := httptest.NewRecorder()
+					handler.ServeHTTP(rec, req)
+					return rec
+				}
+
+				Expect(get("/api/v1/clients", "").Code).To(Equal(http.StatusUnauthorized))
+				Expect(get("/api/v1/clients", "wrong").Code).To(Equal(http.StatusUnauthorized))
+
+				rec := get("/api/v1/clients?namespace="+tstNamespace+"&state=pending", "token")
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				var data controllers.SyncReportData
+				Expect(json.Unmarshal(rec.Body.Bytes(), &data)).To(Succeed())
+				var names []string
+				for _, entry := range data.Clients {
+					Expect(entry.State).To(Equal(controllers.SyncStatePending))
+					names = append(names, entry.Name)
+				}
+				Expect(names).To(ContainElement(pending.Name))
+				Expect(names).NotTo(ContainElement(synced.Name))
+				Expect(data.Summary.Total).To(Equal(len(data.Clients)))
+
+				rec = get("/api/v1/clients/"+tstNamespace+"/"+synced.Name, "token")
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				var entry controllers.SyncReportEntry
+				Expect(json.Unmarshal(rec.Body.Bytes(), &entry)).To(Succeed())
+				Expect(entry.State).To(Equal(controllers.SyncStateSynced))
+				Expect(entry.HydraEndpoint).To(Equal("http://hydra-admin:4445"))
+
+				Expect(get("/api/v1/clients/"+tstNamespace+"/missing", "token").Code).To(Equal(http.StatusNotFound))
+
+				//delete instances
+				for _, instance := range []*hydrav1alpha1.OAuth2Client{synced, pending} {
+					var retrieved hydrav1alpha1.OAuth2Client
+					Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: tstNamespace}, &retrieved)).To(Succeed())
+					retrieved.Finalizers = nil
+					Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+					Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+				}
+			})
+
+			It("report clients in ORY Hydra which are not owned by an OAuth2Client", func() {
+
+				fake := hydratest.NewServer()
+				defer fake.Close()
+				fake.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("managed"), Owner: "my-client/default@prod"})
+				fake.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("managed-legacy"), Owner: "my-client/default"})
+				fake.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("shadow-b"), ClientName: "cli", Owner: "alice"})
+				fake.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("shadow-a"), Scope: "openid"})
+				fake.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("shadow-c"), Owner: "my-client/default@staging"})
+				fake.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("shadow-d"), Owner: "my-client/default/remote@prod"})
+
+				reg := prometheus.NewRegistry()
+				gauge, err := controllers.UnmanagedClientsGauge(reg)
+				Expect(err).NotTo(HaveOccurred())
+				recorder := record.NewFakeRecorder(10)
+				clock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+				r := controllers.New(
+					k8sClient,
+					fake.Client(),
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithControllerID("prod"),
+					controllers.WithClock(clock),
+				)
+				scanner := &controllers.UnmanagedClientScanner{
+					Client:      k8sClient,
+					HydraClient: func() hydra.Client { return fake.Client() },
+					Reconcilers: []*controllers.OAuth2ClientReconciler{r},
+					ConfigMap:   types.NamespacedName{Name: "hydra-maester-unmanaged", Namespace: tstNamespace},
+					Log:         ctrl.Log.WithName("unmanaged-clients"),
+					Recorder:    recorder,
+					Gauge:       gauge,
+				}
+				Expect(scanner.Scan(context.TODO())).To(Succeed())
+
+				var cm apiv1.ConfigMap
+				Expect(k8sClient.Get(context.TODO(), scanner.ConfigMap, &cm)).To(Succeed())
+				Expect(cm.Annotations).To(HaveKeyWithValue(controllers.UnmanagedClientsTimeAnnotation, "2024-05-01T12:00:00Z"))
+				var report controllers.UnmanagedClientsReport
+				Expect(json.Unmarshal([]byte(cm.Data[controllers.UnmanagedClientsKey]), &report)).To(Succeed())
+				Expect(report.Total).To(Equal(4))
+				// clients of other controllers or clusters are unmanaged too
+				Expect(report.Clients).To(Equal([]controllers.UnmanagedClient{
+					{ClientID: "shadow-a", Scope: "openid"},
+					{ClientID: "shadow-b", ClientName: "cli", Owner: "alice"},
+					{ClientID: "shadow-c", Owner: "my-client/default@staging"},
+					{ClientID: "shadow-d", Owner: "my-client/default/remote@prod"},
+				}))
+				Expect(metricValue(reg, "hydra_maester_unmanaged_clients", nil)).To(Equal(float64(4)))
+				Expect(recorder.Events).To(HaveLen(4))
+
+				// an unchanged report is not written again and clients are
+				// reported only once
+				written := cm.ResourceVersion
+				Expect(scanner.Scan(context.TODO())).To(Succeed())
+				Expect(k8sClient.Get(context.TODO(), scanner.ConfigMap, &cm)).To(Succeed())
+				Expect(cm.ResourceVersion).To(Equal(written))
+				Expect(recorder.Events).To(HaveLen(4))
+
+				Expect(k8sClient.Delete(context.TODO(), &cm)).To(Succeed())
+			})
+
+			It("only delete clients carrying the controller identity", func() {
+
+				tstName, tstSecretName := "test-identity", "my-secret-identity"
+
+				fake := hydratest.NewServer()
+				defer fake.Close()
+				fake.AddClient(&hydra.OAuth2ClientJSON{Owner: fmt.Sprintf("%s/%s@staging", tstName, tstNamespace)})
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.HydraAdmin = hydrav1alpha1.HydraAdmin{}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					fake.Client(),
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithControllerID("prod"),
+				)
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fake.ClientsOwnedBy(fmt.Sprintf("%s/%s@prod", tstName, tstNamespace))).To(HaveLen(1))
+
+				Expect(k8sClient.Delete(context.TODO(), instance)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fake.ClientsOwnedBy(fmt.Sprintf("%s/%s@prod", tstName, tstNamespace))).To(BeEmpty())
+				Expect(fake.ClientsOwnedBy(fmt.Sprintf("%s/%s@staging", tstName, tstNamespace))).To(HaveLen(1))
 			})
 
 			It("update object status if provided Secret is invalid", func() {
@@ -315,8 +3118,8 @@ var _ = Describe("OAuth2Client Controller", func() {
 				c := mgr.GetClient()
 
 				mch := mocks.Client{}
-				mch.On("GetOAuth2Client", Anything).Return(nil, false, nil)
-				mch.On("DeleteOAuth2Client", Anything).Return(nil)
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("DeleteOAuth2Client", Anything, Anything).Return(nil)
 				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
 
 				recFn, requests := SetupTestReconcile(getAPIReconciler(mgr, &mch))
@@ -359,6 +3162,11 @@ var _ = Describe("OAuth2Client Controller", func() {
 				Expect(retrieved.Status.ReconciliationError).NotTo(BeNil())
 				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusInvalidSecret))
 				Expect(retrieved.Status.ReconciliationError.Description).To(Equal("CLIENT_SECRET property missing"))
+				Expect(retrieved.Status.Conditions).To(ConsistOf(hydrav1alpha1.OAuth2ClientCondition{
+					Type:   hydrav1alpha1.OAuth2ClientConditionReady,
+					Status: hydrav1alpha1.ConditionFalse,
+					Reason: hydrav1alpha1.ReasonInvalidConfiguration,
+				}))
 
 				//delete instance
 				c.Delete(context.TODO(), instance)
@@ -387,10 +3195,10 @@ var _ = Describe("OAuth2Client Controller", func() {
 				c := mgr.GetClient()
 
 				mch := &mocks.Client{}
-				mch.On("GetOAuth2Client", Anything).Return(nil, false, nil)
-				mch.On("DeleteOAuth2Client", Anything).Return(nil)
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("DeleteOAuth2Client", Anything, Anything).Return(nil)
 				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
-				mch.On("PostOAuth2Client", AnythingOfType("*hydra.OAuth2ClientJSON")).Return(func(o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
 					return &hydra.OAuth2ClientJSON{
 						ClientID:      &tstClientID,
 						Secret:        nil,
@@ -401,7 +3209,7 @@ var _ = Describe("OAuth2Client Controller", func() {
 						Audience:      o.Audience,
 						Owner:         o.Owner,
 					}
-				}, func(o *hydra.OAuth2ClientJSON) error {
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
 					return nil
 				})
 
@@ -472,12 +3280,12 @@ var _ = Describe("OAuth2Client Controller", func() {
 
 				deleteHasHappened := false
 				mch := &mocks.Client{}
-				mch.On("GetOAuth2Client", Anything).Return(nil, false, nil)
-				mch.On("DeleteOAuth2Client", Anything).Return(func(id string) error {
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("DeleteOAuth2Client", Anything, Anything).Return(func(_ context.Context, id string) error {
 					deleteHasHappened = true
 					return nil
 				})
-				mch.On("ListOAuth2Client", Anything).Return(func() []*hydra.OAuth2ClientJSON {
+				mch.On("ListOAuth2Client", Anything).Return(func(context.Context) []*hydra.OAuth2ClientJSON {
 					return []*hydra.OAuth2ClientJSON{
 						{
 							ClientID: &tstClientID,
@@ -486,7 +3294,7 @@ var _ = Describe("OAuth2Client Controller", func() {
 						},
 					}
 				}, nil)
-				mch.On("PostOAuth2Client", AnythingOfType("*hydra.OAuth2ClientJSON")).Return(func(o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
 					return &hydra.OAuth2ClientJSON{
 						ClientID:      &tstClientID,
 						Secret:        ptr.To(tstSecret),
@@ -497,7 +3305,7 @@ var _ = Describe("OAuth2Client Controller", func() {
 						Audience:      o.Audience,
 						Owner:         o.Owner,
 					}
-				}, func(o *hydra.OAuth2ClientJSON) error {
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
 					return nil
 				})
 
@@ -535,6 +3343,140 @@ var _ = Describe("OAuth2Client Controller", func() {
 				stopMgr.Done()
 			})
 
+			It("not add finalizers to detached OAuth2 clients", func() {
+				tstName, tstClientID, tstSecretName := "test-detach", "testClientID-detach", "my-secret-detach"
+
+				mch := &mocks.Client{}
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+					return &hydra.OAuth2ClientJSON{
+						ClientID: &tstClientID,
+						Secret:   ptr.To(tstSecret),
+						Owner:    o.Owner,
+					}
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
+					return nil
+				})
+
+				detached := testInstance(tstName, tstSecretName)
+				detached.Spec.DeletionPolicy = hydrav1alpha1.OAuth2ClientDeletionPolicyDetach
+				Expect(k8sClient.Create(context.TODO(), detached)).To(Succeed())
+
+				// finalizers added before they were disabled are removed
+				attached := testInstance(tstName+"-attached", tstSecretName+"-attached")
+				attached.Finalizers = []string{controllers.FinalizerName}
+				Expect(k8sClient.Create(context.TODO(), attached)).To(Succeed())
+
+				newReconciler := func(opts ...controllers.Option) *controllers.OAuth2ClientReconciler {
+					return controllers.New(
+						k8sClient,
+						mch,
+						ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+						append(opts, controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+							return mch, nil
+						}))...,
+					)
+				}
+				key := types.NamespacedName{Name: detached.Name, Namespace: tstNamespace}
+				_, err := newReconciler().Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				attachedKey := types.NamespacedName{Name: attached.Name, Namespace: tstNamespace}
+				_, err = newReconciler(controllers.WithFinalizersDisabled(true)).Reconcile(context.TODO(), reconcile.Request{NamespacedName: attachedKey})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertNumberOfCalls(GinkgoT(), "PostOAuth2Client", 2)
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				for _, key := range []types.NamespacedName{key, attachedKey} {
+					Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+					Expect(retrieved.Finalizers).To(BeEmpty())
+
+					// the deletion does not wait for the controller
+					Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+					Expect(apierrors.IsNotFound(k8sClient.Get(context.TODO(), key, &retrieved))).To(BeTrue())
+				}
+				mch.AssertNotCalled(GinkgoT(), "DeleteOAuth2Client", Anything, Anything)
+			})
+
+			It("orphan OAuth2 clients on deletion if hydra is unreachable and the annotation says so", func() {
+				tstName, tstSecretName := "test-unreachable", "my-secret-unreachable"
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+
+				mch := &mocks.Client{}
+				mch.On("ListOAuth2Client", Anything).Return(nil, &hydra.RequestError{StatusCode: 503, Status: "503 Service Unavailable"})
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Finalizers = []string{controllers.FinalizerName}
+				instance.Annotations = map[string]string{controllers.OrphanOnUnreachableAnnotation: "true"}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				err = k8sClient.Get(context.TODO(), key, &retrieved)
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+
+			It("block the deletion of protected OAuth2 clients until the annotation is removed", func() {
+				tstName, tstClientID, tstSecretName := "test-protect", "testClientID-protect", "my-secret-protect"
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+
+				deleteHasHappened := false
+				mch := &mocks.Client{}
+				mch.On("DeleteOAuth2Client", Anything, AnythingOfType("string")).Return(func(_ context.Context, id string) error {
+					deleteHasHappened = true
+					return nil
+				})
+				mch.On("ListOAuth2Client", Anything).Return([]*hydra.OAuth2ClientJSON{
+					{
+						ClientID: &tstClientID,
+						Owner:    fmt.Sprintf("%s/%s", tstName, tstNamespace),
+					},
+				}, nil)
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Finalizers = []string{controllers.FinalizerName}
+				instance.Annotations = map[string]string{controllers.ProtectAnnotation: "true"}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), instance)).To(Succeed())
+
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+				)
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusDeletionProtected))
+				Expect(deleteHasHappened).To(BeFalse())
+
+				// Lift the protection
+				delete(retrieved.Annotations, controllers.ProtectAnnotation)
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deleteHasHappened).To(BeTrue())
+				err = k8sClient.Get(context.TODO(), key, &retrieved)
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+
 			It("delete OAuth2 clients with Delete deletion policy", func() {
 				tstName, tstClientID, tstSecretName := "test-delete", "testClientID-delete", "my-secret-delete"
 				expectedRequest := &reconcile.Request{NamespacedName: types.NamespacedName{Name: tstName, Namespace: tstNamespace}}
@@ -559,12 +3501,12 @@ var _ = Describe("OAuth2Client Controller", func() {
 
 				deleteHasHappened := false
 				mch := &mocks.Client{}
-				mch.On("GetOAuth2Client", Anything).Return(nil, false, nil)
-				mch.On("DeleteOAuth2Client", AnythingOfType("string")).Return(func(id string) error {
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("DeleteOAuth2Client", Anything, AnythingOfType("string")).Return(func(_ context.Context, id string) error {
 					deleteHasHappened = true
 					return nil
 				})
-				mch.On("ListOAuth2Client", Anything).Return(func() []*hydra.OAuth2ClientJSON {
+				mch.On("ListOAuth2Client", Anything).Return(func(context.Context) []*hydra.OAuth2ClientJSON {
 					return []*hydra.OAuth2ClientJSON{
 						{
 							ClientID: &tstClientID,
@@ -573,7 +3515,7 @@ var _ = Describe("OAuth2Client Controller", func() {
 						},
 					}
 				}, nil)
-				mch.On("PostOAuth2Client", AnythingOfType("*hydra.OAuth2ClientJSON")).Return(func(o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+				mch.On("PostOAuth2Client", Anything, IsType(&hydra.OAuth2ClientJSON{})).Return(func(_ context.Context, o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
 					return &hydra.OAuth2ClientJSON{
 						ClientID:      &tstClientID,
 						Secret:        ptr.To(tstSecret),
@@ -584,7 +3526,7 @@ var _ = Describe("OAuth2Client Controller", func() {
 						Audience:      o.Audience,
 						Owner:         o.Owner,
 					}
-				}, func(o *hydra.OAuth2ClientJSON) error {
+				}, func(_ context.Context, o *hydra.OAuth2ClientJSON) error {
 					return nil
 				})
 
@@ -621,6 +3563,62 @@ var _ = Describe("OAuth2Client Controller", func() {
 				// Ensure manager is stopped properly.
 				stopMgr.Done()
 			})
+
+			It("back off from failed version checks of hydra instances", func() {
+
+				tstName, tstSecretName := "test-version-check", "my-secret-version-check"
+
+				mch := &mocks.Client{}
+				mch.On("GetVersion", Anything).Return("v1.0.0", nil)
+				mch.On("GetOAuth2Client", Anything, Anything).Return(nil, false, nil)
+				mch.On("ListOAuth2Client", Anything).Return(nil, nil)
+
+				clock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+				r := controllers.New(
+					k8sClient,
+					mch,
+					ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
+					controllers.WithClientFactory(func(hydrav1alpha1.OAuth2ClientSpec, string, bool) (hydra.Client, error) {
+						return mch, nil
+					}),
+					controllers.WithVersionCheck(controllers.VersionCheckEnforce),
+					controllers.WithClock(clock),
+				)
+
+				instance := testInstance(tstName, tstSecretName)
+				instance.Spec.HydraAdmin = hydrav1alpha1.HydraAdmin{URL: "http://hydra-version-check", Port: 4445}
+				Expect(k8sClient.Create(context.TODO(), instance)).To(Succeed())
+				key := types.NamespacedName{Name: tstName, Namespace: tstNamespace}
+
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				var retrieved hydrav1alpha1.OAuth2Client
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				Expect(retrieved.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusUnsupportedHydra))
+				mch.AssertNumberOfCalls(GinkgoT(), "GetVersion", 1)
+
+				//the failed check is not repeated during the backoff
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertNumberOfCalls(GinkgoT(), "GetVersion", 1)
+
+				//the check is repeated once the backoff expired, which then doubles
+				clock.Step(5 * time.Second)
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertNumberOfCalls(GinkgoT(), "GetVersion", 2)
+
+				clock.Step(5 * time.Second)
+				_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				mch.AssertNumberOfCalls(GinkgoT(), "GetVersion", 2)
+
+				//delete instance
+				Expect(k8sClient.Get(context.TODO(), key, &retrieved)).To(Succeed())
+				retrieved.Finalizers = nil
+				Expect(k8sClient.Update(context.TODO(), &retrieved)).To(Succeed())
+				Expect(k8sClient.Delete(context.TODO(), &retrieved)).To(Succeed())
+			})
 		})
 	})
 })
@@ -664,6 +3662,31 @@ func getAPIReconciler(mgr ctrl.Manager, mock hydra.Client) reconcile.Reconciler
 	)
 }
 
+// metricValue returns the value of the gauge or counter name of g with
+// labels, or -1 if it is not exported.
+func metricValue(g prometheus.Gatherer, name string, labels map[string]string) float64 {
+	families, err := g.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if value, ok := labels[l.GetName()]; ok && value != l.GetValue() {
+					continue metrics
+				}
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	return -1
+}
+
 func testInstance(name, secretName string) *hydrav1alpha1.OAuth2Client {
 
 	return &hydrav1alpha1.OAuth2Client{
@@ -687,3 +3710,61 @@ func testInstance(name, secretName string) *hydrav1alpha1.OAuth2Client {
 			},
 		}}
 }
+
+// recordingAuditor collects the audit records of a reconciler.
+type recordingAuditor struct {
+	records []audit.Record
+}
+
+func (a *recordingAuditor) Audit(_ context.Context, r audit.Record) {
+	a.records = append(a.records, r)
+}
+
+// recordingNotifier collects the notifications of a reconciler.
+type recordingNotifier struct {
+	notifications []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notification notify.Notification) {
+	n.notifications = append(n.notifications, notification)
+}
+
+// recordingKetoWriter keeps the relation tuples written by a reconciler,
+// failing all writes while fail is set.
+type recordingKetoWriter struct {
+	tuples map[string]bool
+	writes int
+	fail   bool
+}
+
+func (w *recordingKetoWriter) CreateRelationTuple(_ context.Context, t keto.RelationTuple) error {
+	if w.fail {
+		return errors.New("ORY Keto is unavailable")
+	}
+	w.writes++
+	w.tuples[t.String()] = true
+	return nil
+}
+
+func (w *recordingKetoWriter) DeleteRelationTuple(_ context.Context, t keto.RelationTuple) error {
+	if w.fail {
+		return errors.New("ORY Keto is unavailable")
+	}
+	delete(w.tuples, t.String())
+	return nil
+}
+
+// xorKeyWrapper wraps data keys by xoring them with a byte.
+type xorKeyWrapper byte
+
+func (w xorKeyWrapper) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ byte(w)
+	}
+	return out, nil
+}
+
+func (w xorKeyWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return w.WrapKey(ctx, wrapped)
+}
@@ -0,0 +1,38 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// PanicError is the error of a reconciliation which panicked, e.g. on an
+// unexpected payload of ORY Hydra.
+type PanicError struct {
+	// Value is the value the reconciliation panicked with.
+	Value interface{}
+	// Stack is the stack trace of the panic.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("reconciliation panicked: %v\n%s", e.Value, e.Stack)
+}
+
+// reconcileRecovering reconciles req and turns a panic into a PanicError,
+// which is retried with backoff like other errors, so a single OAuth2Client
+// does not crash the controller and with it the reconciliation of all others.
+func (r *OAuth2ClientReconciler) reconcileRecovering(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			r.metrics.panics.WithLabelValues(r.ClusterName).Inc()
+			result, err = ctrl.Result{}, &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return r.reconcile(ctx, req)
+}
@@ -0,0 +1,39 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/stretchr/testify/mock"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/ory/hydra-maester/controllers"
+	mocks "github.com/ory/hydra-maester/controllers/mocks/hydra"
+)
+
+var _ = Describe("WaitForHydra", func() {
+
+	It("waits until hydra is ready", func() {
+		mch := &mocks.Client{}
+		mch.On("IsReady", Anything).Return(false, errors.New("connection refused")).Once()
+		mch.On("IsReady", Anything).Return(true, nil)
+
+		log := ctrl.Log.WithName("setup")
+		Expect(controllers.WaitForHydra(context.TODO(), mch, "http://hydra-admin", 10*time.Second, log)).To(BeTrue())
+		mch.AssertNumberOfCalls(GinkgoT(), "IsReady", 2)
+	})
+
+	It("gives up after the timeout", func() {
+		mch := &mocks.Client{}
+		mch.On("IsReady", Anything).Return(false, nil)
+
+		log := ctrl.Log.WithName("setup")
+		Expect(controllers.WaitForHydra(context.TODO(), mch, "http://hydra-admin", 1500*time.Millisecond, log)).To(BeFalse())
+	})
+})
@@ -0,0 +1,107 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// RecoveryWatch polls the readiness of the ORY Hydra instances which
+// OAuth2Clients failed to reconcile against, and reconciles those
+// OAuth2Clients as soon as their instance is ready again. Without it, they
+// wait for their exponential backoff, which reaches minutes during an outage.
+type RecoveryWatch struct {
+	Reconciler *OAuth2ClientReconciler
+	Interval   time.Duration
+	Log        logr.Logger
+
+	// ready records the instances which were ready at the last check
+	ready map[hydra.Client]bool
+}
+
+// Start checks every interval until ctx is done. Failed checks are logged
+// and retried at the next interval.
+func (w *RecoveryWatch) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		recovered, err := w.Check(ctx)
+		if err != nil {
+			w.Log.Error(err, "recovery check of hydra instances failed")
+		}
+		for _, name := range recovered {
+			w.Log.V(1).Info("hydra is ready again, reconciling", "oauth2client", name)
+			c := &hydrav1alpha1.OAuth2Client{}
+			c.Name, c.Namespace = name.Name, name.Namespace
+			select {
+			case w.Reconciler.probes <- event.GenericEvent{Object: c}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// Check returns the OAuth2Clients which failed to reconcile because their
+// ORY Hydra instance was unavailable or failed, if the instance became ready
+// since the last check. Instances without such OAuth2Clients are not
+// polled and are forgotten, so the first check after new failures returns
+// them if the instance is ready by then.
+func (w *RecoveryWatch) Check(ctx context.Context) ([]types.NamespacedName, error) {
+	r := w.Reconciler
+
+	var opts []client.ListOption
+	if r.ControllerNamespace != "" {
+		opts = append(opts, client.InNamespace(r.ControllerNamespace))
+	}
+	var list hydrav1alpha1.OAuth2ClientList
+	if err := r.List(ctx, &list, opts...); err != nil {
+		return nil, err
+	}
+
+	byHydra := map[hydra.Client][]types.NamespacedName{}
+	for _, c := range list.Items {
+		if !r.watches(c.Namespace) || !c.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if reason := c.Status.ReconciliationError.Code.Reason(); c.Status.ReconciliationError.Code == "" ||
+			(reason != hydrav1alpha1.ReasonHydraUnavailable && reason != hydrav1alpha1.ReasonHydraError) {
+			continue
+		}
+
+		hydraClient, err := r.getHydraClientForClient(ctx, c)
+		if err != nil {
+			continue
+		}
+		byHydra[hydraClient] = append(byHydra[hydraClient], types.NamespacedName{Name: c.Name, Namespace: c.Namespace})
+	}
+
+	ready := make(map[hydra.Client]bool, len(byHydra))
+	var recovered []types.NamespacedName
+	for hydraClient, clients := range byHydra {
+		isReady, err := hydraClient.IsReady(ctx)
+		ready[hydraClient] = err == nil && isReady
+		if ready[hydraClient] && !w.ready[hydraClient] {
+			recovered = append(recovered, clients...)
+		}
+	}
+	w.ready = ready
+	return recovered, nil
+}
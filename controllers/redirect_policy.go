@@ -0,0 +1,118 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// RedirectURIDomains restricts the hosts of the redirect URIs and post logout
+// redirect URIs of OAuth2Clients, so tokens are not redirected to hosts outside
+// of the cluster's control. A domain matches its host exactly, a domain
+// starting with "*." matches all of its subdomains, e.g. *.example.com matches
+// app.example.com but not example.com. An empty list allows all hosts.
+// Loopback IP addresses are always allowed, as native apps receive their
+// redirects on them (RFC 8252 7.3) and tokens can't leave the device.
+type RedirectURIDomains []string
+
+// RedirectURINotAllowedError is returned for OAuth2Clients with redirect URIs
+// whose host is not in the allowed domains.
+type RedirectURINotAllowedError struct {
+	URIs []string
+}
+
+func (e *RedirectURINotAllowedError) Error() string {
+	return fmt.Sprintf("redirect URIs %s are not in the allowed domains", strings.Join(e.URIs, ", "))
+}
+
+// Check returns a *RedirectURINotAllowedError if c has redirect URIs outside
+// of the allowed domains. URIs without a host, e.g. those of private-use
+// schemes, are not allowed.
+func (d RedirectURIDomains) Check(c *hydrav1alpha1.OAuth2Client) error {
+	if len(d) == 0 {
+		return nil
+	}
+
+	var disallowed []string
+	for _, uri := range append(c.Spec.RedirectURIs, c.Spec.PostLogoutRedirectURIs...) {
+		if !d.allows(string(uri)) && !containsString(disallowed, string(uri)) {
+			disallowed = append(disallowed, string(uri))
+		}
+	}
+	if len(disallowed) > 0 {
+		return &RedirectURINotAllowedError{URIs: disallowed}
+	}
+	return nil
+}
+
+func (d RedirectURIDomains) allows(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	for _, domain := range d {
+		domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+		if parent, ok := strings.CutPrefix(domain, "*."); ok {
+			if strings.HasSuffix(host, "."+parent) {
+				return true
+			}
+		} else if host == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// WildcardRedirectURIError is returned for OAuth2Clients with redirect URIs
+// containing wildcards which are not allowed.
+type WildcardRedirectURIError struct {
+	URIs []string
+}
+
+func (e *WildcardRedirectURIError) Error() string {
+	return fmt.Sprintf("redirect URIs %s contain wildcards, which are not allowed", strings.Join(e.URIs, ", "))
+}
+
+// CheckWildcardRedirectURIs returns a *WildcardRedirectURIError if c has
+// redirect URIs or post logout redirect URIs with wildcards. With allow, a
+// wildcard is allowed as the leftmost label of the host of https URIs, e.g.
+// https://*.example.com/callback, for ORY Hydra deployments matching them;
+// other wildcards are refused either way.
+func CheckWildcardRedirectURIs(c *hydrav1alpha1.OAuth2Client, allow bool) error {
+	var disallowed []string
+	for _, uri := range append(c.Spec.RedirectURIs, c.Spec.PostLogoutRedirectURIs...) {
+		if !strings.Contains(string(uri), "*") || (allow && isSubdomainWildcard(string(uri))) {
+			continue
+		}
+		if !containsString(disallowed, string(uri)) {
+			disallowed = append(disallowed, string(uri))
+		}
+	}
+	if len(disallowed) > 0 {
+		return &WildcardRedirectURIError{URIs: disallowed}
+	}
+	return nil
+}
+
+// isSubdomainWildcard reports whether the only wildcard of uri is the
+// leftmost label of the host of an https URI.
+func isSubdomainWildcard(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "https" && u.User == nil && strings.HasPrefix(u.Host, "*.") && strings.Count(uri, "*") == 1
+}
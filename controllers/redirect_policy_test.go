@@ -0,0 +1,90 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+)
+
+var _ = Describe("RedirectURIDomains", func() {
+
+	client := func(redirectURIs ...hydrav1alpha1.RedirectURI) *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec:       hydrav1alpha1.OAuth2ClientSpec{RedirectURIs: redirectURIs},
+		}
+	}
+
+	It("allows all hosts without domains", func() {
+		Expect(controllers.RedirectURIDomains{}.Check(client("https://evil.com/callback"))).To(Succeed())
+	})
+
+	It("matches hosts exactly", func() {
+		domains := controllers.RedirectURIDomains{"example.com"}
+
+		Expect(domains.Check(client("https://example.com/callback", "https://EXAMPLE.com:8443/callback"))).To(Succeed())
+		Expect(domains.Check(client("https://app.example.com/callback"))).NotTo(Succeed())
+		Expect(domains.Check(client("https://example.com.evil.com/callback"))).NotTo(Succeed())
+	})
+
+	It("matches subdomains of wildcard domains", func() {
+		domains := controllers.RedirectURIDomains{"*.example.com"}
+
+		Expect(domains.Check(client("https://app.example.com/callback", "https://a.b.example.com/callback"))).To(Succeed())
+		Expect(domains.Check(client("https://example.com/callback"))).NotTo(Succeed())
+		Expect(domains.Check(client("https://evilexample.com/callback"))).NotTo(Succeed())
+	})
+
+	It("allows loopback addresses of native apps", func() {
+		domains := controllers.RedirectURIDomains{"example.com"}
+
+		Expect(domains.Check(client("http://127.0.0.1:51004/callback", "http://127.0.0.1/callback", "http://[::1]:8080/callback"))).To(Succeed())
+		Expect(domains.Check(client("http://localhost:8080/callback"))).NotTo(Succeed())
+		Expect(domains.Check(client("http://127.0.0.1.evil.com/callback"))).NotTo(Succeed())
+	})
+
+	It("refuses wildcards by default", func() {
+		Expect(controllers.CheckWildcardRedirectURIs(client("https://app.example.com/callback"), false)).To(Succeed())
+
+		c := client("https://app.example.com/callback", "https://*.example.com/callback")
+		c.Spec.PostLogoutRedirectURIs = []hydrav1alpha1.RedirectURI{"https://*.example.com/logout"}
+		err := controllers.CheckWildcardRedirectURIs(c, false)
+		var wildcard *controllers.WildcardRedirectURIError
+		Expect(errors.As(err, &wildcard)).To(BeTrue())
+		Expect(wildcard.URIs).To(Equal([]string{"https://*.example.com/callback", "https://*.example.com/logout"}))
+	})
+
+	It("allows subdomain wildcards of https URIs if enabled", func() {
+		Expect(controllers.CheckWildcardRedirectURIs(client("https://*.example.com/callback", "https://*.app.example.com:8443/callback"), true)).To(Succeed())
+		Expect(controllers.CheckWildcardRedirectURIs(client("http://*.example.com/callback"), true)).NotTo(Succeed())
+		Expect(controllers.CheckWildcardRedirectURIs(client("https://app.*.example.com/callback"), true)).NotTo(Succeed())
+		Expect(controllers.CheckWildcardRedirectURIs(client("https://*/callback"), true)).NotTo(Succeed())
+		Expect(controllers.CheckWildcardRedirectURIs(client("https://*.example.com/*"), true)).NotTo(Succeed())
+		Expect(controllers.CheckWildcardRedirectURIs(client("https://user@*.example.com/callback"), true)).NotTo(Succeed())
+	})
+
+	It("matches wildcard hosts against wildcard domains", func() {
+		Expect(controllers.RedirectURIDomains{"*.example.com"}.Check(client("https://*.example.com/callback", "https://*.app.example.com/callback"))).To(Succeed())
+		Expect(controllers.RedirectURIDomains{"example.com"}.Check(client("https://*.example.com/callback"))).NotTo(Succeed())
+		Expect(controllers.RedirectURIDomains{"*.example.com"}.Check(client("https://*.evil.com/callback"))).NotTo(Succeed())
+	})
+
+	It("reports the disallowed redirect URIs", func() {
+		domains := controllers.RedirectURIDomains{"example.com"}
+		c := client("https://example.com/callback", "https://example.com@evil.com/callback", "com.example.app:/callback")
+		c.Spec.PostLogoutRedirectURIs = []hydrav1alpha1.RedirectURI{"https://evil.com/logout"}
+
+		err := domains.Check(c)
+		var notAllowed *controllers.RedirectURINotAllowedError
+		Expect(errors.As(err, &notAllowed)).To(BeTrue())
+		Expect(notAllowed.URIs).To(Equal([]string{"https://example.com@evil.com/callback", "com.example.app:/callback", "https://evil.com/logout"}))
+	})
+})
@@ -0,0 +1,121 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/keto"
+)
+
+// desiredRelationTuples renders the relation tuple templates for the client
+// clientID of c.
+func (r *OAuth2ClientReconciler) desiredRelationTuples(c *hydrav1alpha1.OAuth2Client, clientID string) ([]keto.RelationTuple, error) {
+	data := keto.TemplateData{ClientID: clientID, Namespace: c.Namespace, Name: c.Name, Cluster: r.ClusterName}
+	tuples := make([]keto.RelationTuple, 0, len(r.relationTuples))
+	for _, tmpl := range r.relationTuples {
+		t, err := tmpl.Execute(data)
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, t)
+	}
+	return tuples, nil
+}
+
+// relationTuplesChanged reports whether the relation tuples of the client
+// clientID of c differ from those recorded in its status, e.g. because the
+// templates changed or the client was registered before they were set.
+func (r *OAuth2ClientReconciler) relationTuplesChanged(c *hydrav1alpha1.OAuth2Client, clientID string) bool {
+	if r.ketoWriter == nil {
+		return false
+	}
+	tuples, err := r.desiredRelationTuples(c, clientID)
+	if err != nil {
+		return true
+	}
+	return !reflect.DeepEqual(relationTupleStrings(tuples), c.Status.RelationTuples)
+}
+
+// ensureRelationTuples writes the relation tuples of the client clientID of c
+// to ORY Keto and deletes those recorded in its status which are no longer
+// rendered. The written tuples are recorded in the status of c, which the
+// caller persists.
+func (r *OAuth2ClientReconciler) ensureRelationTuples(ctx context.Context, c *hydrav1alpha1.OAuth2Client, clientID string) error {
+	if r.ketoWriter == nil || !r.relationTuplesChanged(c, clientID) {
+		return nil
+	}
+	tuples, err := r.desiredRelationTuples(c, clientID)
+	if err != nil {
+		return err
+	}
+
+	desired := relationTupleStrings(tuples)
+	for i, t := range tuples {
+		if containsString(c.Status.RelationTuples, desired[i]) {
+			continue
+		}
+		if err := r.ketoWriter.CreateRelationTuple(ctx, t); err != nil {
+			return err
+		}
+	}
+	for _, s := range c.Status.RelationTuples {
+		if containsString(desired, s) {
+			continue
+		}
+		if err := r.deleteRelationTuple(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	c.Status.RelationTuples = desired
+	return nil
+}
+
+// handleRelationTuplesError records err, the error of writing the relation
+// tuples of c, and retries it according to the retry policy.
+func (r *OAuth2ClientReconciler) handleRelationTuplesError(ctx context.Context, c *hydrav1alpha1.OAuth2Client, err error) error {
+	if updateErr := r.updateReconciliationStatusError(ctx, c, hydrav1alpha1.StatusRelationTuplesFailed, err); updateErr != nil {
+		return updateErr
+	}
+	if r.retryPolicy.retries(err) {
+		return r.retry(c, err)
+	}
+	return nil
+}
+
+// deleteRelationTuples deletes the relation tuples recorded in the status of
+// c from ORY Keto.
+func (r *OAuth2ClientReconciler) deleteRelationTuples(ctx context.Context, c *hydrav1alpha1.OAuth2Client) error {
+	if r.ketoWriter == nil {
+		return nil
+	}
+	for _, s := range c.Status.RelationTuples {
+		if err := r.deleteRelationTuple(ctx, s); err != nil {
+			return err
+		}
+	}
+	c.Status.RelationTuples = nil
+	return nil
+}
+
+func (r *OAuth2ClientReconciler) deleteRelationTuple(ctx context.Context, s string) error {
+	t, err := keto.ParseRelationTuple(s)
+	if err != nil {
+		// tuples which can't be parsed were not written by the controller
+		r.Log.Error(err, "ignoring invalid relation tuple in the status")
+		return nil
+	}
+	return r.ketoWriter.DeleteRelationTuple(ctx, t)
+}
+
+func relationTupleStrings(tuples []keto.RelationTuple) []string {
+	var s []string
+	for _, t := range tuples {
+		s = append(s, t.String())
+	}
+	return s
+}
@@ -0,0 +1,94 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// KubeconfigKey is the key of the kubeconfig in the Secrets of remote clusters.
+const KubeconfigKey = "kubeconfig"
+
+// RemoteCluster references the Secret holding the kubeconfig of a cluster
+// whose OAuth2Clients are reconciled in addition to the local ones.
+type RemoteCluster struct {
+	Name            string
+	SecretNamespace string
+	SecretName      string
+}
+
+// ParseRemoteCluster parses a remote cluster in the name=namespace/secret form.
+func ParseRemoteCluster(s string) (RemoteCluster, error) {
+	name, ref, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return RemoteCluster{}, fmt.Errorf("remote cluster %q must have the name=namespace/secret form", s)
+	}
+	ns, secret, ok := strings.Cut(ref, "/")
+	if !ok || ns == "" || secret == "" {
+		return RemoteCluster{}, fmt.Errorf("remote cluster %q must have the name=namespace/secret form", s)
+	}
+	return RemoteCluster{Name: name, SecretNamespace: ns, SecretName: secret}, nil
+}
+
+// NewRemoteCluster reads the kubeconfig of rc with c and returns a cluster
+// for it, watching only the given namespaces if there are any. The cluster
+// must be added to the manager.
+func NewRemoteCluster(ctx context.Context, c client.Reader, rc RemoteCluster, scheme *runtime.Scheme, namespaces ...string) (cluster.Cluster, error) {
+	var secret apiv1.Secret
+	if err := c.Get(ctx, client.ObjectKey{Namespace: rc.SecretNamespace, Name: rc.SecretName}, &secret); err != nil {
+		return nil, fmt.Errorf("reading kubeconfig of cluster %s: %w", rc.Name, err)
+	}
+
+	kubeconfig, found := secret.Data[KubeconfigKey]
+	if !found {
+		return nil, fmt.Errorf("secret %s/%s: %s property missing", rc.SecretNamespace, rc.SecretName, KubeconfigKey)
+	}
+
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig of cluster %s: %w", rc.Name, err)
+	}
+
+	return cluster.New(cfg, func(o *cluster.Options) {
+		o.Scheme = scheme
+		if len(namespaces) > 0 {
+			o.Cache.DefaultNamespaces = map[string]cache.Config{}
+			for _, ns := range namespaces {
+				o.Cache.DefaultNamespaces[ns] = cache.Config{}
+			}
+		}
+	})
+}
+
+// SetupWithCluster sets up the reconciler for the OAuth2Clients of the remote
+// cluster cl. The reconciler must have been created with the client of cl.
+func (r *OAuth2ClientReconciler) SetupWithCluster(mgr ctrl.Manager, cl cluster.Cluster) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName + "-" + r.ClusterName).
+		WatchesRawSource(source.Kind(
+			cl.GetCache(),
+			&hydrav1alpha1.OAuth2Client{},
+			&handler.TypedEnqueueRequestForObject[*hydrav1alpha1.OAuth2Client]{},
+			predicate.NewTypedPredicateFuncs(func(o *hydrav1alpha1.OAuth2Client) bool {
+				return r.shard.Owns(o.GetNamespace())
+			}),
+		)).
+		WatchesRawSource(source.Channel(r.probes, &handler.EnqueueRequestForObject{})).
+		Complete(r)
+}
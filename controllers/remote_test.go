@@ -0,0 +1,125 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/stretchr/testify/mock"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	mocks "github.com/ory/hydra-maester/controllers/mocks/hydra"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+const remoteKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: east
+  cluster:
+    server: https://east.example.com:6443
+contexts:
+- name: east
+  context:
+    cluster: east
+    user: controller
+current-context: east
+users:
+- name: controller
+  user:
+    token: secret-token
+`
+
+var _ = Describe("Remote clusters", func() {
+
+	Describe("parsing", func() {
+		It("parses the name=namespace/secret form", func() {
+			rc, err := controllers.ParseRemoteCluster("east=hydra/east-kubeconfig")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rc).To(Equal(controllers.RemoteCluster{Name: "east", SecretNamespace: "hydra", SecretName: "east-kubeconfig"}))
+		})
+
+		It("rejects other forms", func() {
+			for _, s := range []string{"", "east", "=hydra/east-kubeconfig", "east=hydra", "east=/east-kubeconfig", "east=hydra/"} {
+				_, err := controllers.ParseRemoteCluster(s)
+				Expect(err).To(MatchError(`remote cluster "` + s + `" must have the name=namespace/secret form`))
+			}
+		})
+	})
+
+	Describe("creating", func() {
+		rc := controllers.RemoteCluster{Name: "east", SecretNamespace: "hydra", SecretName: "east-kubeconfig"}
+
+		newCluster := func(data map[string][]byte) error {
+			c := newFakeClient()
+			if data != nil {
+				Expect(c.Create(context.Background(), &apiv1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: rc.SecretName, Namespace: rc.SecretNamespace},
+					Data:       data,
+				})).To(Succeed())
+			}
+			cl, err := controllers.NewRemoteCluster(context.Background(), c, rc, runtime.NewScheme(), "team")
+			if err == nil {
+				Expect(cl).NotTo(BeNil())
+			}
+			return err
+		}
+
+		It("creates a cluster from the kubeconfig in the secret", func() {
+			Expect(newCluster(map[string][]byte{controllers.KubeconfigKey: []byte(remoteKubeconfig)})).To(Succeed())
+		})
+
+		It("fails without the secret", func() {
+			Expect(newCluster(nil)).To(MatchError(ContainSubstring("reading kubeconfig of cluster east: ")))
+		})
+
+		It("fails without the kubeconfig", func() {
+			Expect(newCluster(map[string][]byte{"config": []byte(remoteKubeconfig)})).To(MatchError("secret hydra/east-kubeconfig: kubeconfig property missing"))
+		})
+
+		It("fails on invalid kubeconfigs", func() {
+			Expect(newCluster(map[string][]byte{controllers.KubeconfigKey: []byte("clusters: [")})).To(MatchError(ContainSubstring("parsing kubeconfig of cluster east: ")))
+		})
+	})
+
+	It("registers the clients of each cluster with their own owner", func() {
+		key := types.NamespacedName{Name: "app", Namespace: "default"}
+
+		for cluster, owner := range map[string]string{"": "app/default", "east": "app/default/east"} {
+			c := newFakeClient(&hydrav1alpha1.OAuth2Client{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+				Spec: hydrav1alpha1.OAuth2ClientSpec{
+					GrantTypes: []hydrav1alpha1.GrantType{"client_credentials"},
+					SecretName: "app-credentials",
+				},
+			})
+
+			var posted *hydra.OAuth2ClientJSON
+			mch := &mocks.Client{}
+			mch.On("PostOAuth2Client", AnythingOfType("*hydra.OAuth2ClientJSON")).Return(func(o *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+				posted = o
+				registered := *o
+				registered.ClientID = ptr.To("app-id")
+				registered.Secret = ptr.To("app-secret")
+				return &registered
+			}, nil)
+
+			r := controllers.New(c, mch, logr.Discard(), controllers.WithClusterName(cluster))
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(posted).NotTo(BeNil())
+			Expect(posted.Owner).To(Equal(owner))
+		}
+	})
+})
@@ -0,0 +1,102 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+var _ = Describe("Hydra admin request timeout", func() {
+
+	var server *hydratest.Server
+	var used []string
+	BeforeEach(func() {
+		server = hydratest.NewServer()
+		used = nil
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	key := types.NamespacedName{Name: "app", Namespace: "default"}
+
+	// reconcileApp reconciles default/app on c with the default request
+	// timeout and records the request timeouts of the hydra clients in used.
+	reconcileApp := func(c client.Client, timeout time.Duration) error {
+		r := controllers.New(c, nil, logr.Discard(), controllers.WithRequestTimeout(timeout), controllers.WithClientFactory(func(spec hydrav1alpha1.OAuth2ClientSpec, _ string, _ bool) (hydra.Client, error) {
+			used = append(used, spec.HydraAdmin.RequestTimeout)
+			return server.Client(), nil
+		}))
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		return err
+	}
+
+	newOAuth2Client := func(admin hydrav1alpha1.HydraAdmin) *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: hydrav1alpha1.OAuth2ClientSpec{
+				GrantTypes: []hydrav1alpha1.GrantType{"client_credentials"},
+				SecretName: "app-credentials",
+				HydraAdmin: admin,
+			},
+		}
+	}
+
+	It("uses the default request timeout", func() {
+		c := newFakeClient(newOAuth2Client(hydrav1alpha1.HydraAdmin{URL: "http://hydra-admin:4445/clients"}))
+		Expect(reconcileApp(c, 10*time.Second)).To(Succeed())
+		Expect(used).To(Equal([]string{"10s"}))
+	})
+
+	It("sets no request timeout by default", func() {
+		c := newFakeClient(newOAuth2Client(hydrav1alpha1.HydraAdmin{URL: "http://hydra-admin:4445/clients"}))
+		Expect(reconcileApp(c, 0)).To(Succeed())
+		Expect(used).To(Equal([]string{""}))
+	})
+
+	It("prefers the request timeout of the OAuth2Client", func() {
+		c := newFakeClient(newOAuth2Client(hydrav1alpha1.HydraAdmin{URL: "http://hydra-admin.remote.example.com:4445/clients", RequestTimeout: "1m"}))
+		Expect(reconcileApp(c, 10*time.Second)).To(Succeed())
+		Expect(used).To(Equal([]string{"1m"}))
+	})
+
+	It("uses the request timeout of the namespace defaults", func() {
+		c := newFakeClient(newOAuth2Client(hydrav1alpha1.HydraAdmin{}), &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: controllers.NamespaceDefaultsConfigMap, Namespace: key.Namespace},
+			Data: map[string]string{
+				controllers.NamespaceDefaultsURLKey:            "http://hydra-admin.tenant.example.com:4445/clients",
+				controllers.NamespaceDefaultsRequestTimeoutKey: "45s",
+			},
+		})
+		Expect(reconcileApp(c, 10*time.Second)).To(Succeed())
+		Expect(used).To(Equal([]string{"45s"}))
+	})
+
+	It("rejects invalid request timeouts of the namespace defaults", func() {
+		c := newFakeClient(newOAuth2Client(hydrav1alpha1.HydraAdmin{}), &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: controllers.NamespaceDefaultsConfigMap, Namespace: key.Namespace},
+			Data: map[string]string{
+				controllers.NamespaceDefaultsURLKey:            "http://hydra-admin.tenant.example.com:4445/clients",
+				controllers.NamespaceDefaultsRequestTimeoutKey: "soon",
+			},
+		})
+		Expect(reconcileApp(c, 0)).To(MatchError(ContainSubstring("sets an invalid requestTimeout")))
+		Expect(used).To(BeEmpty())
+	})
+})
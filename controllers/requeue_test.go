@@ -0,0 +1,35 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+)
+
+var _ = Describe("RequeuePolicy", func() {
+
+	It("never requeues with RequeueNever", func() {
+		Expect(controllers.RequeueNever(&hydrav1alpha1.OAuth2Client{})).To(BeZero())
+	})
+
+	It("requeues after a fixed interval", func() {
+		Expect(controllers.RequeueAfter(time.Hour)(&hydrav1alpha1.OAuth2Client{})).To(Equal(time.Hour))
+		Expect(controllers.RequeueJittered(time.Hour, 0)(&hydrav1alpha1.OAuth2Client{})).To(Equal(time.Hour))
+	})
+
+	It("adds up to the jitter to the interval", func() {
+		policy := controllers.RequeueJittered(time.Hour, 0.2)
+		for i := 0; i < 20; i++ {
+			d := policy(&hydrav1alpha1.OAuth2Client{})
+			Expect(d).To(BeNumerically(">=", time.Hour))
+			Expect(d).To(BeNumerically("<=", 72*time.Minute))
+		}
+	})
+})
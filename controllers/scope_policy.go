@@ -0,0 +1,52 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// ScopePolicy restricts the scopes OAuth2Clients may request, e.g. to keep
+// tenants from registering clients with privileged scopes such as admin or
+// offline_access.
+type ScopePolicy struct {
+	// Allowed are the scopes OAuth2Clients may request. If empty, all scopes
+	// are allowed.
+	Allowed []string
+	// ExemptNamespaces are namespaces whose OAuth2Clients may request any
+	// scope.
+	ExemptNamespaces []string
+}
+
+// ScopeNotAllowedError is returned for OAuth2Clients requesting scopes which
+// the scope policy does not allow.
+type ScopeNotAllowedError struct {
+	Scopes []string
+}
+
+func (e *ScopeNotAllowedError) Error() string {
+	return fmt.Sprintf("scopes %s are not allowed in this namespace", strings.Join(e.Scopes, ", "))
+}
+
+// Check returns a *ScopeNotAllowedError if c requests scopes which are not
+// allowed.
+func (p ScopePolicy) Check(c *hydrav1alpha1.OAuth2Client) error {
+	if len(p.Allowed) == 0 || containsString(p.ExemptNamespaces, c.Namespace) {
+		return nil
+	}
+
+	var disallowed []string
+	for _, scope := range append(strings.Fields(c.Spec.Scope), c.Spec.ScopeArray...) {
+		if !containsString(p.Allowed, scope) && !containsString(disallowed, scope) {
+			disallowed = append(disallowed, scope)
+		}
+	}
+	if len(disallowed) > 0 {
+		return &ScopeNotAllowedError{Scopes: disallowed}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+)
+
+var _ = Describe("ScopePolicy", func() {
+
+	client := func(namespace, scope string, scopeArray ...string) *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: namespace},
+			Spec:       hydrav1alpha1.OAuth2ClientSpec{Scope: scope, ScopeArray: scopeArray},
+		}
+	}
+
+	It("allows all scopes without allowed scopes", func() {
+		Expect(controllers.ScopePolicy{}.Check(client("default", "admin"))).To(Succeed())
+	})
+
+	It("rejects scopes which are not allowed", func() {
+		policy := controllers.ScopePolicy{Allowed: []string{"openid", "profile"}}
+
+		Expect(policy.Check(client("default", "openid profile"))).To(Succeed())
+
+		err := policy.Check(client("default", "openid admin", "offline_access", "admin"))
+		var notAllowed *controllers.ScopeNotAllowedError
+		Expect(errors.As(err, &notAllowed)).To(BeTrue())
+		Expect(notAllowed.Scopes).To(Equal([]string{"admin", "offline_access"}))
+	})
+
+	It("allows all scopes in exempt namespaces", func() {
+		policy := controllers.ScopePolicy{Allowed: []string{"openid"}, ExemptNamespaces: []string{"platform"}}
+
+		Expect(policy.Check(client("platform", "admin"))).To(Succeed())
+		Expect(policy.Check(client("default", "admin"))).NotTo(Succeed())
+	})
+})
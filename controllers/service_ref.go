@@ -0,0 +1,81 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+// DefaultServiceRefPath is the path of the clients endpoint of Services
+// referenced in spec.hydraAdmin.serviceRef without one.
+const DefaultServiceRefPath = "/clients"
+
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+
+// resolveServiceRef returns admin with the Service referenced by its
+// ServiceRef resolved to the cluster-internal URL
+// <scheme>://<name>.<namespace>.svc:<port><path>. The Service is looked up in
+// namespace, the namespace of the OAuth2Client, unless the reference names
+// another one. admin is returned as it is if it references no Service.
+func (r *OAuth2ClientReconciler) resolveServiceRef(ctx context.Context, namespace string, admin hydrav1alpha1.HydraAdmin) (hydrav1alpha1.HydraAdmin, error) {
+	ref := admin.ServiceRef
+	if ref == nil {
+		return admin, nil
+	}
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+
+	var svc apiv1.Service
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, &svc); err != nil {
+		return admin, fmt.Errorf("cannot resolve hydra admin service %s/%s: %w", namespace, ref.Name, err)
+	}
+	port, err := servicePort(&svc, ref.Port)
+	if err != nil {
+		return admin, err
+	}
+
+	scheme := "http"
+	if port.AppProtocol != nil && *port.AppProtocol == "https" {
+		scheme = "https"
+	}
+	path := ref.Path
+	if path == "" {
+		path = DefaultServiceRefPath
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(svc.Name+"."+svc.Namespace+".svc", strconv.Itoa(int(port.Port))),
+		Path:   path,
+	}
+	admin.URL, admin.ServiceRef = u.String(), nil
+	return admin, nil
+}
+
+// servicePort returns the port of svc named or numbered like port, or its
+// only port if port is empty.
+func servicePort(svc *apiv1.Service, port intstr.IntOrString) (*apiv1.ServicePort, error) {
+	if port == (intstr.IntOrString{}) {
+		if len(svc.Spec.Ports) != 1 {
+			return nil, fmt.Errorf("hydra admin service %s/%s has %d ports, set the port of the serviceRef", svc.Namespace, svc.Name, len(svc.Spec.Ports))
+		}
+		return &svc.Spec.Ports[0], nil
+	}
+	for i, p := range svc.Spec.Ports {
+		if port.Type == intstr.String && p.Name == port.StrVal || port.Type == intstr.Int && p.Port == port.IntVal {
+			return &svc.Spec.Ports[i], nil
+		}
+	}
+	return nil, fmt.Errorf("hydra admin service %s/%s has no port %s", svc.Namespace, svc.Name, port.String())
+}
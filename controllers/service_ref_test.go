@@ -0,0 +1,131 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+var _ = Describe("Hydra admin service references", func() {
+
+	var server *hydratest.Server
+	var urls []string
+	BeforeEach(func() {
+		server = hydratest.NewServer()
+		urls = nil
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	key := types.NamespacedName{Name: "app", Namespace: "default"}
+
+	// reconcileApp reconciles default/app on c and returns it. The URLs
+	// the referenced Services resolve to are recorded in urls.
+	reconcileApp := func(c client.Client) *hydrav1alpha1.OAuth2Client {
+		r := controllers.New(c, nil, logr.Discard(), controllers.WithClientFactory(func(spec hydrav1alpha1.OAuth2ClientSpec, _ string, _ bool) (hydra.Client, error) {
+			urls = append(urls, spec.HydraAdmin.URL)
+			return server.Client(), nil
+		}))
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		var oauth2client hydrav1alpha1.OAuth2Client
+		Expect(c.Get(context.Background(), key, &oauth2client)).To(Succeed())
+		return &oauth2client
+	}
+
+	newOAuth2Client := func(ref hydrav1alpha1.ServiceReference) *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: hydrav1alpha1.OAuth2ClientSpec{
+				GrantTypes: []hydrav1alpha1.GrantType{"client_credentials"},
+				SecretName: "app-credentials",
+				HydraAdmin: hydrav1alpha1.HydraAdmin{ServiceRef: &ref},
+			},
+		}
+	}
+
+	newService := func(namespace string, ports ...apiv1.ServicePort) *apiv1.Service {
+		return &apiv1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "hydra-admin", Namespace: namespace},
+			Spec:       apiv1.ServiceSpec{Ports: ports},
+		}
+	}
+
+	It("resolves the named port of a service in another namespace", func() {
+		svc := newService("ory",
+			apiv1.ServicePort{Name: "http-public", Port: 4444},
+			apiv1.ServicePort{Name: "https-admin", Port: 4445, AppProtocol: ptr.To("https")})
+		c := newFakeClient(svc, newOAuth2Client(hydrav1alpha1.ServiceReference{
+			Name: "hydra-admin", Namespace: "ory", Port: intstr.FromString("https-admin"), Path: "/admin/clients",
+		}))
+
+		Expect(reconcileApp(c).Status.ReconciliationError.Code).To(BeEmpty())
+		Expect(urls).To(Equal([]string{"https://hydra-admin.ory.svc:4445/admin/clients"}))
+		Expect(server.ClientsOwnedBy("app/default")).To(HaveLen(1))
+	})
+
+	It("uses the only port of a service in the namespace of the OAuth2Client", func() {
+		c := newFakeClient(newService("default", apiv1.ServicePort{Port: 4445}), newOAuth2Client(hydrav1alpha1.ServiceReference{Name: "hydra-admin"}))
+
+		Expect(reconcileApp(c).Status.ReconciliationError.Code).To(BeEmpty())
+		Expect(urls).To(Equal([]string{"http://hydra-admin.default.svc:4445/clients"}))
+	})
+
+	It("picks up port changes", func() {
+		svc := newService("default", apiv1.ServicePort{Name: "admin", Port: 4445})
+		c := newFakeClient(svc, newOAuth2Client(hydrav1alpha1.ServiceReference{Name: "hydra-admin", Port: intstr.FromString("admin")}))
+		r := controllers.New(c, nil, logr.Discard(), controllers.WithClientFactory(func(spec hydrav1alpha1.OAuth2ClientSpec, _ string, _ bool) (hydra.Client, error) {
+			urls = append(urls, spec.HydraAdmin.URL)
+			return server.Client(), nil
+		}))
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.Get(context.Background(), client.ObjectKeyFromObject(svc), svc)).To(Succeed())
+		svc.Spec.Ports[0].Port = 14445
+		Expect(c.Update(context.Background(), svc)).To(Succeed())
+		_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(urls).To(Equal([]string{
+			"http://hydra-admin.default.svc:4445/clients",
+			"http://hydra-admin.default.svc:14445/clients",
+		}))
+	})
+
+	It("records missing services and ports in the status", func() {
+		c := newFakeClient(newOAuth2Client(hydrav1alpha1.ServiceReference{Name: "hydra-admin"}))
+		r := controllers.New(c, server.Client(), logr.Discard())
+		// retried, the service may be created later
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).To(MatchError(ContainSubstring("cannot resolve hydra admin service default/hydra-admin")))
+		var oauth2client hydrav1alpha1.OAuth2Client
+		Expect(c.Get(context.Background(), key, &oauth2client)).To(Succeed())
+		Expect(oauth2client.Status.ReconciliationError.Code).To(Equal(hydrav1alpha1.StatusInvalidHydraAddress))
+
+		c = newFakeClient(newService("default", apiv1.ServicePort{Name: "admin", Port: 4445}), newOAuth2Client(hydrav1alpha1.ServiceReference{Name: "hydra-admin", Port: intstr.FromInt32(4446)}))
+		r = controllers.New(c, server.Client(), logr.Discard())
+		_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).To(MatchError(ContainSubstring("has no port 4446")))
+		Expect(server.ClientsOwnedBy("app/default")).To(BeEmpty())
+	})
+})
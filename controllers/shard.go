@@ -0,0 +1,40 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Shard selects the namespaces a controller replica is responsible for, so
+// several replicas can reconcile OAuth2Clients concurrently. Namespaces are
+// assigned by a hash of their name, so all OAuth2Clients of a namespace are
+// handled by the same replica.
+type Shard struct {
+	// Index of this replica, starting at 0.
+	Index int
+	// Total number of replicas. Values below 2 disable sharding.
+	Total int
+}
+
+// Owns reports whether the namespace belongs to the shard.
+func (s Shard) Owns(namespace string) bool {
+	if s.Total < 2 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespace))
+	return int(h.Sum32()%uint32(s.Total)) == s.Index
+}
+
+// Name returns name suffixed with the shard, e.g. report-shard-0-of-3, so the
+// leaders of the shards don't overwrite each other's objects. Without
+// sharding, name is returned unchanged.
+func (s Shard) Name(name string) string {
+	if s.Total < 2 {
+		return name
+	}
+	return fmt.Sprintf("%s-shard-%d-of-%d", name, s.Index, s.Total)
+}
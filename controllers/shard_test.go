@@ -0,0 +1,39 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/ory/hydra-maester/controllers"
+)
+
+var _ = Describe("Shard", func() {
+
+	It("owns all namespaces when sharding is disabled", func() {
+		Expect(controllers.Shard{}.Owns("default")).To(BeTrue())
+	})
+
+	It("assigns each namespace to exactly one shard", func() {
+		for i := 0; i < 20; i++ {
+			ns := fmt.Sprintf("namespace-%d", i)
+
+			owners := 0
+			for index := 0; index < 3; index++ {
+				if (controllers.Shard{Index: index, Total: 3}).Owns(ns) {
+					owners++
+				}
+			}
+			Expect(owners).To(Equal(1), "namespace %s", ns)
+		}
+	})
+
+	It("names objects after the shard", func() {
+		Expect(controllers.Shard{}.Name("report")).To(Equal("report"))
+		Expect(controllers.Shard{Index: 1, Total: 3}.Name("report")).To(Equal("report-shard-1-of-3"))
+	})
+})
@@ -0,0 +1,35 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"time"
+)
+
+// drain returns the context of a reconciliation started with ctx. It is not
+// cancelled with ctx when the manager stops, but only the shutdown grace
+// period later, so the requests to ORY Hydra and the status writes in flight
+// complete instead of leaving clients half registered, e.g. created in ORY
+// Hydra without their Secret. Without a grace period, ctx is returned.
+func (r *OAuth2ClientReconciler) drain(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.shutdownGracePeriod <= 0 {
+		return ctx, func() {}
+	}
+
+	drained, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		timer := time.NewTimer(r.shutdownGracePeriod)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel()
+		case <-drained.Done():
+		}
+	})
+	return drained, func() {
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// StateAPIHandler returns a read-only HTTP API serving the sync state of the
+// OAuth2Clients of reconcilers as JSON, so internal developer portals can show
+// the health of OAuth2Clients without access to the Kubernetes API. Requests
+// must carry token as a bearer token. It serves:
+//
+//   - GET /api/v1/clients, the SyncReportData of all OAuth2Clients, filtered
+//     by the cluster, namespace and state query parameters if given.
+//   - GET /api/v1/clients/{namespace}/{name}, the SyncReportEntry of an
+//     OAuth2Client, of the cluster query parameter if given.
+func StateAPIHandler(reconcilers []*OAuth2ClientReconciler, token string, log logr.Logger) http.Handler {
+	api := &stateAPI{reconcilers: reconcilers, log: log}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/clients", api.list)
+	mux.HandleFunc("GET /api/v1/clients/{namespace}/{name}", api.get)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="hydra-maester"`)
+			writeStateAPIError(w, http.StatusUnauthorized, "a valid bearer token is required")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+type stateAPI struct {
+	reconcilers []*OAuth2ClientReconciler
+	log         logr.Logger
+}
+
+func (a *stateAPI) list(w http.ResponseWriter, r *http.Request) {
+	report, err := syncReport(r.Context(), a.reconcilers)
+	if err != nil {
+		a.log.Error(err, "building sync report failed")
+		writeStateAPIError(w, http.StatusInternalServerError, "the sync state is unavailable")
+		return
+	}
+
+	query := r.URL.Query()
+	filtered := &SyncReportData{Clients: []SyncReportEntry{}}
+	for _, entry := range report.Clients {
+		if matchesFilter(query.Get("cluster"), entry.Cluster) &&
+			matchesFilter(query.Get("namespace"), entry.Namespace) &&
+			matchesFilter(query.Get("state"), entry.State) {
+			filtered.add(entry)
+		}
+	}
+	writeStateAPIResponse(w, http.StatusOK, filtered)
+}
+
+func (a *stateAPI) get(w http.ResponseWriter, r *http.Request) {
+	report, err := syncReport(r.Context(), a.reconcilers)
+	if err != nil {
+		a.log.Error(err, "building sync report failed")
+		writeStateAPIError(w, http.StatusInternalServerError, "the sync state is unavailable")
+		return
+	}
+
+	for _, entry := range report.Clients {
+		if entry.Namespace == r.PathValue("namespace") && entry.Name == r.PathValue("name") &&
+			matchesFilter(r.URL.Query().Get("cluster"), entry.Cluster) {
+			writeStateAPIResponse(w, http.StatusOK, entry)
+			return
+		}
+	}
+	writeStateAPIError(w, http.StatusNotFound, "no such OAuth2Client")
+}
+
+// matchesFilter reports whether value matches the query parameter filter,
+// which matches all values if it is empty.
+func matchesFilter(filter, value string) bool {
+	return filter == "" || strings.EqualFold(filter, value)
+}
+
+func writeStateAPIResponse(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeStateAPIError(w http.ResponseWriter, code int, message string) {
+	writeStateAPIResponse(w, code, struct {
+		Error string `json:"error"`
+	}{Error: message})
+}
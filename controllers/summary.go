@@ -0,0 +1,146 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/audit"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// ActionNone is the action of reconciliations which did not change ORY Hydra.
+const ActionNone = "none"
+
+// summaryKey is the context key of the summary of a reconciliation.
+type summaryKey struct{}
+
+// reconcileSummary collects what a reconciliation did, so it is logged in a
+// single line when the reconciliation is done.
+type reconcileSummary struct {
+	actions  []string
+	clientID string
+	changes  []hydra.FieldDiff
+	code     hydrav1alpha1.StatusCode
+	err      error
+
+	// version is the version of the ORY Hydra instance of the client, if
+	// it was detected.
+	version string
+	// incompatibilities are the fields ORY Hydra ignores or deprecates,
+	// once checked is set.
+	incompatibilities []hydra.Incompatibility
+	checked           bool
+}
+
+// summaryFrom returns the summary of the reconciliation of ctx, or nil outside
+// of reconciliations. The methods of reconcileSummary accept nil.
+func summaryFrom(ctx context.Context) *reconcileSummary {
+	s, _ := ctx.Value(summaryKey{}).(*reconcileSummary)
+	return s
+}
+
+// record adds a change made to the client clientID in ORY Hydra.
+func (s *reconcileSummary) record(action audit.Action, clientID string) {
+	if s == nil {
+		return
+	}
+	s.actions = append(s.actions, string(action))
+	if clientID != "" {
+		s.clientID = clientID
+	}
+}
+
+// changed records the fields in which desired differs from current, the
+// client registered in ORY Hydra, which is nil for new clients.
+func (s *reconcileSummary) changed(desired, current *hydra.OAuth2ClientJSON) {
+	if s == nil {
+		return
+	}
+	diffs, err := hydra.Drift(desired, current)
+	if err != nil {
+		return
+	}
+	s.changes = hydra.Redact(diffs)
+}
+
+// fail records the reconciliation error err with code.
+func (s *reconcileSummary) fail(code hydrav1alpha1.StatusCode, err error) {
+	if s == nil {
+		return
+	}
+	s.code, s.err = code, err
+}
+
+// detected records the version of the ORY Hydra instance of the client.
+func (s *reconcileSummary) detected(version string) {
+	if s == nil {
+		return
+	}
+	s.version = version
+}
+
+// hydraVersion returns the version of the ORY Hydra instance of the client,
+// or "" if it is unknown.
+func (s *reconcileSummary) hydraVersion() string {
+	if s == nil {
+		return ""
+	}
+	return s.version
+}
+
+// incompatible records the fields of the written client which ORY Hydra
+// ignores or deprecates.
+func (s *reconcileSummary) incompatible(found []hydra.Incompatibility) {
+	if s == nil {
+		return
+	}
+	s.incompatibilities, s.checked = found, true
+}
+
+// action returns the changes made to ORY Hydra joined by commas, e.g.
+// delete,create when a client was registered again, or ActionNone.
+func (s *reconcileSummary) action() string {
+	if len(s.actions) == 0 {
+		return ActionNone
+	}
+	return strings.Join(s.actions, ",")
+}
+
+// logSummary logs the summary s of the reconciliation of name. Failed
+// reconciliations and those which changed ORY Hydra are logged at the info
+// level, the others at the debug level. Errors are sanitized, as ORY Hydra
+// may echo the client secret in them.
+func (r *OAuth2ClientReconciler) logSummary(name types.NamespacedName, s *reconcileSummary, result ctrl.Result, err error, duration time.Duration) {
+	if err == nil {
+		err = s.err
+	}
+	keysAndValues := []interface{}{
+		"oauth2client", name,
+		"action", s.action(),
+		"clientID", s.clientID,
+		"changes", s.changes,
+		"requeueAfter", result.RequeueAfter.String(),
+		"duration", duration.String(),
+	}
+	if s.code != "" {
+		keysAndValues = append(keysAndValues, "code", s.code)
+	}
+
+	switch {
+	case err != nil:
+		r.Log.Error(errors.New(hydra.Sanitize(err.Error())), "reconciled client", keysAndValues...)
+	case len(s.actions) > 0:
+		r.Log.Info("reconciled client", keysAndValues...)
+	default:
+		r.Log.V(1).Info("reconciled client", keysAndValues...)
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+)
+
+const (
+	// SyncReportKey is the key of the report in the sync report ConfigMap.
+	SyncReportKey = "report.json"
+	// SyncReportTimeAnnotation records when the report last changed.
+	SyncReportTimeAnnotation = "hydra.ory.sh/report-time"
+	// DefaultSyncReportMaxSize is the default size the report is truncated
+	// to, which leaves room for the metadata within the 1 MiB a ConfigMap
+	// holds.
+	DefaultSyncReportMaxSize = 900 * 1024
+)
+
+// Sync states of the OAuth2Clients in a SyncReport.
+const (
+	SyncStateSynced   = "Synced"
+	SyncStatePending  = "Pending"
+	SyncStateFailed   = "Failed"
+	SyncStateDeleting = "Deleting"
+)
+
+// SyncReportEntry is the sync state of an OAuth2Client.
+type SyncReportEntry struct {
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// State is Synced if the current generation was reconciled, Failed if
+	// the last reconciliation failed, Deleting if the OAuth2Client is being
+	// deleted and Pending otherwise.
+	State string `json:"state"`
+	// HydraEndpoint is the address of the ORY Hydra instance of the
+	// OAuth2Client, or default for the instance of the controller.
+	HydraEndpoint       string `json:"hydraEndpoint"`
+	Generation          int64  `json:"generation"`
+	ObservedGeneration  int64  `json:"observedGeneration"`
+	ConsecutiveFailures int32  `json:"consecutiveFailures,omitempty"`
+	// Reason is the reason of the Ready condition of failed OAuth2Clients.
+	Reason    hydrav1alpha1.ConditionReason `json:"reason,omitempty"`
+	LastError *SyncReportEntryError         `json:"lastError,omitempty"`
+}
+
+// SyncReportEntryError is the error of the last reconciliation of a failed
+// OAuth2Client.
+type SyncReportEntryError struct {
+	Code        hydrav1alpha1.StatusCode `json:"code"`
+	Description string                   `json:"description"`
+}
+
+// SyncReportSummary counts the OAuth2Clients of a SyncReport by state.
+type SyncReportSummary struct {
+	Total    int `json:"total"`
+	Synced   int `json:"synced"`
+	Pending  int `json:"pending"`
+	Failed   int `json:"failed"`
+	Deleting int `json:"deleting"`
+}
+
+// SyncReportData is the content of the sync report ConfigMap.
+type SyncReportData struct {
+	Summary SyncReportSummary `json:"summary"`
+	Clients []SyncReportEntry `json:"clients"`
+	// Omitted is the number of OAuth2Clients left out of Clients to fit the
+	// report into the ConfigMap. They are still counted in the summary.
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// SyncReport periodically writes the sync state of all OAuth2Clients of its
+// reconcilers into a ConfigMap, so GitOps and audit tooling can inspect a
+// single object instead of listing all OAuth2Clients of the cluster.
+type SyncReport struct {
+	// Client writes the ConfigMap.
+	client.Client
+	Reconcilers []*OAuth2ClientReconciler
+	ConfigMap   types.NamespacedName
+	Interval    time.Duration
+	Log         logr.Logger
+	// MaxSize is the size in bytes the report is truncated to,
+	// DefaultSyncReportMaxSize if 0.
+	MaxSize int
+}
+
+// Start writes the report right away and then every interval until ctx is
+// done. Failed writes are logged and retried at the next interval.
+func (s *SyncReport) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Write(ctx); err != nil {
+			s.Log.Error(err, "writing sync report failed", "configmap", s.ConfigMap)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Write writes the current report to the ConfigMap. The ConfigMap is only
+// updated if the report changed.
+func (s *SyncReport) Write(ctx context.Context) error {
+	report, err := s.Report(ctx)
+	if err != nil {
+		return err
+	}
+	maxSize := s.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultSyncReportMaxSize
+	}
+	data, err := report.truncate(maxSize)
+	if err != nil {
+		return err
+	}
+	if report.Omitted > 0 {
+		s.Log.Info("sync report exceeds the size of a ConfigMap, omitted clients", "configmap", s.ConfigMap, "omitted", report.Omitted)
+	}
+
+	cm := apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: s.ConfigMap.Name, Namespace: s.ConfigMap.Namespace},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, s.Client, &cm, func() error {
+		if cm.Data[SyncReportKey] == string(data) {
+			return nil
+		}
+		if cm.Annotations == nil {
+			cm.Annotations = map[string]string{}
+		}
+		cm.Annotations[SyncReportTimeAnnotation] = s.now().UTC().Format(time.RFC3339)
+		cm.Data = map[string]string{SyncReportKey: string(data)}
+		return nil
+	})
+	return err
+}
+
+// now returns the current time of the clock of the reconcilers.
+func (s *SyncReport) now() time.Time {
+	if len(s.Reconcilers) == 0 {
+		return time.Now()
+	}
+	return s.Reconcilers[0].clock.Now()
+}
+
+// Report returns the sync state of the OAuth2Clients, sorted by cluster,
+// namespace and name.
+func (s *SyncReport) Report(ctx context.Context) (*SyncReportData, error) {
+	return syncReport(ctx, s.Reconcilers)
+}
+
+// syncReport returns the sync state of the OAuth2Clients of reconcilers,
+// sorted by cluster, namespace and name.
+func syncReport(ctx context.Context, reconcilers []*OAuth2ClientReconciler) (*SyncReportData, error) {
+	report := &SyncReportData{Clients: []SyncReportEntry{}}
+	for _, r := range reconcilers {
+		var opts []client.ListOption
+		if r.ControllerNamespace != "" {
+			opts = append(opts, client.InNamespace(r.ControllerNamespace))
+		}
+		var list hydrav1alpha1.OAuth2ClientList
+		if err := r.List(ctx, &list, opts...); err != nil {
+			return nil, fmt.Errorf("listing OAuth2Clients of cluster %q: %w", r.ClusterName, err)
+		}
+
+		for i := range list.Items {
+			c := &list.Items[i]
+			if !r.watches(c.Namespace) {
+				continue
+			}
+			report.add(r.syncReportEntry(ctx, c))
+		}
+	}
+
+	sort.Slice(report.Clients, func(i, j int) bool {
+		a, b := report.Clients[i], report.Clients[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+// add adds entry to the clients and the summary of d.
+func (d *SyncReportData) add(entry SyncReportEntry) {
+	d.Clients = append(d.Clients, entry)
+	d.Summary.Total++
+	switch entry.State {
+	case SyncStateSynced:
+		d.Summary.Synced++
+	case SyncStatePending:
+		d.Summary.Pending++
+	case SyncStateFailed:
+		d.Summary.Failed++
+	case SyncStateDeleting:
+		d.Summary.Deleting++
+	}
+}
+
+// truncate omits clients from d until it encodes to at most size bytes and
+// returns the encoding. The synced clients are omitted first, so the failed
+// ones stay in the report.
+func (d *SyncReportData) truncate(size int) ([]byte, error) {
+	data, err := json.Marshal(d)
+	if err != nil || len(data) <= size {
+		return data, err
+	}
+
+	sizes := make([]int, len(d.Clients))
+	for i, entry := range d.Clients {
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		// with the comma separating it from the next entry
+		sizes[i] = len(encoded) + 1
+	}
+	var order []int
+	for i := len(d.Clients) - 1; i >= 0; i-- {
+		if d.Clients[i].State == SyncStateSynced {
+			order = append(order, i)
+		}
+	}
+	for i := len(d.Clients) - 1; i >= 0; i-- {
+		if d.Clients[i].State != SyncStateSynced {
+			order = append(order, i)
+		}
+	}
+
+	// leave room for the omitted count
+	excess := len(data) - size + len(`,"omitted":`) + 20
+	omitted := make(map[int]bool)
+	for _, i := range order {
+		if excess <= 0 {
+			break
+		}
+		omitted[i] = true
+		excess -= sizes[i]
+	}
+	clients := make([]SyncReportEntry, 0, len(d.Clients)-len(omitted))
+	for i, entry := range d.Clients {
+		if !omitted[i] {
+			clients = append(clients, entry)
+		}
+	}
+	d.Clients, d.Omitted = clients, len(omitted)
+	return json.Marshal(d)
+}
+
+// syncReportEntry returns the sync state of c.
+func (r *OAuth2ClientReconciler) syncReportEntry(ctx context.Context, c *hydrav1alpha1.OAuth2Client) SyncReportEntry {
+	entry := SyncReportEntry{
+		Cluster:             r.ClusterName,
+		Namespace:           c.Namespace,
+		Name:                c.Name,
+		HydraEndpoint:       r.hydraAddress(ctx, c),
+		Generation:          c.Generation,
+		ObservedGeneration:  c.Status.ObservedGeneration,
+		ConsecutiveFailures: c.Status.ConsecutiveFailures,
+	}
+
+	switch {
+	case !c.DeletionTimestamp.IsZero():
+		entry.State = SyncStateDeleting
+	case c.Status.ReconciliationError.Code != "":
+		entry.State = SyncStateFailed
+		entry.Reason = c.Status.ReconciliationError.Code.Reason()
+		entry.LastError = &SyncReportEntryError{
+			Code:        c.Status.ReconciliationError.Code,
+			Description: c.Status.ReconciliationError.Description,
+		}
+	case c.Status.ObservedGeneration == c.Generation:
+		entry.State = SyncStateSynced
+	default:
+		entry.State = SyncStatePending
+	}
+	return entry
+}
+
+// hydraAddress returns the address of the ORY Hydra instance c was last
+// reconciled against if it has a fallback instance, that of its hydraAdmin
+// or the namespace defaults otherwise, and default for the instance of the
+// controller.
+func (r *OAuth2ClientReconciler) hydraAddress(ctx context.Context, c *hydrav1alpha1.OAuth2Client) string {
+	if c.Status.HydraEndpoint != "" {
+		return c.Status.HydraEndpoint
+	}
+	admin := c.Spec.HydraAdmin
+	if ref := admin.ServiceRef; ref != nil {
+		resolved, err := r.resolveServiceRef(ctx, c.Namespace, admin)
+		if err != nil {
+			return "service " + ref.Name
+		}
+		admin = resolved
+	}
+	if admin.URL == "" {
+		if defaults, err := r.namespaceHydraAdmin(ctx, c.Namespace); err == nil && defaults != nil {
+			admin = *defaults
+		}
+	}
+	if admin.URL == "" {
+		return "default"
+	}
+	return admin.Address()
+}
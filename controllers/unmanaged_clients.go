@@ -0,0 +1,193 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/ory/hydra-maester/hydra"
+)
+
+const (
+	// UnmanagedClientsKey is the key of the report in the unmanaged clients
+	// ConfigMap.
+	UnmanagedClientsKey = "unmanaged.json"
+	// UnmanagedClientsTimeAnnotation records when the report last changed.
+	UnmanagedClientsTimeAnnotation = "hydra.ory.sh/scan-time"
+	// UnmanagedClientFoundReason is the reason of the events emitted for
+	// unmanaged clients.
+	UnmanagedClientFoundReason = "UnmanagedClientFound"
+)
+
+// UnmanagedClient is a client in ORY Hydra which is not owned by an
+// OAuth2Client of the controller.
+type UnmanagedClient struct {
+	ClientID   string `json:"clientId"`
+	ClientName string `json:"clientName,omitempty"`
+	// Owner is the owner of the client in ORY Hydra, if any.
+	Owner      string   `json:"owner,omitempty"`
+	GrantTypes []string `json:"grantTypes,omitempty"`
+	Scope      string   `json:"scope,omitempty"`
+}
+
+// UnmanagedClientsReport is the content of the unmanaged clients ConfigMap.
+type UnmanagedClientsReport struct {
+	Total   int               `json:"total"`
+	Clients []UnmanagedClient `json:"clients"`
+}
+
+// UnmanagedClientScanner periodically lists the clients of the default ORY
+// Hydra instance which are not owned by an OAuth2Client, e.g. shadow clients
+// created with the ORY Hydra CLI, and writes them into a ConfigMap, so they
+// can be imported with the import command or deleted. A warning event is
+// emitted on the ConfigMap for each client found since the controller
+// started.
+type UnmanagedClientScanner struct {
+	// Client writes the ConfigMap.
+	client.Client
+	// HydraClient returns the current default hydra client.
+	HydraClient func() hydra.Client
+	// Reconcilers manage the OAuth2Clients of the controller. Clients whose
+	// owner doesn't carry the cluster and controller identity of one of
+	// them are unmanaged, and the clock of the first one times the scans.
+	Reconcilers []*OAuth2ClientReconciler
+	ConfigMap   types.NamespacedName
+	Interval    time.Duration
+	Log         logr.Logger
+	// Recorder emits the events, if set.
+	Recorder record.EventRecorder
+	// Gauge is set to the number of unmanaged clients, if set, see
+	// UnmanagedClientsGauge.
+	Gauge prometheus.Gauge
+
+	reported map[string]bool
+}
+
+// Start scans right away and then every interval until ctx is done. Failed
+// scans are logged and retried at the next interval.
+func (s *UnmanagedClientScanner) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Scan(ctx); err != nil {
+			s.Log.Error(err, "scanning for unmanaged hydra clients failed", "configmap", s.ConfigMap)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan writes the unmanaged clients to the ConfigMap. The ConfigMap is only
+// updated if they changed.
+func (s *UnmanagedClientScanner) Scan(ctx context.Context) error {
+	report, err := s.Report(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	cm := apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: s.ConfigMap.Name, Namespace: s.ConfigMap.Namespace},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, s.Client, &cm, func() error {
+		if cm.Data[UnmanagedClientsKey] == string(data) {
+			return nil
+		}
+		if cm.Annotations == nil {
+			cm.Annotations = map[string]string{}
+		}
+		cm.Annotations[UnmanagedClientsTimeAnnotation] = s.now().UTC().Format(time.RFC3339)
+		cm.Data = map[string]string{UnmanagedClientsKey: string(data)}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.Gauge != nil {
+		s.Gauge.Set(float64(report.Total))
+	}
+	// clients are reported once while they exist
+	reported := make(map[string]bool, len(report.Clients))
+	for _, c := range report.Clients {
+		reported[c.ClientID] = true
+		if s.reported[c.ClientID] {
+			continue
+		}
+		s.Log.Info("found unmanaged hydra client", "client_id", c.ClientID, "owner", c.Owner)
+		if s.Recorder != nil {
+			s.Recorder.Event(&cm, apiv1.EventTypeWarning, UnmanagedClientFoundReason,
+				fmt.Sprintf("client %s (%s) in ORY Hydra is not owned by an OAuth2Client", c.ClientID, c.ClientName))
+		}
+	}
+	s.reported = reported
+	return nil
+}
+
+// Report returns the unmanaged clients of the default ORY Hydra instance,
+// sorted by client ID.
+func (s *UnmanagedClientScanner) Report(ctx context.Context) (*UnmanagedClientsReport, error) {
+	clients, err := s.HydraClient().ListOAuth2Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UnmanagedClientsReport{Clients: []UnmanagedClient{}}
+	for _, c := range clients {
+		if s.managed(c.Owner) || c.ClientID == nil {
+			continue
+		}
+		report.Clients = append(report.Clients, UnmanagedClient{
+			ClientID:   *c.ClientID,
+			ClientName: c.ClientName,
+			Owner:      c.Owner,
+			GrantTypes: c.GrantTypes,
+			Scope:      c.Scope,
+		})
+	}
+	sort.Slice(report.Clients, func(i, j int) bool {
+		return report.Clients[i].ClientID < report.Clients[j].ClientID
+	})
+	report.Total = len(report.Clients)
+	return report, nil
+}
+
+// managed reports whether owner names an OAuth2Client of one of the
+// reconcilers.
+func (s *UnmanagedClientScanner) managed(owner string) bool {
+	for _, r := range s.Reconcilers {
+		if r.owns(owner) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *UnmanagedClientScanner) now() time.Time {
+	if len(s.Reconcilers) == 0 {
+		return time.Now()
+	}
+	return s.Reconcilers[0].clock.Now()
+}
@@ -0,0 +1,109 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package envelope encrypts client secrets before they are written to
+// Kubernetes Secrets, for clusters without encryption at rest in etcd. Every
+// value is encrypted with a new AES-256-GCM data key, and the data key is
+// encrypted (wrapped) by a KeyWrapper, e.g. a KMS behind a Plugin.
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Prefix starts every encrypted value. It is followed by the wrapped data key
+// and the nonce and ciphertext, both base64 encoded and separated by a colon.
+const Prefix = "hydra-maester:v1:"
+
+const keySize = 32
+
+// KeyWrapper encrypts and decrypts data keys, usually with a key which never
+// leaves a KMS.
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// IsEncrypted reports whether value was encrypted by Encrypt.
+func IsEncrypted(value []byte) bool {
+	return bytes.HasPrefix(value, []byte(Prefix))
+}
+
+// Encrypt encrypts plaintext with a new data key wrapped by kw.
+func Encrypt(ctx context.Context, kw KeyWrapper, plaintext []byte) ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := kw.WrapKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to wrap data key: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return []byte(Prefix + base64.StdEncoding.EncodeToString(wrapped) + ":" + base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt decrypts a value encrypted by Encrypt, unwrapping its data key with
+// kw.
+func Decrypt(ctx context.Context, kw KeyWrapper, value []byte) ([]byte, error) {
+	if !IsEncrypted(value) {
+		return nil, errors.New("value is not encrypted")
+	}
+	encodedKey, encodedSealed, ok := bytes.Cut(bytes.TrimPrefix(value, []byte(Prefix)), []byte(":"))
+	if !ok {
+		return nil, errors.New("malformed encrypted value")
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(string(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(encodedSealed))
+	if err != nil {
+		return nil, fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	key, err := kw.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt value: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("data key must have %d bytes, got %d", keySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,105 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package envelope_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/envelope"
+)
+
+// xorWrapper wraps keys by xoring them with a byte, standing in for a KMS.
+type xorWrapper struct {
+	b   byte
+	err error
+}
+
+func (w xorWrapper) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	return w.xor(key), w.err
+}
+
+func (w xorWrapper) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	return w.xor(wrapped), w.err
+}
+
+func (w xorWrapper) xor(in []byte) []byte {
+	out := make([]byte, len(in))
+	for i := range in {
+		out[i] = in[i] ^ w.b
+	}
+	return out
+}
+
+func TestEncrypt(t *testing.T) {
+	ctx := context.Background()
+	kw := xorWrapper{b: 0x5a}
+
+	encrypted, err := envelope.Encrypt(ctx, kw, []byte("client-secret"))
+	require.NoError(t, err)
+	assert.True(t, envelope.IsEncrypted(encrypted))
+	assert.NotContains(t, string(encrypted), "client-secret")
+
+	decrypted, err := envelope.Decrypt(ctx, kw, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("client-secret"), decrypted)
+
+	t.Run("case=uses a new data key for every value", func(t *testing.T) {
+		again, err := envelope.Encrypt(ctx, kw, []byte("client-secret"))
+		require.NoError(t, err)
+		assert.NotEqual(t, encrypted, again)
+	})
+
+	t.Run("case=fails with another key", func(t *testing.T) {
+		_, err := envelope.Decrypt(ctx, xorWrapper{b: 0x42}, encrypted)
+		assert.Error(t, err)
+	})
+
+	t.Run("case=fails if the key can't be wrapped", func(t *testing.T) {
+		_, err := envelope.Encrypt(ctx, xorWrapper{err: errors.New("kms unavailable")}, []byte("client-secret"))
+		assert.ErrorContains(t, err, "kms unavailable")
+	})
+
+	t.Run("case=rejects malformed values", func(t *testing.T) {
+		for _, value := range []string{"client-secret", envelope.Prefix, envelope.Prefix + "a:b", envelope.Prefix + "AAAA:AAAA"} {
+			_, err := envelope.Decrypt(ctx, kw, []byte(value))
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugin is a shell script")
+	}
+
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	require.NoError(t, os.WriteFile(path, []byte(`#!/bin/sh
+case "$1" in
+wrap) base64 ;;
+unwrap) base64 -d ;;
+*) echo "unknown operation $1" >&2; exit 1 ;;
+esac
+`), 0o700))
+
+	ctx := context.Background()
+	kw := envelope.Plugin{Path: path}
+	encrypted, err := envelope.Encrypt(ctx, kw, []byte("client-secret"))
+	require.NoError(t, err)
+	decrypted, err := envelope.Decrypt(ctx, kw, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("client-secret"), decrypted)
+
+	t.Run("case=reports the errors of the plugin", func(t *testing.T) {
+		_, err := envelope.Plugin{Path: filepath.Join(t.TempDir(), "missing")}.WrapKey(ctx, []byte("key"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,45 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Plugin wraps data keys with an executable, so any KMS can be used through
+// its command-line tools. The executable is called with the argument wrap or
+// unwrap, reads the key from stdin and writes the result to stdout. See
+// hack/encryption for plugins for AWS KMS, GCP KMS and age.
+type Plugin struct {
+	Path string
+}
+
+// WrapKey implements KeyWrapper.
+func (p Plugin) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	return p.run(ctx, "wrap", key)
+}
+
+// UnwrapKey implements KeyWrapper.
+func (p Plugin) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return p.run(ctx, "unwrap", wrapped)
+}
+
+func (p Plugin) run(ctx context.Context, op string, in []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path, op)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("encryption plugin %s %s: %w: %s", p.Path, op, err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.Len() == 0 {
+		return nil, fmt.Errorf("encryption plugin %s %s: no output", p.Path, op)
+	}
+	return stdout.Bytes(), nil
+}
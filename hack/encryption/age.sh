@@ -0,0 +1,21 @@
+#!/bin/sh
+# Copyright © 2024 Ory Corp
+# SPDX-License-Identifier: Apache-2.0
+
+# Secret encryption plugin wrapping the data keys with age. Wrapping needs the
+# public key in AGE_RECIPIENT, unwrapping the path of the private key in
+# AGE_IDENTITY_FILE.
+set -eu
+
+case "$1" in
+wrap)
+  age -r "$AGE_RECIPIENT"
+  ;;
+unwrap)
+  age -d -i "$AGE_IDENTITY_FILE"
+  ;;
+*)
+  echo "usage: $0 wrap|unwrap" >&2
+  exit 1
+  ;;
+esac
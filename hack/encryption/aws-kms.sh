@@ -0,0 +1,20 @@
+#!/bin/sh
+# Copyright © 2024 Ory Corp
+# SPDX-License-Identifier: Apache-2.0
+
+# Secret encryption plugin wrapping the data keys with AWS KMS. Needs the aws
+# CLI and the key ID or alias in KMS_KEY_ID, e.g. alias/hydra-maester.
+set -eu
+
+case "$1" in
+wrap)
+  aws kms encrypt --key-id "$KMS_KEY_ID" --plaintext fileb:///dev/stdin --output text --query CiphertextBlob | base64 -d
+  ;;
+unwrap)
+  aws kms decrypt --key-id "$KMS_KEY_ID" --ciphertext-blob fileb:///dev/stdin --output text --query Plaintext | base64 -d
+  ;;
+*)
+  echo "usage: $0 wrap|unwrap" >&2
+  exit 1
+  ;;
+esac
@@ -0,0 +1,21 @@
+#!/bin/sh
+# Copyright © 2024 Ory Corp
+# SPDX-License-Identifier: Apache-2.0
+
+# Secret encryption plugin wrapping the data keys with GCP Cloud KMS. Needs the
+# gcloud CLI and the resource name of the key in KMS_KEY, e.g.
+# projects/my-project/locations/global/keyRings/hydra/cryptoKeys/hydra-maester.
+set -eu
+
+case "$1" in
+wrap)
+  gcloud kms encrypt --key "$KMS_KEY" --plaintext-file - --ciphertext-file -
+  ;;
+unwrap)
+  gcloud kms decrypt --key "$KMS_KEY" --ciphertext-file - --plaintext-file -
+  ;;
+*)
+  echo "usage: $0 wrap|unwrap" >&2
+  exit 1
+  ;;
+esac
@@ -0,0 +1,39 @@
+#!/usr/bin/env bash
+# Copyright © 2024 Ory Corp
+# SPDX-License-Identifier: Apache-2.0
+
+# Generates the typed clientset, listers and informers of the hydra.ory.sh
+# API group into client/.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+CODEGEN_VERSION=${CODEGEN_VERSION:-v0.30.2}
+MODULE=github.com/ory/hydra-maester
+ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+HEADER="${ROOT}/hack/boilerplate.go.txt"
+
+cd "${ROOT}"
+
+go run "k8s.io/code-generator/cmd/client-gen@${CODEGEN_VERSION}" \
+  --clientset-name versioned \
+  --input-base "${MODULE}" \
+  --input api/v1alpha1 \
+  --output-dir client/clientset \
+  --output-pkg "${MODULE}/client/clientset" \
+  --go-header-file "${HEADER}"
+
+go run "k8s.io/code-generator/cmd/lister-gen@${CODEGEN_VERSION}" \
+  --output-dir client/listers \
+  --output-pkg "${MODULE}/client/listers" \
+  --go-header-file "${HEADER}" \
+  ./api/v1alpha1
+
+go run "k8s.io/code-generator/cmd/informer-gen@${CODEGEN_VERSION}" \
+  --versioned-clientset-package "${MODULE}/client/clientset/versioned" \
+  --listers-package "${MODULE}/client/listers" \
+  --output-dir client/informers \
+  --output-pkg "${MODULE}/client/informers" \
+  --go-header-file "${HEADER}" \
+  ./api/v1alpha1
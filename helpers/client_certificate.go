@@ -0,0 +1,194 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// clientCertificateRetryInterval is how long a failed renewal of a client
+// certificate waits before it is retried.
+const clientCertificateRetryInterval = time.Minute
+
+// +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=get;create
+
+// ClientCertificate obtains a client certificate from the Kubernetes
+// CertificateSigningRequest API, e.g. for mTLS with the ORY Hydra admin API,
+// and renews it after two thirds of its lifetime. The requests must be
+// approved by an approver of the signer, e.g. cert-manager's approver-policy.
+// Issued requests are garbage collected by Kubernetes.
+type ClientCertificate struct {
+	Client client.Client
+	// SignerName is the signer of the requests, e.g.
+	// clusterissuers.cert-manager.io/hydra-clients.
+	SignerName string
+	// CommonName is the common name of the subject of the certificate.
+	CommonName string
+	// Duration is the requested lifetime of the certificate. Zero leaves it
+	// to the signer.
+	Duration time.Duration
+	Log      logr.Logger
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// GetClientCertificate returns the current certificate, it can be used as
+// tls.Config.GetClientCertificate. It fails before the first certificate was
+// issued.
+func (c *ClientCertificate) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.cert == nil {
+		return nil, fmt.Errorf("client certificate has not been issued yet")
+	}
+	return c.cert, nil
+}
+
+// Request requests a new certificate with a new key and waits until it is
+// issued, or denied, or ctx is done.
+func (c *ClientCertificate) Request(ctx context.Context) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: c.CommonName},
+	}, key)
+	if err != nil {
+		return err
+	}
+
+	csr := &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "hydra-maester-"},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}),
+			SignerName: c.SignerName,
+			Usages:     []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth},
+		},
+	}
+	if c.Duration > 0 {
+		seconds := int32(c.Duration.Seconds())
+		csr.Spec.ExpirationSeconds = &seconds
+	}
+	if err := c.Client.Create(ctx, csr); err != nil {
+		return fmt.Errorf("creating CertificateSigningRequest: %w", err)
+	}
+
+	err = wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		if err := c.Client.Get(ctx, client.ObjectKeyFromObject(csr), csr); err != nil {
+			return false, err
+		}
+		for _, cond := range csr.Status.Conditions {
+			if (cond.Type == certificatesv1.CertificateDenied || cond.Type == certificatesv1.CertificateFailed) && cond.Status == apiv1.ConditionTrue {
+				return false, fmt.Errorf("CertificateSigningRequest %s is %s: %s", csr.Name, cond.Type, cond.Message)
+			}
+		}
+		return len(csr.Status.Certificate) > 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for CertificateSigningRequest %s to be issued: %w", csr.Name, err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.X509KeyPair(csr.Status.Certificate, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		return fmt.Errorf("certificate of CertificateSigningRequest %s is invalid: %w", csr.Name, err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return fmt.Errorf("certificate of CertificateSigningRequest %s is invalid: %w", csr.Name, err)
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.mu.Unlock()
+	c.Log.Info("client certificate issued", "certificatesigningrequest", csr.Name, "notAfter", cert.Leaf.NotAfter)
+	return nil
+}
+
+// Start renews the certificate after two thirds of its lifetime until ctx is
+// done, requesting the first one right away if there is none yet. Failed
+// renewals are logged and retried.
+func (c *ClientCertificate) Start(ctx context.Context) error {
+	for {
+		if delay := time.Until(c.renewal()); delay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+		}
+
+		if err := c.Request(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			c.Log.Error(err, "renewing client certificate failed", "retryAfter", clientCertificateRetryInterval)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(clientCertificateRetryInterval):
+			}
+		}
+	}
+}
+
+// NeedLeaderElection is false as all replicas need a valid certificate.
+func (c *ClientCertificate) NeedLeaderElection() bool {
+	return false
+}
+
+// renewal returns when the current certificate is renewed, the zero time if
+// there is none.
+func (c *ClientCertificate) renewal() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.cert == nil {
+		return time.Time{}
+	}
+	lifetime := c.cert.Leaf.NotAfter.Sub(c.cert.Leaf.NotBefore)
+	return c.cert.Leaf.NotBefore.Add(lifetime * 2 / 3)
+}
+
+// UseClientCertificate makes c present the certificate returned by get, e.g.
+// ClientCertificate.GetClientCertificate, to servers asking for one. The
+// other TLS settings of c are kept.
+func UseClientCertificate(c *http.Client, get func(*tls.CertificateRequestInfo) (*tls.Certificate, error)) error {
+	var tr *http.Transport
+	switch t := c.Transport.(type) {
+	case nil:
+		tr = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		tr = t.Clone()
+	default:
+		return fmt.Errorf("client certificates can't be used with transport %T", c.Transport)
+	}
+	if tr.TLSClientConfig == nil {
+		tr.TLSClientConfig = &tls.Config{}
+	}
+	tr.TLSClientConfig.GetClientCertificate = get
+	c.Transport = tr
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/ory/hydra-maester/helpers"
+)
+
+func TestClientCertificate(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "hydra-clients"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	s := runtime.NewScheme()
+	require.NoError(t, certificatesv1.AddToScheme(s))
+
+	// newClient returns a client on which created CertificateSigningRequests
+	// are decided right away by sign, and records the created requests.
+	newClient := func(sign func(*certificatesv1.CertificateSigningRequest), created *[]certificatesv1.CertificateSigningRequest) client.Client {
+		return fake.NewClientBuilder().WithScheme(s).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				csr := obj.(*certificatesv1.CertificateSigningRequest)
+				*created = append(*created, *csr.DeepCopy())
+				sign(csr)
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+	}
+
+	// issue signs requests with the CA.
+	issue := func(csr *certificatesv1.CertificateSigningRequest) {
+		block, _ := pem.Decode(csr.Spec.Request)
+		request, err := x509.ParseCertificateRequest(block.Bytes)
+		require.NoError(t, err)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      request.Subject,
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, ca, request.PublicKey, caKey)
+		require.NoError(t, err)
+		csr.Status.Certificate = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	t.Run("case=requests a certificate of the signer", func(t *testing.T) {
+		var created []certificatesv1.CertificateSigningRequest
+		cert := &helpers.ClientCertificate{
+			Client:     newClient(issue, &created),
+			SignerName: "example.com/hydra-clients",
+			CommonName: "hydra-maester",
+			Duration:   24 * time.Hour,
+			Log:        logr.Discard(),
+		}
+
+		_, err := cert.GetClientCertificate(nil)
+		require.EqualError(t, err, "client certificate has not been issued yet")
+
+		require.NoError(t, cert.Request(context.Background()))
+		require.Len(t, created, 1)
+		assert.Equal(t, "example.com/hydra-clients", created[0].Spec.SignerName)
+		assert.Equal(t, []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth}, created[0].Spec.Usages)
+		assert.Equal(t, int32(86400), *created[0].Spec.ExpirationSeconds)
+
+		issued, err := cert.GetClientCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hydra-maester", issued.Leaf.Subject.CommonName)
+
+		require.NoError(t, cert.Request(context.Background()))
+		renewed, err := cert.GetClientCertificate(nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, issued.PrivateKey, renewed.PrivateKey)
+	})
+
+	t.Run("case=presents the certificate to servers", func(t *testing.T) {
+		var created []certificatesv1.CertificateSigningRequest
+		cert := &helpers.ClientCertificate{Client: newClient(issue, &created), SignerName: "example.com/hydra-clients", CommonName: "hydra-maester", Log: logr.Discard()}
+		require.NoError(t, cert.Request(context.Background()))
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+		}))
+		pool := x509.NewCertPool()
+		pool.AddCert(ca)
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+		server.StartTLS()
+		defer server.Close()
+
+		c := server.Client()
+		require.NoError(t, helpers.UseClientCertificate(c, cert.GetClientCertificate))
+		resp, err := c.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "hydra-maester", string(body))
+	})
+
+	t.Run("case=fails if the request is denied", func(t *testing.T) {
+		var created []certificatesv1.CertificateSigningRequest
+		cert := &helpers.ClientCertificate{Client: newClient(func(csr *certificatesv1.CertificateSigningRequest) {
+			csr.Status.Conditions = []certificatesv1.CertificateSigningRequestCondition{{
+				Type:    certificatesv1.CertificateDenied,
+				Status:  apiv1.ConditionTrue,
+				Message: "not allowed by policy",
+			}}
+		}, &created), SignerName: "example.com/hydra-clients", CommonName: "hydra-maester", Log: logr.Discard()}
+
+		err := cert.Request(context.Background())
+		require.ErrorContains(t, err, "is Denied: not allowed by policy")
+		_, err = cert.GetClientCertificate(nil)
+		require.Error(t, err)
+	})
+
+	t.Run("case=fails if the request is not issued in time", func(t *testing.T) {
+		var created []certificatesv1.CertificateSigningRequest
+		cert := &helpers.ClientCertificate{Client: newClient(func(*certificatesv1.CertificateSigningRequest) {}, &created), SignerName: "example.com/hydra-clients", CommonName: "hydra-maester", Log: logr.Discard()}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		require.ErrorContains(t, cert.Request(ctx), "to be issued: ")
+	})
+}
+
+func TestUseClientCertificate(t *testing.T) {
+	t.Run("case=keeps the TLS settings of the client", func(t *testing.T) {
+		c, err := helpers.CreateHttpClient(true, "")
+		require.NoError(t, err)
+		require.NoError(t, helpers.UseClientCertificate(c, nil))
+		assert.True(t, c.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+	})
+
+	t.Run("case=rejects other transports", func(t *testing.T) {
+		c := &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })}
+		require.EqualError(t, helpers.UseClientCertificate(c, nil), "client certificates can't be used with transport helpers_test.roundTripperFunc")
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
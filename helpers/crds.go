@@ -0,0 +1,143 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// CRDFieldManager is the field manager used when applying CRDs.
+const CRDFieldManager = "hydra-maester"
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;create;patch
+
+// InstallCRDs applies the given CustomResourceDefinition manifests with
+// server-side apply and waits until they are established. Fields owned by
+// other field managers, e.g. Helm, are not taken over; such conflicts are
+// returned as errors.
+func InstallCRDs(ctx context.Context, c client.Client, manifests ...[]byte) error {
+	for _, m := range manifests {
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := yaml.Unmarshal(m, &crd); err != nil {
+			return err
+		}
+		crd.APIVersion = apiextensionsv1.SchemeGroupVersion.String()
+		crd.Kind = "CustomResourceDefinition"
+
+		if err := c.Patch(ctx, &crd, client.Apply, client.FieldOwner(CRDFieldManager)); err != nil {
+			if apierrors.IsConflict(err) {
+				return fmt.Errorf("CRD %s is managed by another tool, update it there or remove --install-crds: %w", crd.Name, err)
+			}
+			return fmt.Errorf("applying CRD %s: %w", crd.Name, err)
+		}
+
+		err := wait.PollUntilContextTimeout(ctx, time.Second, time.Minute, true, func(ctx context.Context) (bool, error) {
+			if err := c.Get(ctx, client.ObjectKeyFromObject(&crd), &crd); err != nil {
+				return false, err
+			}
+			for _, cond := range crd.Status.Conditions {
+				if cond.Type == apiextensionsv1.Established && cond.Status == apiextensionsv1.ConditionTrue {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		if err != nil {
+			return fmt.Errorf("waiting for CRD %s to be established: %w", crd.Name, err)
+		}
+	}
+	return nil
+}
+
+// storageMigrationPageSize is the number of objects MigrateStorageVersion
+// lists at once.
+const storageMigrationPageSize = 500
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=update
+
+// MigrateStorageVersion rewrites all objects of the CRD name in its storage
+// version and then records it as the only stored version of the CRD, so older
+// versions can be removed from the CRD in an upgrade without stranding
+// objects. Like the kube-storage-version-migrator, the objects are updated
+// unchanged, which makes the API server store them in the storage version.
+// Nothing is rewritten if the storage version is the only stored version
+// already. It returns the number of rewritten objects, which leaves out the
+// objects changed or deleted since they were listed.
+func MigrateStorageVersion(ctx context.Context, c client.Client, name string) (int, error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, &crd); err != nil {
+		return 0, err
+	}
+	storage := storageVersion(&crd)
+	if storage == "" {
+		return 0, fmt.Errorf("CRD %s has no storage version", name)
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storage {
+		return 0, nil
+	}
+
+	migrated := 0
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: crd.Spec.Group, Version: storage, Kind: crd.Spec.Names.ListKind})
+	for {
+		opts := []client.ListOption{client.Limit(storageMigrationPageSize)}
+		if cont := list.GetContinue(); cont != "" {
+			opts = append(opts, client.Continue(cont))
+		}
+		if err := c.List(ctx, list, opts...); err != nil {
+			return migrated, fmt.Errorf("listing the objects of CRD %s: %w", name, err)
+		}
+		for i := range list.Items {
+			// objects which changed or were deleted since they were listed
+			// are stored in the storage version already
+			obj := &list.Items[i]
+			if err := c.Update(ctx, obj); err != nil {
+				if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+					continue
+				}
+				return migrated, fmt.Errorf("rewriting %s %s/%s: %w", crd.Spec.Names.Kind, obj.GetNamespace(), obj.GetName(), err)
+			}
+			migrated++
+		}
+		if list.GetContinue() == "" {
+			break
+		}
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, &crd); err != nil {
+			return err
+		}
+		if storageVersion(&crd) != storage {
+			return fmt.Errorf("the storage version of CRD %s changed during the migration", name)
+		}
+		crd.Status.StoredVersions = []string{storage}
+		return c.Status().Update(ctx, &crd)
+	})
+	if err != nil {
+		return migrated, fmt.Errorf("updating the stored versions of CRD %s: %w", name, err)
+	}
+	return migrated, nil
+}
+
+// storageVersion returns the storage version of crd, or "" if it has none.
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return ""
+}
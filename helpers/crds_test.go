@@ -0,0 +1,184 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/helpers"
+)
+
+const crdManifest = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: oauth2clients.hydra.ory.sh
+spec:
+  group: hydra.ory.sh
+  names:
+    kind: OAuth2Client
+    plural: oauth2clients
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+`
+
+func TestInstallCRDs(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, apiextensionsv1.AddToScheme(s))
+
+	// newClient returns a client on which applying a CRD creates it, as
+	// established if established is set, and records the applied CRDs and
+	// their field managers. The fake client doesn't support apply patches.
+	newClient := func(established bool, applied *[]string) client.Client {
+		return fake.NewClientBuilder().WithScheme(s).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				require.Equal(t, client.Apply, patch)
+				po := &client.PatchOptions{}
+				po.ApplyOptions(opts)
+
+				crd := obj.(*apiextensionsv1.CustomResourceDefinition)
+				assert.Equal(t, "apiextensions.k8s.io/v1", crd.APIVersion)
+				assert.Equal(t, "CustomResourceDefinition", crd.Kind)
+				*applied = append(*applied, crd.Name+"@"+po.FieldManager)
+
+				created := crd.DeepCopy()
+				created.ResourceVersion = ""
+				if established {
+					created.Status.Conditions = []apiextensionsv1.CustomResourceDefinitionCondition{{
+						Type:   apiextensionsv1.Established,
+						Status: apiextensionsv1.ConditionTrue,
+					}}
+				}
+				return c.Create(ctx, created)
+			},
+		}).Build()
+	}
+
+	t.Run("case=applies the CRDs and waits until they are established", func(t *testing.T) {
+		var applied []string
+		c := newClient(true, &applied)
+
+		require.NoError(t, helpers.InstallCRDs(context.Background(), c, []byte(crdManifest)))
+		assert.Equal(t, []string{"oauth2clients.hydra.ory.sh@" + helpers.CRDFieldManager}, applied)
+
+		var crd apiextensionsv1.CustomResourceDefinition
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "oauth2clients.hydra.ory.sh"}, &crd))
+		assert.Equal(t, "hydra.ory.sh", crd.Spec.Group)
+	})
+
+	t.Run("case=fails if a CRD is not established in time", func(t *testing.T) {
+		var applied []string
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		err := helpers.InstallCRDs(ctx, newClient(false, &applied), []byte(crdManifest))
+		require.ErrorContains(t, err, "waiting for CRD oauth2clients.hydra.ory.sh to be established: ")
+	})
+
+	t.Run("case=does not take over CRDs managed by another tool", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(s).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				return apierrors.NewConflict(schema.GroupResource{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}, obj.GetName(), nil)
+			},
+		}).Build()
+
+		err := helpers.InstallCRDs(context.Background(), c, []byte(crdManifest))
+		require.ErrorContains(t, err, "CRD oauth2clients.hydra.ory.sh is managed by another tool, update it there or remove --install-crds: ")
+		assert.True(t, apierrors.IsConflict(err))
+	})
+
+	t.Run("case=fails on invalid manifests", func(t *testing.T) {
+		var applied []string
+		err := helpers.InstallCRDs(context.Background(), newClient(true, &applied), []byte("spec: ["))
+		require.Error(t, err)
+		assert.Empty(t, applied)
+	})
+}
+
+func TestMigrateStorageVersion(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, apiextensionsv1.AddToScheme(s))
+	require.NoError(t, hydrav1alpha1.AddToScheme(s))
+
+	newCRD := func(storedVersions ...string) *apiextensionsv1.CustomResourceDefinition {
+		return &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "oauth2clients.hydra.ory.sh"},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: "hydra.ory.sh",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: "OAuth2Client", ListKind: "OAuth2ClientList", Plural: "oauth2clients"},
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+					{Name: "v1alpha0", Served: false},
+					{Name: "v1alpha1", Served: true, Storage: true},
+				},
+			},
+			Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: storedVersions},
+		}
+	}
+	newOAuth2Client := func(namespace, name string) *hydrav1alpha1.OAuth2Client {
+		return &hydrav1alpha1.OAuth2Client{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+
+	// newClient returns a client with objs which records the names of the
+	// updated OAuth2Clients, and fails the update of those in conflicts.
+	newClient := func(updated *[]string, conflicts []string, objs ...client.Object) client.Client {
+		return fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).WithStatusSubresource(&apiextensionsv1.CustomResourceDefinition{}).WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				name := obj.GetNamespace() + "/" + obj.GetName()
+				for _, conflict := range conflicts {
+					if conflict == name {
+						return apierrors.NewConflict(schema.GroupResource{Group: "hydra.ory.sh", Resource: "oauth2clients"}, obj.GetName(), nil)
+					}
+				}
+				*updated = append(*updated, name)
+				return c.Update(ctx, obj, opts...)
+			},
+		}).Build()
+	}
+
+	t.Run("case=rewrites the objects and drops the old stored versions", func(t *testing.T) {
+		var updated []string
+		c := newClient(&updated, []string{"b/app"}, newCRD("v1alpha0", "v1alpha1"), newOAuth2Client("a", "app"), newOAuth2Client("b", "app"), newOAuth2Client("b", "web"))
+
+		migrated, err := helpers.MigrateStorageVersion(context.Background(), c, "oauth2clients.hydra.ory.sh")
+		require.NoError(t, err)
+		assert.Equal(t, 2, migrated)
+		assert.ElementsMatch(t, []string{"a/app", "b/web"}, updated)
+
+		var crd apiextensionsv1.CustomResourceDefinition
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "oauth2clients.hydra.ory.sh"}, &crd))
+		assert.Equal(t, []string{"v1alpha1"}, crd.Status.StoredVersions)
+	})
+
+	t.Run("case=does nothing if the storage version is the only stored version", func(t *testing.T) {
+		var updated []string
+		c := newClient(&updated, nil, newCRD("v1alpha1"), newOAuth2Client("a", "app"))
+
+		migrated, err := helpers.MigrateStorageVersion(context.Background(), c, "oauth2clients.hydra.ory.sh")
+		require.NoError(t, err)
+		assert.Zero(t, migrated)
+		assert.Empty(t, updated)
+	})
+
+	t.Run("case=fails without the CRD", func(t *testing.T) {
+		var updated []string
+		_, err := helpers.MigrateStorageVersion(context.Background(), newClient(&updated, nil), "oauth2clients.hydra.ory.sh")
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// NewDialer returns a dialer resolving host names with the DNS servers at
+// addrs, given as <ip>:<port>, instead of those of the host, e.g. for
+// split-horizon DNS. Queries are spread over the servers round-robin, so
+// retries skip a server which doesn't answer.
+func NewDialer(addrs []string) *net.Dialer {
+	var next atomic.Uint32
+	dnsDialer := &net.Dialer{Timeout: 5 * time.Second}
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				addr := addrs[int(next.Add(1)-1)%len(addrs)]
+				return dnsDialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+// UseDialer makes c dial its connections with d, e.g. one returned by
+// NewDialer. The other settings of the transport of c are kept.
+func UseDialer(c *http.Client, d *net.Dialer) error {
+	var tr *http.Transport
+	switch t := c.Transport.(type) {
+	case nil:
+		tr = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		tr = t.Clone()
+	default:
+		return fmt.Errorf("a dialer can't be used with transport %T", c.Transport)
+	}
+	tr.DialContext = d.DialContext
+	c.Transport = tr
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers_test
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/ory/hydra-maester/helpers"
+)
+
+// serveDNS answers the A queries for name with 127.0.0.1 on a local UDP
+// port, and all other queries with NXDOMAIN. It returns the address of the
+// server.
+func serveDNS(t *testing.T, name string) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true, RCode: dnsmessage.RCodeNameError},
+				Questions: []dnsmessage.Question{q},
+			}
+			if q.Name.String() == name {
+				resp.Header.RCode = dnsmessage.RCodeSuccess
+				if q.Type == dnsmessage.TypeA {
+					resp.Answers = []dnsmessage.Resource{{
+						Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+						Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+					}}
+				}
+			}
+			packed, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDialer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	u.Host = net.JoinHostPort("hydra.split-horizon.test", u.Port())
+
+	t.Run("case=resolves with the DNS servers", func(t *testing.T) {
+		c := &http.Client{}
+		require.NoError(t, helpers.UseDialer(c, helpers.NewDialer([]string{serveDNS(t, "hydra.split-horizon.test.")})))
+
+		resp, err := c.Get(u.String())
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("case=keeps the TLS settings", func(t *testing.T) {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{ServerName: "hydra"}}}
+		require.NoError(t, helpers.UseDialer(c, helpers.NewDialer([]string{"127.0.0.1:53"})))
+		assert.Equal(t, "hydra", c.Transport.(*http.Transport).TLSClientConfig.ServerName)
+	})
+
+	t.Run("case=rejects other transports", func(t *testing.T) {
+		c := &http.Client{Transport: roundTripper(nil)}
+		assert.Error(t, helpers.UseDialer(c, helpers.NewDialer([]string{"127.0.0.1:53"})))
+	})
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
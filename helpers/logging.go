@@ -0,0 +1,28 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers
+
+import (
+	"fmt"
+	"time"
+
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// LogSampling returns the logger option sampling log entries with the same
+// level and message: each second, the first initial entries are logged and
+// then every thereafter-th one. Sampling is disabled if initial is zero.
+func LogSampling(initial, thereafter int) (zap.Opts, error) {
+	if initial < 0 || thereafter < 0 {
+		return nil, fmt.Errorf("log-sampling-initial and log-sampling-thereafter must not be negative")
+	}
+	if initial == 0 {
+		return func(*zap.Options) {}, nil
+	}
+	return zap.RawZapOpts(uberzap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(core, time.Second, initial, thereafter)
+	})), nil
+}
@@ -0,0 +1,60 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/ory/hydra-maester/helpers"
+)
+
+func TestLogSampling(t *testing.T) {
+	// logged logs the message a and b ten times each with sampling and
+	// returns the logged lines of a and b.
+	logged := func(t *testing.T, initial, thereafter int) (a, b int) {
+		sampling, err := helpers.LogSampling(initial, thereafter)
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		log := zap.New(zap.WriteTo(&out), sampling)
+		for i := 0; i < 10; i++ {
+			log.Info("a")
+			log.Info("b")
+		}
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		for _, line := range lines {
+			if strings.Contains(line, `"msg":"a"`) {
+				a++
+			} else if strings.Contains(line, `"msg":"b"`) {
+				b++
+			}
+		}
+		return a, b
+	}
+
+	t.Run("case=samples entries with the same message", func(t *testing.T) {
+		a, b := logged(t, 2, 3)
+		assert.Equal(t, 4, a)
+		assert.Equal(t, 4, b)
+	})
+
+	t.Run("case=logs all entries without sampling", func(t *testing.T) {
+		a, b := logged(t, 0, 100)
+		assert.Equal(t, 10, a)
+		assert.Equal(t, 10, b)
+	})
+
+	t.Run("case=rejects negative values", func(t *testing.T) {
+		for _, values := range [][2]int{{-1, 100}, {1, -1}} {
+			_, err := helpers.LogSampling(values[0], values[1])
+			require.EqualError(t, err, "log-sampling-initial and log-sampling-thereafter must not be negative")
+		}
+	})
+}
@@ -0,0 +1,35 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// nameHashLength is the number of hex digits of the hash appended to
+// shortened names.
+const nameHashLength = 10
+
+// ShortenName returns name if it has at most max characters. Longer names are
+// cut and suffixed with a hash of the whole name, so different long names stay
+// different and the same name is always shortened the same way. The result is
+// a valid DNS subdomain if name is one, provided max is greater than the hash.
+func ShortenName(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:nameHashLength]
+	if max <= nameHashLength+1 {
+		return hash[:max]
+	}
+	prefix := strings.TrimRight(name[:max-nameHashLength-1], "-.")
+	if prefix == "" {
+		return hash
+	}
+	return prefix + "-" + hash
+}
@@ -0,0 +1,36 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/ory/hydra-maester/helpers"
+)
+
+func TestShortenName(t *testing.T) {
+	t.Run("case=keeps short names", func(t *testing.T) {
+		assert.Equal(t, "my-client", helpers.ShortenName("my-client", 63))
+	})
+
+	t.Run("case=shortens long names deterministically", func(t *testing.T) {
+		name := strings.Repeat("a", 300)
+		short := helpers.ShortenName(name, 63)
+		assert.Len(t, short, 63)
+		assert.Equal(t, short, helpers.ShortenName(name, 63))
+		assert.NotEqual(t, short, helpers.ShortenName(name+"b", 63))
+		assert.Empty(t, validation.IsDNS1123Subdomain(short))
+	})
+
+	t.Run("case=does not end the prefix with a separator", func(t *testing.T) {
+		short := helpers.ShortenName(strings.Repeat("a", 51)+"-."+strings.Repeat("b", 20), 63)
+		assert.Equal(t, strings.Repeat("a", 51), short[:51])
+		assert.Equal(t, "-", short[51:52])
+		assert.Empty(t, validation.IsDNS1123Subdomain(short))
+	})
+}
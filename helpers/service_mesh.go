@@ -0,0 +1,20 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServiceMeshTLS checks the ORY Hydra url for service mesh mode, in which the
+// sidecar handles mTLS and the controller talks plaintext HTTP, so url must
+// not use https. It reports whether a TLS trust store or skipping the
+// verification was configured too, which are ignored in this mode.
+func ServiceMeshTLS(url, tlsTrustStore string, insecureSkipVerify bool) (ignored bool, err error) {
+	if strings.HasPrefix(strings.ToLower(url), "https://") {
+		return false, fmt.Errorf("hydra URL must use plaintext HTTP in service mesh mode")
+	}
+	return tlsTrustStore != "" || insecureSkipVerify, nil
+}
@@ -0,0 +1,40 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/helpers"
+)
+
+func TestServiceMeshTLS(t *testing.T) {
+	t.Run("case=accepts plaintext URLs", func(t *testing.T) {
+		for _, url := range []string{"http://hydra-admin.ory", "hydra-admin.ory"} {
+			ignored, err := helpers.ServiceMeshTLS(url, "", false)
+			require.NoError(t, err)
+			assert.False(t, ignored)
+		}
+	})
+
+	t.Run("case=rejects https URLs", func(t *testing.T) {
+		for _, url := range []string{"https://hydra-admin.ory", "HTTPS://hydra-admin.ory"} {
+			_, err := helpers.ServiceMeshTLS(url, "", false)
+			require.EqualError(t, err, "hydra URL must use plaintext HTTP in service mesh mode")
+		}
+	})
+
+	t.Run("case=reports ignored TLS settings", func(t *testing.T) {
+		ignored, err := helpers.ServiceMeshTLS("http://hydra-admin.ory", "/etc/ssl/certs/ca-certificates.crt", false)
+		require.NoError(t, err)
+		assert.True(t, ignored)
+
+		ignored, err = helpers.ServiceMeshTLS("http://hydra-admin.ory", "", true)
+		require.NoError(t, err)
+		assert.True(t, ignored)
+	})
+}
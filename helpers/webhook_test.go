@@ -0,0 +1,62 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ory/hydra-maester/helpers"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeCertificate(t *testing.T, dir string, notBefore, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "webhook-service.hydra-maester-system.svc"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+func TestWebhookCertificateChecker(t *testing.T) {
+	t.Run("should fail without certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		check := helpers.WebhookCertificateChecker(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+		require.ErrorContains(t, check(nil), "webhook certificate is not available")
+	})
+
+	t.Run("should succeed with valid certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		check := helpers.WebhookCertificateChecker(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+		writeCertificate(t, dir, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, check(nil))
+	})
+
+	t.Run("should fail with expired certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCertificate(t, dir, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+		check := helpers.WebhookCertificateChecker(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+		require.ErrorContains(t, check(nil), "webhook certificate is only valid")
+	})
+}
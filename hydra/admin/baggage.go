@@ -0,0 +1,31 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// baggageKey is the context key of the baggage members of requests.
+type baggageKey struct{}
+
+// ContextWithBaggage returns a copy of ctx whose requests to ORY Hydra carry
+// key=value in the W3C baggage header, after the members already in ctx.
+// OpenTelemetry instrumented servers attach the baggage to their spans, so
+// the traces and access logs of ORY Hydra can be joined with the origin of
+// the requests. key must be a token, e.g. k8s.namespace.name; value is
+// percent-encoded.
+func ContextWithBaggage(ctx context.Context, key, value string) context.Context {
+	members, _ := ctx.Value(baggageKey{}).([]string)
+	members = append(members[:len(members):len(members)], key+"="+url.PathEscape(value))
+	return context.WithValue(ctx, baggageKey{}, members)
+}
+
+// baggage returns the W3C baggage header of the members in ctx.
+func baggage(ctx context.Context) string {
+	members, _ := ctx.Value(baggageKey{}).([]string)
+	return strings.Join(members, ",")
+}
@@ -0,0 +1,405 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Client is a client of the OAuth2 client management endpoints of the ORY
+// Hydra admin API.
+type Client interface {
+	GetOAuth2Client(ctx context.Context, id string) (*OAuth2ClientJSON, bool, error)
+	ListOAuth2Client(ctx context.Context) ([]*OAuth2ClientJSON, error)
+	PostOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error)
+	PutOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error)
+	DeleteOAuth2Client(ctx context.Context, id string) error
+	GetVersion(ctx context.Context) (string, error)
+	IsReady(ctx context.Context) (bool, error)
+}
+
+// PatchOperation is an operation of a JSON Patch (RFC 6902).
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Patcher is implemented by clients which can update OAuth2 clients with a
+// JSON Patch, changing only the fields the patch touches. It is not part of
+// Client, so existing implementations of Client keep working.
+type Patcher interface {
+	PatchOAuth2Client(ctx context.Context, id string, patch []PatchOperation) (*OAuth2ClientJSON, error)
+}
+
+// InternalClient implements Client and Patcher over HTTP.
+type InternalClient struct {
+	// HydraURL is the URL of the clients endpoint.
+	HydraURL url.URL
+	// HTTPClient sends the requests. http.DefaultClient is used if it is
+	// nil.
+	HTTPClient *http.Client
+	// ForwardedProto is sent as the X-Forwarded-Proto header if it is not
+	// empty, for ORY Hydra instances which require TLS behind a proxy.
+	ForwardedProto string
+
+	log         logr.Logger
+	userAgent   string
+	retryPolicy RetryPolicy
+	observer    RequestObserver
+	tracer      RequestTracer
+	faults      FaultInjection
+}
+
+// RequestObserver is called after every attempt of a request to ORY Hydra,
+// e.g. to export metrics. endpoint is the path of the request with client IDs
+// replaced by {id}, so it can be used as a metric label. status is 0 if no
+// response was received, err the error of the attempt, if any.
+type RequestObserver func(method, endpoint string, status int, err error)
+
+// endpointKey is the context key of the endpoint of a request passed to
+// the RequestObserver.
+type endpointKey struct{}
+
+// New returns a client of the ORY Hydra admin API. endpoint is the URL of
+// the clients endpoint, e.g. http://hydra-admin:4445/admin/clients; the
+// version and health endpoints are resolved against its host.
+func New(endpoint string, opts ...Option) (*InternalClient, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &InternalClient{
+		HydraURL:  *u,
+		log:       logr.Discard(),
+		userAgent: DefaultUserAgent,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client, nil
+}
+
+func (c *InternalClient) GetOAuth2Client(ctx context.Context, id string) (*OAuth2ClientJSON, bool, error) {
+	var jsonClient *OAuth2ClientJSON
+
+	req, err := c.newRequest(ctx, http.MethodGet, id, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := c.do(req, &jsonClient)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return jsonClient, true, nil
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, newError(req, resp)
+	}
+}
+
+func (c *InternalClient) ListOAuth2Client(ctx context.Context) ([]*OAuth2ClientJSON, error) {
+	var jsonClientList []*OAuth2ClientJSON
+
+	req, err := c.newRequest(ctx, http.MethodGet, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, &jsonClientList)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return jsonClientList, nil
+	default:
+		return nil, newError(req, resp)
+	}
+}
+
+func (c *InternalClient) PostOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error) {
+	var jsonClient *OAuth2ClientJSON
+
+	req, err := c.newRequest(ctx, http.MethodPost, "", o)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, &jsonClient)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return jsonClient, nil
+	case http.StatusConflict:
+		err := newError(req, resp)
+		err.Reason = "requested ID already exists"
+		return nil, err
+	default:
+		return nil, newError(req, resp)
+	}
+}
+
+func (c *InternalClient) PutOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error) {
+	var jsonClient *OAuth2ClientJSON
+
+	req, err := c.newRequest(ctx, http.MethodPut, *o.ClientID, o)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, &jsonClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError(req, resp)
+	}
+
+	return jsonClient, nil
+}
+
+// PatchOAuth2Client applies patch to the client with the given ID.
+func (c *InternalClient) PatchOAuth2Client(ctx context.Context, id string, patch []PatchOperation) (*OAuth2ClientJSON, error) {
+	var jsonClient *OAuth2ClientJSON
+
+	req, err := c.newRequest(ctx, http.MethodPatch, id, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, &jsonClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError(req, resp)
+	}
+
+	return jsonClient, nil
+}
+
+func (c *InternalClient) DeleteOAuth2Client(ctx context.Context, id string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, id, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		c.log.V(1).Info("ORY Hydra client to delete does not exist", "id", id)
+		return nil
+	default:
+		return newError(req, resp)
+	}
+}
+
+// GetVersion returns the version reported by the ORY Hydra admin server.
+func (c *InternalClient) GetVersion(ctx context.Context) (string, error) {
+	var version struct {
+		Version string `json:"version"`
+	}
+
+	req, err := c.newRootRequest(ctx, http.MethodGet, "/version")
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.do(req, &version)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newError(req, resp)
+	}
+
+	return version.Version, nil
+}
+
+// IsReady reports whether the ORY Hydra admin server is ready to serve
+// requests, including its database connection.
+func (c *InternalClient) IsReady(ctx context.Context) (bool, error) {
+	req, err := c.newRootRequest(ctx, http.MethodGet, "/health/ready")
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusServiceUnavailable:
+		return false, nil
+	default:
+		return false, newError(req, resp)
+	}
+}
+
+func (c *InternalClient) newRequest(ctx context.Context, method, relativePath string, body interface{}) (*http.Request, error) {
+	u := c.HydraURL
+	endpoint := u.Path
+	if relativePath != "" {
+		endpoint = path.Join(endpoint, "{id}")
+	}
+	u.Path = path.Join(u.Path, relativePath)
+	return c.newRequestWithURL(context.WithValue(ctx, endpointKey{}, endpoint), method, u, body)
+}
+
+// newRootRequest builds a request for an endpoint of the admin server which
+// does not live under the clients endpoint.
+func (c *InternalClient) newRootRequest(ctx context.Context, method, absolutePath string) (*http.Request, error) {
+	u := c.HydraURL
+	u.Path = absolutePath
+	return c.newRequestWithURL(context.WithValue(ctx, endpointKey{}, absolutePath), method, u, nil)
+}
+
+func (c *InternalClient) newRequestWithURL(ctx context.Context, method string, u url.URL, body interface{}) (*http.Request, error) {
+	var buf io.ReadWriter
+	if body != nil {
+		buf = new(bytes.Buffer)
+		// redirect URIs are sent as they are, e.g. with & in their query
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(body); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ForwardedProto != "" {
+		req.Header.Add("X-Forwarded-Proto", c.ForwardedProto)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if b := baggage(ctx); b != "" {
+		req.Header.Set("Baggage", b)
+	}
+
+	return req, nil
+
+}
+
+func (c *InternalClient) do(req *http.Request, v interface{}) (*http.Response, error) {
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		// keep the beginning of the body for the error
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, err
+	}
+	if v != nil {
+		err = json.NewDecoder(resp.Body).Decode(v)
+	}
+	return resp, err
+}
+
+// send sends req, retrying it according to the retry policy.
+func (c *InternalClient) send(req *http.Request) (*http.Response, error) {
+	backoff := c.retryPolicy.Backoff
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		resp, err := c.faults.inject(req)
+		if resp == nil && err == nil {
+			resp, err = c.httpClient().Do(c.traced(req, start))
+		}
+		if err != nil {
+			c.log.V(1).Info("ORY Hydra request failed", "method", req.Method, "url", req.URL.String(), "attempt", attempt, "error", err.Error())
+		} else {
+			c.log.V(1).Info("ORY Hydra request", "method", req.Method, "url", req.URL.String(), "attempt", attempt, "status", resp.StatusCode, "duration", time.Since(start))
+		}
+		c.observe(req, resp, err)
+
+		retry := req.Method != http.MethodPost && attempt < c.retryPolicy.MaxAttempts &&
+			(err != nil && IsRetryable(err) || err == nil && retryableStatus(resp.StatusCode))
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// observe passes an attempt of req to the RequestObserver, if any.
+func (c *InternalClient) observe(req *http.Request, resp *http.Response, err error) {
+	if c.observer == nil {
+		return
+	}
+	endpoint, _ := req.Context().Value(endpointKey{}).(string)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	c.observer(req.Method, endpoint, status, err)
+}
+
+func (c *InternalClient) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
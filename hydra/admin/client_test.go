@@ -0,0 +1,196 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/hydra/admin"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("case=requests the clients endpoint", func(t *testing.T) {
+		var req *http.Request
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req = r
+			w.Write([]byte(`[{"client_id":"test","owner":"name/namespace"}]`))
+		}))
+		defer s.Close()
+
+		c, err := admin.New(s.URL+"/admin/clients", admin.WithForwardedProto("https"))
+		require.NoError(t, err)
+
+		clients, err := c.ListOAuth2Client(ctx)
+		require.NoError(t, err)
+		require.Len(t, clients, 1)
+		assert.Equal(t, "test", *clients[0].ClientID)
+		assert.Equal(t, "/admin/clients", req.URL.Path)
+		assert.Equal(t, "https", req.Header.Get("X-Forwarded-Proto"))
+		assert.Equal(t, admin.DefaultUserAgent, req.UserAgent())
+	})
+
+	t.Run("case=unexpected status", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		}))
+		defer s.Close()
+
+		c, err := admin.New(s.URL + "/admin/clients")
+		require.NoError(t, err)
+
+		_, err = c.PostOAuth2Client(ctx, &admin.OAuth2ClientJSON{})
+		var reqErr *admin.RequestError
+		require.True(t, errors.As(err, &reqErr))
+		assert.True(t, errors.Is(err, admin.ErrConflict))
+	})
+
+	t.Run("case=patches clients", func(t *testing.T) {
+		var req *http.Request
+		var patch []admin.PatchOperation
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req = r
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+			w.Write([]byte(`{"client_id":"test","scope":"read"}`))
+		}))
+		defer s.Close()
+
+		c, err := admin.New(s.URL + "/admin/clients")
+		require.NoError(t, err)
+
+		o, err := c.PatchOAuth2Client(ctx, "test", []admin.PatchOperation{{Op: "replace", Path: "/scope", Value: "read"}})
+		require.NoError(t, err)
+		assert.Equal(t, "read", o.Scope)
+		assert.Equal(t, http.MethodPatch, req.Method)
+		assert.Equal(t, "/admin/clients/test", req.URL.Path)
+		assert.Equal(t, []admin.PatchOperation{{Op: "replace", Path: "/scope", Value: "read"}}, patch)
+	})
+
+	t.Run("case=sends redirect URIs unmodified", func(t *testing.T) {
+		var body []byte
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"client_id":"test"}`))
+		}))
+		defer s.Close()
+
+		c, err := admin.New(s.URL + "/admin/clients")
+		require.NoError(t, err)
+
+		uris := []string{"http://127.0.0.1:51004/callback?a=1&b=2", "http://[::1]/callback", "com.example.app:/oauth2redirect"}
+		_, err = c.PostOAuth2Client(ctx, &admin.OAuth2ClientJSON{RedirectURIs: uris})
+		require.NoError(t, err)
+		for _, u := range uris {
+			assert.Contains(t, string(body), `"`+u+`"`)
+		}
+	})
+
+	t.Run("case=observes requests", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health/ready" {
+				w.Write([]byte(`{"status":"ok"}`))
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer s.Close()
+
+		type observed struct {
+			method, endpoint string
+			status           int
+		}
+		var requests []observed
+		c, err := admin.New(s.URL+"/admin/clients", admin.WithRequestObserver(func(method, endpoint string, status int, err error) {
+			requests = append(requests, observed{method, endpoint, status})
+		}))
+		require.NoError(t, err)
+
+		_, found, err := c.GetOAuth2Client(ctx, "test")
+		assert.True(t, errors.Is(err, admin.ErrUnauthorized))
+		assert.False(t, found)
+		_, err = c.ListOAuth2Client(ctx)
+		assert.True(t, errors.Is(err, admin.ErrUnauthorized))
+		ready, err := c.IsReady(ctx)
+		require.NoError(t, err)
+		assert.True(t, ready)
+
+		assert.Equal(t, []observed{
+			{http.MethodGet, "/admin/clients/{id}", http.StatusUnauthorized},
+			{http.MethodGet, "/admin/clients", http.StatusUnauthorized},
+			{http.MethodGet, "/health/ready", http.StatusOK},
+		}, requests)
+	})
+
+	t.Run("case=sends baggage", func(t *testing.T) {
+		var baggage []string
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			baggage = append(baggage, r.Header.Get("Baggage"))
+			w.Write([]byte(`[]`))
+		}))
+		defer s.Close()
+
+		c, err := admin.New(s.URL + "/admin/clients")
+		require.NoError(t, err)
+
+		_, err = c.ListOAuth2Client(ctx)
+		require.NoError(t, err)
+
+		withBaggage := admin.ContextWithBaggage(ctx, "k8s.namespace.name", "default")
+		withBaggage = admin.ContextWithBaggage(withBaggage, "name", "my client")
+		_, err = c.ListOAuth2Client(withBaggage)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"", "k8s.namespace.name=default,name=my%20client"}, baggage)
+	})
+
+	t.Run("case=injects faults", func(t *testing.T) {
+		var sent int
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sent++
+			w.Write([]byte(`[]`))
+		}))
+		defer s.Close()
+
+		var observed []int
+		failing, err := admin.New(s.URL+"/admin/clients",
+			admin.WithFaultInjection(admin.FaultInjection{ErrorRate: 1}),
+			admin.WithRequestObserver(func(_, _ string, status int, _ error) {
+				observed = append(observed, status)
+			}))
+		require.NoError(t, err)
+
+		_, err = failing.ListOAuth2Client(ctx)
+		var reqErr *admin.RequestError
+		require.True(t, errors.As(err, &reqErr))
+		assert.Equal(t, http.StatusServiceUnavailable, reqErr.StatusCode)
+		assert.Equal(t, admin.InjectedFaultBody, reqErr.Body)
+		assert.True(t, admin.IsRetryable(err))
+		assert.Equal(t, []int{http.StatusServiceUnavailable}, observed)
+		assert.Zero(t, sent)
+
+		delayed, err := admin.New(s.URL+"/admin/clients", admin.WithFaultInjection(admin.FaultInjection{MaxLatency: time.Millisecond}))
+		require.NoError(t, err)
+
+		_, err = delayed.ListOAuth2Client(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+	})
+
+	t.Run("case=invalid URL", func(t *testing.T) {
+		_, err := admin.New("http://[::1")
+		assert.Error(t, err)
+	})
+}
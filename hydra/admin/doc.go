@@ -0,0 +1,22 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package admin is a client of the OAuth2 client management endpoints of the
+// ORY Hydra admin API, for services automating ORY Hydra without running the
+// controller.
+//
+// The package depends only on the standard library and logr, not on the
+// Kubernetes types of the controller. It is versioned with the
+// hydra-maester module, and its exported API only changes in backwards
+// compatible ways within a major version:
+//
+//	c, err := admin.New("http://hydra-admin:4445/admin/clients",
+//		admin.WithRetryPolicy(admin.RetryPolicy{MaxAttempts: 3, Backoff: time.Second}))
+//	if err != nil {
+//		return err
+//	}
+//	clients, err := c.ListOAuth2Client(ctx)
+//
+// Errors of unexpected responses are *RequestError values, which match
+// ErrNotFound, ErrConflict and ErrUnauthorized with errors.Is.
+package admin
@@ -0,0 +1,91 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+var (
+	// ErrNotFound matches RequestErrors of missing resources.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict matches RequestErrors of client IDs which already exist.
+	ErrConflict = errors.New("conflict")
+	// ErrUnauthorized matches RequestErrors of rejected credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// RequestError is returned when the ORY Hydra admin API answers with an
+// unexpected status code. Use errors.Is with ErrNotFound, ErrConflict and
+// ErrUnauthorized to branch on well-known failures.
+type RequestError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	// Reason explains well-known failures, e.g. conflicting client IDs.
+	Reason string
+	// Body is the beginning of the response body. It may echo the request,
+	// including client secrets.
+	Body string
+}
+
+// maxErrorBodySize is the number of bytes of response bodies kept in errors.
+const maxErrorBodySize = 4096
+
+func (e *RequestError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s %s http request failed: %s", e.Method, e.URL, e.Reason)
+	}
+	return fmt.Sprintf("%s %s http request returned unexpected status code %s", e.Method, e.URL, e.Status)
+}
+
+// Is reports whether target is the sentinel error of the status code of e.
+func (e *RequestError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	default:
+		return false
+	}
+}
+
+func newError(req *http.Request, resp *http.Response) *RequestError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return &RequestError{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(body),
+	}
+}
+
+// IsRetryable reports whether err is a transient failure which may succeed
+// when retried: server errors, rate limiting, timeouts and network errors.
+// Other errors, e.g. ORY Hydra rejecting a client as invalid, are terminal.
+func IsRetryable(err error) bool {
+	var hydraErr *RequestError
+	if errors.As(err, &hydraErr) {
+		return retryableStatus(hydraErr.StatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func retryableStatus(code int) bool {
+	return code >= http.StatusInternalServerError ||
+		code == http.StatusTooManyRequests ||
+		code == http.StatusRequestTimeout
+}
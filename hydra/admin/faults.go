@@ -0,0 +1,52 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// InjectedFaultBody is the body of the responses of injected errors.
+const InjectedFaultBody = `{"error":"injected_fault","error_description":"the request was failed by the fault injection of hydra-maester"}`
+
+// FaultInjection makes a client delay and fail requests at random, so the
+// alerting and backoff of the controller can be tested without breaking ORY
+// Hydra. Faults are injected into every attempt, including retries.
+type FaultInjection struct {
+	// ErrorRate is the probability, from 0 to 1, that an attempt fails with
+	// a 503 Service Unavailable response instead of being sent.
+	ErrorRate float64
+	// MaxLatency is the maximum delay added to each attempt. The delay is
+	// chosen uniformly at random.
+	MaxLatency time.Duration
+}
+
+// inject delays req and returns the response of an injected error, or nil if
+// req is to be sent.
+func (f FaultInjection) inject(req *http.Request) (*http.Response, error) {
+	if f.MaxLatency > 0 {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(rand.N(f.MaxLatency)):
+		}
+	}
+	if f.ErrorRate <= 0 || rand.Float64() >= f.ErrorRate {
+		return nil, nil
+	}
+	return &http.Response{
+		Status:     "503 Service Unavailable",
+		StatusCode: http.StatusServiceUnavailable,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(InjectedFaultBody)),
+		Request:    req,
+	}, nil
+}
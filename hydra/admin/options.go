@@ -0,0 +1,92 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// DefaultUserAgent is the default User-Agent header of requests to ORY
+// Hydra.
+const DefaultUserAgent = "hydra-maester"
+
+// Option customizes the client returned by New.
+type Option func(*InternalClient)
+
+// RetryPolicy configures retries of idempotent requests which failed
+// transiently, see IsRetryable. Requests creating clients are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first
+	// one. Values below 2 disable retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. It doubles with every
+	// further retry.
+	Backoff time.Duration
+}
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(ic *InternalClient) {
+		ic.HTTPClient = c
+	}
+}
+
+// WithLogger sets the logger for requests, which are logged at V(1). The
+// default discards all logs.
+func WithLogger(log logr.Logger) Option {
+	return func(ic *InternalClient) {
+		ic.log = log
+	}
+}
+
+// WithUserAgent sets the User-Agent header of requests. The default is
+// DefaultUserAgent.
+func WithUserAgent(userAgent string) Option {
+	return func(ic *InternalClient) {
+		ic.userAgent = userAgent
+	}
+}
+
+// WithRetryPolicy enables retries of failed requests. The default is no
+// retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(ic *InternalClient) {
+		ic.retryPolicy = policy
+	}
+}
+
+// WithForwardedProto sets the X-Forwarded-Proto header of requests, see
+// InternalClient.ForwardedProto.
+func WithForwardedProto(proto string) Option {
+	return func(ic *InternalClient) {
+		ic.ForwardedProto = proto
+	}
+}
+
+// WithFaultInjection makes the client delay and fail requests at random, see
+// FaultInjection. It is meant for resilience tests only.
+func WithFaultInjection(f FaultInjection) Option {
+	return func(ic *InternalClient) {
+		ic.faults = f
+	}
+}
+
+// WithRequestObserver sets an observer which is called after every attempt of
+// a request, see RequestObserver.
+func WithRequestObserver(o RequestObserver) Option {
+	return func(ic *InternalClient) {
+		ic.observer = o
+	}
+}
+
+// WithRequestTracer sets a tracer which is called with the duration of the
+// phases of every attempt of a request, see RequestTracer.
+func WithRequestTracer(t RequestTracer) Option {
+	return func(ic *InternalClient) {
+		ic.tracer = t
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// The phases of a request passed to a RequestTracer.
+const (
+	// PhaseDNS is the lookup of the host of ORY Hydra.
+	PhaseDNS = "dns"
+	// PhaseConnect is the establishment of the TCP connection.
+	PhaseConnect = "connect"
+	// PhaseTLS is the TLS handshake.
+	PhaseTLS = "tls"
+	// PhaseFirstByte is the time from the start of the attempt until the
+	// first byte of the response.
+	PhaseFirstByte = "first_byte"
+)
+
+// RequestTracer is called with the duration of each phase of an attempt of a
+// request to ORY Hydra, e.g. to export latency histograms. endpoint is the
+// same as that passed to a RequestObserver. Phases which did not happen, such
+// as the DNS lookup and connection of an attempt reusing a connection, are
+// not traced.
+type RequestTracer func(endpoint, phase string, d time.Duration)
+
+// traced returns req with a trace passing the phases of the attempt started
+// at start to the RequestTracer, or req itself if there is none.
+func (c *InternalClient) traced(req *http.Request, start time.Time) *http.Request {
+	if c.tracer == nil {
+		return req
+	}
+	endpoint, _ := req.Context().Value(endpointKey{}).(string)
+
+	var dnsStart, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			c.tracer(endpoint, PhaseDNS, time.Since(dnsStart))
+		},
+		ConnectStart: func(_, _ string) { connectStart = time.Now() },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				c.tracer(endpoint, PhaseConnect, time.Since(connectStart))
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				c.tracer(endpoint, PhaseTLS, time.Since(tlsStart))
+			}
+		},
+		GotFirstResponseByte: func() {
+			c.tracer(endpoint, PhaseFirstByte, time.Since(start))
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
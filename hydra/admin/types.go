@@ -0,0 +1,58 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import "encoding/json"
+
+// OAuth2ClientJSON represents an OAuth2 client digestible by ORY Hydra
+type OAuth2ClientJSON struct {
+	ClientName                                 string          `json:"client_name,omitempty"`
+	ClientID                                   *string         `json:"client_id,omitempty"`
+	Secret                                     *string         `json:"client_secret,omitempty"`
+	GrantTypes                                 []string        `json:"grant_types"`
+	RedirectURIs                               []string        `json:"redirect_uris,omitempty"`
+	PostLogoutRedirectURIs                     []string        `json:"post_logout_redirect_uris,omitempty"`
+	AllowedCorsOrigins                         []string        `json:"allowed_cors_origins,omitempty"`
+	ResponseTypes                              []string        `json:"response_types,omitempty"`
+	Audience                                   []string        `json:"audience,omitempty"`
+	Scope                                      string          `json:"scope"`
+	SkipConsent                                bool            `json:"skip_consent,omitempty"`
+	Owner                                      string          `json:"owner"`
+	TokenEndpointAuthMethod                    string          `json:"token_endpoint_auth_method,omitempty"`
+	Metadata                                   json.RawMessage `json:"metadata,omitempty"`
+	JwksUri                                    string          `json:"jwks_uri,omitempty"`
+	FrontChannelLogoutSessionRequired          bool            `json:"frontchannel_logout_session_required"`
+	FrontChannelLogoutURI                      string          `json:"frontchannel_logout_uri"`
+	BackChannelLogoutSessionRequired           bool            `json:"backchannel_logout_session_required"`
+	BackChannelLogoutURI                       string          `json:"backchannel_logout_uri"`
+	AuthorizationCodeGrantAccessTokenLifespan  string          `json:"authorization_code_grant_access_token_lifespan,omitempty"`
+	AuthorizationCodeGrantIdTokenLifespan      string          `json:"authorization_code_grant_id_token_lifespan,omitempty"`
+	AuthorizationCodeGrantRefreshTokenLifespan string          `json:"authorization_code_grant_refresh_token_lifespan,omitempty"`
+	ClientCredentialsGrantAccessTokenLifespan  string          `json:"client_credentials_grant_access_token_lifespan,omitempty"`
+	ImplicitGrantAccessTokenLifespan           string          `json:"implicit_grant_access_token_lifespan,omitempty"`
+	ImplicitGrantIdTokenLifespan               string          `json:"implicit_grant_id_token_lifespan,omitempty"`
+	JwtBearerGrantAccessTokenLifespan          string          `json:"jwt_bearer_grant_access_token_lifespan,omitempty"`
+	RefreshTokenGrantAccessTokenLifespan       string          `json:"refresh_token_grant_access_token_lifespan,omitempty"`
+	RefreshTokenGrantIdTokenLifespan           string          `json:"refresh_token_grant_id_token_lifespan,omitempty"`
+	RefreshTokenGrantRefreshTokenLifespan      string          `json:"refresh_token_grant_refresh_token_lifespan,omitempty"`
+}
+
+// Oauth2ClientCredentials represents a client ID and secret, e.g. fetched
+// from a Kubernetes secret
+type Oauth2ClientCredentials struct {
+	ID       []byte
+	Password []byte
+}
+
+// WithCredentials sets the client ID and, if it is not nil, the secret of oj
+// and returns oj.
+func (oj *OAuth2ClientJSON) WithCredentials(credentials *Oauth2ClientCredentials) *OAuth2ClientJSON {
+	id := string(credentials.ID)
+	oj.ClientID = &id
+	if credentials.Password != nil {
+		secret := string(credentials.Password)
+		oj.Secret = &secret
+	}
+	return oj
+}
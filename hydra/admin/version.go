@@ -0,0 +1,93 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The range of ORY Hydra versions the client supports. The maximum is
+// exclusive.
+const (
+	MinSupportedVersion = "v2.0.0"
+	MaxSupportedVersion = "v3.0.0"
+)
+
+// UnsupportedVersionError is returned by CheckVersion when ORY Hydra reports
+// a version outside the supported range.
+type UnsupportedVersionError struct {
+	Version string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("ORY Hydra %s is not supported, the supported versions are >= %s and < %s", e.Version, MinSupportedVersion, MaxSupportedVersion)
+}
+
+// CheckVersion queries the version of the ORY Hydra instance behind c and
+// returns it. The error is an *UnsupportedVersionError if the version is
+// outside the supported range.
+func CheckVersion(ctx context.Context, c Client) (string, error) {
+	version, err := c.GetVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := parseVersion(version)
+	if err != nil {
+		return version, err
+	}
+	min, _ := parseVersion(MinSupportedVersion)
+	max, _ := parseVersion(MaxSupportedVersion)
+	if compareVersions(v, min) < 0 || compareVersions(v, max) >= 0 {
+		return version, &UnsupportedVersionError{Version: version}
+	}
+	return version, nil
+}
+
+// VersionAtLeast reports whether version, e.g. v2.2.0, is min or newer.
+func VersionAtLeast(version, min string) (bool, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+	m, err := parseVersion(min)
+	if err != nil {
+		return false, err
+	}
+	return compareVersions(v, m) >= 0, nil
+}
+
+// parseVersion parses the major, minor and patch numbers of a version such as
+// v2.2.0 or v2.2.0-rc.1.
+func parseVersion(version string) ([3]int, error) {
+	var v [3]int
+	core, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("unable to parse ORY Hydra version %q", version)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return v, fmt.Errorf("unable to parse ORY Hydra version %q", version)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
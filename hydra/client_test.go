@@ -4,6 +4,7 @@
 package hydra_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -89,7 +90,7 @@ func TestCRUD(t *testing.T) {
 			"getting unauthorized request": {
 				http.StatusUnauthorized,
 				statusUnauthorizedBody,
-				nil,
+				errors.New("http request returned unexpected status code"),
 			},
 			"internal server error when requesting": {
 				http.StatusInternalServerError,
@@ -114,7 +115,7 @@ func TestCRUD(t *testing.T) {
 				runServer(&c, h)
 
 				//when
-				o, found, err := c.GetOAuth2Client(testID)
+				o, found, err := c.GetOAuth2Client(context.Background(), testID)
 
 				//then
 				if tc.err == nil {
@@ -196,10 +197,10 @@ func TestCRUD(t *testing.T) {
 						BackChannelLogoutURI:              "https://localhost/backchannel-logout",
 						BackChannelLogoutSessionRequired:  false,
 					}
-					o, err = c.PostOAuth2Client(testOAuthJSONPost2)
+					o, err = c.PostOAuth2Client(context.Background(), testOAuthJSONPost2)
 					expected = testOAuthJSONPost2
 				} else {
-					o, err = c.PostOAuth2Client(testOAuthJSONPost)
+					o, err = c.PostOAuth2Client(context.Background(), testOAuthJSONPost)
 					expected = testOAuthJSONPost
 				}
 
@@ -209,6 +210,11 @@ func TestCRUD(t *testing.T) {
 				} else {
 					require.Error(t, err)
 					assert.Contains(err.Error(), tc.err.Error())
+
+					var hydraErr *hydra.RequestError
+					require.ErrorAs(t, err, &hydraErr)
+					assert.Equal(tc.statusCode, hydraErr.StatusCode)
+					assert.Equal(tc.respBody, hydraErr.Body)
 				}
 
 				if new {
@@ -271,7 +277,7 @@ func TestCRUD(t *testing.T) {
 				runServer(&c, h)
 
 				//when
-				o, err := c.PutOAuth2Client(testOAuthJSONPut)
+				o, err := c.PutOAuth2Client(context.Background(), testOAuthJSONPut)
 
 				//then
 				if tc.err == nil {
@@ -322,7 +328,7 @@ func TestCRUD(t *testing.T) {
 				runServer(&c, h)
 
 				//when
-				err := c.DeleteOAuth2Client(testID)
+				err := c.DeleteOAuth2Client(context.Background(), testID)
 
 				//then
 				if tc.err == nil {
@@ -373,7 +379,7 @@ func TestCRUD(t *testing.T) {
 				runServer(&c, h)
 
 				//when
-				list, err := c.ListOAuth2Client()
+				list, err := c.ListOAuth2Client(context.Background())
 
 				//then
 				if tc.err == nil {
@@ -390,6 +396,48 @@ func TestCRUD(t *testing.T) {
 		}
 	})
 
+	t.Run("method=version", func(t *testing.T) {
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			assert.Equal("/version", req.URL.Path)
+			assert.Equal(http.MethodGet, req.Method)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"version":"v2.2.0"}`))
+		})
+		runServer(&c, h)
+
+		version, err := c.GetVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal("v2.2.0", version)
+	})
+
+	t.Run("method=ready", func(t *testing.T) {
+		for d, tc := range map[string]struct {
+			statusCode int
+			ready      bool
+			err        bool
+		}{
+			"ready":                 {http.StatusOK, true, false},
+			"not ready":             {http.StatusServiceUnavailable, false, false},
+			"internal server error": {http.StatusInternalServerError, false, true},
+		} {
+			t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+				h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					assert.Equal("/health/ready", req.URL.Path)
+					w.WriteHeader(tc.statusCode)
+				})
+				runServer(&c, h)
+
+				ready, err := c.IsReady(context.Background())
+				if tc.err {
+					require.Error(t, err)
+				} else {
+					require.NoError(t, err)
+				}
+				assert.Equal(tc.ready, ready)
+			})
+		}
+	})
+
 	t.Run("default parameters", func(t *testing.T) {
 		var input = &hydra.OAuth2ClientJSON{
 			Scope:      "some,other,scopes",
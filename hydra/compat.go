@@ -0,0 +1,97 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FieldSupport describes which ORY Hydra versions accept a field of clients.
+type FieldSupport struct {
+	// Since is the first version storing the field. Older versions ignore
+	// it.
+	Since string
+	// Deprecated is the first version deprecating the field, if any.
+	Deprecated string
+	// Replacement is the field to use instead of a deprecated one, if any.
+	Replacement string
+}
+
+// FieldSupportTable maps the JSON names of fields of clients to the ORY Hydra
+// versions accepting them.
+type FieldSupportTable map[string]FieldSupport
+
+// ClientFields are the fields the controller writes which not all supported
+// ORY Hydra versions accept.
+var ClientFields = FieldSupportTable{
+	"skip_consent": {Since: "v2.2.0"},
+}
+
+// Incompatibility is a field of a client which ORY Hydra ignores or
+// deprecates.
+type Incompatibility struct {
+	// Field is the JSON name of the field.
+	Field string
+	// Ignored is set if ORY Hydra doesn't store the field, and unset if it
+	// deprecates it.
+	Ignored bool
+	// Message explains the incompatibility.
+	Message string
+}
+
+// Compatibility returns the fields of sent, the client written to ORY Hydra,
+// which ORY Hydra ignores or deprecates according to ClientFields, see
+// FieldSupportTable.Check.
+func Compatibility(version string, sent, stored *OAuth2ClientJSON) ([]Incompatibility, error) {
+	return ClientFields.Check(version, sent, stored)
+}
+
+// Check returns the fields of t set in sent, the client written to ORY
+// Hydra, which it ignores or deprecates, sorted by field. version is that of
+// ORY Hydra, or empty if it is unknown. stored is the client ORY Hydra
+// returned for the write, if any; fields it lacks were ignored, whatever the
+// version.
+func (t FieldSupportTable) Check(version string, sent, stored *OAuth2ClientJSON) ([]Incompatibility, error) {
+	s, err := toMap(sent)
+	if err != nil {
+		return nil, err
+	}
+	var st map[string]interface{}
+	if stored != nil {
+		if st, err = toMap(stored); err != nil {
+			return nil, err
+		}
+	}
+
+	var found []Incompatibility
+	for field, support := range t {
+		if isEmptyValue(s[field]) {
+			continue
+		}
+		switch {
+		case support.Since != "" && version != "" && !atLeast(version, support.Since):
+			found = append(found, Incompatibility{Field: field, Ignored: true, Message: fmt.Sprintf("%s is ignored by ORY Hydra %s, it needs %s or newer", field, version, support.Since)})
+		case st != nil && isEmptyValue(st[field]):
+			found = append(found, Incompatibility{Field: field, Ignored: true, Message: fmt.Sprintf("%s is ignored by ORY Hydra, it needs %s or newer", field, support.Since)})
+		case support.Deprecated != "" && version != "" && atLeast(version, support.Deprecated):
+			msg := fmt.Sprintf("%s is deprecated since ORY Hydra %s", field, support.Deprecated)
+			if support.Replacement != "" {
+				msg += ", use " + support.Replacement
+			}
+			found = append(found, Incompatibility{Field: field, Message: msg})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Field < found[j].Field
+	})
+	return found, nil
+}
+
+// atLeast reports whether version is min or newer. Unparsable versions are
+// taken to be new enough.
+func atLeast(version, min string) bool {
+	ok, err := VersionAtLeast(version, min)
+	return ok || err != nil
+}
@@ -0,0 +1,65 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/hydra"
+)
+
+func TestCompatibility(t *testing.T) {
+	sent := &hydra.OAuth2ClientJSON{ClientName: "app", SkipConsent: true}
+
+	t.Run("case=reports fields older versions ignore", func(t *testing.T) {
+		found, err := hydra.Compatibility("v2.1.2", sent, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []hydra.Incompatibility{{
+			Field:   "skip_consent",
+			Ignored: true,
+			Message: "skip_consent is ignored by ORY Hydra v2.1.2, it needs v2.2.0 or newer",
+		}}, found)
+	})
+
+	t.Run("case=reports fields missing from the stored client", func(t *testing.T) {
+		found, err := hydra.Compatibility("", sent, &hydra.OAuth2ClientJSON{ClientName: "app"})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "skip_consent", found[0].Field)
+		assert.True(t, found[0].Ignored)
+	})
+
+	t.Run("case=accepts supported and unset fields", func(t *testing.T) {
+		found, err := hydra.Compatibility("v2.2.0", sent, sent)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		found, err = hydra.Compatibility("v2.1.2", &hydra.OAuth2ClientJSON{ClientName: "app"}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		found, err = hydra.Compatibility("master", sent, nil)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+	})
+
+	t.Run("case=reports deprecated fields", func(t *testing.T) {
+		table := hydra.FieldSupportTable{
+			"client_name": {Deprecated: "v2.1.0", Replacement: "metadata.name"},
+		}
+		found, err := table.Check("v2.2.0", sent, sent)
+		require.NoError(t, err)
+		assert.Equal(t, []hydra.Incompatibility{{
+			Field:   "client_name",
+			Message: "client_name is deprecated since ORY Hydra v2.1.0, use metadata.name",
+		}}, found)
+
+		found, err = table.Check("v2.0.3", sent, sent)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+	})
+}
@@ -0,0 +1,191 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// FieldDiff describes a field whose value differs between the desired and the
+// actual state of an OAuth2 client. Field is the JSON name used by ORY Hydra.
+type FieldDiff struct {
+	Field   string      `json:"field"`
+	Desired interface{} `json:"desired"`
+	Actual  interface{} `json:"actual"`
+}
+
+// ignoredDiffFields are never compared: the secret is not returned by ORY Hydra
+// and the ID is the identity of the client, not part of its state.
+var ignoredDiffFields = map[string]bool{
+	"client_id":     true,
+	"client_secret": true,
+}
+
+// Diff returns the fields that differ between desired and actual, sorted by
+// field name. Missing values, empty strings, empty lists and empty objects are
+// considered equal, and both clients are compared normalized, see Normalize.
+func Diff(desired, actual *OAuth2ClientJSON) ([]FieldDiff, error) {
+	d, err := toMap(normalized(desired))
+	if err != nil {
+		return nil, err
+	}
+	a, err := toMap(normalized(actual))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]struct{}, len(d)+len(a))
+	for k := range d {
+		fields[k] = struct{}{}
+	}
+	for k := range a {
+		fields[k] = struct{}{}
+	}
+
+	var diffs []FieldDiff
+	for f := range fields {
+		if ignoredDiffFields[f] {
+			continue
+		}
+		dv, av := d[f], a[f]
+		if isEmptyValue(dv) && isEmptyValue(av) {
+			continue
+		}
+		if !reflect.DeepEqual(dv, av) {
+			diffs = append(diffs, FieldDiff{Field: f, Desired: dv, Actual: av})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Field < diffs[j].Field
+	})
+	return diffs, nil
+}
+
+// serverDefaults are the values ORY Hydra sets for fields which are empty
+// when a client is registered.
+var serverDefaults = map[string]interface{}{
+	"token_endpoint_auth_method": "client_secret_basic",
+	"grant_types":                []interface{}{"authorization_code"},
+	"response_types":             []interface{}{"code"},
+}
+
+// Drift returns the fields of actual, the client registered in ORY Hydra,
+// which were changed out of band and differ from desired. Unlike Diff, it
+// ignores the fields ORY Hydra set to their defaults because desired left them
+// empty.
+func Drift(desired, actual *OAuth2ClientJSON) ([]FieldDiff, error) {
+	diffs, err := Diff(desired, actual)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := diffs[:0]
+	for _, d := range diffs {
+		if def, ok := serverDefaults[d.Field]; ok && isEmptyValue(d.Desired) && reflect.DeepEqual(d.Actual, def) {
+			continue
+		}
+		drift = append(drift, d)
+	}
+	return drift, nil
+}
+
+// Patch returns the JSON Patch which changes actual, the client registered in
+// ORY Hydra, into desired. It only touches the fields of OAuth2ClientJSON, so
+// fields set in ORY Hydra which the controller does not manage are kept, and
+// like Drift it leaves the fields ORY Hydra set to their defaults alone. The
+// secret of desired, if any, is always included.
+func Patch(desired, actual *OAuth2ClientJSON) ([]PatchOperation, error) {
+	drift, err := Drift(desired, actual)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make([]PatchOperation, 0, len(drift)+1)
+	for _, d := range drift {
+		path := "/" + d.Field
+		switch {
+		case isEmptyValue(d.Desired):
+			patch = append(patch, PatchOperation{Op: "remove", Path: path})
+		case d.Actual == nil:
+			patch = append(patch, PatchOperation{Op: "add", Path: path, Value: d.Desired})
+		default:
+			patch = append(patch, PatchOperation{Op: "replace", Path: path, Value: d.Desired})
+		}
+	}
+	if desired.Secret != nil {
+		patch = append(patch, PatchOperation{Op: "add", Path: "/client_secret", Value: *desired.Secret})
+	}
+	return patch, nil
+}
+
+// redactedDiffFields may hold confidential values, e.g. API keys stored in
+// the metadata by other systems, so Redact never shows them.
+var redactedDiffFields = map[string]bool{
+	"metadata": true,
+}
+
+// Redact returns a copy of diffs fit for logs: the values of fields which may
+// hold confidential data are replaced, and credentials in the other values,
+// e.g. in URLs, are redacted like Sanitize does.
+func Redact(diffs []FieldDiff) []FieldDiff {
+	redactedDiffs := make([]FieldDiff, len(diffs))
+	for i, d := range diffs {
+		redactedDiffs[i] = FieldDiff{Field: d.Field, Desired: redactValue(d.Field, d.Desired), Actual: redactValue(d.Field, d.Actual)}
+	}
+	return redactedDiffs
+}
+
+func redactValue(field string, v interface{}) interface{} {
+	if isEmptyValue(v) {
+		return v
+	}
+	if redactedDiffFields[field] {
+		return redacted
+	}
+	switch t := v.(type) {
+	case string:
+		return Sanitize(t)
+	case []interface{}:
+		values := make([]interface{}, len(t))
+		for i, e := range t {
+			values[i] = redactValue(field, e)
+		}
+		return values
+	}
+	return v
+}
+
+func toMap(o *OAuth2ClientJSON) (map[string]interface{}, error) {
+	if o == nil {
+		return map[string]interface{}{}, nil
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case bool:
+		return !t
+	case []interface{}:
+		return len(t) == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	}
+	return false
+}
@@ -0,0 +1,453 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// DefaultDiscoveryInterval is how often a DiscoveryClient resolves its
+// instances again.
+const DefaultDiscoveryInterval = 30 * time.Second
+
+var (
+	// ErrIncomplete matches IncompleteErrors.
+	ErrIncomplete = errors.New("incomplete")
+	// ErrNoInstances is returned by a DiscoveryClient which did not discover
+	// any instance.
+	ErrNoInstances = errors.New("no ORY Hydra instances discovered")
+
+	errMissing = errors.New("client does not exist")
+)
+
+// Resolver returns the admin addresses of a set of ORY Hydra instances, in
+// the url:port form of Endpoint.Address, e.g. http://10.0.0.7:4445.
+type Resolver interface {
+	Resolve(ctx context.Context) ([]string, error)
+}
+
+// ResolverFunc is a function implementing Resolver.
+type ResolverFunc func(ctx context.Context) ([]string, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+// SRVResolver resolves the instances from the targets of a DNS SRV record,
+// e.g. the record of a named port of a headless Service.
+type SRVResolver struct {
+	// Name is the SRV record, e.g.
+	// _http-admin._tcp.hydra-admin.hydra.svc.cluster.local.
+	Name string
+	// Scheme of the admin addresses, http if empty.
+	Scheme string
+	// Resolver looks the record up, net.DefaultResolver if nil.
+	Resolver *net.Resolver
+}
+
+func (r SRVResolver) Resolve(ctx context.Context) ([]string, error) {
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, records, err := resolver.LookupSRV(ctx, "", "", r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		addresses = append(addresses, InstanceAddress(r.Scheme, host, int(record.Port)))
+	}
+	slices.Sort(addresses)
+	return slices.Compact(addresses), nil
+}
+
+// InstanceAddress returns the admin address of the instance listening on
+// host and port, with scheme http if it is empty. IPv6 hosts are enclosed in
+// brackets.
+func InstanceAddress(scheme, host string, port int) string {
+	if scheme == "" {
+		scheme = "http"
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// IncompleteError is returned by a DiscoveryClient when a client is missing
+// in some of the discovered instances, or a request failed on some of them
+// only. It matches ErrIncomplete and unwraps to the errors of the instances.
+type IncompleteError struct {
+	ClientID string
+	// Errs are the errors of the instances by their address.
+	Errs map[string]error
+}
+
+func (e *IncompleteError) Error() string {
+	failed := make([]string, 0, len(e.Errs))
+	for _, address := range e.addresses() {
+		failed = append(failed, fmt.Sprintf("%s: %s", address, e.Errs[address]))
+	}
+	return fmt.Sprintf("client %s is incomplete in the discovered ORY Hydra instances: %s", e.ClientID, strings.Join(failed, "; "))
+}
+
+// Is reports whether target is ErrIncomplete.
+func (e *IncompleteError) Is(target error) bool {
+	return target == ErrIncomplete
+}
+
+func (e *IncompleteError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errs))
+	for _, address := range e.addresses() {
+		errs = append(errs, e.Errs[address])
+	}
+	return errs
+}
+
+func (e *IncompleteError) addresses() []string {
+	addresses := make([]string, 0, len(e.Errs))
+	for address := range e.Errs {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// DiscoveryClient registers clients in all ORY Hydra instances returned by a
+// Resolver, e.g. sharded or per-region instances which don't share a
+// database. The instances are resolved before the first request and at most
+// once per interval after that; if resolving fails, the previous instances
+// are used.
+//
+// Clients are created in the first instance, by address, and written with
+// its client ID and secret to the others. Updates create clients missing in
+// an instance, and deletions ignore instances which lack them. Reads return the client of the
+// first instance which has it, with an IncompleteError if other instances
+// lack it, so an update can complete it. A DiscoveryClient does not
+// implement Patcher, as patches can't create missing clients.
+type DiscoveryClient struct {
+	resolver  Resolver
+	newClient func(address string) (Client, error)
+	interval  time.Duration
+	log       logr.Logger
+
+	mu        sync.Mutex
+	resolved  time.Time
+	resolving chan struct{}
+	endpoints []Endpoint
+	clients   map[string]Client
+}
+
+// Discover returns a DiscoveryClient sending requests to the instances
+// returned by resolver, through clients made by newClient. An interval of
+// zero uses DefaultDiscoveryInterval.
+func Discover(resolver Resolver, newClient func(address string) (Client, error), interval time.Duration, log logr.Logger) *DiscoveryClient {
+	if interval == 0 {
+		interval = DefaultDiscoveryInterval
+	}
+	return &DiscoveryClient{
+		resolver:  resolver,
+		newClient: newClient,
+		interval:  interval,
+		log:       log,
+		clients:   map[string]Client{},
+	}
+}
+
+// Addresses returns the addresses of the discovered instances, sorted.
+func (d *DiscoveryClient) Addresses() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return addressesOf(d.endpoints)
+}
+
+// instances returns the discovered instances, resolving them if it is due.
+// Resolving happens outside of the lock: while it is in flight, other
+// callers use the previous instances, or wait for it if there are none yet.
+func (d *DiscoveryClient) instances(ctx context.Context) ([]Endpoint, error) {
+	d.mu.Lock()
+	if d.resolving == nil && (d.resolved.IsZero() || time.Since(d.resolved) >= d.interval) {
+		done := make(chan struct{})
+		d.resolving = done
+		known := d.clients
+		d.mu.Unlock()
+
+		endpoints, clients, err := d.resolve(ctx, known)
+
+		d.mu.Lock()
+		d.resolved, d.resolving = time.Now(), nil
+		close(done)
+		switch {
+		case err != nil && len(d.endpoints) == 0:
+			d.mu.Unlock()
+			return nil, err
+		case err != nil:
+			d.log.Error(err, "resolving ORY Hydra instances failed, using the previous instances", "instances", len(d.endpoints))
+		default:
+			if addresses := addressesOf(endpoints); !slices.Equal(addresses, addressesOf(d.endpoints)) {
+				d.log.Info("discovered ORY Hydra instances changed", "instances", addresses)
+			}
+			d.endpoints, d.clients = endpoints, clients
+		}
+	} else if d.resolving != nil && len(d.endpoints) == 0 {
+		wait := d.resolving
+		d.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		d.mu.Lock()
+	}
+	endpoints := d.endpoints
+	d.mu.Unlock()
+
+	if len(endpoints) == 0 {
+		return nil, ErrNoInstances
+	}
+	return endpoints, nil
+}
+
+// resolve returns the resolved instances, reusing the known clients of
+// instances which were resolved before. known is not modified.
+func (d *DiscoveryClient) resolve(ctx context.Context, known map[string]Client) ([]Endpoint, map[string]Client, error) {
+	addresses, err := d.resolver.Resolve(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	slices.Sort(addresses)
+	addresses = slices.Compact(addresses)
+
+	endpoints := make([]Endpoint, 0, len(addresses))
+	clients := make(map[string]Client, len(addresses))
+	for _, address := range addresses {
+		c, ok := known[address]
+		if !ok {
+			if c, err = d.newClient(address); err != nil {
+				return nil, nil, fmt.Errorf("making hydra client for %s: %w", address, err)
+			}
+		}
+		clients[address] = c
+		endpoints = append(endpoints, Endpoint{Address: address, Client: c})
+	}
+	return endpoints, clients, nil
+}
+
+func addressesOf(endpoints []Endpoint) []string {
+	addresses := make([]string, 0, len(endpoints))
+	for _, e := range endpoints {
+		addresses = append(addresses, e.Address)
+	}
+	return addresses
+}
+
+func (d *DiscoveryClient) GetOAuth2Client(ctx context.Context, id string) (*OAuth2ClientJSON, bool, error) {
+	endpoints, err := d.instances(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var fetched *OAuth2ClientJSON
+	errs := map[string]error{}
+	for _, e := range endpoints {
+		c, found, err := e.Client.GetOAuth2Client(ctx, id)
+		switch {
+		case err != nil:
+			errs[e.Address] = err
+		case !found:
+			errs[e.Address] = errMissing
+		case fetched == nil:
+			fetched = c
+		}
+	}
+	if fetched == nil {
+		for _, err := range errs {
+			if err != errMissing {
+				return nil, false, &IncompleteError{ClientID: id, Errs: errs}
+			}
+		}
+		return nil, false, nil
+	}
+	if len(errs) > 0 {
+		return fetched, true, &IncompleteError{ClientID: id, Errs: errs}
+	}
+	return fetched, true, nil
+}
+
+// ListOAuth2Client returns the clients of all instances, taking clients
+// which exist in several instances from the first of them. It fails if any
+// instance fails.
+func (d *DiscoveryClient) ListOAuth2Client(ctx context.Context) ([]*OAuth2ClientJSON, error) {
+	endpoints, err := d.instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*OAuth2ClientJSON
+	seen := map[string]bool{}
+	for _, e := range endpoints {
+		clients, err := e.Client.ListOAuth2Client(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing clients of %s: %w", e.Address, err)
+		}
+		for _, c := range clients {
+			if c.ClientID != nil {
+				if seen[*c.ClientID] {
+					continue
+				}
+				seen[*c.ClientID] = true
+			}
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+// PostOAuth2Client creates the client in the first instance and writes it
+// with the client ID and secret of the first instance to the others. If a
+// write to another instance fails, the created client is returned with an
+// IncompleteError.
+func (d *DiscoveryClient) PostOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error) {
+	endpoints, err := d.instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := endpoints[0].Client.PostOAuth2Client(ctx, o)
+	if err != nil {
+		return created, err
+	}
+
+	replicated := *o
+	replicated.ClientID = created.ClientID
+	if replicated.Secret == nil {
+		replicated.Secret = created.Secret
+	}
+	errs := map[string]error{}
+	for _, e := range endpoints[1:] {
+		_, err := e.Client.PostOAuth2Client(ctx, &replicated)
+		if errors.Is(err, ErrConflict) {
+			_, err = e.Client.PutOAuth2Client(ctx, &replicated)
+		}
+		if err != nil {
+			errs[e.Address] = err
+		}
+	}
+	if len(errs) > 0 {
+		return created, &IncompleteError{ClientID: *created.ClientID, Errs: errs}
+	}
+	return created, nil
+}
+
+// PutOAuth2Client updates the client in all instances, creating it in the
+// instances which lack it. It fails with ErrNotFound if no instance has it.
+// If some instances fail, the client of the first instance which updated it
+// is returned with an IncompleteError.
+func (d *DiscoveryClient) PutOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error) {
+	endpoints, err := d.instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated *OAuth2ClientJSON
+	var missing []Endpoint
+	errs := map[string]error{}
+	for _, e := range endpoints {
+		c, err := e.Client.PutOAuth2Client(ctx, o)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			missing = append(missing, e)
+			errs[e.Address] = err
+		case err != nil:
+			errs[e.Address] = err
+		case updated == nil:
+			updated = c
+		}
+	}
+	if len(missing) == len(endpoints) {
+		return nil, errs[endpoints[0].Address]
+	}
+
+	for _, e := range missing {
+		c, err := e.Client.PostOAuth2Client(ctx, o)
+		if err != nil {
+			errs[e.Address] = err
+			continue
+		}
+		delete(errs, e.Address)
+		if updated == nil {
+			updated = c
+		}
+	}
+	if len(errs) == 0 {
+		return updated, nil
+	}
+	if updated == nil {
+		return nil, errs[endpoints[0].Address]
+	}
+	var id string
+	if o.ClientID != nil {
+		id = *o.ClientID
+	}
+	return updated, &IncompleteError{ClientID: id, Errs: errs}
+}
+
+// DeleteOAuth2Client deletes the client from all instances. Like
+// InternalClient, instances which lack it don't fail the deletion.
+func (d *DiscoveryClient) DeleteOAuth2Client(ctx context.Context, id string) error {
+	endpoints, err := d.instances(ctx)
+	if err != nil {
+		return err
+	}
+
+	errs := map[string]error{}
+	for _, e := range endpoints {
+		if err := e.Client.DeleteOAuth2Client(ctx, id); err != nil {
+			errs[e.Address] = err
+		}
+	}
+	if len(errs) > 0 {
+		return &IncompleteError{ClientID: id, Errs: errs}
+	}
+	return nil
+}
+
+// GetVersion returns the version of the first instance.
+func (d *DiscoveryClient) GetVersion(ctx context.Context) (string, error) {
+	endpoints, err := d.instances(ctx)
+	if err != nil {
+		return "", err
+	}
+	return endpoints[0].Client.GetVersion(ctx)
+}
+
+// IsReady reports whether all instances are ready. It is false if no
+// instance was discovered.
+func (d *DiscoveryClient) IsReady(ctx context.Context) (bool, error) {
+	endpoints, err := d.instances(ctx)
+	if errors.Is(err, ErrNoInstances) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, e := range endpoints {
+		ready, err := e.Client.IsReady(ctx)
+		if err != nil || !ready {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,189 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+// instanceAddress returns the address under which s is discovered.
+func instanceAddress(s *hydratest.Server) string {
+	admin := s.HydraAdmin()
+	return hydra.InstanceAddress("", strings.TrimPrefix(admin.URL, "http://"), admin.Port)
+}
+
+func TestDiscover(t *testing.T) {
+	ctx := context.Background()
+
+	// setup returns n servers and a DiscoveryClient resolving the servers
+	// whose index is in *discovered.
+	setup := func(t *testing.T, n int) (servers []*hydratest.Server, discovered *[]int, c *hydra.DiscoveryClient) {
+		byAddress := map[string]*hydratest.Server{}
+		for i := 0; i < n; i++ {
+			s := hydratest.NewServer()
+			t.Cleanup(s.Close)
+			servers = append(servers, s)
+			byAddress[instanceAddress(s)] = s
+		}
+		discovered = &[]int{}
+		for i := range servers {
+			*discovered = append(*discovered, i)
+		}
+
+		resolver := hydra.ResolverFunc(func(context.Context) ([]string, error) {
+			var addresses []string
+			for _, i := range *discovered {
+				addresses = append(addresses, instanceAddress(servers[i]))
+			}
+			return addresses, nil
+		})
+		newClient := func(address string) (hydra.Client, error) {
+			return byAddress[address].Client(), nil
+		}
+		return servers, discovered, hydra.Discover(resolver, newClient, time.Millisecond, logr.Discard())
+	}
+
+	t.Run("case=creates clients in all instances with the same credentials", func(t *testing.T) {
+		servers, _, c := setup(t, 3)
+
+		created, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read", Owner: "test/default"})
+		require.NoError(t, err)
+
+		for _, s := range servers {
+			stored, found := s.GetClient(*created.ClientID)
+			require.True(t, found)
+			assert.Equal(t, created.Secret, stored.Secret)
+			assert.Equal(t, "read", stored.Scope)
+		}
+		assert.Len(t, c.Addresses(), 3)
+	})
+
+	t.Run("case=reports clients missing in an instance as incomplete", func(t *testing.T) {
+		servers, _, c := setup(t, 2)
+		servers[1].AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Scope: "read"})
+
+		fetched, found, err := c.GetOAuth2Client(ctx, "id")
+		require.ErrorIs(t, err, hydra.ErrIncomplete)
+		assert.True(t, found)
+		assert.Equal(t, "read", fetched.Scope)
+
+		_, err = c.PutOAuth2Client(ctx, &hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret"), Scope: "write"})
+		require.NoError(t, err)
+		for _, s := range servers {
+			stored, found := s.GetClient("id")
+			require.True(t, found)
+			assert.Equal(t, "write", stored.Scope)
+			assert.Equal(t, "secret", *stored.Secret)
+		}
+
+		_, found, err = c.GetOAuth2Client(ctx, "id")
+		require.NoError(t, err)
+		assert.True(t, found)
+	})
+
+	t.Run("case=does not create clients missing in all instances on updates", func(t *testing.T) {
+		servers, _, c := setup(t, 2)
+
+		_, found, err := c.GetOAuth2Client(ctx, "id")
+		require.NoError(t, err)
+		assert.False(t, found)
+
+		_, err = c.PutOAuth2Client(ctx, &hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Scope: "write"})
+		require.ErrorIs(t, err, hydra.ErrNotFound)
+		for _, s := range servers {
+			assert.Empty(t, s.Clients())
+		}
+	})
+
+	t.Run("case=returns created clients with an error if an instance fails", func(t *testing.T) {
+		servers, _, c := setup(t, 2)
+		// clients are created in the first instance by address and
+		// replicated to the second
+		failing := servers[1]
+		if instanceAddress(servers[0]) > instanceAddress(servers[1]) {
+			failing = servers[0]
+		}
+		failing.FailNext(http.MethodPost, http.StatusServiceUnavailable, 1)
+
+		created, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read"})
+		require.ErrorIs(t, err, hydra.ErrIncomplete)
+		assert.True(t, hydra.IsRetryable(err))
+		require.NotNil(t, created)
+
+		var incomplete *hydra.IncompleteError
+		require.True(t, errors.As(err, &incomplete))
+		assert.Len(t, incomplete.Errs, 1)
+		assert.Empty(t, failing.Clients())
+	})
+
+	t.Run("case=deletes clients from all instances which have them", func(t *testing.T) {
+		servers, _, c := setup(t, 2)
+		servers[0].AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id")})
+
+		list, err := c.ListOAuth2Client(ctx)
+		require.NoError(t, err)
+		assert.Len(t, list, 1)
+
+		require.NoError(t, c.DeleteOAuth2Client(ctx, "id"))
+		assert.Empty(t, servers[0].Clients())
+		require.NoError(t, c.DeleteOAuth2Client(ctx, "id"))
+
+		servers[1].AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id")})
+		servers[0].FailNext(http.MethodDelete, http.StatusServiceUnavailable, 1)
+		require.ErrorIs(t, c.DeleteOAuth2Client(ctx, "id"), hydra.ErrIncomplete)
+		assert.Empty(t, servers[1].Clients())
+	})
+
+	t.Run("case=follows changes of the discovered instances", func(t *testing.T) {
+		servers, discovered, c := setup(t, 2)
+		*discovered = []int{0}
+
+		created, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read"})
+		require.NoError(t, err)
+		assert.Empty(t, servers[1].Clients())
+
+		*discovered = []int{0, 1}
+		time.Sleep(2 * time.Millisecond)
+		_, _, err = c.GetOAuth2Client(ctx, *created.ClientID)
+		require.ErrorIs(t, err, hydra.ErrIncomplete)
+		assert.Len(t, c.Addresses(), 2)
+
+		ready, err := c.IsReady(ctx)
+		require.NoError(t, err)
+		assert.True(t, ready)
+		servers[1].SetReady(false)
+		ready, err = c.IsReady(ctx)
+		require.NoError(t, err)
+		assert.False(t, ready)
+	})
+
+	t.Run("case=fails without instances", func(t *testing.T) {
+		_, discovered, c := setup(t, 1)
+		*discovered = nil
+
+		_, _, err := c.GetOAuth2Client(ctx, "id")
+		require.ErrorIs(t, err, hydra.ErrNoInstances)
+		ready, err := c.IsReady(ctx)
+		require.NoError(t, err)
+		assert.False(t, ready)
+	})
+}
+
+func TestInstanceAddress(t *testing.T) {
+	assert.Equal(t, "http://10.0.0.7:4445", hydra.InstanceAddress("", "10.0.0.7", 4445))
+	assert.Equal(t, "https://[fd00::7]:4445", hydra.InstanceAddress("https", "fd00::7", 4445))
+}
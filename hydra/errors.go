@@ -0,0 +1,26 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import "github.com/ory/hydra-maester/hydra/admin"
+
+var (
+	// ErrNotFound matches RequestErrors of missing resources.
+	ErrNotFound = admin.ErrNotFound
+	// ErrConflict matches RequestErrors of client IDs which already exist.
+	ErrConflict = admin.ErrConflict
+	// ErrUnauthorized matches RequestErrors of rejected credentials.
+	ErrUnauthorized = admin.ErrUnauthorized
+)
+
+// RequestError is returned when the ORY Hydra admin API answers with an
+// unexpected status code, see admin.RequestError. Its body may echo the
+// request, use Sanitize before showing it.
+type RequestError = admin.RequestError
+
+// IsRetryable reports whether err is a transient failure which may succeed
+// when retried, see admin.IsRetryable.
+func IsRetryable(err error) bool {
+	return admin.IsRetryable(err)
+}
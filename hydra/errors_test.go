@@ -0,0 +1,58 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/hydra-maester/hydra"
+)
+
+func TestIsRetryable(t *testing.T) {
+	for d, tc := range map[string]struct {
+		err       error
+		retryable bool
+	}{
+		"internal server error": {&hydra.RequestError{StatusCode: 500}, true},
+		"service unavailable":   {&hydra.RequestError{StatusCode: 503}, true},
+		"too many requests":     {&hydra.RequestError{StatusCode: 429}, true},
+		"bad request":           {&hydra.RequestError{StatusCode: 400}, false},
+		"conflict":              {&hydra.RequestError{StatusCode: 409, Reason: "requested ID already exists"}, false},
+		"wrapped server error":  {fmt.Errorf("registering: %w", &hydra.RequestError{StatusCode: 502}), true},
+		"network error":         {&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		"deadline exceeded":     {context.DeadlineExceeded, true},
+		"other error":           {errors.New("unable to encode metadata"), false},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+			assert.Equal(t, tc.retryable, hydra.IsRetryable(tc.err))
+		})
+	}
+}
+
+func TestRequestErrorIs(t *testing.T) {
+	for d, tc := range map[string]struct {
+		err      error
+		sentinel error
+		matches  bool
+	}{
+		"not found":             {&hydra.RequestError{StatusCode: 404}, hydra.ErrNotFound, true},
+		"conflict":              {&hydra.RequestError{StatusCode: 409}, hydra.ErrConflict, true},
+		"unauthorized":          {&hydra.RequestError{StatusCode: 401}, hydra.ErrUnauthorized, true},
+		"forbidden":             {&hydra.RequestError{StatusCode: 403}, hydra.ErrUnauthorized, true},
+		"wrapped conflict":      {fmt.Errorf("registering: %w", &hydra.RequestError{StatusCode: 409}), hydra.ErrConflict, true},
+		"server error":          {&hydra.RequestError{StatusCode: 500}, hydra.ErrNotFound, false},
+		"conflict is no 404":    {&hydra.RequestError{StatusCode: 409}, hydra.ErrNotFound, false},
+		"other error is no 401": {errors.New("unauthorized"), hydra.ErrUnauthorized, false},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+			assert.Equal(t, tc.matches, errors.Is(tc.err, tc.sentinel))
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultFailoverCheckInterval is how often a FailoverClient checks the
+// readiness of its primary instance.
+const DefaultFailoverCheckInterval = 10 * time.Second
+
+// Endpoint is an ORY Hydra instance and the Client sending requests to it.
+type Endpoint struct {
+	// Address identifies the instance, e.g. its admin URL.
+	Address string
+	Client  Client
+}
+
+// FailoverClient sends requests to a primary ORY Hydra instance while it is
+// ready, and to a fallback instance otherwise. The readiness of the primary
+// is checked before the first request, at most once per check interval
+// after that, and before the next request when a request failed
+// transiently, so it is used again as soon as it recovers.
+type FailoverClient struct {
+	primary, fallback Endpoint
+	interval          time.Duration
+
+	mu         sync.Mutex
+	checked    time.Time
+	onFallback bool
+}
+
+// Failover returns a FailoverClient switching from primary to fallback when
+// the primary is not ready. An interval of zero uses
+// DefaultFailoverCheckInterval.
+func Failover(primary, fallback Endpoint, interval time.Duration) *FailoverClient {
+	if interval == 0 {
+		interval = DefaultFailoverCheckInterval
+	}
+	return &FailoverClient{primary: primary, fallback: fallback, interval: interval}
+}
+
+// Endpoint returns the address of the instance which the last request was
+// sent to, or of the primary if no request was sent yet.
+func (f *FailoverClient) Endpoint() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.onFallback {
+		return f.fallback.Address
+	}
+	return f.primary.Address
+}
+
+// ActiveEndpoint returns the address of the instance which c sends requests
+// to if it fails over between instances, see FailoverClient.Endpoint, and
+// false otherwise. Clients returned by Mirror and Throttle are looked
+// through.
+func ActiveEndpoint(c Client) (string, bool) {
+	switch c := c.(type) {
+	case *FailoverClient:
+		return c.Endpoint(), true
+	case *mirrorClient:
+		return ActiveEndpoint(c.Client)
+	case *mirrorPatcher:
+		return ActiveEndpoint(c.Client)
+	case *throttledClient:
+		return ActiveEndpoint(c.Client)
+	case *throttledPatcher:
+		return ActiveEndpoint(c.Client)
+	}
+	return "", false
+}
+
+// active returns the endpoint to send the next request to, checking the
+// readiness of the primary if the check is due. The primary is checked
+// without holding the lock, so a slow check doesn't block requests to the
+// endpoint in use.
+func (f *FailoverClient) active(ctx context.Context) Endpoint {
+	now := time.Now()
+	f.mu.Lock()
+	due := f.checked.IsZero() || now.Sub(f.checked) >= f.interval
+	onFallback := f.onFallback
+	f.mu.Unlock()
+
+	if due {
+		ready, err := f.primary.Client.IsReady(ctx)
+		onFallback = err != nil || !ready
+
+		f.mu.Lock()
+		f.checked = now
+		f.onFallback = onFallback
+		f.mu.Unlock()
+	}
+	if onFallback {
+		return f.fallback
+	}
+	return f.primary
+}
+
+// observe makes the next request check the readiness of the primary again
+// if err is transient.
+func (f *FailoverClient) observe(err error) {
+	if err == nil || !IsRetryable(err) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checked = time.Time{}
+}
+
+func (f *FailoverClient) GetOAuth2Client(ctx context.Context, id string) (*OAuth2ClientJSON, bool, error) {
+	c, found, err := f.active(ctx).Client.GetOAuth2Client(ctx, id)
+	f.observe(err)
+	return c, found, err
+}
+
+func (f *FailoverClient) ListOAuth2Client(ctx context.Context) ([]*OAuth2ClientJSON, error) {
+	list, err := f.active(ctx).Client.ListOAuth2Client(ctx)
+	f.observe(err)
+	return list, err
+}
+
+func (f *FailoverClient) PostOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error) {
+	c, err := f.active(ctx).Client.PostOAuth2Client(ctx, o)
+	f.observe(err)
+	return c, err
+}
+
+func (f *FailoverClient) PutOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error) {
+	c, err := f.active(ctx).Client.PutOAuth2Client(ctx, o)
+	f.observe(err)
+	return c, err
+}
+
+// PatchOAuth2Client patches the client, failing if the Client of the active
+// instance does not implement Patcher.
+func (f *FailoverClient) PatchOAuth2Client(ctx context.Context, id string, patch []PatchOperation) (*OAuth2ClientJSON, error) {
+	active := f.active(ctx)
+	patcher, ok := active.Client.(Patcher)
+	if !ok {
+		return nil, fmt.Errorf("client of %s does not support patches", active.Address)
+	}
+	c, err := patcher.PatchOAuth2Client(ctx, id, patch)
+	f.observe(err)
+	return c, err
+}
+
+func (f *FailoverClient) DeleteOAuth2Client(ctx context.Context, id string) error {
+	err := f.active(ctx).Client.DeleteOAuth2Client(ctx, id)
+	f.observe(err)
+	return err
+}
+
+func (f *FailoverClient) GetVersion(ctx context.Context) (string, error) {
+	version, err := f.active(ctx).Client.GetVersion(ctx)
+	f.observe(err)
+	return version, err
+}
+
+// IsReady reports whether the active instance is ready.
+func (f *FailoverClient) IsReady(ctx context.Context) (bool, error) {
+	ready, err := f.active(ctx).Client.IsReady(ctx)
+	f.observe(err)
+	return ready, err
+}
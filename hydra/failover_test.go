@@ -0,0 +1,148 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+func TestFailover(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, interval time.Duration) (primary, fallback *hydratest.Server, c *hydra.FailoverClient) {
+		primary, fallback = hydratest.NewServer(), hydratest.NewServer()
+		t.Cleanup(primary.Close)
+		t.Cleanup(fallback.Close)
+		c = hydra.Failover(
+			hydra.Endpoint{Address: "primary", Client: primary.Client()},
+			hydra.Endpoint{Address: "fallback", Client: fallback.Client()},
+			interval)
+		return primary, fallback, c
+	}
+
+	t.Run("case=uses the primary while it is ready", func(t *testing.T) {
+		primary, fallback, c := setup(t, time.Hour)
+
+		_, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read"})
+		require.NoError(t, err)
+
+		assert.Len(t, primary.Clients(), 1)
+		assert.Empty(t, fallback.Clients())
+		assert.Equal(t, "primary", c.Endpoint())
+	})
+
+	t.Run("case=uses the fallback while the primary is not ready", func(t *testing.T) {
+		primary, fallback, c := setup(t, time.Hour)
+		primary.SetReady(false)
+
+		_, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read"})
+		require.NoError(t, err)
+
+		assert.Empty(t, primary.Clients())
+		assert.Len(t, fallback.Clients(), 1)
+		assert.Equal(t, "fallback", c.Endpoint())
+
+		endpoint, ok := hydra.ActiveEndpoint(c)
+		assert.True(t, ok)
+		assert.Equal(t, "fallback", endpoint)
+	})
+
+	t.Run("case=checks the primary again after the interval", func(t *testing.T) {
+		primary, _, c := setup(t, time.Millisecond)
+		primary.SetReady(false)
+
+		_, _, err := c.GetOAuth2Client(ctx, "id")
+		require.NoError(t, err)
+		assert.Equal(t, "fallback", c.Endpoint())
+
+		primary.SetReady(true)
+		time.Sleep(2 * time.Millisecond)
+		_, _, err = c.GetOAuth2Client(ctx, "id")
+		require.NoError(t, err)
+		assert.Equal(t, "primary", c.Endpoint())
+	})
+
+	t.Run("case=checks the primary again after a transient failure", func(t *testing.T) {
+		primary, fallback, c := setup(t, time.Hour)
+		primary.FailNext(http.MethodPost, http.StatusServiceUnavailable, 1)
+
+		_, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read"})
+		require.Error(t, err)
+
+		primary.SetReady(false)
+		_, err = c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read"})
+		require.NoError(t, err)
+		assert.Len(t, fallback.Clients(), 1)
+		assert.Equal(t, "fallback", c.Endpoint())
+	})
+
+	t.Run("case=does not block requests while the primary is checked", func(t *testing.T) {
+		fallback := hydratest.NewServer()
+		t.Cleanup(fallback.Close)
+		primary := &blockingReadiness{Client: fallback.Client(), started: make(chan struct{}), release: make(chan struct{})}
+		c := hydra.Failover(
+			hydra.Endpoint{Address: "primary", Client: primary},
+			hydra.Endpoint{Address: "fallback", Client: fallback.Client()},
+			time.Hour)
+
+		checked := make(chan struct{})
+		go func() {
+			defer close(checked)
+			_, _, _ = c.GetOAuth2Client(ctx, "id")
+		}()
+
+		<-primary.started
+		endpoint := make(chan string)
+		go func() { endpoint <- c.Endpoint() }()
+		select {
+		case e := <-endpoint:
+			assert.Equal(t, "primary", e)
+		case <-time.After(time.Second):
+			t.Fatal("Endpoint blocked while the primary was checked")
+		}
+		close(primary.release)
+		<-checked
+		assert.Equal(t, "fallback", c.Endpoint())
+	})
+
+	t.Run("case=mirrored clients report the endpoint", func(t *testing.T) {
+		primary, _, c := setup(t, time.Hour)
+		primary.SetReady(false)
+		mirror := hydratest.NewServer()
+		t.Cleanup(mirror.Close)
+
+		mirrored := hydra.Mirror(c, mirror.Client(), func(hydra.MirrorDivergence) {})
+		_, err := mirrored.IsReady(ctx)
+		require.NoError(t, err)
+
+		endpoint, ok := hydra.ActiveEndpoint(mirrored)
+		assert.True(t, ok)
+		assert.Equal(t, "fallback", endpoint)
+
+		_, ok = hydra.ActiveEndpoint(primary.Client())
+		assert.False(t, ok)
+	})
+}
+
+// blockingReadiness is a client whose readiness check closes started, blocks
+// until release is closed and then reports it is not ready.
+type blockingReadiness struct {
+	hydra.Client
+	started, release chan struct{}
+}
+
+func (b *blockingReadiness) IsReady(ctx context.Context) (bool, error) {
+	close(b.started)
+	<-b.release
+	return false, nil
+}
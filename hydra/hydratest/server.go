@@ -0,0 +1,430 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package hydratest provides an in-memory fake of the ORY Hydra admin API
+// for tests of code that talks to Hydra through the hydra package, such as
+// the OAuth2Client reconciler.
+package hydratest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+// Endpoint is the path under which the fake server serves OAuth2 clients.
+const Endpoint = "/admin/clients"
+
+// DefaultVersion is the version reported by a Server unless changed with
+// SetVersion.
+const DefaultVersion = "v2.2.0"
+
+// Server is an in-memory fake of the ORY Hydra admin API. It supports
+// creating, reading, updating, patching, listing and deleting OAuth2 clients
+// as well as the version and readiness endpoints. Clients keep the owner they
+// were created or last updated with, just like in Hydra.
+//
+// A Server is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	clients  map[string]*hydra.OAuth2ClientJSON
+	failures []failure
+	version  string
+	ready    bool
+}
+
+type failure struct {
+	method     string
+	statusCode int
+	remaining  int
+}
+
+// NewServer starts and returns a new Server. The caller should call Close
+// when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		clients: make(map[string]*hydra.OAuth2ClientJSON),
+		version: DefaultVersion,
+		ready:   true,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// HydraAdmin returns the admin address of the server, for use in
+// OAuth2Client specs or the controller's default Hydra configuration.
+func (s *Server) HydraAdmin() hydrav1alpha1.HydraAdmin {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		panic(err)
+	}
+
+	return hydrav1alpha1.HydraAdmin{
+		URL:      u.Scheme + "://" + u.Hostname(),
+		Port:     port,
+		Endpoint: Endpoint,
+	}
+}
+
+// Client returns a hydra.Client talking to the server.
+func (s *Server) Client(opts ...hydra.Option) hydra.Client {
+	opts = append([]hydra.Option{hydra.WithHTTPClient(s.Server.Client())}, opts...)
+	c, err := hydra.New(hydrav1alpha1.OAuth2ClientSpec{HydraAdmin: s.HydraAdmin()}, "", false, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// AddClient stores c as if it had been registered through the API. A client
+// ID and secret are generated if c has none.
+func (s *Server) AddClient(c *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := copyClient(c)
+	if stored.ClientID == nil {
+		stored.ClientID = ptr(randomString())
+	}
+	if stored.Secret == nil && stored.TokenEndpointAuthMethod != "none" {
+		stored.Secret = ptr(randomString())
+	}
+	s.clients[*stored.ClientID] = stored
+	return copyClient(stored)
+}
+
+// GetClient returns the stored client with the given ID, including its
+// secret.
+func (s *Server) GetClient(id string) (*hydra.OAuth2ClientJSON, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.clients[id]
+	if !ok {
+		return nil, false
+	}
+	return copyClient(c), true
+}
+
+// Clients returns all stored clients ordered by client ID, including their
+// secrets.
+func (s *Server) Clients() []*hydra.OAuth2ClientJSON {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.list("")
+}
+
+// ClientsOwnedBy returns the stored clients whose owner is owner.
+func (s *Server) ClientsOwnedBy(owner string) []*hydra.OAuth2ClientJSON {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.list(owner)
+}
+
+// Reset removes all stored clients and pending failures and makes the
+// server ready again.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clients = make(map[string]*hydra.OAuth2ClientJSON)
+	s.failures = nil
+	s.version = DefaultVersion
+	s.ready = true
+}
+
+// FailNext makes the next times requests with the given HTTP method fail
+// with statusCode. An empty method matches requests of any method.
+// Failures are consumed in the order they were added.
+func (s *Server) FailNext(method string, statusCode, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failure{method: method, statusCode: statusCode, remaining: times})
+}
+
+// SetVersion changes the version reported by the /version endpoint.
+func (s *Server) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.version = version
+}
+
+// SetReady changes whether the /health/ready endpoint reports the server
+// as ready.
+func (s *Server) SetReady(ready bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ready = ready
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if code, ok := s.nextFailure(r.Method); ok {
+		writeError(w, code, "injected failure")
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/version" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]string{"version": s.version})
+	case r.URL.Path == "/health/ready" && r.Method == http.MethodGet:
+		if !s.ready {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	case r.URL.Path == Endpoint:
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, withoutSecrets(s.list(r.URL.Query().Get("owner"))))
+		case http.MethodPost:
+			s.create(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case strings.HasPrefix(r.URL.Path, Endpoint+"/"):
+		id := strings.TrimPrefix(r.URL.Path, Endpoint+"/")
+		switch r.Method {
+		case http.MethodGet:
+			c, ok := s.clients[id]
+			if !ok {
+				writeError(w, http.StatusNotFound, "Unable to locate the resource")
+				return
+			}
+			writeJSON(w, http.StatusOK, withoutSecret(c))
+		case http.MethodPut:
+			s.update(w, r, id)
+		case http.MethodPatch:
+			s.patch(w, r, id)
+		case http.MethodDelete:
+			if _, ok := s.clients[id]; !ok {
+				writeError(w, http.StatusNotFound, "Unable to locate the resource")
+				return
+			}
+			delete(s.clients, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var c hydra.OAuth2ClientJSON
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if c.ClientID == nil || *c.ClientID == "" {
+		c.ClientID = ptr(randomString())
+	} else if _, ok := s.clients[*c.ClientID]; ok {
+		writeError(w, http.StatusConflict, "Unable to insert or update resource because a resource with that value exists already")
+		return
+	}
+	if (c.Secret == nil || *c.Secret == "") && c.TokenEndpointAuthMethod != "none" {
+		c.Secret = ptr(randomString())
+	}
+
+	s.clients[*c.ClientID] = copyClient(&c)
+	writeJSON(w, http.StatusCreated, &c)
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request, id string) {
+	stored, ok := s.clients[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Unable to locate the resource")
+		return
+	}
+
+	var c hydra.OAuth2ClientJSON
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.ClientID = ptr(id)
+	if c.Secret == nil || *c.Secret == "" {
+		c.Secret = stored.Secret
+	}
+
+	s.clients[id] = copyClient(&c)
+	writeJSON(w, http.StatusOK, withoutSecret(&c))
+}
+
+// patch applies a JSON Patch of operations on top-level fields, which is all
+// the controller sends.
+func (s *Server) patch(w http.ResponseWriter, r *http.Request, id string) {
+	stored, ok := s.clients[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Unable to locate the resource")
+		return
+	}
+
+	var patch []hydra.PatchOperation
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for _, op := range patch {
+		field := strings.TrimPrefix(op.Path, "/")
+		if field == "" || strings.Contains(field, "/") || field == "client_id" {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported path %q", op.Path))
+			return
+		}
+		switch op.Op {
+		case "add":
+			fields[field] = op.Value
+		case "replace":
+			if _, ok := fields[field]; !ok {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("replace of missing path %q", op.Path))
+				return
+			}
+			fields[field] = op.Value
+		case "remove":
+			if _, ok := fields[field]; !ok {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("remove of missing path %q", op.Path))
+				return
+			}
+			delete(fields, field)
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported operation %q", op.Op))
+			return
+		}
+	}
+
+	if raw, err = json.Marshal(fields); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var c hydra.OAuth2ClientJSON
+	if err := json.Unmarshal(raw, &c); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.ClientID = ptr(id)
+	if c.Secret == nil || *c.Secret == "" {
+		c.Secret = stored.Secret
+	}
+
+	s.clients[id] = copyClient(&c)
+	writeJSON(w, http.StatusOK, withoutSecret(&c))
+}
+
+func (s *Server) nextFailure(method string) (int, bool) {
+	for i, f := range s.failures {
+		if f.method != "" && f.method != method {
+			continue
+		}
+		if f.remaining--; f.remaining <= 0 {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		} else {
+			s.failures[i] = f
+		}
+		return f.statusCode, true
+	}
+	return 0, false
+}
+
+func (s *Server) list(owner string) []*hydra.OAuth2ClientJSON {
+	clients := make([]*hydra.OAuth2ClientJSON, 0, len(s.clients))
+	for _, c := range s.clients {
+		if owner != "" && c.Owner != owner {
+			continue
+		}
+		clients = append(clients, copyClient(c))
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return *clients[i].ClientID < *clients[j].ClientID
+	})
+	return clients
+}
+
+func withoutSecret(c *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+	c = copyClient(c)
+	c.Secret = nil
+	return c
+}
+
+func withoutSecrets(clients []*hydra.OAuth2ClientJSON) []*hydra.OAuth2ClientJSON {
+	for i, c := range clients {
+		clients[i] = withoutSecret(c)
+	}
+	return clients
+}
+
+func copyClient(c *hydra.OAuth2ClientJSON) *hydra.OAuth2ClientJSON {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		panic(err)
+	}
+	var out hydra.OAuth2ClientJSON
+	if err := json.Unmarshal(raw, &out); err != nil {
+		panic(err)
+	}
+	return &out
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, description string) {
+	writeJSON(w, statusCode, map[string]string{
+		"error":             strings.ToLower(strings.ReplaceAll(http.StatusText(statusCode), " ", "_")),
+		"error_description": description,
+	})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("hydratest: reading random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,138 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydratest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("case=clients CRUD", func(t *testing.T) {
+		s := hydratest.NewServer()
+		defer s.Close()
+		c := s.Client()
+
+		created, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{
+			ClientName: "test",
+			GrantTypes: []string{"client_credentials"},
+			Owner:      "test/default",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, created.ClientID)
+		require.NotNil(t, created.Secret)
+
+		got, found, err := c.GetOAuth2Client(ctx, *created.ClientID)
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, "test/default", got.Owner)
+		assert.Nil(t, got.Secret)
+
+		got.ClientName = "updated"
+		_, err = c.PutOAuth2Client(ctx, got)
+		require.NoError(t, err)
+
+		stored, ok := s.GetClient(*created.ClientID)
+		require.True(t, ok)
+		assert.Equal(t, "updated", stored.ClientName)
+		assert.Equal(t, *created.Secret, *stored.Secret)
+
+		list, err := c.ListOAuth2Client(ctx)
+		require.NoError(t, err)
+		assert.Len(t, list, 1)
+		assert.Len(t, s.ClientsOwnedBy("test/default"), 1)
+		assert.Empty(t, s.ClientsOwnedBy("other/default"))
+
+		require.NoError(t, c.DeleteOAuth2Client(ctx, *created.ClientID))
+		_, found, err = c.GetOAuth2Client(ctx, *created.ClientID)
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("case=patch clients", func(t *testing.T) {
+		s := hydratest.NewServer()
+		defer s.Close()
+
+		existing := s.AddClient(&hydra.OAuth2ClientJSON{
+			ClientName: "test",
+			JwksUri:    "https://example.com/jwks.json",
+			Scope:      "read",
+			Owner:      "test/default",
+		})
+
+		c, ok := s.Client().(hydra.Patcher)
+		require.True(t, ok)
+		_, err := c.PatchOAuth2Client(ctx, *existing.ClientID, []hydra.PatchOperation{
+			{Op: "replace", Path: "/scope", Value: "read write"},
+			{Op: "remove", Path: "/client_name"},
+		})
+		require.NoError(t, err)
+
+		stored, ok := s.GetClient(*existing.ClientID)
+		require.True(t, ok)
+		assert.Equal(t, "read write", stored.Scope)
+		assert.Empty(t, stored.ClientName)
+		assert.Equal(t, "https://example.com/jwks.json", stored.JwksUri)
+		assert.Equal(t, *existing.Secret, *stored.Secret)
+
+		_, err = c.PatchOAuth2Client(ctx, *existing.ClientID, []hydra.PatchOperation{{Op: "remove", Path: "/client_name"}})
+		assert.Error(t, err, "the name was removed already")
+	})
+
+	t.Run("case=conflict on existing client ID", func(t *testing.T) {
+		s := hydratest.NewServer()
+		defer s.Close()
+
+		existing := s.AddClient(&hydra.OAuth2ClientJSON{Owner: "other/default"})
+
+		_, err := s.Client().PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{ClientID: existing.ClientID})
+		assert.True(t, errors.Is(err, hydra.ErrConflict))
+	})
+
+	t.Run("case=injected failures", func(t *testing.T) {
+		s := hydratest.NewServer()
+		defer s.Close()
+		c := s.Client()
+
+		s.FailNext(http.MethodPost, http.StatusInternalServerError, 2)
+
+		for i := 0; i < 2; i++ {
+			_, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{})
+			var reqErr *hydra.RequestError
+			require.True(t, errors.As(err, &reqErr))
+			assert.Equal(t, http.StatusInternalServerError, reqErr.StatusCode)
+		}
+
+		_, err := c.ListOAuth2Client(ctx)
+		require.NoError(t, err)
+
+		_, err = c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{})
+		require.NoError(t, err)
+	})
+
+	t.Run("case=version and readiness", func(t *testing.T) {
+		s := hydratest.NewServer()
+		defer s.Close()
+		c := s.Client()
+
+		version, err := hydra.CheckVersion(ctx, c)
+		require.NoError(t, err)
+		assert.Equal(t, hydratest.DefaultVersion, version)
+
+		s.SetReady(false)
+		ready, err := c.IsReady(ctx)
+		require.NoError(t, err)
+		assert.False(t, ready)
+	})
+}
@@ -0,0 +1,199 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// ProvenanceMetadataKey is the metadata key under which the controller records
+// the OAuth2Client a client was registered from, see Provenance.
+const ProvenanceMetadataKey = "k8s"
+
+// Provenance identifies the OAuth2Client a client in ORY Hydra was registered
+// from. Unlike the owner, it is never shortened and includes the UID, which
+// tells apart OAuth2Clients recreated with the same name.
+type Provenance struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	UID       string `json:"uid"`
+	Cluster   string `json:"cluster,omitempty"`
+}
+
+// GetMetadata decodes the value of key in metadata, the JSON object of
+// spec.metadata or of an ORY Hydra client, into a T. ok is false if metadata
+// is empty or does not contain key.
+func GetMetadata[T any](metadata []byte, key string) (value T, ok bool, err error) {
+	m, err := metadataObject(metadata)
+	if err != nil {
+		return value, false, err
+	}
+	raw, ok := m[key]
+	if !ok {
+		return value, false, nil
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, false, fmt.Errorf("unable to decode metadata key %q: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// SetMetadata returns metadata with key set to value. The other keys are kept.
+func SetMetadata(metadata []byte, key string, value interface{}) ([]byte, error) {
+	m, err := metadataObject(metadata)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode metadata key %q: %w", key, err)
+	}
+	if m == nil {
+		m = map[string]json.RawMessage{}
+	}
+	m[key] = raw
+	return json.Marshal(m)
+}
+
+// MergeMetadata deep merges override into base and returns the result, like
+// a JSON merge patch (RFC 7386). Objects are merged key by key, keys set to
+// null in override are removed and all other values of override replace
+// those of base. Keys which are only in base are kept.
+func MergeMetadata(base, override []byte) ([]byte, error) {
+	if isEmptyMetadata(override) {
+		return base, nil
+	}
+
+	var b, o interface{}
+	if !isEmptyMetadata(base) {
+		if err := json.Unmarshal(base, &b); err != nil {
+			return nil, fmt.Errorf("unable to decode metadata: %w", err)
+		}
+	}
+	if err := json.Unmarshal(override, &o); err != nil {
+		return nil, fmt.Errorf("unable to decode metadata: %w", err)
+	}
+	return json.Marshal(mergeValues(b, o, false))
+}
+
+// ComposeMetadata returns the metadata which merges like first merged and
+// then second, see MergeMetadata. Unlike MergeMetadata, keys set to null in
+// second are kept as null, so they still remove the keys of the metadata the
+// result is merged into.
+func ComposeMetadata(first, second []byte) ([]byte, error) {
+	if isEmptyMetadata(second) {
+		return first, nil
+	}
+
+	var f, s interface{}
+	if !isEmptyMetadata(first) {
+		if err := json.Unmarshal(first, &f); err != nil {
+			return nil, fmt.Errorf("unable to decode metadata: %w", err)
+		}
+	}
+	if err := json.Unmarshal(second, &s); err != nil {
+		return nil, fmt.Errorf("unable to decode metadata: %w", err)
+	}
+	return json.Marshal(mergeValues(f, s, true))
+}
+
+// mergeValues merges override into base, removing the keys set to null in
+// override unless keepNulls is set.
+func mergeValues(base, override interface{}, keepNulls bool) interface{} {
+	o, ok := override.(map[string]interface{})
+	if !ok {
+		return override
+	}
+	b, ok := base.(map[string]interface{})
+	if !ok {
+		b = make(map[string]interface{}, len(o))
+	}
+	for k, v := range o {
+		switch {
+		case v != nil:
+			b[k] = mergeValues(b[k], v, keepNulls)
+		case keepNulls:
+			b[k] = nil
+		default:
+			delete(b, k)
+		}
+	}
+	return b
+}
+
+// EncodeMetadata encodes m, the spec.metadata of an OAuth2Client, as the JSON
+// object of the metadata of a client. Keys set to null, which only remove
+// keys on updates, see MergeMetadata, are left out. Absent metadata is
+// encoded as null.
+func EncodeMetadata(m map[string]apiextensionsv1.JSON) ([]byte, error) {
+	raw, err := json.Marshal(m)
+	if err != nil || m == nil {
+		return raw, err
+	}
+	return MergeMetadata(nil, raw)
+}
+
+// DecodeMetadata decodes metadata, the JSON object of the metadata of a
+// client, into the spec.metadata of an OAuth2Client. Empty metadata is
+// decoded as nil.
+func DecodeMetadata(metadata []byte) (map[string]apiextensionsv1.JSON, error) {
+	if isEmptyMetadata(metadata) {
+		return nil, nil
+	}
+	var m map[string]apiextensionsv1.JSON
+	if err := json.Unmarshal(metadata, &m); err != nil {
+		return nil, fmt.Errorf("metadata is not a JSON object: %w", err)
+	}
+	return m, nil
+}
+
+// MetadataSchema validates metadata against a JSON Schema.
+type MetadataSchema struct {
+	schema *spec.Schema
+}
+
+// NewMetadataSchema parses a JSON Schema (draft 4) from raw.
+func NewMetadataSchema(raw []byte) (*MetadataSchema, error) {
+	var s spec.Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("unable to parse metadata schema: %w", err)
+	}
+	return &MetadataSchema{schema: &s}, nil
+}
+
+// Validate returns an error describing the violations of the schema by
+// metadata. Empty metadata is validated as an empty object.
+func (s *MetadataSchema) Validate(metadata []byte) error {
+	var data interface{} = map[string]interface{}{}
+	if !isEmptyMetadata(metadata) {
+		if err := json.Unmarshal(metadata, &data); err != nil {
+			return fmt.Errorf("unable to decode metadata: %w", err)
+		}
+	}
+	if err := validate.AgainstSchema(s.schema, data, strfmt.Default); err != nil {
+		return fmt.Errorf("metadata does not match the schema: %w", err)
+	}
+	return nil
+}
+
+func metadataObject(metadata []byte) (map[string]json.RawMessage, error) {
+	if isEmptyMetadata(metadata) {
+		return nil, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(metadata, &m); err != nil {
+		return nil, fmt.Errorf("metadata is not a JSON object: %w", err)
+	}
+	return m, nil
+}
+
+func isEmptyMetadata(metadata []byte) bool {
+	return len(metadata) == 0 || string(metadata) == "null"
+}
@@ -0,0 +1,141 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/ory/hydra-maester/hydra"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("case=get and set typed keys", func(t *testing.T) {
+		type team struct {
+			Name string `json:"name"`
+		}
+
+		metadata, err := hydra.SetMetadata(nil, "team", team{Name: "payments"})
+		require.NoError(t, err)
+		metadata, err = hydra.SetMetadata(metadata, "tier", 2)
+		require.NoError(t, err)
+
+		got, ok, err := hydra.GetMetadata[team](metadata, "team")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "payments", got.Name)
+
+		tier, ok, err := hydra.GetMetadata[int](metadata, "tier")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, 2, tier)
+
+		_, ok, err = hydra.GetMetadata[string]([]byte("null"), "team")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, _, err = hydra.GetMetadata[string](metadata, "tier")
+		assert.Error(t, err)
+
+		_, err = hydra.SetMetadata([]byte(`[]`), "team", "payments")
+		assert.Error(t, err)
+	})
+
+	t.Run("case=deep merge", func(t *testing.T) {
+		merged, err := hydra.MergeMetadata(
+			[]byte(`{"owner":"billing","labels":{"a":"1","b":"2"},"list":[1,2]}`),
+			[]byte(`{"labels":{"b":"3","c":"4"},"list":[3]}`),
+		)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"owner":"billing","labels":{"a":"1","b":"3","c":"4"},"list":[3]}`, string(merged))
+
+		merged, err = hydra.MergeMetadata([]byte("null"), []byte(`{"a":1}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"a":1}`, string(merged))
+
+		merged, err = hydra.MergeMetadata([]byte(`{"a":1}`), []byte("null"))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"a":1}`, string(merged))
+	})
+
+	t.Run("case=deep merge removes keys set to null", func(t *testing.T) {
+		merged, err := hydra.MergeMetadata(
+			[]byte(`{"owner":"billing","automation":{"synced":true},"labels":{"a":"1","b":"2"}}`),
+			[]byte(`{"owner":null,"labels":{"b":null,"c":"3"},"missing":null}`),
+		)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"automation":{"synced":true},"labels":{"a":"1","c":"3"}}`, string(merged))
+
+		// values which aren't objects are replaced by objects without nulls
+		merged, err = hydra.MergeMetadata([]byte(`{"labels":"none"}`), []byte(`{"labels":{"a":null,"b":"2"}}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"labels":{"b":"2"}}`, string(merged))
+
+		merged, err = hydra.MergeMetadata(nil, []byte(`{"a":1,"b":null}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"a":1}`, string(merged))
+
+		_, err = hydra.MergeMetadata([]byte(`{`), []byte(`{"a":1}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("case=compose keeps keys set to null", func(t *testing.T) {
+		composed, err := hydra.ComposeMetadata(
+			[]byte(`{"costCenter":"cc-42","labels":{"tier":"gold"}}`),
+			[]byte(`{"costCenter":null,"labels":{"app":"checkout"},"legacy":null}`),
+		)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"costCenter":null,"labels":{"tier":"gold","app":"checkout"},"legacy":null}`, string(composed))
+
+		// merging the composition is merging both in turn
+		current := []byte(`{"costCenter":"cc-1","legacy":true,"automation":1}`)
+		merged, err := hydra.MergeMetadata(current, composed)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"labels":{"tier":"gold","app":"checkout"},"automation":1}`, string(merged))
+	})
+
+	t.Run("case=encode and decode spec metadata", func(t *testing.T) {
+		m, err := hydra.DecodeMetadata([]byte(`{"team":"payments","labels":{"app":"checkout"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]apiextensionsv1.JSON{
+			"team":   {Raw: []byte(`"payments"`)},
+			"labels": {Raw: []byte(`{"app":"checkout"}`)},
+		}, m)
+
+		m["removed"] = apiextensionsv1.JSON{}
+		raw, err := hydra.EncodeMetadata(m)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"team":"payments","labels":{"app":"checkout"}}`, string(raw))
+
+		m, err = hydra.DecodeMetadata([]byte("null"))
+		require.NoError(t, err)
+		assert.Nil(t, m)
+
+		raw, err = hydra.EncodeMetadata(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "null", string(raw))
+
+		_, err = hydra.DecodeMetadata([]byte(`"payments"`))
+		assert.Error(t, err)
+	})
+
+	t.Run("case=schema validation", func(t *testing.T) {
+		schema, err := hydra.NewMetadataSchema([]byte(`{
+			"type": "object",
+			"required": ["team"],
+			"properties": {"team": {"type": "string"}}
+		}`))
+		require.NoError(t, err)
+
+		assert.NoError(t, schema.Validate([]byte(`{"team":"payments"}`)))
+		assert.Error(t, schema.Validate([]byte(`{"team":1}`)))
+		assert.Error(t, schema.Validate(nil))
+
+		_, err = hydra.NewMetadataSchema([]byte(`{`))
+		assert.Error(t, err)
+	})
+}
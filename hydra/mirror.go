@@ -0,0 +1,140 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// MirrorDivergence describes a write which the mirror of a Client returned by
+// Mirror did not apply like the primary ORY Hydra instance.
+type MirrorDivergence struct {
+	// Method is the HTTP method of the write, e.g. POST.
+	Method string
+	// ClientID is the ID of the written client.
+	ClientID string
+	// Err is the error of the mirror, if it failed the write.
+	Err error
+	// Diffs are the redacted fields in which the client stored by the mirror
+	// differs from the one stored by the primary instance.
+	Diffs []FieldDiff
+}
+
+// Mirror returns a Client which sends all requests to primary and repeats the
+// writes which succeed on mirror, e.g. to register all clients in a new ORY
+// Hydra instance during a migration. Reads are only sent to primary. Writes
+// failed by mirror don't fail the request, but are passed to report, and so
+// are clients which mirror stores differently than primary. Clients missing
+// in mirror are created by updates, and clients existing in mirror already
+// are updated by creations. The returned Client implements Patcher if primary
+// does; patched clients are written to mirror with a PUT.
+func Mirror(primary, mirror Client, report func(MirrorDivergence)) Client {
+	m := &mirrorClient{Client: primary, mirror: mirror, report: report}
+	if patcher, ok := primary.(Patcher); ok {
+		return &mirrorPatcher{mirrorClient: m, patcher: patcher}
+	}
+	return m
+}
+
+type mirrorClient struct {
+	Client
+	mirror Client
+	report func(MirrorDivergence)
+}
+
+func (m *mirrorClient) PostOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error) {
+	created, err := m.Client.PostOAuth2Client(ctx, o)
+	if err != nil {
+		return created, err
+	}
+
+	// the mirror stores the client with the ID and secret of the primary
+	mirrored := *o
+	mirrored.ClientID = created.ClientID
+	if mirrored.Secret == nil {
+		mirrored.Secret = created.Secret
+	}
+	m.write(ctx, http.MethodPost, &mirrored, created)
+	return created, nil
+}
+
+func (m *mirrorClient) PutOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (*OAuth2ClientJSON, error) {
+	updated, err := m.Client.PutOAuth2Client(ctx, o)
+	if err != nil {
+		return updated, err
+	}
+	m.write(ctx, http.MethodPut, o, updated)
+	return updated, nil
+}
+
+func (m *mirrorClient) DeleteOAuth2Client(ctx context.Context, id string) error {
+	if err := m.Client.DeleteOAuth2Client(ctx, id); err != nil {
+		return err
+	}
+	if err := m.mirror.DeleteOAuth2Client(ctx, id); err != nil && !errors.Is(err, ErrNotFound) {
+		m.report(MirrorDivergence{Method: http.MethodDelete, ClientID: id, Err: err})
+	}
+	return nil
+}
+
+// write stores o in the mirror, creating or replacing it, and reports the
+// divergence of the result from stored, the client stored by the primary.
+func (m *mirrorClient) write(ctx context.Context, method string, o, stored *OAuth2ClientJSON) {
+	var id string
+	if o.ClientID != nil {
+		id = *o.ClientID
+	}
+
+	var mirrored *OAuth2ClientJSON
+	var err error
+	if method == http.MethodPost {
+		mirrored, err = m.mirror.PostOAuth2Client(ctx, o)
+		if errors.Is(err, ErrConflict) {
+			mirrored, err = m.mirror.PutOAuth2Client(ctx, o)
+		}
+	} else {
+		mirrored, err = m.mirror.PutOAuth2Client(ctx, o)
+		if errors.Is(err, ErrNotFound) {
+			mirrored, err = m.mirror.PostOAuth2Client(ctx, o)
+		}
+	}
+	if err != nil {
+		m.report(MirrorDivergence{Method: method, ClientID: id, Err: err})
+		return
+	}
+
+	diffs, err := Diff(stored, mirrored)
+	if err != nil {
+		m.report(MirrorDivergence{Method: method, ClientID: id, Err: err})
+		return
+	}
+	if len(diffs) > 0 {
+		m.report(MirrorDivergence{Method: method, ClientID: id, Diffs: Redact(diffs)})
+	}
+}
+
+type mirrorPatcher struct {
+	*mirrorClient
+	patcher Patcher
+}
+
+func (m *mirrorPatcher) PatchOAuth2Client(ctx context.Context, id string, patch []PatchOperation) (*OAuth2ClientJSON, error) {
+	patched, err := m.patcher.PatchOAuth2Client(ctx, id, patch)
+	if err != nil {
+		return patched, err
+	}
+
+	// ORY Hydra does not return the secret, take it from the patch
+	mirrored := *patched
+	mirrored.ClientID = &id
+	for _, op := range patch {
+		if secret, ok := op.Value.(string); ok && op.Path == "/client_secret" {
+			mirrored.Secret = &secret
+		}
+	}
+	m.write(ctx, http.MethodPatch, &mirrored, patched)
+	return patched, nil
+}
@@ -0,0 +1,172 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+// scopeChanging stores clients with another scope than requested.
+type scopeChanging struct {
+	hydra.Client
+}
+
+func (c scopeChanging) PutOAuth2Client(ctx context.Context, o *hydra.OAuth2ClientJSON) (*hydra.OAuth2ClientJSON, error) {
+	changed := *o
+	changed.Scope = "changed"
+	return c.Client.PutOAuth2Client(ctx, &changed)
+}
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (primary, mirror *hydratest.Server, divergences *[]hydra.MirrorDivergence) {
+		primary, mirror = hydratest.NewServer(), hydratest.NewServer()
+		t.Cleanup(primary.Close)
+		t.Cleanup(mirror.Close)
+		divergences = &[]hydra.MirrorDivergence{}
+		return primary, mirror, divergences
+	}
+
+	t.Run("case=creates clients with the ID and secret of the primary", func(t *testing.T) {
+		primary, mirror, divergences := setup(t)
+		c := hydra.Mirror(primary.Client(), mirror.Client(), func(d hydra.MirrorDivergence) {
+			*divergences = append(*divergences, d)
+		})
+
+		created, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read", Owner: "test/default"})
+		require.NoError(t, err)
+
+		mirrored, found := mirror.GetClient(*created.ClientID)
+		require.True(t, found)
+		assert.Equal(t, created.Secret, mirrored.Secret)
+		assert.Equal(t, "read", mirrored.Scope)
+		assert.Empty(t, *divergences)
+	})
+
+	t.Run("case=updates clients existing in the mirror on creation", func(t *testing.T) {
+		primary, mirror, divergences := setup(t)
+		mirror.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret"), Scope: "stale"})
+		c := hydra.Mirror(primary.Client(), mirror.Client(), func(d hydra.MirrorDivergence) {
+			*divergences = append(*divergences, d)
+		})
+
+		_, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret"), Scope: "read"})
+		require.NoError(t, err)
+
+		mirrored, _ := mirror.GetClient("id")
+		assert.Equal(t, "read", mirrored.Scope)
+		assert.Empty(t, *divergences)
+	})
+
+	t.Run("case=creates clients missing in the mirror on update", func(t *testing.T) {
+		primary, mirror, divergences := setup(t)
+		primary.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret"), Scope: "read"})
+		c := hydra.Mirror(primary.Client(), mirror.Client(), func(d hydra.MirrorDivergence) {
+			*divergences = append(*divergences, d)
+		})
+
+		_, err := c.PutOAuth2Client(ctx, &hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret"), Scope: "write"})
+		require.NoError(t, err)
+
+		mirrored, found := mirror.GetClient("id")
+		require.True(t, found)
+		assert.Equal(t, "write", mirrored.Scope)
+		assert.Equal(t, "secret", *mirrored.Secret)
+		assert.Empty(t, *divergences)
+	})
+
+	t.Run("case=patches are put to the mirror with the patched secret", func(t *testing.T) {
+		primary, mirror, divergences := setup(t)
+		primary.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret"), Scope: "read"})
+		mirror.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret"), Scope: "read"})
+		c := hydra.Mirror(primary.Client(), mirror.Client(), func(d hydra.MirrorDivergence) {
+			*divergences = append(*divergences, d)
+		})
+
+		patcher, ok := c.(hydra.Patcher)
+		require.True(t, ok)
+		_, err := patcher.PatchOAuth2Client(ctx, "id", []hydra.PatchOperation{
+			{Op: "replace", Path: "/scope", Value: "write"},
+			{Op: "replace", Path: "/client_secret", Value: "rotated"},
+		})
+		require.NoError(t, err)
+
+		mirrored, _ := mirror.GetClient("id")
+		assert.Equal(t, "write", mirrored.Scope)
+		assert.Equal(t, "rotated", *mirrored.Secret)
+		assert.Empty(t, *divergences)
+	})
+
+	t.Run("case=deletes clients and ignores clients missing in the mirror", func(t *testing.T) {
+		primary, mirror, divergences := setup(t)
+		primary.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret")})
+		c := hydra.Mirror(primary.Client(), mirror.Client(), func(d hydra.MirrorDivergence) {
+			*divergences = append(*divergences, d)
+		})
+
+		require.NoError(t, c.DeleteOAuth2Client(ctx, "id"))
+		assert.Empty(t, primary.Clients())
+		assert.Empty(t, *divergences)
+	})
+
+	t.Run("case=failures of the mirror are reported but don't fail the write", func(t *testing.T) {
+		primary, mirror, divergences := setup(t)
+		mirror.FailNext(http.MethodPost, http.StatusInternalServerError, 1)
+		c := hydra.Mirror(primary.Client(), mirror.Client(), func(d hydra.MirrorDivergence) {
+			*divergences = append(*divergences, d)
+		})
+
+		created, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read"})
+		require.NoError(t, err)
+
+		require.Len(t, *divergences, 1)
+		assert.Equal(t, http.MethodPost, (*divergences)[0].Method)
+		assert.Equal(t, *created.ClientID, (*divergences)[0].ClientID)
+		assert.Error(t, (*divergences)[0].Err)
+		assert.Empty(t, mirror.Clients())
+	})
+
+	t.Run("case=failures of the primary are not mirrored", func(t *testing.T) {
+		primary, mirror, divergences := setup(t)
+		primary.FailNext(http.MethodPost, http.StatusInternalServerError, 1)
+		c := hydra.Mirror(primary.Client(), mirror.Client(), func(d hydra.MirrorDivergence) {
+			*divergences = append(*divergences, d)
+		})
+
+		_, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read"})
+		require.Error(t, err)
+		assert.Empty(t, mirror.Clients())
+		assert.Empty(t, *divergences)
+	})
+
+	t.Run("case=clients stored differently by the mirror are reported", func(t *testing.T) {
+		primary, mirror, divergences := setup(t)
+		primary.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret"), Scope: "read"})
+		mirror.AddClient(&hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Secret: ptr.To("secret"), Scope: "read"})
+		c := hydra.Mirror(primary.Client(), scopeChanging{mirror.Client()}, func(d hydra.MirrorDivergence) {
+			*divergences = append(*divergences, d)
+		})
+
+		_, err := c.PutOAuth2Client(ctx, &hydra.OAuth2ClientJSON{ClientID: ptr.To("id"), Scope: "write"})
+		require.NoError(t, err)
+
+		require.Len(t, *divergences, 1)
+		d := (*divergences)[0]
+		assert.Equal(t, http.MethodPut, d.Method)
+		assert.Equal(t, "id", d.ClientID)
+		assert.NoError(t, d.Err)
+		require.Len(t, d.Diffs, 1)
+		assert.Equal(t, "scope", d.Diffs[0].Field)
+	})
+}
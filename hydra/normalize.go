@@ -0,0 +1,55 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"sort"
+	"strings"
+)
+
+// Normalize sorts and deduplicates the fields of o whose order has no meaning
+// to ORY Hydra: the scopes, which stay space-separated, the audience, the
+// grant and response types, the redirect URIs, the post logout redirect URIs
+// and the allowed CORS origins. Reordering them in an OAuth2Client then
+// neither changes the client written to ORY Hydra nor shows up as a
+// difference. The lists are copied, so o may share them with an OAuth2Client.
+func Normalize(o *OAuth2ClientJSON) {
+	if o == nil {
+		return
+	}
+	o.Scope = strings.Join(normalizeList(strings.Fields(o.Scope)), " ")
+	o.Audience = normalizeList(o.Audience)
+	o.GrantTypes = normalizeList(o.GrantTypes)
+	o.ResponseTypes = normalizeList(o.ResponseTypes)
+	o.RedirectURIs = normalizeList(o.RedirectURIs)
+	o.PostLogoutRedirectURIs = normalizeList(o.PostLogoutRedirectURIs)
+	o.AllowedCorsOrigins = normalizeList(o.AllowedCorsOrigins)
+}
+
+// normalized returns a normalized copy of o.
+func normalized(o *OAuth2ClientJSON) *OAuth2ClientJSON {
+	if o == nil {
+		return nil
+	}
+	n := *o
+	Normalize(&n)
+	return &n
+}
+
+// normalizeList returns a sorted copy of list without duplicates. Empty lists
+// are returned as they are, so null and [] are kept apart in JSON.
+func normalizeList(list []string) []string {
+	if len(list) == 0 {
+		return list
+	}
+	sorted := append([]string(nil), list...)
+	sort.Strings(sorted)
+	unique := sorted[:1]
+	for _, s := range sorted[1:] {
+		if s != unique[len(unique)-1] {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,38 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/hydra-maester/hydra"
+)
+
+func TestNormalize(t *testing.T) {
+	audience := []string{"b", "a", "b"}
+	o := &hydra.OAuth2ClientJSON{
+		Scope:                  " write read  write ",
+		Audience:               audience,
+		GrantTypes:             []string{"refresh_token", "authorization_code"},
+		ResponseTypes:          []string{"code", "code"},
+		RedirectURIs:           []string{"https://b.example.com/callback", "https://a.example.com/callback"},
+		PostLogoutRedirectURIs: []string{},
+		AllowedCorsOrigins:     nil,
+	}
+
+	hydra.Normalize(o)
+
+	assert.Equal(t, "read write", o.Scope)
+	assert.Equal(t, []string{"a", "b"}, o.Audience)
+	assert.Equal(t, []string{"authorization_code", "refresh_token"}, o.GrantTypes)
+	assert.Equal(t, []string{"code"}, o.ResponseTypes)
+	assert.Equal(t, []string{"https://a.example.com/callback", "https://b.example.com/callback"}, o.RedirectURIs)
+	assert.Equal(t, []string{}, o.PostLogoutRedirectURIs)
+	assert.Nil(t, o.AllowedCorsOrigins)
+
+	// the lists are copied
+	assert.Equal(t, []string{"b", "a", "b"}, audience)
+}
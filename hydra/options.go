@@ -0,0 +1,94 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/ory/hydra-maester/hydra/admin"
+)
+
+// DefaultUserAgent is the User-Agent header of requests to ORY Hydra.
+const DefaultUserAgent = admin.DefaultUserAgent
+
+// UserAgent returns a User-Agent header identifying the controller version
+// and the cluster it runs in, e.g. "hydra-maester/v0.0.36 (cluster=east)",
+// so ORY Hydra operators can tell which controller sends the requests. Empty
+// values are left out.
+func UserAgent(version, cluster string) string {
+	var b strings.Builder
+	b.WriteString(DefaultUserAgent)
+	if version != "" {
+		b.WriteString("/" + version)
+	}
+	if cluster != "" {
+		b.WriteString(" (cluster=" + cluster + ")")
+	}
+	return b.String()
+}
+
+// Option customizes the client returned by New.
+type Option = admin.Option
+
+// RetryPolicy configures retries of idempotent requests which failed
+// transiently, see admin.RetryPolicy.
+type RetryPolicy = admin.RetryPolicy
+
+// FaultInjection makes a client delay and fail requests at random, see
+// admin.FaultInjection.
+type FaultInjection = admin.FaultInjection
+
+// RequestObserver is called after every attempt of a request, see
+// admin.RequestObserver.
+type RequestObserver = admin.RequestObserver
+
+// RequestTracer is called with the duration of each phase of an attempt of a
+// request, see admin.RequestTracer.
+type RequestTracer = admin.RequestTracer
+
+// WithHTTPClient sets the HTTP client used for requests. The TLS settings
+// passed to New are ignored.
+func WithHTTPClient(c *http.Client) Option {
+	return admin.WithHTTPClient(c)
+}
+
+// WithLogger sets the logger for requests, which are logged at V(1). The
+// default discards all logs.
+func WithLogger(log logr.Logger) Option {
+	return admin.WithLogger(log)
+}
+
+// WithUserAgent sets the User-Agent header of requests. The default is
+// DefaultUserAgent.
+func WithUserAgent(userAgent string) Option {
+	return admin.WithUserAgent(userAgent)
+}
+
+// WithRetryPolicy enables retries of failed requests. The default is no
+// retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return admin.WithRetryPolicy(policy)
+}
+
+// WithRequestObserver sets an observer which is called after every attempt of
+// a request, e.g. to export metrics.
+func WithRequestObserver(o RequestObserver) Option {
+	return admin.WithRequestObserver(o)
+}
+
+// WithRequestTracer sets a tracer which is called with the duration of the
+// DNS lookup, connection, TLS handshake and time to first byte of every
+// attempt of a request, e.g. to export latency histograms.
+func WithRequestTracer(t RequestTracer) Option {
+	return admin.WithRequestTracer(t)
+}
+
+// WithFaultInjection makes the client delay and fail requests at random. It
+// is meant for resilience tests only.
+func WithFaultInjection(f FaultInjection) Option {
+	return admin.WithFaultInjection(f)
+}
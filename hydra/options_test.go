@@ -0,0 +1,218 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc, opts ...hydra.Option) hydra.Client {
+	s := httptest.NewServer(h)
+	t.Cleanup(s.Close)
+
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	c, err := hydra.New(hydrav1alpha1.OAuth2ClientSpec{
+		HydraAdmin: hydrav1alpha1.HydraAdmin{
+			URL:      u.Scheme + "://" + u.Hostname(),
+			Port:     port,
+			Endpoint: "/admin/clients",
+		},
+	}, "", false, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestNew(t *testing.T) {
+	var path string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(s.Close)
+
+	for _, tc := range []struct {
+		name  string
+		admin hydrav1alpha1.HydraAdmin
+	}{
+		{"case=single URL", hydrav1alpha1.HydraAdmin{URL: s.URL + "/admin/clients"}},
+		{"case=separate fields", hydrav1alpha1.HydraAdmin{URL: "http://127.0.0.1", Port: s.Listener.Addr().(*net.TCPAddr).Port, Endpoint: "/admin/clients"}},
+		{"case=port of the URL wins", hydrav1alpha1.HydraAdmin{URL: s.URL + "/admin/clients", Port: 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path = ""
+			c, err := hydra.New(hydrav1alpha1.OAuth2ClientSpec{HydraAdmin: tc.admin}, "", false)
+			require.NoError(t, err)
+			_, err = c.ListOAuth2Client(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, "/admin/clients", path)
+		})
+	}
+
+	t.Run("case=request timeout", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			w.Write([]byte(`[]`))
+		}))
+		t.Cleanup(slow.Close)
+
+		shared := &http.Client{}
+		c, err := hydra.New(hydrav1alpha1.OAuth2ClientSpec{HydraAdmin: hydrav1alpha1.HydraAdmin{URL: slow.URL + "/admin/clients", RequestTimeout: "50ms"}}, "", false, hydra.WithHTTPClient(shared))
+		require.NoError(t, err)
+		_, err = c.ListOAuth2Client(context.Background())
+		assert.ErrorContains(t, err, "Client.Timeout exceeded")
+		assert.Zero(t, shared.Timeout)
+	})
+
+	t.Run("case=rejects invalid request timeouts", func(t *testing.T) {
+		_, err := hydra.New(hydrav1alpha1.OAuth2ClientSpec{HydraAdmin: hydrav1alpha1.HydraAdmin{URL: s.URL, RequestTimeout: "soon"}}, "", false)
+		assert.Error(t, err)
+	})
+
+	t.Run("case=rejects IPv6 addresses without brackets", func(t *testing.T) {
+		_, err := hydra.New(hydrav1alpha1.OAuth2ClientSpec{HydraAdmin: hydrav1alpha1.HydraAdmin{URL: "http://fd00::1:4445"}}, "", false)
+		assert.Error(t, err)
+	})
+}
+
+func TestUserAgent(t *testing.T) {
+	for _, tc := range []struct {
+		version, cluster, expected string
+	}{
+		{"", "", "hydra-maester"},
+		{"v0.0.36", "", "hydra-maester/v0.0.36"},
+		{"v0.0.36", "east", "hydra-maester/v0.0.36 (cluster=east)"},
+		{"", "east", "hydra-maester (cluster=east)"},
+	} {
+		t.Run("case="+tc.expected, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hydra.UserAgent(tc.version, tc.cluster))
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Run("option=user agent", func(t *testing.T) {
+		var userAgent string
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.UserAgent()
+			w.Write([]byte(`[]`))
+		}, hydra.WithUserAgent("my-agent"))
+
+		_, err := c.ListOAuth2Client(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "my-agent", userAgent)
+	})
+
+	t.Run("option=default user agent", func(t *testing.T) {
+		var userAgent string
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.UserAgent()
+			w.Write([]byte(`[]`))
+		})
+
+		_, err := c.ListOAuth2Client(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, hydra.DefaultUserAgent, userAgent)
+	})
+
+	t.Run("option=request tracer", func(t *testing.T) {
+		phases := map[string]int{}
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[]`))
+		}, hydra.WithRequestTracer(func(endpoint, phase string, d time.Duration) {
+			assert.Equal(t, "/admin/clients", endpoint)
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+			phases[phase]++
+		}))
+
+		_, err := c.ListOAuth2Client(context.Background())
+		require.NoError(t, err)
+		_, err = c.ListOAuth2Client(context.Background())
+		require.NoError(t, err)
+		// the second request reuses the connection
+		assert.Equal(t, map[string]int{"connect": 1, "first_byte": 2}, phases)
+	})
+
+	t.Run("option=http client", func(t *testing.T) {
+		var used bool
+		hc := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			used = true
+			return http.DefaultTransport.RoundTrip(r)
+		})}
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[]`))
+		}, hydra.WithHTTPClient(hc))
+
+		_, err := c.ListOAuth2Client(context.Background())
+		require.NoError(t, err)
+		assert.True(t, used)
+	})
+
+	t.Run("option=retry policy", func(t *testing.T) {
+		policy := hydra.WithRetryPolicy(hydra.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+		for d, tc := range map[string]struct {
+			method   string
+			failures int
+			attempts int
+			success  bool
+		}{
+			"retries transient failures":  {http.MethodGet, 2, 3, true},
+			"gives up after max attempts": {http.MethodGet, 5, 3, false},
+			"never retries creations":     {http.MethodPost, 1, 1, false},
+		} {
+			t.Run("case="+d, func(t *testing.T) {
+				attempts := 0
+				c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, tc.method, r.Method)
+					attempts++
+					if attempts <= tc.failures {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+					if r.Method == http.MethodPost {
+						w.WriteHeader(http.StatusCreated)
+						w.Write([]byte(`{}`))
+						return
+					}
+					w.Write([]byte(`[]`))
+				}, policy)
+
+				var err error
+				if tc.method == http.MethodPost {
+					_, err = c.PostOAuth2Client(context.Background(), &hydra.OAuth2ClientJSON{})
+				} else {
+					_, err = c.ListOAuth2Client(context.Background())
+				}
+				assert.Equal(t, tc.success, err == nil, "error: %v", err)
+				assert.Equal(t, tc.attempts, attempts)
+			})
+		}
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
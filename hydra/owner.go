@@ -0,0 +1,61 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"strings"
+
+	"github.com/ory/hydra-maester/helpers"
+)
+
+// MaxOwnerLength is the longest owner ORY Hydra stores.
+const MaxOwnerLength = 255
+
+// minOwnerNameLength is the shortest the name in an owner is shortened to.
+const minOwnerNameLength = 16
+
+// Owner returns the owner of the client of the OAuth2Client name/namespace,
+// i.e. name/namespace, followed by /cluster and @controller if they are set.
+// If the owner would exceed MaxOwnerLength, the name is shortened with
+// helpers.ShortenName, so ParseOwner returns the shortened name for it.
+func Owner(name, namespace, cluster, controller string) string {
+	suffix := "/" + namespace
+	if cluster != "" {
+		suffix += "/" + cluster
+	}
+	if controller != "" {
+		suffix += "@" + controller
+	}
+	return helpers.ShortenName(name, max(MaxOwnerLength-len(suffix), minOwnerNameLength)) + suffix
+}
+
+// ParseOwner splits an owner written by the controller into the name and the
+// namespace of the OAuth2Client, and the name of its cluster for owners
+// written in multi-cluster mode. ok is false for clients which are not owned
+// by the controller. The controller identity is ignored, see
+// SplitOwnerController.
+func ParseOwner(owner string) (name, namespace, cluster string, ok bool) {
+	owner, _ = SplitOwnerController(owner)
+	parts := strings.Split(owner, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", false
+	}
+	if len(parts) == 3 {
+		if parts[2] == "" {
+			return "", "", "", false
+		}
+		cluster = parts[2]
+	}
+	return parts[0], parts[1], cluster, true
+}
+
+// SplitOwnerController splits the identity of the controller off an owner
+// written by a controller started with one, i.e. name/namespace@controller or
+// name/namespace/cluster@controller. controller is empty for other owners.
+func SplitOwnerController(owner string) (base, controller string) {
+	if i := strings.LastIndex(owner, "@"); i >= 0 {
+		return owner[:i], owner[i+1:]
+	}
+	return owner, ""
+}
@@ -0,0 +1,61 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/hydra-maester/hydra"
+)
+
+func TestParseOwner(t *testing.T) {
+	for owner, expected := range map[string][4]interface{}{
+		"app/default":              {"app", "default", "", true},
+		"app/default/cluster":      {"app", "default", "cluster", true},
+		"":                         {"", "", "", false},
+		"someone":                  {"", "", "", false},
+		"app/":                     {"", "", "", false},
+		"app/default/":             {"", "", "", false},
+		"a/b/c/d":                  {"", "", "", false},
+		"app/default@ctrl":         {"app", "default", "", true},
+		"app/default/cluster@ctrl": {"app", "default", "cluster", true},
+		"@ctrl":                    {"", "", "", false},
+	} {
+		name, namespace, cluster, ok := hydra.ParseOwner(owner)
+		assert.Equal(t, expected, [4]interface{}{name, namespace, cluster, ok}, "owner %q", owner)
+	}
+}
+
+func TestSplitOwnerController(t *testing.T) {
+	for owner, expected := range map[string][2]string{
+		"app/default":              {"app/default", ""},
+		"app/default@ctrl":         {"app/default", "ctrl"},
+		"app/default/cluster@ctrl": {"app/default/cluster", "ctrl"},
+	} {
+		base, controller := hydra.SplitOwnerController(owner)
+		assert.Equal(t, expected, [2]string{base, controller}, "owner %q", owner)
+	}
+}
+
+func TestOwner(t *testing.T) {
+	assert.Equal(t, "app/default", hydra.Owner("app", "default", "", ""))
+	assert.Equal(t, "app/default/cluster@ctrl", hydra.Owner("app", "default", "cluster", "ctrl"))
+
+	t.Run("case=shortens long names", func(t *testing.T) {
+		name := strings.Repeat("a", 253)
+		owner := hydra.Owner(name, "default", "cluster", "ctrl")
+		assert.Len(t, owner, hydra.MaxOwnerLength)
+		assert.Equal(t, owner, hydra.Owner(name, "default", "cluster", "ctrl"))
+		assert.NotEqual(t, owner, hydra.Owner(name[1:], "default", "cluster", "ctrl"))
+
+		short, namespace, cluster, ok := hydra.ParseOwner(owner)
+		assert.True(t, ok)
+		assert.Equal(t, name[:len(short)-11], short[:len(short)-11])
+		assert.Equal(t, "default", namespace)
+		assert.Equal(t, "cluster", cluster)
+	})
+}
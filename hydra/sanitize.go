@@ -0,0 +1,45 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// MaxErrorMessageLength is the maximum length in bytes of messages returned by
+// Sanitize.
+const MaxErrorMessageLength = 1024
+
+const redacted = "[REDACTED]"
+
+var (
+	sensitiveFields  = `(client_secret|secret|registration_access_token|access_token|refresh_token|id_token|password)`
+	sensitiveJSON    = regexp.MustCompile(`"` + sensitiveFields + `"\s*:\s*"(?:[^"\\]|\\.)*"`)
+	sensitiveForm    = regexp.MustCompile(`\b` + sensitiveFields + `=[^&\s"]+`)
+	sensitiveAuth    = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	sensitiveURLUser = regexp.MustCompile(`://[^/@\s]+@`)
+)
+
+// Sanitize prepares an error message for places every reader of an
+// OAuth2Client can see, like its status. ORY Hydra sometimes echoes request
+// payloads in errors, so client secrets, tokens and credentials in URLs are
+// redacted, and the message is truncated to MaxErrorMessageLength.
+func Sanitize(msg string) string {
+	msg = sensitiveJSON.ReplaceAllString(msg, `"$1":"`+redacted+`"`)
+	msg = sensitiveForm.ReplaceAllString(msg, `$1=`+redacted)
+	msg = sensitiveAuth.ReplaceAllString(msg, `$1 `+redacted)
+	msg = sensitiveURLUser.ReplaceAllString(msg, `://`+redacted+`@`)
+
+	if len(msg) <= MaxErrorMessageLength {
+		return msg
+	}
+
+	const suffix = "... (truncated)"
+	cut := MaxErrorMessageLength - len(suffix)
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut] + suffix
+}
@@ -0,0 +1,209 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultThrottleLatency is the latency above which a Client returned by
+// Throttle considers its ORY Hydra instance overloaded.
+const DefaultThrottleLatency = time.Second
+
+// ThrottleConfig configures the concurrency limit of a Client returned by
+// Throttle.
+type ThrottleConfig struct {
+	// MinLimit is the lowest number of concurrent requests, and the limit
+	// the Client starts with. Values below 1 are raised to 1.
+	MinLimit int
+	// MaxLimit is the highest number of concurrent requests. Values below
+	// MinLimit are raised to MinLimit.
+	MaxLimit int
+	// Latency is the latency above which a request counts as a sign of
+	// overload, DefaultThrottleLatency if zero.
+	Latency time.Duration
+	// OnLimit, if set, is called with the limit whenever it changes, e.g. to
+	// export it as a metric.
+	OnLimit func(limit int)
+}
+
+// Throttle returns a Client which sends at most as many concurrent requests
+// to c as its limit allows. Further requests wait until one finishes or
+// their context is done. The limit adapts to the latency and the errors of
+// the requests: it starts at MinLimit and is doubled with every round of
+// requests answered in time, until the first request is slower than Latency
+// or fails transiently, see IsRetryable. That halves the limit, at most once
+// per round, and from then on it only grows by about one per round, so an
+// overloaded instance is relieved quickly and probed carefully while it
+// recovers. The returned Client implements Patcher if c does.
+func Throttle(c Client, config ThrottleConfig) Client {
+	config.MinLimit = max(config.MinLimit, 1)
+	config.MaxLimit = max(config.MaxLimit, config.MinLimit)
+	if config.Latency == 0 {
+		config.Latency = DefaultThrottleLatency
+	}
+	t := &throttledClient{
+		Client:    c,
+		config:    config,
+		limit:     float64(config.MinLimit),
+		slowStart: true,
+		wake:      make(chan struct{}),
+	}
+	if config.OnLimit != nil {
+		config.OnLimit(config.MinLimit)
+	}
+	if patcher, ok := c.(Patcher); ok {
+		return &throttledPatcher{throttledClient: t, patcher: patcher}
+	}
+	return t
+}
+
+type throttledClient struct {
+	Client
+	config ThrottleConfig
+
+	mu        sync.Mutex
+	limit     float64
+	inFlight  int
+	slowStart bool
+	// decreased is when the limit was last halved. Requests started
+	// before don't halve it again.
+	decreased time.Time
+	// wake is closed and replaced when a request finishes or the limit
+	// changes, to let the waiting requests try again.
+	wake chan struct{}
+}
+
+// acquire waits until a request may be sent or ctx is done.
+func (t *throttledClient) acquire(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		if t.inFlight < int(t.limit) {
+			t.inFlight++
+			t.mu.Unlock()
+			return nil
+		}
+		wake := t.wake
+		t.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release adapts the limit to the outcome of a request started at start,
+// which failed with err, if at all.
+func (t *throttledClient) release(start time.Time, err error) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	saturated := t.inFlight >= int(t.limit)
+	t.inFlight--
+	previous := int(t.limit)
+	switch {
+	case errors.Is(err, context.Canceled):
+		// the caller gave up, which says nothing about the instance
+	case now.Sub(start) > t.config.Latency || (err != nil && IsRetryable(err)):
+		if start.After(t.decreased) {
+			t.limit = math.Max(t.limit/2, float64(t.config.MinLimit))
+			t.decreased = now
+			t.slowStart = false
+		}
+	case saturated && t.slowStart:
+		t.limit = math.Min(t.limit+1, float64(t.config.MaxLimit))
+	case saturated:
+		t.limit = math.Min(t.limit+1/t.limit, float64(t.config.MaxLimit))
+	}
+	close(t.wake)
+	t.wake = make(chan struct{})
+	if limit := int(t.limit); limit != previous && t.config.OnLimit != nil {
+		t.config.OnLimit(limit)
+	}
+}
+
+// do calls f once a request may be sent.
+func (t *throttledClient) do(ctx context.Context, f func() error) error {
+	if err := t.acquire(ctx); err != nil {
+		return err
+	}
+	start := time.Now()
+	err := f()
+	t.release(start, err)
+	return err
+}
+
+func (t *throttledClient) GetOAuth2Client(ctx context.Context, id string) (c *OAuth2ClientJSON, found bool, err error) {
+	err = t.do(ctx, func() error {
+		c, found, err = t.Client.GetOAuth2Client(ctx, id)
+		return err
+	})
+	return c, found, err
+}
+
+func (t *throttledClient) ListOAuth2Client(ctx context.Context) (list []*OAuth2ClientJSON, err error) {
+	err = t.do(ctx, func() error {
+		list, err = t.Client.ListOAuth2Client(ctx)
+		return err
+	})
+	return list, err
+}
+
+func (t *throttledClient) PostOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (c *OAuth2ClientJSON, err error) {
+	err = t.do(ctx, func() error {
+		c, err = t.Client.PostOAuth2Client(ctx, o)
+		return err
+	})
+	return c, err
+}
+
+func (t *throttledClient) PutOAuth2Client(ctx context.Context, o *OAuth2ClientJSON) (c *OAuth2ClientJSON, err error) {
+	err = t.do(ctx, func() error {
+		c, err = t.Client.PutOAuth2Client(ctx, o)
+		return err
+	})
+	return c, err
+}
+
+func (t *throttledClient) DeleteOAuth2Client(ctx context.Context, id string) error {
+	return t.do(ctx, func() error {
+		return t.Client.DeleteOAuth2Client(ctx, id)
+	})
+}
+
+func (t *throttledClient) GetVersion(ctx context.Context) (version string, err error) {
+	err = t.do(ctx, func() error {
+		version, err = t.Client.GetVersion(ctx)
+		return err
+	})
+	return version, err
+}
+
+func (t *throttledClient) IsReady(ctx context.Context) (ready bool, err error) {
+	err = t.do(ctx, func() error {
+		ready, err = t.Client.IsReady(ctx)
+		return err
+	})
+	return ready, err
+}
+
+type throttledPatcher struct {
+	*throttledClient
+	patcher Patcher
+}
+
+func (t *throttledPatcher) PatchOAuth2Client(ctx context.Context, id string, patch []PatchOperation) (c *OAuth2ClientJSON, err error) {
+	err = t.do(ctx, func() error {
+		c, err = t.patcher.PatchOAuth2Client(ctx, id, patch)
+		return err
+	})
+	return c, err
+}
@@ -0,0 +1,240 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/hydra/hydratest"
+)
+
+// versionClient answers GetVersion after delay with err, and records the
+// highest number of concurrent requests.
+type versionClient struct {
+	hydra.Client
+	delay time.Duration
+	err   error
+	block chan struct{}
+
+	mu                sync.Mutex
+	inFlight, maxSeen int
+}
+
+func (c *versionClient) GetVersion(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.maxSeen = max(c.maxSeen, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	if c.block != nil {
+		<-c.block
+	}
+	time.Sleep(c.delay)
+	return "v2.2.0", c.err
+}
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+
+	// limits records the limits reported by a throttled client.
+	type limits struct {
+		mu     sync.Mutex
+		values []int
+	}
+	record := func(l *limits) func(int) {
+		return func(limit int) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.values = append(l.values, limit)
+		}
+	}
+	last := func(l *limits) int {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.values[len(l.values)-1]
+	}
+
+	// saturate sends n concurrent requests through c to fake, which are
+	// answered once all of them are in flight.
+	saturate := func(t *testing.T, c hydra.Client, fake *versionClient, n int) {
+		fake.block = make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = c.GetVersion(ctx)
+			}()
+		}
+		require.Eventually(t, func() bool {
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			return fake.inFlight == n
+		}, time.Second, time.Millisecond)
+		close(fake.block)
+		wg.Wait()
+		fake.block = nil
+	}
+
+	t.Run("case=limits concurrent requests", func(t *testing.T) {
+		fake := &versionClient{block: make(chan struct{})}
+		c := hydra.Throttle(fake, hydra.ThrottleConfig{MinLimit: 2, MaxLimit: 2})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.GetVersion(ctx)
+				assert.NoError(t, err)
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(fake.block)
+		wg.Wait()
+		assert.Equal(t, 2, fake.maxSeen)
+	})
+
+	t.Run("case=grows the limit only under load", func(t *testing.T) {
+		var l limits
+		fake := &versionClient{}
+		c := hydra.Throttle(fake, hydra.ThrottleConfig{MinLimit: 1, MaxLimit: 4, OnLimit: record(&l)})
+
+		for i := 0; i < 10; i++ {
+			_, err := c.GetVersion(ctx)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []int{1, 2}, l.values)
+
+		for i := 0; i < 4; i++ {
+			saturate(t, c, fake, last(&l))
+		}
+		assert.Equal(t, []int{1, 2, 3, 4}, l.values)
+	})
+
+	t.Run("case=halves the limit on slow and failed requests", func(t *testing.T) {
+		var l limits
+		fake := &versionClient{}
+		c := hydra.Throttle(fake, hydra.ThrottleConfig{MinLimit: 1, MaxLimit: 8, Latency: 100 * time.Millisecond, OnLimit: record(&l)})
+		for last(&l) < 8 {
+			saturate(t, c, fake, last(&l))
+		}
+
+		fake.delay = 150 * time.Millisecond
+		_, err := c.GetVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, last(&l))
+
+		fake.delay, fake.err = 0, &hydra.RequestError{StatusCode: http.StatusServiceUnavailable}
+		_, err = c.GetVersion(ctx)
+		require.Error(t, err)
+		assert.Equal(t, 2, last(&l))
+
+		fake.err = &hydra.RequestError{StatusCode: http.StatusBadRequest}
+		_, err = c.GetVersion(ctx)
+		require.Error(t, err)
+		assert.Equal(t, 2, last(&l), "terminal errors are no sign of overload")
+
+		fake.err = context.DeadlineExceeded
+		for i := 0; i < 3; i++ {
+			_, _ = c.GetVersion(ctx)
+		}
+		assert.Equal(t, 1, last(&l), "the limit does not drop below MinLimit")
+	})
+
+	t.Run("case=halves the limit once per round", func(t *testing.T) {
+		var l limits
+		fake := &versionClient{}
+		c := hydra.Throttle(fake, hydra.ThrottleConfig{MinLimit: 1, MaxLimit: 8, OnLimit: record(&l)})
+		for last(&l) < 8 {
+			saturate(t, c, fake, last(&l))
+		}
+
+		fake.err = &hydra.RequestError{StatusCode: http.StatusServiceUnavailable}
+		saturate(t, c, fake, 8)
+		assert.Equal(t, 4, last(&l))
+	})
+
+	t.Run("case=grows the limit slowly after a decrease", func(t *testing.T) {
+		var l limits
+		fake := &versionClient{}
+		c := hydra.Throttle(fake, hydra.ThrottleConfig{MinLimit: 1, MaxLimit: 8, OnLimit: record(&l)})
+		for last(&l) < 4 {
+			saturate(t, c, fake, last(&l))
+		}
+
+		fake.err = context.DeadlineExceeded
+		_, _ = c.GetVersion(ctx)
+		require.Equal(t, 2, last(&l))
+
+		fake.err = nil
+		saturate(t, c, fake, 2)
+		assert.Equal(t, 2, last(&l), "the limit is not doubled anymore")
+		for last(&l) < 4 {
+			saturate(t, c, fake, last(&l))
+		}
+		assert.Equal(t, []int{1, 2, 3, 4, 2, 3, 4}, l.values)
+	})
+
+	t.Run("case=gives up waiting when the context is done", func(t *testing.T) {
+		fake := &versionClient{block: make(chan struct{})}
+		c := hydra.Throttle(fake, hydra.ThrottleConfig{MaxLimit: 1})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = c.GetVersion(ctx)
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := c.GetVersion(timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		close(fake.block)
+		<-done
+	})
+
+	t.Run("case=supports patches if the client does", func(t *testing.T) {
+		server := hydratest.NewServer()
+		t.Cleanup(server.Close)
+
+		c := hydra.Throttle(server.Client(), hydra.ThrottleConfig{MaxLimit: 4})
+		patcher, ok := c.(hydra.Patcher)
+		require.True(t, ok)
+
+		created, err := c.PostOAuth2Client(ctx, &hydra.OAuth2ClientJSON{Scope: "read"})
+		require.NoError(t, err)
+		patched, err := patcher.PatchOAuth2Client(ctx, *created.ClientID, []hydra.PatchOperation{{Op: "replace", Path: "/scope", Value: "write"}})
+		require.NoError(t, err)
+		assert.Equal(t, "write", patched.Scope)
+
+		_, ok = hydra.Throttle(&versionClient{}, hydra.ThrottleConfig{}).(hydra.Patcher)
+		assert.False(t, ok)
+	})
+
+	t.Run("case=looks through to the active endpoint", func(t *testing.T) {
+		failover := hydra.Failover(
+			hydra.Endpoint{Address: "primary", Client: &versionClient{}},
+			hydra.Endpoint{Address: "fallback", Client: &versionClient{}},
+			time.Hour)
+		endpoint, ok := hydra.ActiveEndpoint(hydra.Throttle(failover, hydra.ThrottleConfig{}))
+		assert.True(t, ok)
+		assert.Equal(t, "primary", endpoint)
+	})
+}
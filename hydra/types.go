@@ -4,90 +4,55 @@
 package hydra
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
 	"k8s.io/utils/ptr"
 
 	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/hydra/admin"
 )
 
 // OAuth2ClientJSON represents an OAuth2 client digestible by ORY Hydra
-type OAuth2ClientJSON struct {
-	ClientName                                 string          `json:"client_name,omitempty"`
-	ClientID                                   *string         `json:"client_id,omitempty"`
-	Secret                                     *string         `json:"client_secret,omitempty"`
-	GrantTypes                                 []string        `json:"grant_types"`
-	RedirectURIs                               []string        `json:"redirect_uris,omitempty"`
-	PostLogoutRedirectURIs                     []string        `json:"post_logout_redirect_uris,omitempty"`
-	AllowedCorsOrigins                         []string        `json:"allowed_cors_origins,omitempty"`
-	ResponseTypes                              []string        `json:"response_types,omitempty"`
-	Audience                                   []string        `json:"audience,omitempty"`
-	Scope                                      string          `json:"scope"`
-	SkipConsent                                bool            `json:"skip_consent,omitempty"`
-	Owner                                      string          `json:"owner"`
-	TokenEndpointAuthMethod                    string          `json:"token_endpoint_auth_method,omitempty"`
-	Metadata                                   json.RawMessage `json:"metadata,omitempty"`
-	JwksUri                                    string          `json:"jwks_uri,omitempty"`
-	FrontChannelLogoutSessionRequired          bool            `json:"frontchannel_logout_session_required"`
-	FrontChannelLogoutURI                      string          `json:"frontchannel_logout_uri"`
-	BackChannelLogoutSessionRequired           bool            `json:"backchannel_logout_session_required"`
-	BackChannelLogoutURI                       string          `json:"backchannel_logout_uri"`
-	AuthorizationCodeGrantAccessTokenLifespan  string          `json:"authorization_code_grant_access_token_lifespan,omitempty"`
-	AuthorizationCodeGrantIdTokenLifespan      string          `json:"authorization_code_grant_id_token_lifespan,omitempty"`
-	AuthorizationCodeGrantRefreshTokenLifespan string          `json:"authorization_code_grant_refresh_token_lifespan,omitempty"`
-	ClientCredentialsGrantAccessTokenLifespan  string          `json:"client_credentials_grant_access_token_lifespan,omitempty"`
-	ImplicitGrantAccessTokenLifespan           string          `json:"implicit_grant_access_token_lifespan,omitempty"`
-	ImplicitGrantIdTokenLifespan               string          `json:"implicit_grant_id_token_lifespan,omitempty"`
-	JwtBearerGrantAccessTokenLifespan          string          `json:"jwt_bearer_grant_access_token_lifespan,omitempty"`
-	RefreshTokenGrantAccessTokenLifespan       string          `json:"refresh_token_grant_access_token_lifespan,omitempty"`
-	RefreshTokenGrantIdTokenLifespan           string          `json:"refresh_token_grant_id_token_lifespan,omitempty"`
-	RefreshTokenGrantRefreshTokenLifespan      string          `json:"refresh_token_grant_refresh_token_lifespan,omitempty"`
-}
+type OAuth2ClientJSON = admin.OAuth2ClientJSON
 
 // Oauth2ClientCredentials represents client ID and password fetched from a
 // Kubernetes secret
-type Oauth2ClientCredentials struct {
-	ID       []byte
-	Password []byte
-}
+type Oauth2ClientCredentials = admin.Oauth2ClientCredentials
 
-func (oj *OAuth2ClientJSON) WithCredentials(credentials *Oauth2ClientCredentials) *OAuth2ClientJSON {
-	oj.ClientID = ptr.To(string(credentials.ID))
-	if credentials.Password != nil {
-		oj.Secret = ptr.To(string(credentials.Password))
-	}
-	return oj
-}
+// ErrScopeAndScopeArray is returned for OAuth2Clients which set both the
+// deprecated `scope` and `scopeArray`.
+var ErrScopeAndScopeArray = errors.New("`scope` and `scopeArray` must not both be set, use `scopeArray`")
 
-// FromOAuth2Client converts an OAuth2Client into a OAuth2ClientJSON object that represents an OAuth2 InternalClient digestible by ORY Hydra
+// FromOAuth2Client converts an OAuth2Client into a OAuth2ClientJSON object that represents an OAuth2 InternalClient digestible by ORY Hydra.
+// The result is normalized, see Normalize.
 func FromOAuth2Client(c *hydrav1alpha1.OAuth2Client) (*OAuth2ClientJSON, error) {
-	meta, err := json.Marshal(c.Spec.Metadata)
+	meta, err := EncodeMetadata(c.Spec.Metadata)
 	if err != nil {
 		return nil, fmt.Errorf("unable to encode `metadata` property value to json: %w", err)
 	}
 
-	if c.Spec.Scope != "" {
-		fmt.Println("Property `scope` in client '" + c.Name + "' is deprecated. Rather use scopeArray.")
+	if c.Spec.Scope != "" && len(c.Spec.ScopeArray) > 0 {
+		return nil, ErrScopeAndScopeArray
 	}
 
 	var scope = c.Spec.Scope
 	if c.Spec.ScopeArray != nil {
-		scope = strings.Trim(strings.Join(c.Spec.ScopeArray, " ")+" "+scope, " ")
+		scope = strings.Join(c.Spec.ScopeArray, " ")
 	}
 
-	return &OAuth2ClientJSON{
+	oj := &OAuth2ClientJSON{
 		ClientName:                        c.Spec.ClientName,
 		GrantTypes:                        grantToStringSlice(c.Spec.GrantTypes),
 		ResponseTypes:                     responseToStringSlice(c.Spec.ResponseTypes),
 		RedirectURIs:                      redirectToStringSlice(c.Spec.RedirectURIs),
 		PostLogoutRedirectURIs:            redirectToStringSlice(c.Spec.PostLogoutRedirectURIs),
-		AllowedCorsOrigins:                redirectToStringSlice(c.Spec.AllowedCorsOrigins),
+		AllowedCorsOrigins:                corsToStringSlice(c.Spec.AllowedCorsOrigins),
 		Audience:                          c.Spec.Audience,
 		Scope:                             scope,
 		SkipConsent:                       c.Spec.SkipConsent,
-		Owner:                             fmt.Sprintf("%s/%s", c.Name, c.Namespace),
+		Owner:                             Owner(c.Name, c.Namespace, "", ""),
 		TokenEndpointAuthMethod:           string(c.Spec.TokenEndpointAuthMethod),
 		Metadata:                          meta,
 		FrontChannelLogoutURI:             c.Spec.BackChannelLogoutURI,
@@ -104,7 +69,60 @@ func FromOAuth2Client(c *hydrav1alpha1.OAuth2Client) (*OAuth2ClientJSON, error)
 		RefreshTokenGrantAccessTokenLifespan:       c.Spec.TokenLifespans.RefreshTokenGrantAccessTokenLifespan,
 		RefreshTokenGrantIdTokenLifespan:           c.Spec.TokenLifespans.RefreshTokenGrantIdTokenLifespan,
 		RefreshTokenGrantRefreshTokenLifespan:      c.Spec.TokenLifespans.RefreshTokenGrantRefreshTokenLifespan,
-	}, nil
+	}
+	Normalize(oj)
+	return oj, nil
+}
+
+// ToOAuth2Client converts an OAuth2ClientJSON returned by ORY Hydra into an
+// OAuth2Client. The name and the namespace are taken from the owner if it was
+// written by the controller. The scope is split into ScopeArray, and the
+// SecretName and HydraAdmin are left for the caller to set.
+func ToOAuth2Client(oj *OAuth2ClientJSON) (*hydrav1alpha1.OAuth2Client, error) {
+	c := &hydrav1alpha1.OAuth2Client{
+		Spec: hydrav1alpha1.OAuth2ClientSpec{
+			ClientName:                        oj.ClientName,
+			GrantTypes:                        stringToGrantSlice(oj.GrantTypes),
+			ResponseTypes:                     stringToResponseSlice(oj.ResponseTypes),
+			RedirectURIs:                      stringToRedirectSlice(oj.RedirectURIs),
+			PostLogoutRedirectURIs:            stringToRedirectSlice(oj.PostLogoutRedirectURIs),
+			AllowedCorsOrigins:                stringToCorsSlice(oj.AllowedCorsOrigins),
+			Audience:                          oj.Audience,
+			ScopeArray:                        strings.Fields(oj.Scope),
+			SkipConsent:                       oj.SkipConsent,
+			TokenEndpointAuthMethod:           hydrav1alpha1.TokenEndpointAuthMethod(oj.TokenEndpointAuthMethod),
+			JwksUri:                           oj.JwksUri,
+			FrontChannelLogoutSessionRequired: oj.FrontChannelLogoutSessionRequired,
+			FrontChannelLogoutURI:             oj.FrontChannelLogoutURI,
+			BackChannelLogoutSessionRequired:  oj.BackChannelLogoutSessionRequired,
+			BackChannelLogoutURI:              oj.BackChannelLogoutURI,
+			TokenLifespans: hydrav1alpha1.TokenLifespans{
+				AuthorizationCodeGrantAccessTokenLifespan:  oj.AuthorizationCodeGrantAccessTokenLifespan,
+				AuthorizationCodeGrantIdTokenLifespan:      oj.AuthorizationCodeGrantIdTokenLifespan,
+				AuthorizationCodeGrantRefreshTokenLifespan: oj.AuthorizationCodeGrantRefreshTokenLifespan,
+				ClientCredentialsGrantAccessTokenLifespan:  oj.ClientCredentialsGrantAccessTokenLifespan,
+				ImplicitGrantAccessTokenLifespan:           oj.ImplicitGrantAccessTokenLifespan,
+				ImplicitGrantIdTokenLifespan:               oj.ImplicitGrantIdTokenLifespan,
+				JwtBearerGrantAccessTokenLifespan:          oj.JwtBearerGrantAccessTokenLifespan,
+				RefreshTokenGrantAccessTokenLifespan:       oj.RefreshTokenGrantAccessTokenLifespan,
+				RefreshTokenGrantIdTokenLifespan:           oj.RefreshTokenGrantIdTokenLifespan,
+				RefreshTokenGrantRefreshTokenLifespan:      oj.RefreshTokenGrantRefreshTokenLifespan,
+			},
+		},
+	}
+
+	metadata, err := DecodeMetadata(oj.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode `metadata` property value of client %q: %w", ptr.Deref(oj.ClientID, ""), err)
+	}
+	c.Spec.Metadata = metadata
+
+	if name, namespace, _, ok := ParseOwner(oj.Owner); ok {
+		c.Name = name
+		c.Namespace = namespace
+	}
+
+	return c, nil
 }
 
 func responseToStringSlice(rt []hydrav1alpha1.ResponseType) []string {
@@ -130,3 +148,55 @@ func redirectToStringSlice(ru []hydrav1alpha1.RedirectURI) []string {
 	}
 	return output
 }
+
+func corsToStringSlice(co []hydrav1alpha1.CorsOrigin) []string {
+	var output = make([]string, len(co))
+	for i, elem := range co {
+		output[i] = string(elem)
+	}
+	return output
+}
+
+func stringToResponseSlice(s []string) []hydrav1alpha1.ResponseType {
+	if s == nil {
+		return nil
+	}
+	var output = make([]hydrav1alpha1.ResponseType, len(s))
+	for i, elem := range s {
+		output[i] = hydrav1alpha1.ResponseType(elem)
+	}
+	return output
+}
+
+func stringToGrantSlice(s []string) []hydrav1alpha1.GrantType {
+	if s == nil {
+		return nil
+	}
+	var output = make([]hydrav1alpha1.GrantType, len(s))
+	for i, elem := range s {
+		output[i] = hydrav1alpha1.GrantType(elem)
+	}
+	return output
+}
+
+func stringToRedirectSlice(s []string) []hydrav1alpha1.RedirectURI {
+	if s == nil {
+		return nil
+	}
+	var output = make([]hydrav1alpha1.RedirectURI, len(s))
+	for i, elem := range s {
+		output[i] = hydrav1alpha1.RedirectURI(elem)
+	}
+	return output
+}
+
+func stringToCorsSlice(s []string) []hydrav1alpha1.CorsOrigin {
+	if s == nil {
+		return nil
+	}
+	var output = make([]hydrav1alpha1.CorsOrigin, len(s))
+	for i, elem := range s {
+		output[i] = hydrav1alpha1.CorsOrigin(elem)
+	}
+	return output
+}
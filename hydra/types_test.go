@@ -4,11 +4,14 @@
 package hydra_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
 	"github.com/ory/hydra-maester/hydra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
 
 func TestTypes(t *testing.T) {
@@ -35,11 +38,82 @@ func TestTypes(t *testing.T) {
 			},
 		}
 
-		var parsedClient, err = hydra.FromOAuth2Client(&c)
-		if err != nil {
-			assert.Fail(t, "unexpected error: %s", err)
+		_, err := hydra.FromOAuth2Client(&c)
+		assert.ErrorIs(t, err, hydra.ErrScopeAndScopeArray)
+	})
+
+	t.Run("Test converting back to an OAuth2Client", func(t *testing.T) {
+		oj := &hydra.OAuth2ClientJSON{
+			ClientName:              "test",
+			GrantTypes:              []string{"client_credentials"},
+			ResponseTypes:           []string{"token"},
+			RedirectURIs:            []string{"https://example.com/callback"},
+			Scope:                   "scope1 scope2",
+			Owner:                   "name/namespace",
+			TokenEndpointAuthMethod: "client_secret_basic",
+			Metadata:                json.RawMessage(`{"key":"value"}`),
+			FrontChannelLogoutURI:   "https://example.com/logout",
+			ClientCredentialsGrantAccessTokenLifespan: "1h0m0s",
 		}
 
-		assert.Equal(t, parsedClient.Scope, "scope1 scope2 scope3")
+		c, err := hydra.ToOAuth2Client(oj)
+		require.NoError(t, err)
+
+		assert.Equal(t, "name", c.Name)
+		assert.Equal(t, "namespace", c.Namespace)
+		assert.Equal(t, "test", c.Spec.ClientName)
+		assert.Equal(t, []hydrav1alpha1.GrantType{"client_credentials"}, c.Spec.GrantTypes)
+		assert.Equal(t, []hydrav1alpha1.ResponseType{"token"}, c.Spec.ResponseTypes)
+		assert.Equal(t, []hydrav1alpha1.RedirectURI{"https://example.com/callback"}, c.Spec.RedirectURIs)
+		assert.Equal(t, []string{"scope1", "scope2"}, c.Spec.ScopeArray)
+		assert.Empty(t, c.Spec.Scope)
+		assert.Equal(t, hydrav1alpha1.TokenEndpointAuthMethod("client_secret_basic"), c.Spec.TokenEndpointAuthMethod)
+		assert.Equal(t, map[string]apiextensionsv1.JSON{"key": {Raw: []byte(`"value"`)}}, c.Spec.Metadata)
+		assert.Equal(t, "https://example.com/logout", c.Spec.FrontChannelLogoutURI)
+		assert.Equal(t, "1h0m0s", c.Spec.TokenLifespans.ClientCredentialsGrantAccessTokenLifespan)
+
+		back, err := hydra.FromOAuth2Client(c)
+		require.NoError(t, err)
+		assert.Equal(t, oj.Owner, back.Owner)
+		assert.Equal(t, oj.Scope, back.Scope)
+		assert.Equal(t, oj.GrantTypes, back.GrantTypes)
+		assert.JSONEq(t, string(oj.Metadata), string(back.Metadata))
+	})
+
+	t.Run("Test leaving out metadata keys set to null", func(t *testing.T) {
+		c := hydrav1alpha1.OAuth2Client{
+			Spec: hydrav1alpha1.OAuth2ClientSpec{
+				Metadata: map[string]apiextensionsv1.JSON{
+					"team":    {Raw: []byte(`"payments"`)},
+					"removed": {},
+					"labels":  {Raw: []byte(`{"app":"checkout","tier":null}`)},
+				},
+			},
+		}
+
+		oj, err := hydra.FromOAuth2Client(&c)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"team":"payments","labels":{"app":"checkout"}}`, string(oj.Metadata))
+
+		oj, err = hydra.FromOAuth2Client(&hydrav1alpha1.OAuth2Client{})
+		require.NoError(t, err)
+		assert.Equal(t, "null", string(oj.Metadata))
+	})
+
+	t.Run("Test converting back a client not owned by the controller", func(t *testing.T) {
+		c, err := hydra.ToOAuth2Client(&hydra.OAuth2ClientJSON{Owner: "someone", Metadata: json.RawMessage("null")})
+		require.NoError(t, err)
+
+		assert.Empty(t, c.Name)
+		assert.Empty(t, c.Namespace)
+		assert.Nil(t, c.Spec.Metadata)
+	})
+
+	t.Run("Test converting back invalid metadata", func(t *testing.T) {
+		_, err := hydra.ToOAuth2Client(&hydra.OAuth2ClientJSON{Metadata: json.RawMessage("{")})
+		assert.Error(t, err)
+
+		_, err = hydra.ToOAuth2Client(&hydra.OAuth2ClientJSON{Metadata: json.RawMessage("[1,2]")})
+		assert.ErrorContains(t, err, "metadata is not a JSON object")
 	})
 }
@@ -0,0 +1,34 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra
+
+import (
+	"context"
+
+	"github.com/ory/hydra-maester/hydra/admin"
+)
+
+// The range of ORY Hydra versions the controller supports. The maximum is
+// exclusive.
+const (
+	MinSupportedVersion = admin.MinSupportedVersion
+	MaxSupportedVersion = admin.MaxSupportedVersion
+)
+
+// UnsupportedVersionError is returned by CheckVersion when ORY Hydra reports
+// a version outside the supported range.
+type UnsupportedVersionError = admin.UnsupportedVersionError
+
+// CheckVersion queries the version of the ORY Hydra instance behind c and
+// returns it. The error is an *UnsupportedVersionError if the version is
+// outside the supported range.
+func CheckVersion(ctx context.Context, c Client) (string, error) {
+	return admin.CheckVersion(ctx, c)
+}
+
+// VersionAtLeast reports whether version is min or newer, see
+// admin.VersionAtLeast.
+func VersionAtLeast(version, min string) (bool, error) {
+	return admin.VersionAtLeast(version, min)
+}
@@ -0,0 +1,54 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package hydra_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/hydra"
+)
+
+func TestCheckVersion(t *testing.T) {
+	for version, tc := range map[string]struct {
+		unsupported bool
+		err         bool
+	}{
+		"v2.0.0":       {},
+		"v2.2.0-rc.1":  {},
+		"v1.11.10":     {unsupported: true},
+		"v3.0.0":       {unsupported: true},
+		"master":       {err: true},
+		"v2.2.0.1-foo": {err: true},
+	} {
+		t.Run(fmt.Sprintf("version=%s", version), func(t *testing.T) {
+			c := hydra.InternalClient{HTTPClient: &http.Client{}}
+			runServer(&c, func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintf(w, `{"version":%q}`, version)
+			})
+
+			got, err := hydra.CheckVersion(context.Background(), &c)
+			assert.Equal(t, version, got)
+
+			var unsupported *hydra.UnsupportedVersionError
+			switch {
+			case tc.unsupported:
+				require.Error(t, err)
+				assert.True(t, errors.As(err, &unsupported))
+			case tc.err:
+				require.Error(t, err)
+				assert.False(t, errors.As(err, &unsupported))
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package keto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Writer creates and deletes relation tuples.
+type Writer interface {
+	CreateRelationTuple(ctx context.Context, t RelationTuple) error
+	DeleteRelationTuple(ctx context.Context, t RelationTuple) error
+}
+
+// Client writes relation tuples with the write API of ORY Keto. Both calls are
+// idempotent: creating an existing tuple and deleting a missing one succeed.
+type Client struct {
+	// URL is the address of the write API, e.g. http://keto-write:4467.
+	URL string
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+var _ Writer = (*Client)(nil)
+
+// NewClient returns a client of the write API at rawURL.
+func NewClient(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORY Keto URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid ORY Keto URL %q: expected an http(s) URL", rawURL)
+	}
+	return &Client{URL: strings.TrimSuffix(u.String(), "/")}, nil
+}
+
+// CreateRelationTuple implements Writer.
+func (c *Client) CreateRelationTuple(ctx context.Context, t RelationTuple) error {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.URL+"/admin/relation-tuples", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.do(req); err != nil {
+		return fmt.Errorf("creating relation tuple %s: %w", t, err)
+	}
+	return nil
+}
+
+// DeleteRelationTuple implements Writer.
+func (c *Client) DeleteRelationTuple(ctx context.Context, t RelationTuple) error {
+	query := url.Values{
+		"namespace": {t.Namespace},
+		"object":    {t.Object},
+		"relation":  {t.Relation},
+	}
+	if t.SubjectSet != nil {
+		query.Set("subject_set.namespace", t.SubjectSet.Namespace)
+		query.Set("subject_set.object", t.SubjectSet.Object)
+		query.Set("subject_set.relation", t.SubjectSet.Relation)
+	} else {
+		query.Set("subject_id", t.SubjectID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.URL+"/admin/relation-tuples?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if err := c.do(req); err != nil {
+		return fmt.Errorf("deleting relation tuple %s: %w", t, err)
+	}
+	return nil
+}
+
+func (c *Client) do(req *http.Request) error {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("ORY Keto responded with %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package keto_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/keto"
+)
+
+func TestClient(t *testing.T) {
+	tuple := keto.RelationTuple{Namespace: "OAuth2Client", Object: "my-client", Relation: "owners",
+		SubjectSet: &keto.SubjectSet{Namespace: "Team", Object: "payments", Relation: "members"}}
+
+	t.Run("case=creates relation tuples", func(t *testing.T) {
+		var received keto.RelationTuple
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "/admin/relation-tuples", r.URL.Path)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer s.Close()
+
+		c, err := keto.NewClient(s.URL + "/")
+		require.NoError(t, err)
+		require.NoError(t, c.CreateRelationTuple(context.Background(), tuple))
+		assert.Equal(t, tuple, received)
+	})
+
+	t.Run("case=deletes relation tuples", func(t *testing.T) {
+		var query map[string][]string
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodDelete, r.Method)
+			assert.Equal(t, "/admin/relation-tuples", r.URL.Path)
+			query = r.URL.Query()
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer s.Close()
+
+		c, err := keto.NewClient(s.URL)
+		require.NoError(t, err)
+		require.NoError(t, c.DeleteRelationTuple(context.Background(), tuple))
+		assert.Equal(t, map[string][]string{
+			"namespace":             {"OAuth2Client"},
+			"object":                {"my-client"},
+			"relation":              {"owners"},
+			"subject_set.namespace": {"Team"},
+			"subject_set.object":    {"payments"},
+			"subject_set.relation":  {"members"},
+		}, query)
+	})
+
+	t.Run("case=fails on error responses", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error":{"message":"unknown namespace"}}`, http.StatusBadRequest)
+		}))
+		defer s.Close()
+
+		c, err := keto.NewClient(s.URL)
+		require.NoError(t, err)
+		err = c.CreateRelationTuple(context.Background(), tuple)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+		assert.Contains(t, err.Error(), "unknown namespace")
+	})
+
+	for _, url := range []string{"keto-write:4467", "ftp://keto", "http://"} {
+		t.Run("invalid="+url, func(t *testing.T) {
+			_, err := keto.NewClient(url)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package keto
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data a Template is executed with.
+type TemplateData struct {
+	// ClientID is the ID of the client in ORY Hydra.
+	ClientID string
+	// Namespace and Name are those of the OAuth2Client.
+	Namespace string
+	Name      string
+	// Cluster is the name of the cluster of the OAuth2Client, empty for the
+	// local cluster.
+	Cluster string
+}
+
+// Template renders the relation tuple of an OAuth2Client from a Go template
+// of a tuple in the syntax of ORY Keto, e.g.
+// OAuth2Client:{{.ClientID}}#owners@Team:{{.Namespace}}#members.
+type Template struct {
+	text string
+	tmpl *template.Template
+}
+
+// ParseTemplate parses text as a Template. It fails unless text renders a
+// valid relation tuple.
+func ParseTemplate(text string) (*Template, error) {
+	tmpl, err := template.New("relation-tuple").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid relation tuple template %q: %w", text, err)
+	}
+	t := &Template{text: text, tmpl: tmpl}
+	if _, err := t.Execute(TemplateData{ClientID: "client", Namespace: "namespace", Name: "name", Cluster: "cluster"}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Execute renders the relation tuple of data.
+func (t *Template) Execute(data TemplateData) (RelationTuple, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return RelationTuple{}, fmt.Errorf("rendering relation tuple template %q: %w", t.text, err)
+	}
+	return ParseRelationTuple(b.String())
+}
+
+// String returns the text of t.
+func (t *Template) String() string {
+	return t.text
+}
@@ -0,0 +1,76 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package keto writes the relation tuples of OAuth2Clients to ORY Keto, so
+// the authorization of the Ory stack follows the lifecycle of the clients.
+package keto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RelationTuple is a relation tuple of ORY Keto. Its subject is either
+// SubjectID or SubjectSet.
+type RelationTuple struct {
+	Namespace  string      `json:"namespace"`
+	Object     string      `json:"object"`
+	Relation   string      `json:"relation"`
+	SubjectID  string      `json:"subject_id,omitempty"`
+	SubjectSet *SubjectSet `json:"subject_set,omitempty"`
+}
+
+// SubjectSet is the subject of a relation tuple referring to all subjects
+// with Relation to Object.
+type SubjectSet struct {
+	Namespace string `json:"namespace"`
+	Object    string `json:"object"`
+	Relation  string `json:"relation"`
+}
+
+// ParseRelationTuple parses a relation tuple in the syntax of ORY Keto,
+// namespace:object#relation@subject, where the subject is either a subject ID
+// or a subject set namespace:object#relation, e.g.
+// OAuth2Client:my-client#owners@Team:payments#members.
+func ParseRelationTuple(s string) (RelationTuple, error) {
+	var t RelationTuple
+	var ok bool
+	var rest, subject string
+	if t.Namespace, rest, ok = strings.Cut(s, ":"); !ok {
+		return t, fmt.Errorf("invalid relation tuple %q: expected namespace:object#relation@subject", s)
+	}
+	if t.Object, rest, ok = strings.Cut(rest, "#"); !ok {
+		return t, fmt.Errorf("invalid relation tuple %q: expected namespace:object#relation@subject", s)
+	}
+	if t.Relation, subject, ok = strings.Cut(rest, "@"); !ok {
+		return t, fmt.Errorf("invalid relation tuple %q: expected namespace:object#relation@subject", s)
+	}
+	if t.Namespace == "" || t.Object == "" || t.Relation == "" || subject == "" {
+		return t, fmt.Errorf("invalid relation tuple %q: the namespace, object, relation and subject must not be empty", s)
+	}
+
+	subject = strings.TrimSuffix(strings.TrimPrefix(subject, "("), ")")
+	namespace, rest, isSet := strings.Cut(subject, ":")
+	if !isSet {
+		t.SubjectID = subject
+		return t, nil
+	}
+	object, relation, _ := strings.Cut(rest, "#")
+	if namespace == "" || object == "" {
+		return t, fmt.Errorf("invalid relation tuple %q: the subject set must have a namespace and an object", s)
+	}
+	t.SubjectSet = &SubjectSet{Namespace: namespace, Object: object, Relation: relation}
+	return t, nil
+}
+
+// String returns t in the syntax of ORY Keto.
+func (t RelationTuple) String() string {
+	subject := t.SubjectID
+	if t.SubjectSet != nil {
+		subject = t.SubjectSet.Namespace + ":" + t.SubjectSet.Object
+		if t.SubjectSet.Relation != "" {
+			subject += "#" + t.SubjectSet.Relation
+		}
+	}
+	return fmt.Sprintf("%s:%s#%s@%s", t.Namespace, t.Object, t.Relation, subject)
+}
@@ -0,0 +1,65 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package keto_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/keto"
+)
+
+func TestParseRelationTuple(t *testing.T) {
+	for _, tc := range []struct {
+		tuple    string
+		expected keto.RelationTuple
+	}{
+		{
+			tuple:    "OAuth2Client:my-client#owners@alice",
+			expected: keto.RelationTuple{Namespace: "OAuth2Client", Object: "my-client", Relation: "owners", SubjectID: "alice"},
+		},
+		{
+			tuple: "OAuth2Client:my-client#owners@Team:payments#members",
+			expected: keto.RelationTuple{Namespace: "OAuth2Client", Object: "my-client", Relation: "owners",
+				SubjectSet: &keto.SubjectSet{Namespace: "Team", Object: "payments", Relation: "members"}},
+		},
+		{
+			tuple: "OAuth2Client:my-client#parents@Namespace:payments",
+			expected: keto.RelationTuple{Namespace: "OAuth2Client", Object: "my-client", Relation: "parents",
+				SubjectSet: &keto.SubjectSet{Namespace: "Namespace", Object: "payments"}},
+		},
+	} {
+		t.Run("tuple="+tc.tuple, func(t *testing.T) {
+			actual, err := keto.ParseRelationTuple(tc.tuple)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.tuple, actual.String())
+		})
+	}
+
+	for _, tuple := range []string{"", "OAuth2Client", "OAuth2Client:my-client", "OAuth2Client:my-client#owners", "OAuth2Client:#owners@alice", "OAuth2Client:my-client#owners@Team:"} {
+		t.Run("invalid="+tuple, func(t *testing.T) {
+			_, err := keto.ParseRelationTuple(tuple)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := keto.ParseTemplate("OAuth2Client:{{.ClientID}}#owners@Team:{{.Namespace}}#members")
+	require.NoError(t, err)
+
+	tuple, err := tmpl.Execute(keto.TemplateData{ClientID: "4b8f", Namespace: "payments", Name: "checkout"})
+	require.NoError(t, err)
+	assert.Equal(t, "OAuth2Client:4b8f#owners@Team:payments#members", tuple.String())
+
+	for _, text := range []string{"OAuth2Client:{{.ClientID}", "OAuth2Client:{{.Owner}}#owners@alice", "OAuth2Client:{{.ClientID}}"} {
+		t.Run("invalid="+text, func(t *testing.T) {
+			_, err := keto.ParseTemplate(text)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -4,46 +4,207 @@
 package main
 
 import (
+	"context"
+	_ "embed"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
-	"sigs.k8s.io/controller-runtime/pkg/cache"
-	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/ory/hydra-maester/hydra"
-
+	certificatesv1 "k8s.io/api/certificates/v1"
 	apiv1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	hydrav1alpha1 "github.com/ory/hydra-maester/api/v1alpha1"
+	"github.com/ory/hydra-maester/audit"
+	"github.com/ory/hydra-maester/cli"
 	"github.com/ory/hydra-maester/controllers"
+	"github.com/ory/hydra-maester/envelope"
+	"github.com/ory/hydra-maester/helpers"
+	"github.com/ory/hydra-maester/hydra"
+	"github.com/ory/hydra-maester/keto"
+	"github.com/ory/hydra-maester/notify"
+	"github.com/ory/hydra-maester/oathkeeper"
+	"github.com/ory/hydra-maester/settings"
 	// +kubebuilder:scaffold:imports
 )
 
+//go:embed config/crd/bases/hydra.ory.sh_oauth2clients.yaml
+var oauth2ClientCRD []byte
+
+// oauth2ClientCRDName is the name of the CRD of oauth2ClientCRD.
+const oauth2ClientCRDName = "oauth2clients.hydra.ory.sh"
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
+
+	// version is the version of the controller, set at build time with
+	// -ldflags "-X main.version=...".
+	version = "dev"
+
+	// reloadableSettings are the settings which apply without a restart when
+	// the settings file changes.
+	reloadableSettings = map[string]bool{
+		"hydra-url":                true,
+		"hydra-public-url":         true,
+		"hydra-port":               true,
+		"endpoint":                 true,
+		"forwarded-proto":          true,
+		"tls-trust-store":          true,
+		"insecure-skip-verify":     true,
+		"service-mesh-mode":        true,
+		"hydra-fallback-url":       true,
+		"hydra-fallback-port":      true,
+		"mirror-hydra-url":         true,
+		"mirror-hydra-port":        true,
+		"hydra-discovery":          true,
+		"hydra-discovery-interval": true,
+	}
 )
 
 func init() {
 	_ = apiv1.AddToScheme(scheme)
+	_ = apiextensionsv1.AddToScheme(scheme)
+	_ = certificatesv1.AddToScheme(scheme)
 	_ = hydrav1alpha1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
+// stringList is a flag which can be repeated or set to a comma-separated list.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, strings.Split(v, ",")...)
+	return nil
+}
+
 func main() {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		cli.CRD = oauth2ClientCRD
+		if err := cli.Run(os.Args[1], os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var (
-		metricsAddr, hydraURL, endpoint, forwardedProto, syncPeriod, tlsTrustStore, namespace, leaderElectorNs string
-		hydraPort                                                                                              int
-		enableLeaderElection, insecureSkipVerify                                                               bool
+		metricsAddr               string
+		probeAddr                 string
+		hydraURL                  string
+		hydraPublicURL            string
+		endpoint                  string
+		forwardedProto            string
+		syncPeriod                string
+		tlsTrustStore             string
+		namespace                 string
+		leaderElectorNs           string
+		configFile                string
+		versionCheck              string
+		clusterName               string
+		backupSecret              string
+		backupInterval            string
+		retryPolicy               string
+		conflictPolicy            string
+		maxFinalization           string
+		hydraRequestTimeout       string
+		hydraLatencyTarget        string
+		metadataSchema            string
+		controllerID              string
+		auditSink                 string
+		secretEncryptionPlugin    string
+		requeueAfter              string
+		existenceProbeInterval    string
+		waitForHydraTimeout       string
+		eventDedupWindow          string
+		webhookCertDir            string
+		clientNameTemplate        string
+		shutdownGracePeriod       string
+		recoveryCheckInterval     string
+		faultMaxLatency           string
+		mirrorHydraURL            string
+		hydraFallbackURL          string
+		notificationSink          string
+		syncReportConfigMap       string
+		syncReportInterval        string
+		stateAPIAddr              string
+		stateAPITokenFile         string
+		ketoWriteURL              string
+		oathkeeperRuleTemplate    string
+		unmanagedClientsConfigMap string
+		unmanagedClientsInterval  string
+		hydraDiscovery            string
+		hydraDiscoveryInterval    string
+		adminMTLSSigner           string
+		adminMTLSCommonName       string
+		adminMTLSDuration         string
+		userAgent                 string
+		requeueJitter             float64
+		faultErrorRate            float64
+		hydraPort                 int
+		shardIndex                int
+		shardCount                int
+		auditQueueSize            int
+		logSamplingInitial        int
+		logSamplingThereafter     int
+		webhookPort               int
+		degradedThreshold         int
+		mirrorHydraPort           int
+		hydraFallbackPort         int
+		hydraMinConcurrency       int
+		hydraMaxConcurrency       int
+		enableLeaderElection      bool
+		insecureSkipVerify        bool
+		serviceMeshMode           bool
+		installCRDs               bool
+		migrateStorageVersion     bool
+		mergeMetadata             bool
+		disableHydraAdmin         bool
+		namespaceScoped           bool
+		repairDrift               bool
+		patchUpdates              bool
+		provenanceMetadata        bool
+		requestBaggage            bool
+		enableWebhooks            bool
+		wildcardRedirectURIs      bool
+		disableFinalizers         bool
+		remoteClusters            stringList
+		allowedHydraURLs          stringList
+		dnsServers                stringList
+		allowedScopes             stringList
+		scopeExemptNamespaces     stringList
+		allowedRedirectURIDomains stringList
+		auditSinkHeaders          stringList
+		notificationSinkHeaders   stringList
+		relationTupleTemplates    stringList
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
-	flag.StringVar(&hydraURL, "hydra-url", "", "The address of ORY Hydra")
+	flag.StringVar(&probeAddr, "health-probe-addr", ":8081", "The address the health probe endpoint binds to. Keep it on a dedicated port so it can be excluded from the service mesh.")
+	flag.StringVar(&hydraURL, "hydra-url", "", "The address of ORY Hydra, optionally with the port and path of the clients endpoint, e.g. http://[fd00::1]:4445/admin/clients, which take precedence over hydra-port and endpoint.")
+	flag.StringVar(&hydraPublicURL, "hydra-public-url", "", "The public address of ORY Hydra, used to publish the issuer and OAuth2 endpoints to discovery ConfigMaps")
 	flag.IntVar(&hydraPort, "hydra-port", 4445, "Port ORY Hydra is listening on")
 	flag.StringVar(&endpoint, "endpoint", "/clients", "ORY Hydra's client endpoint")
 	flag.StringVar(&forwardedProto, "forwarded-proto", "", "If set, this adds the value as the X-Forwarded-Proto header in requests to the ORY Hydra admin server")
@@ -51,11 +212,108 @@ func main() {
 	flag.StringVar(&syncPeriod, "sync-period", "10h", "Determines the minimum frequency at which watched resources are reconciled")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false, "Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "If set, http client will be configured to skip insecure verification to connect with hydra admin")
-	flag.StringVar(&namespace, "namespace", "", "Namespace in which the controller should operate. Setting this will make the controller ignore other namespaces.")
+	flag.StringVar(&namespace, "namespace", "", "Namespaces in which the controller should operate, comma-separated. Setting this will make the controller ignore other namespaces.")
+	flag.BoolVar(&serviceMeshMode, "service-mesh-mode", false, "If set, the controller talks plaintext HTTP to ORY Hydra and relies on the service mesh sidecar for mTLS. The tls-trust-store and insecure-skip-verify flags are ignored.")
+	flag.StringVar(&adminMTLSSigner, "admin-mtls-signer", "", "If set, the controller requests its client certificate for the ORY Hydra admin API from this signer with a CertificateSigningRequest and renews it automatically, e.g. clusterissuers.cert-manager.io/hydra-clients.")
+	flag.StringVar(&adminMTLSCommonName, "admin-mtls-common-name", "hydra-maester", "The common name of the client certificate requested from admin-mtls-signer.")
+	flag.StringVar(&adminMTLSDuration, "admin-mtls-duration", "24h", "The lifetime of the client certificate requested from admin-mtls-signer. Zero leaves it to the signer. The certificate is renewed after two thirds of it.")
 	flag.StringVar(&leaderElectorNs, "leader-elector-namespace", "", "Leader elector namespace where controller should be set.")
+	flag.StringVar(&versionCheck, "hydra-version-check", string(controllers.VersionCheckWarn), "What to do when an ORY Hydra instance reports an unsupported version: off, warn or enforce. With enforce, the controller refuses to start for the default instance and marks OAuth2Clients of other instances as failed.")
+	flag.IntVar(&shardIndex, "shard-index", 0, "Index of this replica when OAuth2Clients are sharded by namespace, starting at 0.")
+	flag.IntVar(&shardCount, "shard-count", 1, "Number of replicas OAuth2Clients are sharded across by a hash of their namespace. Each shard elects its own leader.")
+	flag.BoolVar(&installCRDs, "install-crds", false, "If set, the controller applies its CRDs with server-side apply on startup. Fails if the CRDs are managed by another tool, e.g. Helm.")
+	flag.BoolVar(&migrateStorageVersion, "migrate-storage-version", false, "If set, the leader rewrites the stored OAuth2Clients in the storage version of the CRD on startup and then drops older versions from its stored versions, so they can be removed from the CRD in later upgrades.")
+	flag.StringVar(&userAgent, "user-agent", "", "The User-Agent header of requests to ORY Hydra. {version} and {cluster} are replaced by the version of the controller and the cluster-name, or the name of the remote cluster. Defaults to hydra-maester/{version} (cluster={cluster}), without the cluster if it is empty.")
+	flag.StringVar(&clusterName, "cluster-name", "", "Name of the cluster the controller runs in. If set, it is appended to the owner of the clients in ORY Hydra, so clusters sharing an instance don't conflict.")
+	flag.Var(&remoteClusters, "remote-cluster", "A remote cluster whose OAuth2Clients are reconciled too, in the name=namespace/secret form. The Secret must hold a kubeconfig in its kubeconfig key. Can be repeated.")
+	flag.StringVar(&backupSecret, "backup-secret", "", "If set, the controller-owned clients of the default ORY Hydra instance are periodically backed up to this Secret, in the namespace/name form.")
+	flag.StringVar(&backupInterval, "backup-interval", "1h", "How often the clients are backed up to the backup-secret.")
+	flag.StringVar(&retryPolicy, "retry-policy", string(controllers.RetryTransient), "Which failed ORY Hydra requests are retried with backoff: transient (server errors, timeouts and network errors), always or never. Failures are recorded in the status of the OAuth2Client either way.")
+	flag.StringVar(&conflictPolicy, "conflict-policy", string(hydrav1alpha1.ConflictPolicyFail), "How client IDs of Secrets which are already taken in ORY Hydra by another owner are handled: fail, adopt or regenerate. OAuth2Clients may override it with spec.conflictPolicy.")
+	flag.StringVar(&maxFinalization, "max-finalization-duration", "0", "How long the deletion of an OAuth2Client waits for its client to be deleted from ORY Hydra before giving up and orphaning it. Zero waits forever.")
+	flag.StringVar(&metadataSchema, "metadata-schema", "", "Path to a JSON Schema (draft 4) which the spec.metadata of OAuth2Clients must match. OAuth2Clients with invalid metadata are not registered.")
+	flag.BoolVar(&mergeMetadata, "merge-metadata", true, "If set, spec.metadata is deep merged into the metadata of clients in ORY Hydra on updates, so keys written by other systems are kept and keys set to null are removed. Otherwise, the metadata is replaced.")
+	flag.Var(&allowedHydraURLs, "allowed-hydra-urls", "Patterns of the ORY Hydra admin addresses OAuth2Clients may set in spec.hydraAdmin, matched against the URL and the port, e.g. https://*.ory.svc.cluster.local:4445. Can be repeated or comma-separated. If unset, all addresses are allowed.")
+	flag.Var(&dnsServers, "dns-servers", "IP addresses with an optional port of the DNS servers resolving the ORY Hydra admin hosts, e.g. 10.0.0.53 or [fd00::53]:5353, instead of those of the pod, for split-horizon DNS. Also used for the SRV records of hydra-discovery. OAuth2Clients can override them with spec.hydraAdmin.dnsServers. Can be repeated or comma-separated.")
+	flag.StringVar(&hydraRequestTimeout, "hydra-request-timeout", "0", "Timeout of each request to ORY Hydra, e.g. 10s. OAuth2Clients can override it with spec.hydraAdmin.requestTimeout, e.g. for instances behind slow links. Zero disables it.")
+	flag.IntVar(&hydraMaxConcurrency, "hydra-max-concurrency", 0, "If set, the concurrent requests to each ORY Hydra instance are limited to an adaptive limit of at most this many, which is halved when requests are slower than hydra-latency-target or fail transiently, and grows again while they succeed in time. Zero disables the limit.")
+	flag.IntVar(&hydraMinConcurrency, "hydra-min-concurrency", 1, "The lowest limit of the concurrent requests to each ORY Hydra instance, and the one it starts with, see hydra-max-concurrency.")
+	flag.StringVar(&hydraLatencyTarget, "hydra-latency-target", hydra.DefaultThrottleLatency.String(), "Latency of the requests to ORY Hydra above which the concurrency limit of hydra-max-concurrency is lowered.")
+	flag.BoolVar(&disableHydraAdmin, "disable-per-resource-hydra-admin", false, "If set, all OAuth2Clients are registered in the ORY Hydra of hydra-url. OAuth2Clients setting spec.hydraAdmin.url are not reconciled.")
+	flag.StringVar(&controllerID, "controller-id", "", "Identity of this controller, appended to the owner of the clients in ORY Hydra. Controllers with different identities sharing an ORY Hydra instance never update or delete each other's clients.")
+	flag.Var(&allowedScopes, "allowed-scopes", "Scopes OAuth2Clients may request. OAuth2Clients requesting other scopes are not registered. Can be repeated or comma-separated. If unset, all scopes are allowed.")
+	flag.Var(&scopeExemptNamespaces, "scope-policy-exempt-namespaces", "Namespaces whose OAuth2Clients may request any scope, regardless of allowed-scopes. Can be repeated or comma-separated.")
+	flag.Var(&allowedRedirectURIDomains, "allowed-redirect-uri-domains", "Hosts the redirect URIs of OAuth2Clients may point to, a leading *. matches all subdomains, e.g. *.example.com. OAuth2Clients with other redirect URIs are not registered. Can be repeated or comma-separated. If unset, all hosts are allowed.")
+	flag.BoolVar(&wildcardRedirectURIs, "allow-wildcard-redirect-uris", false, "If set, redirect URIs of OAuth2Clients may use a wildcard as the leftmost label of their host, e.g. https://*.example.com/callback, for ORY Hydra deployments matching them. Otherwise OAuth2Clients with wildcards in their redirect URIs are not registered.")
+	flag.BoolVar(&namespaceScoped, "namespace-scoped", false, "If set, the controller runs with permissions in the namespaces of namespace only, e.g. granted by a Role. Settings which need cluster-wide permissions are refused.")
+	flag.StringVar(&auditSink, "audit-sink", "", "If set, a record of every change made to clients in ORY Hydra is exported to this URL: an http(s) webhook, syslog+tcp://host:port, syslog+udp://host:port or the topic of a Kafka REST Proxy, e.g. kafka+https://kafka-rest:8082/topics/hydra-audit.")
+	flag.Var(&auditSinkHeaders, "audit-sink-header", "A header added to the requests of HTTP based audit sinks, in the Name: value form. Can be repeated.")
+	flag.IntVar(&auditQueueSize, "audit-queue-size", 1000, "Number of audit records queued while the audit sink is unavailable. Reconciliations wait for room in a full queue.")
+	flag.StringVar(&secretEncryptionPlugin, "secret-encryption-plugin", "", "Path of an executable wrapping the data keys of the client secrets written to Kubernetes Secrets, e.g. with a KMS. If set, client secrets are stored encrypted.")
+	flag.BoolVar(&repairDrift, "repair-drift", false, "If set, clients which were changed in ORY Hydra out of band are restored to their OAuth2Client on every resync.")
+	flag.BoolVar(&patchUpdates, "update-with-patch", false, "If set, clients are updated in ORY Hydra with a JSON Patch of the changed fields instead of a PUT, keeping fields the controller does not manage.")
+	flag.BoolVar(&provenanceMetadata, "provenance-metadata", false, "If set, the namespace, name and UID of OAuth2Clients and the cluster-name are recorded under the k8s key of the metadata of their clients in ORY Hydra.")
+	flag.BoolVar(&requestBaggage, "request-baggage", false, "If set, the namespace, name and UID of the reconciled OAuth2Client are sent in the W3C baggage header of the requests to ORY Hydra.")
+	flag.StringVar(&requeueAfter, "requeue-after", "0", "How long after a successful reconciliation OAuth2Clients are reconciled again, although they did not change. Zero requeues them only on the next sync-period. OAuth2Clients may override it with the hydra.ory.sh/resync-interval annotation.")
+	flag.Float64Var(&requeueJitter, "requeue-jitter", 0, "Fraction of requeue-after added at random to each requeue, e.g. 0.2 for up to 20%, spreading the requests to ORY Hydra.")
+	flag.StringVar(&existenceProbeInterval, "existence-probe-interval", "0", "How often the controller checks that the clients of unchanged OAuth2Clients still exist in ORY Hydra, registering clients deleted out of band again. Zero disables the probe.")
+	flag.StringVar(&recoveryCheckInterval, "recovery-check-interval", "15s", "How often the controller polls the readiness of the ORY Hydra instances OAuth2Clients failed to reconcile against, reconciling them right away once their instance is ready again instead of waiting for their backoff. Zero disables the check.")
+	flag.StringVar(&waitForHydraTimeout, "wait-for-hydra-timeout", "0", "How long the controller waits at startup for the readiness endpoint of the default ORY Hydra instance to report ready before reconciling. Zero does not wait.")
+	flag.StringVar(&eventDedupWindow, "event-dedup-window", controllers.DefaultEventDedupWindow.String(), "Window in which warning events with the same reason are emitted only once per OAuth2Client. Zero emits every event.")
+	flag.IntVar(&logSamplingInitial, "log-sampling-initial", 0, "Number of log entries with the same level and message logged each second before sampling starts. Zero disables sampling.")
+	flag.IntVar(&logSamplingThereafter, "log-sampling-thereafter", 100, "With log-sampling-initial, only every nth of the further log entries with the same level and message is logged in that second.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "If set, the webhook server is started to validate OAuth2Clients on admission, and the pod is only ready once its serving certificate is available and it accepts connections.")
+	flag.IntVar(&webhookPort, "webhook-port", webhook.DefaultPort, "Port the webhook server is listening on.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs"), "Directory holding the tls.crt and tls.key serving certificate of the webhook server, e.g. mounted from the Secret issued by cert-manager.")
+	flag.StringVar(&clientNameTemplate, "client-name-template", controllers.DefaultClientNameTemplate, "Go template of the client_name of the clients of OAuth2Clients which don't set spec.clientName, with the .Namespace, .Name and .Cluster fields. If empty, the client_name is left empty.")
+	flag.StringVar(&shutdownGracePeriod, "shutdown-grace-period", "20s", "How long reconciliations in flight when the controller stops may run to complete their requests to ORY Hydra and status writes. No reconciliations are started once it stops. Zero cancels them right away.")
+	flag.IntVar(&degradedThreshold, "degraded-threshold", 5, "Number of consecutive failed reconciliations after which the Degraded condition of an OAuth2Client becomes true. Zero does not set the condition.")
+	flag.BoolVar(&disableFinalizers, "disable-finalizers", false, "If set, no finalizer is added to OAuth2Clients and their clients are left in ORY Hydra when they are deleted.")
+	flag.Float64Var(&faultErrorRate, "inject-fault-error-rate", 0, "For resilience tests only: probability, from 0 to 1, that a request to ORY Hydra fails with a 503 Service Unavailable response instead of being sent.")
+	flag.StringVar(&faultMaxLatency, "inject-fault-max-latency", "0", "For resilience tests only: maximum delay added at random to each request to ORY Hydra.")
+	flag.StringVar(&hydraFallbackURL, "hydra-fallback-url", "", "The address of a second ORY Hydra instance sharing the database of the default one, to which requests are sent while the default one is not ready.")
+	flag.IntVar(&hydraFallbackPort, "hydra-fallback-port", 0, "Port the fallback ORY Hydra instance is listening on. Zero uses hydra-port.")
+	flag.StringVar(&hydraDiscovery, "hydra-discovery", "", "If set, clients are registered in all ORY Hydra instances discovered from the targets of a DNS SRV record, dns+srv://<record>, or the ready endpoints of the EndpointSlices of a Service, endpointslice://<namespace>/<service>?port=<port name>, instead of the instance of hydra-url. The scheme query parameter sets the scheme of the instances, http by default.")
+	flag.StringVar(&hydraDiscoveryInterval, "hydra-discovery-interval", hydra.DefaultDiscoveryInterval.String(), "How often the ORY Hydra instances of hydra-discovery are resolved again.")
+	flag.StringVar(&mirrorHydraURL, "mirror-hydra-url", "", "The address of a second ORY Hydra instance to which all writes of clients to the default instance are repeated, e.g. during a migration. Failed and diverging writes are logged and counted but don't fail reconciliations.")
+	flag.IntVar(&mirrorHydraPort, "mirror-hydra-port", 0, "Port the mirror ORY Hydra instance is listening on. Zero uses hydra-port.")
+	flag.StringVar(&notificationSink, "notification-sink", "", "If set, a notification is sent to this URL when an OAuth2Client becomes degraded and when it recovers: an http(s) webhook receiving JSON or a Slack incoming webhook with the slack+https scheme, e.g. slack+https://hooks.slack.com/services/T000/B000/XXXX.")
+	flag.Var(&notificationSinkHeaders, "notification-sink-header", "A header added to the requests of the notification sink, in the Name: value form. Can be repeated.")
+	flag.StringVar(&syncReportConfigMap, "sync-report-configmap", "", "If set, the sync state of all OAuth2Clients is periodically written to this ConfigMap, in the namespace/name form.")
+	flag.StringVar(&syncReportInterval, "sync-report-interval", "1m", "How often the sync report is written to the sync-report-configmap.")
+	flag.StringVar(&unmanagedClientsConfigMap, "unmanaged-clients-configmap", "", "If set, the clients of the default ORY Hydra instance which are not owned by an OAuth2Client are periodically written to this ConfigMap, in the namespace/name form.")
+	flag.StringVar(&unmanagedClientsInterval, "unmanaged-clients-interval", "1h", "How often the default ORY Hydra instance is scanned for the unmanaged-clients-configmap.")
+	flag.StringVar(&stateAPIAddr, "state-api-bind-address", "", "If set, a read-only HTTP API serving the sync state of the OAuth2Clients as JSON listens on this address, e.g. :8082.")
+	flag.StringVar(&stateAPITokenFile, "state-api-token-file", "", "Path to a file holding the bearer token required by the state API.")
+	flag.StringVar(&ketoWriteURL, "keto-write-url", "", "If set, the relation tuples of keto-relation-tuple are written for every registered client to the write API of ORY Keto at this address, e.g. http://keto-write:4467, and deleted with the client.")
+	flag.Var(&relationTupleTemplates, "keto-relation-tuple", "A Go template of a relation tuple written to ORY Keto for every registered client, e.g. OAuth2Client:{{.ClientID}}#owners@Team:{{.Namespace}}#members. The template is executed with the ClientID, Namespace, Name and Cluster of the client. Can be repeated.")
+	flag.StringVar(&oathkeeperRuleTemplate, "oathkeeper-rule-template", "", "Path to a Go template of the spec of the ORY Oathkeeper Rule created for OAuth2Clients with the hydra.ory.sh/oathkeeper-rule annotation. The template is executed with the ClientID, Namespace, Name, Cluster, Scopes and Annotations of the client.")
+	flag.StringVar(&configFile, "config", "", "Path to a YAML settings file whose keys are the names of these flags. Flags given on the command line take precedence. Changes of the ORY Hydra settings are applied without a restart.")
+	logOptions := zap.Options{Development: true}
+	logOptions.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+	logSampling, err := helpers.LogSampling(logSamplingInitial, logSamplingThereafter)
+	if err != nil {
+		ctrl.SetLogger(zap.New(zap.UseFlagOptions(&logOptions)))
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&logOptions), logSampling))
+
+	var explicit map[string]bool
+	if configFile != "" {
+		values, err := settings.Load(configFile)
+		if err != nil {
+			setupLog.Error(err, "unable to read settings file")
+			os.Exit(1)
+		}
+		explicit = settings.Explicit(flag.CommandLine)
+		if _, err := settings.Apply(flag.CommandLine, values, explicit); err != nil {
+			setupLog.Error(err, "unable to apply settings file")
+			os.Exit(1)
+		}
+	}
 
 	syncPeriodParsed, err := time.ParseDuration(syncPeriod)
 	if err != nil {
@@ -63,67 +321,913 @@ func main() {
 		os.Exit(1)
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	maxFinalizationParsed, err := time.ParseDuration(maxFinalization)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	hydraRequestTimeoutParsed, err := time.ParseDuration(hydraRequestTimeout)
+	if err == nil && hydraRequestTimeoutParsed < 0 {
+		err = fmt.Errorf("hydra-request-timeout must not be negative")
+	}
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+	var defaultRequestTimeout string
+	if hydraRequestTimeoutParsed > 0 {
+		defaultRequestTimeout = hydraRequestTimeoutParsed.String()
+	}
+
+	var throttle *hydra.ThrottleConfig
+	if hydraMaxConcurrency > 0 {
+		latency, err := time.ParseDuration(hydraLatencyTarget)
+		if err == nil && latency <= 0 {
+			err = fmt.Errorf("hydra-latency-target must be positive")
+		}
+		if err == nil && (hydraMinConcurrency < 1 || hydraMinConcurrency > hydraMaxConcurrency) {
+			err = fmt.Errorf("hydra-min-concurrency must be between 1 and hydra-max-concurrency")
+		}
+		if err != nil {
+			setupLog.Error(err, "unable to start manager")
+			os.Exit(1)
+		}
+		throttle = &hydra.ThrottleConfig{MinLimit: hydraMinConcurrency, MaxLimit: hydraMaxConcurrency, Latency: latency}
+	}
+
+	shutdownGracePeriodParsed, err := time.ParseDuration(shutdownGracePeriod)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+	// leave the manager time to stop its other runnables after the drain
+	gracefulShutdownTimeout := shutdownGracePeriodParsed + 10*time.Second
+
+	waitForHydraTimeoutParsed, err := time.ParseDuration(waitForHydraTimeout)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	eventDedupWindowParsed, err := time.ParseDuration(eventDedupWindow)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	requeueAfterParsed, err := time.ParseDuration(requeueAfter)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+	if requeueAfterParsed < 0 || requeueJitter < 0 {
+		setupLog.Error(fmt.Errorf("requeue-after and requeue-jitter must not be negative"), "unable to start manager")
+		os.Exit(1)
+	}
+	faultMaxLatencyParsed, err := time.ParseDuration(faultMaxLatency)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+	if faultErrorRate < 0 || faultErrorRate > 1 || faultMaxLatencyParsed < 0 {
+		setupLog.Error(fmt.Errorf("inject-fault-error-rate must be between 0 and 1 and inject-fault-max-latency must not be negative"), "unable to start manager")
+		os.Exit(1)
+	}
+	faults := hydra.FaultInjection{ErrorRate: faultErrorRate, MaxLatency: faultMaxLatencyParsed}
+	if faults != (hydra.FaultInjection{}) {
+		setupLog.Info("injecting faults into the requests to ORY Hydra, do not use in production", "errorRate", faultErrorRate, "maxLatency", faultMaxLatencyParsed.String())
+	}
+
+	adminMTLSDurationParsed, err := time.ParseDuration(adminMTLSDuration)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+	if adminMTLSSigner != "" && serviceMeshMode {
+		setupLog.Error(fmt.Errorf("admin-mtls-signer can't be combined with service-mesh-mode, the sidecar handles mTLS"), "unable to start manager")
+		os.Exit(1)
+	}
+
+	requeuePolicy := controllers.RequeueNever
+	if requeueAfterParsed > 0 {
+		requeuePolicy = controllers.RequeueJittered(requeueAfterParsed, requeueJitter)
+	}
+
+	switch controllers.VersionCheck(versionCheck) {
+	case controllers.VersionCheckOff, controllers.VersionCheckWarn, controllers.VersionCheckEnforce:
+	default:
+		setupLog.Error(fmt.Errorf("invalid hydra-version-check %q", versionCheck), "unable to start manager")
+		os.Exit(1)
+	}
+
+	switch controllers.RetryPolicy(retryPolicy) {
+	case controllers.RetryTransient, controllers.RetryAlways, controllers.RetryNever:
+	default:
+		setupLog.Error(fmt.Errorf("invalid retry-policy %q", retryPolicy), "unable to start manager")
+		os.Exit(1)
+	}
+
+	switch hydrav1alpha1.ConflictPolicy(conflictPolicy) {
+	case hydrav1alpha1.ConflictPolicyFail, hydrav1alpha1.ConflictPolicyAdopt, hydrav1alpha1.ConflictPolicyRegenerate:
+	default:
+		setupLog.Error(fmt.Errorf("invalid conflict-policy %q", conflictPolicy), "unable to start manager")
+		os.Exit(1)
+	}
+
+	if strings.ContainsAny(controllerID, "/@") {
+		setupLog.Error(fmt.Errorf("controller-id must not contain / or @"), "unable to start manager")
+		os.Exit(1)
+	}
+
+	allowlist, err := controllers.ParseHydraURLAllowlist(allowedHydraURLs)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	dnsServerAddrs, err := hydrav1alpha1.HydraAdmin{DNSServers: dnsServers}.DNSServerAddresses()
+	if err != nil {
+		setupLog.Error(err, "invalid dns-servers")
+		os.Exit(1)
+	}
+
+	var metadataSchemaParsed *hydra.MetadataSchema
+	if metadataSchema != "" {
+		raw, err := os.ReadFile(metadataSchema)
+		if err != nil {
+			setupLog.Error(err, "unable to read metadata schema")
+			os.Exit(1)
+		}
+		if metadataSchemaParsed, err = hydra.NewMetadataSchema(raw); err != nil {
+			setupLog.Error(err, "unable to read metadata schema")
+			os.Exit(1)
+		}
+	}
+
+	clientNameTemplateParsed, err := controllers.ParseClientNameTemplate(clientNameTemplate)
+	if err != nil {
+		setupLog.Error(err, "unable to parse client name template")
+		os.Exit(1)
+	}
+
+	var namespaces []string
+	if namespace != "" {
+		namespaces = strings.Split(namespace, ",")
+	}
+	if namespaceScoped {
+		if err := checkNamespaceScoped(namespaces, installCRDs, migrateStorageVersion, backupSecret, syncReportConfigMap, unmanagedClientsConfigMap, hydraDiscovery, adminMTLSSigner, remoteClusters); err != nil {
+			setupLog.Error(err, "unable to start manager")
+			os.Exit(1)
+		}
+	}
+	cacheNamespaces := map[string]cache.Config{}
+	for _, ns := range namespaces {
+		cacheNamespaces[ns] = cache.Config{}
+	}
+
+	if shardCount < 1 || shardIndex < 0 || shardIndex >= shardCount {
+		setupLog.Error(fmt.Errorf("shard-index must be between 0 and shard-count - 1"), "unable to start manager")
+		os.Exit(1)
+	}
+	shard := controllers.Shard{Index: shardIndex, Total: shardCount}
+	leaderElectionID := shard.Name("hydra-maester")
+
+	ctx := ctrl.SetupSignalHandler()
+	cfg := ctrl.GetConfigOrDie()
+
+	if installCRDs {
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to install CRDs")
+			os.Exit(1)
+		}
+		if err := helpers.InstallCRDs(ctx, c, oauth2ClientCRD); err != nil {
+			setupLog.Error(err, "unable to install CRDs")
+			os.Exit(1)
+		}
+		setupLog.Info("installed CRDs")
+	}
+
+	// the first certificate is requested before any client of ORY Hydra is
+	// created, the manager renews it
+	var adminCertificate *helpers.ClientCertificate
+	if adminMTLSSigner != "" {
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to request admin client certificate")
+			os.Exit(1)
+		}
+		adminCertificate = &helpers.ClientCertificate{
+			Client:     c,
+			SignerName: adminMTLSSigner,
+			CommonName: adminMTLSCommonName,
+			Duration:   adminMTLSDurationParsed,
+			Log:        ctrl.Log.WithName("admin-mtls"),
+		}
+		if err := adminCertificate.Request(ctx); err != nil {
+			setupLog.Error(err, "unable to request admin client certificate")
+			os.Exit(1)
+		}
+	}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme: scheme,
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
-		LeaderElection: enableLeaderElection,
+		HealthProbeBindAddress: probeAddr,
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    webhookPort,
+			CertDir: webhookCertDir,
+		}),
+		LeaderElection:   enableLeaderElection,
+		LeaderElectionID: leaderElectionID,
 		Cache: cache.Options{
-			SyncPeriod: &syncPeriodParsed,
-			DefaultNamespaces: map[string]cache.Config{
-				namespace: {},
-			},
+			SyncPeriod:        &syncPeriodParsed,
+			DefaultNamespaces: cacheNamespaces,
 		},
 		LeaderElectionNamespace: leaderElectorNs,
+		GracefulShutdownTimeout: &gracefulShutdownTimeout,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if hydraURL == "" {
-		setupLog.Error(fmt.Errorf("hydra URL can't be empty"), "unable to create controller", "controller", "OAuth2Client")
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+	if adminCertificate != nil {
+		if err := mgr.Add(adminCertificate); err != nil {
+			setupLog.Error(err, "unable to set up admin client certificate renewal")
+			os.Exit(1)
+		}
+	}
+	if enableWebhooks {
+		// The webhook server is checked separately from the reconciler, so the
+		// API server only routes admission requests to pods serving them.
+		certCheck := helpers.WebhookCertificateChecker(filepath.Join(webhookCertDir, "tls.crt"), filepath.Join(webhookCertDir, "tls.key"))
+		if err := mgr.AddReadyzCheck("webhook-certificate", certCheck); err != nil {
+			setupLog.Error(err, "unable to set up ready check")
+			os.Exit(1)
+		}
+		if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+			setupLog.Error(err, "unable to set up ready check")
+			os.Exit(1)
+		}
+		if err := hydrav1alpha1.SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to set up webhook", "webhook", "OAuth2Client")
+			os.Exit(1)
+		}
+	}
+
+	hydraObserver, err := controllers.HydraRequestObserver(metrics.Registry)
+	if err != nil {
+		setupLog.Error(err, "unable to register metrics")
+		os.Exit(1)
+	}
+
+	hydraTracer, err := controllers.HydraRequestTracer(metrics.Registry)
+	if err != nil {
+		setupLog.Error(err, "unable to register metrics")
+		os.Exit(1)
+	}
+
+	// userAgentFor returns the User-Agent header of requests to ORY Hydra on
+	// behalf of the cluster.
+	userAgentFor := func(cluster string) string {
+		if userAgent == "" {
+			return hydra.UserAgent(version, cluster)
+		}
+		return strings.NewReplacer("{version}", version, "{cluster}", cluster).Replace(userAgent)
+	}
+
+	reportMirrorDivergence, err := controllers.MirrorDivergenceReporter(metrics.Registry, ctrl.Log.WithName("mirror"))
+	if err != nil {
+		setupLog.Error(err, "unable to register metrics")
 		os.Exit(1)
 	}
 
-	defaultSpec := hydrav1alpha1.OAuth2ClientSpec{
-		HydraAdmin: hydrav1alpha1.HydraAdmin{
-			URL:            hydraURL,
-			Port:           hydraPort,
+	reportConcurrencyLimit, err := controllers.HydraConcurrencyLimitReporter(metrics.Registry)
+	if err != nil {
+		setupLog.Error(err, "unable to register metrics")
+		os.Exit(1)
+	}
+
+	newAdminClient := func(url string, port int) (hydra.Client, error) {
+		if url == "" {
+			return nil, fmt.Errorf("hydra URL can't be empty")
+		}
+
+		trustStore, skipVerify := tlsTrustStore, insecureSkipVerify
+		if serviceMeshMode {
+			ignored, err := helpers.ServiceMeshTLS(url, trustStore, skipVerify)
+			if err != nil {
+				return nil, err
+			}
+			if ignored {
+				setupLog.Info("service mesh mode is enabled, ignoring tls-trust-store and insecure-skip-verify")
+			}
+			trustStore, skipVerify = "", false
+		}
+
+		if trustStore != "" {
+			if _, err := os.Stat(trustStore); err != nil {
+				return nil, fmt.Errorf("cannot parse tls trust store: %w", err)
+			}
+		}
+
+		opts := []hydra.Option{
+			hydra.WithLogger(ctrl.Log.WithName("hydra")),
+			hydra.WithUserAgent(userAgentFor(clusterName)),
+			hydra.WithRequestObserver(hydraObserver),
+			hydra.WithRequestTracer(hydraTracer),
+			hydra.WithFaultInjection(faults),
+		}
+		if adminCertificate != nil {
+			httpClient, err := helpers.CreateHttpClient(skipVerify, trustStore)
+			if err != nil {
+				return nil, err
+			}
+			if err := helpers.UseClientCertificate(httpClient, adminCertificate.GetClientCertificate); err != nil {
+				return nil, err
+			}
+			opts = append(opts, hydra.WithHTTPClient(httpClient))
+		}
+
+		// the port and path of the URL take precedence over the flags
+		endpoint := endpoint
+		if u, err := (hydrav1alpha1.HydraAdmin{URL: url}).AdminURL(); err == nil && strings.Trim(u.Path, "/") != "" {
+			endpoint = ""
+		}
+
+		admin := hydrav1alpha1.HydraAdmin{
+			URL:            url,
+			Port:           port,
 			Endpoint:       endpoint,
 			ForwardedProto: forwardedProto,
-		},
+			DNSServers:     dnsServers,
+			RequestTimeout: defaultRequestTimeout,
+		}
+		hc, err := hydra.New(hydrav1alpha1.OAuth2ClientSpec{HydraAdmin: admin}, trustStore, skipVerify, opts...)
+		if err != nil || throttle == nil {
+			return hc, err
+		}
+		limits := *throttle
+		limits.OnLimit = func(limit int) {
+			reportConcurrencyLimit(admin.Address(), limit)
+		}
+		return hydra.Throttle(hc, limits), nil
 	}
-	if tlsTrustStore != "" {
-		if _, err := os.Stat(tlsTrustStore); err != nil {
-			setupLog.Error(err, "cannot parse tls trust store")
-			os.Exit(1)
+
+	newHydraClient := func() (hydra.Client, error) {
+		var hc hydra.Client
+		if hydraDiscovery != "" {
+			if hydraFallbackURL != "" {
+				return nil, fmt.Errorf("hydra-fallback-url can't be combined with hydra-discovery")
+			}
+			resolver, err := controllers.ParseHydraDiscovery(hydraDiscovery, mgr.GetAPIReader())
+			if err != nil {
+				return nil, err
+			}
+			if srv, ok := resolver.(hydra.SRVResolver); ok && len(dnsServerAddrs) > 0 {
+				srv.Resolver = helpers.NewDialer(dnsServerAddrs).Resolver
+				resolver = srv
+			}
+			interval, err := time.ParseDuration(hydraDiscoveryInterval)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hydra-discovery-interval: %w", err)
+			}
+			hc = hydra.Discover(resolver, func(address string) (hydra.Client, error) {
+				// addresses carry their port
+				return newAdminClient(address, 0)
+			}, interval, ctrl.Log.WithName("hydra-discovery"))
+		} else {
+			var err error
+			if hc, err = newAdminClient(hydraURL, hydraPort); err != nil {
+				return nil, err
+			}
+		}
+
+		if hydraFallbackURL != "" {
+			port := hydraFallbackPort
+			if port == 0 {
+				port = hydraPort
+			}
+			fallback, err := newAdminClient(hydraFallbackURL, port)
+			if err != nil {
+				return nil, fmt.Errorf("making fallback hydra client: %w", err)
+			}
+			hc = hydra.Failover(
+				hydra.Endpoint{Address: hydrav1alpha1.HydraAdmin{URL: hydraURL, Port: hydraPort}.Address(), Client: hc},
+				hydra.Endpoint{Address: hydrav1alpha1.HydraAdmin{URL: hydraFallbackURL, Port: port}.Address(), Client: fallback},
+				0)
 		}
+		if mirrorHydraURL == "" {
+			return hc, nil
+		}
+
+		port := mirrorHydraPort
+		if port == 0 {
+			port = hydraPort
+		}
+		mirror, err := newAdminClient(mirrorHydraURL, port)
+		if err != nil {
+			return nil, fmt.Errorf("making mirror hydra client: %w", err)
+		}
+		return hydra.Mirror(hc, mirror, reportMirrorDivergence), nil
 	}
 
-	hydraClient, err := hydra.New(defaultSpec, tlsTrustStore, insecureSkipVerify)
+	hydraClient, err := newHydraClient()
 	if err != nil {
 		setupLog.Error(err, "making default hydra client", "controller", "OAuth2Client")
 		os.Exit(1)
+	}
 
+	var auditor audit.Auditor
+	if auditSink != "" {
+		if auditQueueSize < 1 {
+			setupLog.Error(fmt.Errorf("audit-queue-size must be at least 1"), "unable to set up audit export")
+			os.Exit(1)
+		}
+		header := http.Header{}
+		for _, h := range auditSinkHeaders {
+			name, value, ok := strings.Cut(h, ":")
+			if !ok {
+				setupLog.Error(fmt.Errorf("audit-sink-header %q must have the Name: value form", h), "unable to set up audit export")
+				os.Exit(1)
+			}
+			header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+		sink, err := audit.ParseSink(auditSink, header)
+		if err != nil {
+			setupLog.Error(err, "unable to set up audit export")
+			os.Exit(1)
+		}
+		exporter := audit.NewExporter(sink, auditQueueSize, ctrl.Log.WithName("audit"))
+		if err := mgr.Add(exporter); err != nil {
+			setupLog.Error(err, "unable to set up audit export")
+			os.Exit(1)
+		}
+		auditor = exporter
 	}
 
-	err = controllers.New(
+	var notifier notify.Notifier
+	if notificationSink != "" {
+		header := http.Header{}
+		for _, h := range notificationSinkHeaders {
+			name, value, ok := strings.Cut(h, ":")
+			if !ok {
+				setupLog.Error(fmt.Errorf("notification-sink-header %q must have the Name: value form", h), "unable to set up notifications")
+				os.Exit(1)
+			}
+			header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+		sink, err := notify.ParseSink(notificationSink, header)
+		if err != nil {
+			setupLog.Error(err, "unable to set up notifications")
+			os.Exit(1)
+		}
+		dispatcher := notify.NewDispatcher(sink, 100, ctrl.Log.WithName("notify"))
+		if err := mgr.Add(dispatcher); err != nil {
+			setupLog.Error(err, "unable to set up notifications")
+			os.Exit(1)
+		}
+		notifier = dispatcher
+	}
+
+	var ketoWriter keto.Writer
+	var relationTuples []*keto.Template
+	if ketoWriteURL != "" || len(relationTupleTemplates) > 0 {
+		if ketoWriteURL == "" || len(relationTupleTemplates) == 0 {
+			setupLog.Error(fmt.Errorf("keto-write-url and keto-relation-tuple must be set together"), "unable to set up ORY Keto")
+			os.Exit(1)
+		}
+		writer, err := keto.NewClient(ketoWriteURL)
+		if err != nil {
+			setupLog.Error(err, "unable to set up ORY Keto")
+			os.Exit(1)
+		}
+		writer.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+		for _, text := range relationTupleTemplates {
+			tmpl, err := keto.ParseTemplate(text)
+			if err != nil {
+				setupLog.Error(err, "unable to set up ORY Keto")
+				os.Exit(1)
+			}
+			relationTuples = append(relationTuples, tmpl)
+		}
+		ketoWriter = writer
+	}
+
+	var accessRules oathkeeper.Generator
+	if oathkeeperRuleTemplate != "" {
+		text, err := os.ReadFile(oathkeeperRuleTemplate)
+		if err != nil {
+			setupLog.Error(err, "unable to set up ORY Oathkeeper rules")
+			os.Exit(1)
+		}
+		tmpl, err := oathkeeper.ParseTemplate(string(text))
+		if err != nil {
+			setupLog.Error(err, "unable to set up ORY Oathkeeper rules")
+			os.Exit(1)
+		}
+		accessRules = tmpl
+	}
+
+	var secretEncryption envelope.KeyWrapper
+	if secretEncryptionPlugin != "" {
+		secretEncryption = envelope.Plugin{Path: secretEncryptionPlugin}
+	}
+
+	reconcilerOptions := func(clusterName string, recorder record.EventRecorder) []controllers.Option {
+		opts := []controllers.Option{
+			controllers.WithNamespaces(namespaces),
+			controllers.WithHydraPublicURL(hydraPublicURL),
+			controllers.WithVersionCheck(controllers.VersionCheck(versionCheck)),
+			controllers.WithShard(shard),
+			controllers.WithClusterName(clusterName),
+			controllers.WithRetryPolicy(controllers.RetryPolicy(retryPolicy)),
+			controllers.WithConflictPolicy(hydrav1alpha1.ConflictPolicy(conflictPolicy)),
+			controllers.WithMaxFinalizationDuration(maxFinalizationParsed),
+			controllers.WithShutdownGracePeriod(shutdownGracePeriodParsed),
+			controllers.WithDegradedThreshold(int32(degradedThreshold)),
+			controllers.WithEventRecorder(recorder),
+			controllers.WithEventDedupWindow(eventDedupWindowParsed),
+			controllers.WithMetricsRegisterer(metrics.Registry),
+			controllers.WithMetadataSchema(metadataSchemaParsed),
+			controllers.WithMetadataMerge(mergeMetadata),
+			controllers.WithProvenanceMetadata(provenanceMetadata),
+			controllers.WithClientNameTemplate(clientNameTemplateParsed),
+			controllers.WithRequestBaggage(requestBaggage),
+			controllers.WithAllowedHydraURLs(allowlist),
+			controllers.WithDNSServers(dnsServers),
+			controllers.WithRequestTimeout(hydraRequestTimeoutParsed),
+			controllers.WithPerResourceHydraAdminDisabled(disableHydraAdmin),
+			controllers.WithFinalizersDisabled(disableFinalizers),
+			controllers.WithHydraClientOptions(hydra.WithUserAgent(userAgentFor(clusterName)), hydra.WithFaultInjection(faults)),
+			controllers.WithControllerID(controllerID),
+			controllers.WithScopePolicy(controllers.ScopePolicy{Allowed: allowedScopes, ExemptNamespaces: scopeExemptNamespaces}),
+			controllers.WithRedirectURIDomains(allowedRedirectURIDomains),
+			controllers.WithWildcardRedirectURIs(wildcardRedirectURIs),
+			controllers.WithAuditor(auditor),
+			controllers.WithNotifier(notifier),
+			controllers.WithRelationTuples(ketoWriter, relationTuples...),
+			controllers.WithAccessRules(accessRules),
+			controllers.WithSecretEncryption(secretEncryption),
+			controllers.WithDriftRepair(repairDrift),
+			controllers.WithPatchUpdates(patchUpdates),
+			controllers.WithRequeuePolicy(requeuePolicy),
+		}
+		if throttle != nil {
+			opts = append(opts, controllers.WithAdaptiveConcurrency(*throttle))
+		}
+		return opts
+	}
+
+	reconciler := controllers.New(
 		mgr.GetClient(),
 		hydraClient,
 		ctrl.Log.WithName("controllers").WithName("OAuth2Client"),
-		controllers.WithNamespace(namespace),
-	).SetupWithManager(mgr)
-	if err != nil {
+		reconcilerOptions(clusterName, mgr.GetEventRecorderFor("hydra-maester"))...,
+	)
+	if waitForHydraTimeoutParsed > 0 {
+		controllers.WaitForHydra(ctx, hydraClient, hydraURL, waitForHydraTimeoutParsed, setupLog)
+	}
+	if err := reconciler.CheckVersion(ctx, hydraClient, hydraURL); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "OAuth2Client")
 		os.Exit(1)
 	}
+	if err := reconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OAuth2Client")
+		os.Exit(1)
+	}
+	reconcilers := []*controllers.OAuth2ClientReconciler{reconciler}
+
+	for _, rcSpec := range remoteClusters {
+		rc, err := controllers.ParseRemoteCluster(rcSpec)
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "OAuth2Client")
+			os.Exit(1)
+		}
+		if clusterName == "" {
+			setupLog.Error(fmt.Errorf("cluster-name must be set when reconciling remote clusters"), "unable to create controller", "controller", "OAuth2Client")
+			os.Exit(1)
+		}
+
+		cl, err := controllers.NewRemoteCluster(ctx, mgr.GetAPIReader(), rc, scheme, namespaces...)
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "OAuth2Client", "cluster", rc.Name)
+			os.Exit(1)
+		}
+		if err := mgr.Add(cl); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "OAuth2Client", "cluster", rc.Name)
+			os.Exit(1)
+		}
+
+		r := controllers.New(
+			cl.GetClient(),
+			hydraClient,
+			ctrl.Log.WithName("controllers").WithName("OAuth2Client").WithValues("cluster", rc.Name),
+			reconcilerOptions(rc.Name, cl.GetEventRecorderFor("hydra-maester"))...,
+		)
+		if err := r.SetupWithCluster(mgr, cl); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "OAuth2Client", "cluster", rc.Name)
+			os.Exit(1)
+		}
+		reconcilers = append(reconcilers, r)
+	}
+
+	existenceProbeIntervalParsed, err := time.ParseDuration(existenceProbeInterval)
+	if err != nil {
+		setupLog.Error(err, "unable to set up existence probe")
+		os.Exit(1)
+	}
+	if existenceProbeIntervalParsed > 0 {
+		for _, r := range reconcilers {
+			err := mgr.Add(&controllers.ExistenceProbe{
+				Reconciler: r,
+				Interval:   existenceProbeIntervalParsed,
+				Log:        r.Log.WithName("existence-probe"),
+			})
+			if err != nil {
+				setupLog.Error(err, "unable to set up existence probe")
+				os.Exit(1)
+			}
+		}
+	}
+
+	recoveryCheckIntervalParsed, err := time.ParseDuration(recoveryCheckInterval)
+	if err != nil {
+		setupLog.Error(err, "unable to set up recovery check")
+		os.Exit(1)
+	}
+	if recoveryCheckIntervalParsed > 0 {
+		for _, r := range reconcilers {
+			err := mgr.Add(&controllers.RecoveryWatch{
+				Reconciler: r,
+				Interval:   recoveryCheckIntervalParsed,
+				Log:        r.Log.WithName("recovery-watch"),
+			})
+			if err != nil {
+				setupLog.Error(err, "unable to set up recovery check")
+				os.Exit(1)
+			}
+		}
+	}
+
+	if stateAPIAddr != "" {
+		token, err := os.ReadFile(stateAPITokenFile)
+		if err != nil {
+			setupLog.Error(err, "unable to set up state API")
+			os.Exit(1)
+		}
+		if len(strings.TrimSpace(string(token))) == 0 {
+			setupLog.Error(fmt.Errorf("state-api-token-file must not be empty"), "unable to set up state API")
+			os.Exit(1)
+		}
+		err = mgr.Add(&manager.Server{
+			Name: "state-api",
+			Server: &http.Server{
+				Addr:              stateAPIAddr,
+				Handler:           controllers.StateAPIHandler(reconcilers, strings.TrimSpace(string(token)), ctrl.Log.WithName("state-api")),
+				ReadHeaderTimeout: 10 * time.Second,
+			},
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to set up state API")
+			os.Exit(1)
+		}
+	}
+
+	if syncReportConfigMap != "" {
+		ns, name, ok := strings.Cut(syncReportConfigMap, "/")
+		if !ok || ns == "" || name == "" {
+			setupLog.Error(fmt.Errorf("sync-report-configmap must have the namespace/name form"), "unable to set up sync report")
+			os.Exit(1)
+		}
+		interval, err := time.ParseDuration(syncReportInterval)
+		if err != nil {
+			setupLog.Error(err, "unable to set up sync report")
+			os.Exit(1)
+		}
+		// The ConfigMap may live outside of the watched namespace, so it is
+		// accessed without the cache.
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to set up sync report")
+			os.Exit(1)
+		}
+		// the leader of each shard writes its own report
+		err = mgr.Add(&controllers.SyncReport{
+			Client:      c,
+			Reconcilers: reconcilers,
+			ConfigMap:   types.NamespacedName{Namespace: ns, Name: shard.Name(name)},
+			Interval:    interval,
+			Log:         ctrl.Log.WithName("sync-report"),
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to set up sync report")
+			os.Exit(1)
+		}
+	}
+
+	// clients without an OAuth2Client don't belong to a shard, so only the
+	// first shard scans for them
+	if unmanagedClientsConfigMap != "" && shardIndex == 0 {
+		ns, name, ok := strings.Cut(unmanagedClientsConfigMap, "/")
+		if !ok || ns == "" || name == "" {
+			setupLog.Error(fmt.Errorf("unmanaged-clients-configmap must have the namespace/name form"), "unable to set up unmanaged client scan")
+			os.Exit(1)
+		}
+		interval, err := time.ParseDuration(unmanagedClientsInterval)
+		if err != nil {
+			setupLog.Error(err, "unable to set up unmanaged client scan")
+			os.Exit(1)
+		}
+		gauge, err := controllers.UnmanagedClientsGauge(metrics.Registry)
+		if err != nil {
+			setupLog.Error(err, "unable to register metrics")
+			os.Exit(1)
+		}
+		// The ConfigMap may live outside of the watched namespace, so it is
+		// accessed without the cache.
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to set up unmanaged client scan")
+			os.Exit(1)
+		}
+		err = mgr.Add(&controllers.UnmanagedClientScanner{
+			Client:      c,
+			HydraClient: reconciler.DefaultHydraClient,
+			Reconcilers: reconcilers,
+			ConfigMap:   types.NamespacedName{Namespace: ns, Name: name},
+			Interval:    interval,
+			Log:         ctrl.Log.WithName("unmanaged-clients"),
+			Recorder:    mgr.GetEventRecorderFor("hydra-maester"),
+			Gauge:       gauge,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to set up unmanaged client scan")
+			os.Exit(1)
+		}
+	}
+
+	if backupSecret != "" {
+		ns, name, ok := strings.Cut(backupSecret, "/")
+		if !ok || ns == "" || name == "" {
+			setupLog.Error(fmt.Errorf("backup-secret must have the namespace/name form"), "unable to set up backup")
+			os.Exit(1)
+		}
+		interval, err := time.ParseDuration(backupInterval)
+		if err != nil {
+			setupLog.Error(err, "unable to set up backup")
+			os.Exit(1)
+		}
+		// The Secret may live outside of the watched namespace, so it is
+		// accessed without the cache.
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to set up backup")
+			os.Exit(1)
+		}
+		err = mgr.Add(&controllers.Backup{
+			Client:      c,
+			HydraClient: reconciler.DefaultHydraClient,
+			Secret:      types.NamespacedName{Namespace: ns, Name: shard.Name(name)},
+			Interval:    interval,
+			Log:         ctrl.Log.WithName("backup"),
+			Reconcilers: reconcilers,
+			Shard:       shard,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to set up backup")
+			os.Exit(1)
+		}
+	}
+
+	if migrateStorageVersion {
+		// OAuth2Clients of all namespaces are rewritten, without the cache
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to set up storage version migration")
+			os.Exit(1)
+		}
+		migrationLog := ctrl.Log.WithName("storage-version-migration")
+		err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			migrated, err := helpers.MigrateStorageVersion(ctx, c, oauth2ClientCRDName)
+			if err != nil {
+				// the migration is repeated on the next start
+				migrationLog.Error(err, "unable to migrate the stored OAuth2Clients", "migrated", migrated)
+				return nil
+			}
+			migrationLog.Info("migrated the stored OAuth2Clients to the storage version", "migrated", migrated)
+			return nil
+		}))
+		if err != nil {
+			setupLog.Error(err, "unable to set up storage version migration")
+			os.Exit(1)
+		}
+	}
+
+	if configFile != "" {
+		reloadLog := ctrl.Log.WithName("settings")
+		err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			return settings.Watch(ctx, configFile, reloadLog, func(values map[string]string) {
+				changed, err := settings.Apply(flag.CommandLine, values, explicit)
+				if err != nil {
+					reloadLog.Error(err, "ignoring invalid settings file")
+					return
+				}
+
+				reload := false
+				for _, name := range changed {
+					if reloadableSettings[name] {
+						reload = true
+					} else {
+						reloadLog.Info("setting changed, restart the controller to apply it", "setting", name)
+					}
+				}
+				if !reload {
+					return
+				}
+
+				hc, err := newHydraClient()
+				if err != nil {
+					reloadLog.Error(err, "keeping the previous default hydra client")
+					return
+				}
+				if err := reconciler.CheckVersion(ctx, hc, hydraURL); err != nil {
+					reloadLog.Error(err, "keeping the previous default hydra client")
+					return
+				}
+				for _, r := range reconcilers {
+					r.SetDefaults(hc, hydraPublicURL)
+				}
+				reloadLog.Info("reloaded default hydra client", "url", hydraURL, "port", hydraPort)
+			})
+		}))
+		if err != nil {
+			setupLog.Error(err, "unable to watch settings file")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
-	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	setupLog.Info("starting manager", "version", version)
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// checkNamespaceScoped returns an error if the settings need permissions
+// outside of namespaces, which the controller lacks in namespace-scoped mode.
+func checkNamespaceScoped(namespaces []string, installCRDs, migrateStorageVersion bool, backupSecret, syncReportConfigMap, unmanagedClientsConfigMap, hydraDiscovery, adminMTLSSigner string, remoteClusters []string) error {
+	if len(namespaces) == 0 {
+		return fmt.Errorf("namespace must be set with namespace-scoped")
+	}
+	if installCRDs {
+		return fmt.Errorf("install-crds needs cluster-wide permissions and can't be used with namespace-scoped")
+	}
+	if migrateStorageVersion {
+		return fmt.Errorf("migrate-storage-version needs cluster-wide permissions and can't be used with namespace-scoped")
+	}
+	if adminMTLSSigner != "" {
+		return fmt.Errorf("admin-mtls-signer needs cluster-wide permissions and can't be used with namespace-scoped")
+	}
+	if ns, _, _ := strings.Cut(backupSecret, "/"); backupSecret != "" && !slices.Contains(namespaces, ns) {
+		return fmt.Errorf("backup-secret must be in one of the namespaces of namespace with namespace-scoped")
+	}
+	if ns, _, _ := strings.Cut(syncReportConfigMap, "/"); syncReportConfigMap != "" && !slices.Contains(namespaces, ns) {
+		return fmt.Errorf("sync-report-configmap must be in one of the namespaces of namespace with namespace-scoped")
+	}
+	if ns, _, _ := strings.Cut(unmanagedClientsConfigMap, "/"); unmanagedClientsConfigMap != "" && !slices.Contains(namespaces, ns) {
+		return fmt.Errorf("unmanaged-clients-configmap must be in one of the namespaces of namespace with namespace-scoped")
+	}
+	if hydraDiscovery != "" {
+		resolver, err := controllers.ParseHydraDiscovery(hydraDiscovery, nil)
+		if err != nil {
+			return err
+		}
+		if es, ok := resolver.(*controllers.EndpointSliceResolver); ok && !slices.Contains(namespaces, es.Namespace) {
+			return fmt.Errorf("the EndpointSlices of hydra-discovery must be in one of the namespaces of namespace with namespace-scoped")
+		}
+	}
+	for _, spec := range remoteClusters {
+		rc, err := controllers.ParseRemoteCluster(spec)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(namespaces, rc.SecretNamespace) {
+			return fmt.Errorf("the Secret of remote cluster %s must be in one of the namespaces of namespace with namespace-scoped", rc.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	defaultAttempts = 3
+	defaultTimeout  = 10 * time.Second
+	defaultBackoff  = time.Second
+)
+
+// Dispatcher queues notifications and sends them to a sink one by one, so
+// reconciliations don't wait for the sink. Failed notifications are retried
+// with exponential backoff a few times and then dropped, as they are stale by
+// the time a long outage of the sink ends. Notifications arriving while the
+// queue is full are dropped as well.
+type Dispatcher struct {
+	Sink Sink
+	Log  logr.Logger
+	// Attempts is the number of times a notification is sent before it is
+	// dropped.
+	Attempts int
+	// Timeout limits each attempt.
+	Timeout time.Duration
+	// Backoff is the delay before the first retry. It doubles with every
+	// retry.
+	Backoff time.Duration
+
+	queue chan Notification
+}
+
+// NewDispatcher returns a dispatcher to sink queueing up to queueSize
+// notifications. It must be started, e.g. by adding it to the manager.
+func NewDispatcher(sink Sink, queueSize int, log logr.Logger) *Dispatcher {
+	return &Dispatcher{
+		Sink:     sink,
+		Log:      log,
+		Attempts: defaultAttempts,
+		Timeout:  defaultTimeout,
+		Backoff:  defaultBackoff,
+		queue:    make(chan Notification, queueSize),
+	}
+}
+
+// Notify queues n, or drops and logs it if the queue is full.
+func (d *Dispatcher) Notify(_ context.Context, n Notification) {
+	select {
+	case d.queue <- n:
+	default:
+		d.Log.Error(nil, "notification queue is full, dropping notification", "notification", n)
+	}
+}
+
+// Start sends the queued notifications until ctx is done.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-d.queue:
+			d.send(ctx, n)
+		}
+	}
+}
+
+// send sends n, retrying until it succeeds, the attempts are used up or ctx
+// is done.
+func (d *Dispatcher) send(ctx context.Context, n Notification) {
+	backoff := d.Backoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, d.Timeout)
+		err := d.Sink.Send(attemptCtx, n)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt >= d.Attempts {
+			d.Log.Error(err, "sending notification failed, dropping it", "notification", n)
+			return
+		}
+		d.Log.Error(err, "sending notification failed, retrying", "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
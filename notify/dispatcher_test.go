@@ -0,0 +1,119 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/hydra-maester/notify"
+)
+
+// sinkFunc is a Sink collecting the notifications it is sent, failing while
+// fail returns true.
+type sinkFunc struct {
+	mu       sync.Mutex
+	fail     func() bool
+	attempts int
+	sent     []notify.Notification
+}
+
+func (s *sinkFunc) Send(_ context.Context, n notify.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.fail != nil && s.fail() {
+		return errors.New("unavailable")
+	}
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func (s *sinkFunc) received() ([]notify.Notification, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]notify.Notification(nil), s.sent...), s.attempts
+}
+
+func TestDispatcher(t *testing.T) {
+	start := func(t *testing.T, d *notify.Dispatcher) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = d.Start(ctx)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+	}
+
+	t.Run("case=sends notifications", func(t *testing.T) {
+		sink := &sinkFunc{}
+		d := notify.NewDispatcher(sink, 10, logr.Discard())
+		start(t, d)
+
+		d.Notify(context.Background(), notify.Notification{Name: "a"})
+		d.Notify(context.Background(), notify.Notification{Name: "b"})
+		assert.Eventually(t, func() bool {
+			sent, _ := sink.received()
+			return len(sent) == 2
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("case=retries failed notifications", func(t *testing.T) {
+		failures := 0
+		sink := &sinkFunc{fail: func() bool {
+			failures++
+			return failures < 3
+		}}
+		d := notify.NewDispatcher(sink, 10, logr.Discard())
+		d.Backoff = time.Millisecond
+		start(t, d)
+
+		d.Notify(context.Background(), notify.Notification{Name: "a"})
+		assert.Eventually(t, func() bool {
+			sent, _ := sink.received()
+			return len(sent) == 1
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("case=drops notifications after the attempts", func(t *testing.T) {
+		sink := &sinkFunc{fail: func() bool { return true }}
+		d := notify.NewDispatcher(sink, 10, logr.Discard())
+		d.Attempts = 2
+		d.Backoff = time.Millisecond
+		start(t, d)
+
+		d.Notify(context.Background(), notify.Notification{Name: "a"})
+		d.Notify(context.Background(), notify.Notification{Name: "b"})
+		assert.Eventually(t, func() bool {
+			_, attempts := sink.received()
+			return attempts == 4
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("case=drops notifications while the queue is full", func(t *testing.T) {
+		sink := &sinkFunc{}
+		d := notify.NewDispatcher(sink, 1, logr.Discard())
+
+		d.Notify(context.Background(), notify.Notification{Name: "a"})
+		d.Notify(context.Background(), notify.Notification{Name: "b"})
+		start(t, d)
+
+		assert.Eventually(t, func() bool {
+			sent, _ := sink.received()
+			return len(sent) == 1
+		}, time.Second, 5*time.Millisecond)
+		sent, _ := sink.received()
+		assert.Equal(t, "a", sent[0].Name)
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package notify tells the owners of OAuth2Clients when their clients fail
+// to reconcile and when they recover, e.g. in a Slack channel, so they learn
+// about broken clients without watching the cluster.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Transition is the change of the state of an OAuth2Client which is
+// notified.
+type Transition string
+
+const (
+	// TransitionFailed is notified when an OAuth2Client becomes degraded.
+	TransitionFailed Transition = "failed"
+	// TransitionRecovered is notified when a degraded OAuth2Client is
+	// reconciled successfully again.
+	TransitionRecovered Transition = "recovered"
+)
+
+// Notification describes an OAuth2Client which failed or recovered.
+type Notification struct {
+	Time       time.Time  `json:"time"`
+	Transition Transition `json:"transition"`
+	Cluster    string     `json:"cluster,omitempty"`
+	Namespace  string     `json:"namespace"`
+	Name       string     `json:"name"`
+	// Reason is the reason of the Ready condition of failed OAuth2Clients.
+	Reason string `json:"reason,omitempty"`
+	// Error is the last error of failed OAuth2Clients.
+	Error string `json:"error,omitempty"`
+	// ConsecutiveFailures is the number of reconciliations which failed in a
+	// row, before the recovery for recovered OAuth2Clients.
+	ConsecutiveFailures int32 `json:"consecutiveFailures"`
+}
+
+// Text returns n as a message for humans.
+func (n Notification) Text() string {
+	name := n.Namespace + "/" + n.Name
+	if n.Cluster != "" {
+		name = n.Cluster + ": " + name
+	}
+	if n.Transition == TransitionRecovered {
+		return fmt.Sprintf("OAuth2Client %s recovered after %d failed reconciliations", name, n.ConsecutiveFailures)
+	}
+	return fmt.Sprintf("OAuth2Client %s failed %d reconciliations in a row: %s: %s", name, n.ConsecutiveFailures, n.Reason, n.Error)
+}
+
+// Notifier receives the notifications of the controller.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification)
+}
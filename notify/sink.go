@@ -0,0 +1,120 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Sink delivers notifications to an external system.
+type Sink interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// ParseSink returns the sink of rawURL:
+//
+//   - http:// and https:// URLs receive the notification as JSON in a POST
+//     request, see WebhookSink.
+//   - slack+https:// URLs of a Slack incoming webhook, e.g.
+//     slack+https://hooks.slack.com/services/T000/B000/XXXX, post a message,
+//     see SlackSink.
+//
+// header is added to the requests.
+func ParseSink(rawURL string, header http.Header) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification sink: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid notification sink %q: the host is missing", rawURL)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return &WebhookSink{URL: u.String(), Header: header}, nil
+	case "slack+http", "slack+https":
+		u.Scheme = strings.TrimPrefix(u.Scheme, "slack+")
+		return &SlackSink{URL: u.String(), Header: header}, nil
+	default:
+		return nil, fmt.Errorf("invalid notification sink %q: unsupported scheme %q", rawURL, u.Scheme)
+	}
+}
+
+// WebhookSink posts the notification as JSON to URL.
+type WebhookSink struct {
+	URL    string
+	Header http.Header
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Send implements Sink.
+func (s *WebhookSink) Send(ctx context.Context, n Notification) error {
+	return post(ctx, s.HTTPClient, s.URL, s.Header, n)
+}
+
+// SlackSink posts the text of the notification to a Slack incoming webhook.
+type SlackSink struct {
+	// URL is the address of the webhook, e.g.
+	// https://hooks.slack.com/services/T000/B000/XXXX.
+	URL    string
+	Header http.Header
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Send implements Sink.
+func (s *SlackSink) Send(ctx context.Context, n Notification) error {
+	icon := ":red_circle:"
+	if n.Transition == TransitionRecovered {
+		icon = ":large_green_circle:"
+	}
+	body := struct {
+		Text string `json:"text"`
+	}{Text: icon + " " + n.Text()}
+	return post(ctx, s.HTTPClient, s.URL, s.Header, body)
+}
+
+func post(ctx context.Context, c *http.Client, target string, header http.Header, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		// the path of webhooks is a secret, e.g. for Slack
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("%s %s: %w", urlErr.Op, req.URL.Host, urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded with %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
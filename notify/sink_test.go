@@ -0,0 +1,105 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/notify"
+)
+
+var notification = notify.Notification{
+	Time:                time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	Transition:          notify.TransitionFailed,
+	Cluster:             "eu-west",
+	Namespace:           "namespace",
+	Name:                "name",
+	Reason:              "HydraUnavailable",
+	Error:               "connection refused",
+	ConsecutiveFailures: 5,
+}
+
+func TestParseSink(t *testing.T) {
+	for _, tc := range []struct {
+		url      string
+		expected notify.Sink
+	}{
+		{url: "https://alerts.example.com/hooks/hydra", expected: &notify.WebhookSink{URL: "https://alerts.example.com/hooks/hydra"}},
+		{url: "slack+https://hooks.slack.com/services/T000/B000/XXXX", expected: &notify.SlackSink{URL: "https://hooks.slack.com/services/T000/B000/XXXX"}},
+	} {
+		t.Run("url="+tc.url, func(t *testing.T) {
+			sink, err := notify.ParseSink(tc.url, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, sink)
+		})
+	}
+
+	for _, url := range []string{"ftp://alerts", "https://", "slack://hooks.slack.com"} {
+		t.Run("invalid="+url, func(t *testing.T) {
+			_, err := notify.ParseSink(url, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNotificationText(t *testing.T) {
+	assert.Equal(t, "OAuth2Client eu-west: namespace/name failed 5 reconciliations in a row: HydraUnavailable: connection refused", notification.Text())
+
+	recovered := notify.Notification{Transition: notify.TransitionRecovered, Namespace: "namespace", Name: "name", ConsecutiveFailures: 7}
+	assert.Equal(t, "OAuth2Client namespace/name recovered after 7 failed reconciliations", recovered.Text())
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received notify.Notification
+	var auth string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer s.Close()
+
+	sink, err := notify.ParseSink(s.URL, http.Header{"Authorization": []string{"Bearer token"}})
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(context.Background(), notification))
+	assert.Equal(t, notification, received)
+	assert.Equal(t, "Bearer token", auth)
+
+	t.Run("case=fails on error responses without the path", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer s.Close()
+
+		err := (&notify.WebhookSink{URL: s.URL + "/secret-token"}).Send(context.Background(), notification)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "503")
+		assert.NotContains(t, err.Error(), "secret-token")
+	})
+}
+
+func TestSlackSink(t *testing.T) {
+	var received struct {
+		Text string `json:"text"`
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/T000/B000/XXXX", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer s.Close()
+
+	sink, err := notify.ParseSink(strings.Replace(s.URL, "http://", "slack+http://", 1)+"/services/T000/B000/XXXX", nil)
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(context.Background(), notification))
+	assert.Equal(t, ":red_circle: "+notification.Text(), received.Text)
+}
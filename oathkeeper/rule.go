@@ -0,0 +1,109 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package oathkeeper generates the ORY Oathkeeper access rules of
+// OAuth2Clients, so a client and the gateway rule admitting it are onboarded
+// with one manifest.
+package oathkeeper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// RuleGroupVersionKind is the kind of the Rule custom resource of ORY
+// Oathkeeper Maester.
+var RuleGroupVersionKind = schema.GroupVersionKind{Group: "oathkeeper.ory.sh", Version: "v1alpha1", Kind: "Rule"}
+
+// RuleData is the data the Rule of an OAuth2Client is generated from.
+type RuleData struct {
+	// ClientID is the ID of the client in ORY Hydra.
+	ClientID string
+	// Namespace and Name are those of the OAuth2Client.
+	Namespace string
+	Name      string
+	// Cluster is the name of the cluster of the OAuth2Client, empty for the
+	// local cluster.
+	Cluster string
+	// Scopes are the scopes of the client.
+	Scopes []string
+	// Annotations are the annotations of the OAuth2Client, e.g. to pass the
+	// upstream of the rule.
+	Annotations map[string]string
+}
+
+// Generator generates the spec of the Rule of an OAuth2Client.
+type Generator interface {
+	Generate(ctx context.Context, data RuleData) (map[string]interface{}, error)
+}
+
+// GeneratorFunc is a function implementing Generator.
+type GeneratorFunc func(ctx context.Context, data RuleData) (map[string]interface{}, error)
+
+// Generate calls f.
+func (f GeneratorFunc) Generate(ctx context.Context, data RuleData) (map[string]interface{}, error) {
+	return f(ctx, data)
+}
+
+// Template generates the spec of Rules from a Go template of the YAML spec.
+// The json function renders a value as JSON, which is valid YAML, e.g.
+//
+//	match:
+//	  url: https://api.example.com/{{.Namespace}}/<**>
+//	  methods: [GET, POST]
+//	upstream:
+//	  url: {{index .Annotations "example.com/upstream"}}
+//	authenticators:
+//	- handler: oauth2_introspection
+//	  config:
+//	    required_scope: {{json .Scopes}}
+type Template struct {
+	text string
+	tmpl *template.Template
+}
+
+// ParseTemplate parses text as a Template. It fails unless text renders a
+// YAML object.
+func ParseTemplate(text string) (*Template, error) {
+	tmpl, err := template.New("oathkeeper-rule").Option("missingkey=zero").Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid oathkeeper rule template: %w", err)
+	}
+	t := &Template{text: text, tmpl: tmpl}
+	if _, err := t.Generate(context.Background(), RuleData{ClientID: "client", Namespace: "namespace", Name: "name", Cluster: "cluster", Scopes: []string{"scope"}}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Generate renders the spec of the Rule of data.
+func (t *Template) Generate(_ context.Context, data RuleData) (map[string]interface{}, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return nil, fmt.Errorf("rendering oathkeeper rule template: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := yaml.Unmarshal([]byte(b.String()), &spec); err != nil {
+		return nil, fmt.Errorf("oathkeeper rule template rendered invalid YAML: %w", err)
+	}
+	if spec == nil {
+		return nil, fmt.Errorf("oathkeeper rule template rendered an empty spec")
+	}
+	return spec, nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// String returns the text of t.
+func (t *Template) String() string {
+	return t.text
+}
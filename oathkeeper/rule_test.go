@@ -0,0 +1,69 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package oathkeeper_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/oathkeeper"
+)
+
+func TestTemplate(t *testing.T) {
+	t.Run("case=renders the spec of the rule", func(t *testing.T) {
+		tmpl, err := oathkeeper.ParseTemplate(`match:
+  url: https://api.example.com/{{.Namespace}}/<**>
+  methods: [GET]
+upstream:
+  url: {{index .Annotations "example.com/upstream"}}
+authenticators:
+- handler: oauth2_introspection
+  config:
+    required_scope: {{json .Scopes}}
+    trusted_issuers: ["{{.Cluster}}"]
+`)
+		require.NoError(t, err)
+
+		spec, err := tmpl.Generate(context.Background(), oathkeeper.RuleData{
+			ClientID:    "client-id",
+			Namespace:   "team",
+			Name:        "app",
+			Cluster:     "east",
+			Scopes:      []string{"read", "write"},
+			Annotations: map[string]string{"example.com/upstream": "http://app.team.svc:8080"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"match": map[string]interface{}{
+				"url":     "https://api.example.com/team/<**>",
+				"methods": []interface{}{"GET"},
+			},
+			"upstream": map[string]interface{}{"url": "http://app.team.svc:8080"},
+			"authenticators": []interface{}{map[string]interface{}{
+				"handler": "oauth2_introspection",
+				"config": map[string]interface{}{
+					"required_scope":  []interface{}{"read", "write"},
+					"trusted_issuers": []interface{}{"east"},
+				},
+			}},
+		}, spec)
+	})
+
+	for _, tc := range []struct {
+		name, text, err string
+	}{
+		{name: "case=rejects invalid templates", text: "match: {{.ClientID", err: "invalid oathkeeper rule template: "},
+		{name: "case=rejects unknown fields", text: "match: {{.Secret}}", err: "rendering oathkeeper rule template: "},
+		{name: "case=rejects invalid YAML", text: "match: [{{.ClientID}}", err: "oathkeeper rule template rendered invalid YAML: "},
+		{name: "case=rejects empty specs", text: "# {{.ClientID}}", err: "oathkeeper rule template rendered an empty spec"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := oathkeeper.ParseTemplate(tc.text)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
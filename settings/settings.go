@@ -0,0 +1,116 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+// Package settings reads the controller settings from a YAML file. The keys
+// of the file are the names of the command-line flags, so every flag can be
+// set in the file. Flags given on the command line take precedence.
+package settings
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/yaml"
+)
+
+// Load reads the settings file at path.
+func Load(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		case bool, float64:
+			values[k] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("%s: setting %q must be a string, number or boolean", path, k)
+		}
+	}
+	return values, nil
+}
+
+// Explicit returns the names of the flags of fs given on the command line.
+// It must be called before Apply.
+func Explicit(fs *flag.FlagSet) map[string]bool {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	return explicit
+}
+
+// Apply sets the flags of fs to the given values, skipping the explicit
+// flags. It returns the names of the flags whose value changed.
+func Apply(fs *flag.FlagSet, values map[string]string, explicit map[string]bool) ([]string, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var changed []string
+	for _, k := range keys {
+		f := fs.Lookup(k)
+		if f == nil {
+			return nil, fmt.Errorf("unknown setting %q", k)
+		}
+		if explicit[k] || f.Value.String() == values[k] {
+			continue
+		}
+		if err := fs.Set(k, values[k]); err != nil {
+			return nil, fmt.Errorf("setting %q: %w", k, err)
+		}
+		changed = append(changed, k)
+	}
+	return changed, nil
+}
+
+// Watch calls onChange with the new settings whenever the file at path
+// changes, until ctx is done. The directory of the file is watched, so
+// updates of mounted ConfigMaps, which replace a symlink, are noticed too.
+func Watch(ctx context.Context, path string, log logr.Logger, onChange func(map[string]string)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-w.Errors:
+			log.Error(err, "watching settings file", "path", path)
+		case e := <-w.Events:
+			if !e.Has(fsnotify.Write) && !e.Has(fsnotify.Create) {
+				continue
+			}
+			values, err := Load(path)
+			if err != nil {
+				log.Error(err, "reloading settings file", "path", path)
+				continue
+			}
+			onChange(values)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright © 2024 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package settings_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/hydra-maester/settings"
+)
+
+func TestLoadAndApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("hydra-url: http://hydra\nhydra-port: 4446\ninsecure-skip-verify: true\n"), 0o600))
+
+	values, err := settings.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"hydra-url":            "http://hydra",
+		"hydra-port":           "4446",
+		"insecure-skip-verify": "true",
+	}, values)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	url := fs.String("hydra-url", "", "")
+	port := fs.Int("hydra-port", 4445, "")
+	insecure := fs.Bool("insecure-skip-verify", false, "")
+	require.NoError(t, fs.Parse([]string{"--hydra-url", "http://flag"}))
+
+	explicit := settings.Explicit(fs)
+	changed, err := settings.Apply(fs, values, explicit)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hydra-port", "insecure-skip-verify"}, changed)
+	assert.Equal(t, "http://flag", *url)
+	assert.Equal(t, 4446, *port)
+	assert.True(t, *insecure)
+
+	changed, err = settings.Apply(fs, values, explicit)
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+
+	_, err = settings.Apply(fs, map[string]string{"unknown": "1"}, explicit)
+	assert.Error(t, err)
+}